@@ -0,0 +1,37 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package common
+
+const (
+	// 表结构检查结果级别
+	CheckSeverityInfo  = "INFO"
+	CheckSeverityWarn  = "WARN"
+	CheckSeverityError = "ERROR"
+
+	// 表结构检查对象类型
+	CheckObjectTable      = "TABLE"
+	CheckObjectColumn     = "COLUMN"
+	CheckObjectConstraint = "CONSTRAINT"
+	CheckObjectIndex      = "INDEX"
+	CheckObjectPartition  = "PARTITION"
+)
+
+// CheckSeverityLevel 检查结果级别高低，用于 fail-severity 判断
+var CheckSeverityLevel = map[string]int{
+	CheckSeverityInfo:  1,
+	CheckSeverityWarn:  2,
+	CheckSeverityError: 3,
+}
@@ -0,0 +1,43 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package common
+
+const (
+	// Oracle STANDARD_HASH 函数，数据对比服务端聚合校验
+	// 需要 oracle 12c 及以上
+	OracleStandardHashDBVersion = "12"
+
+	// 数据对比行数据字段拼接分隔符
+	CompareColumnDelimiter = "|"
+
+	// 下游字符类型字段 NULL/空字符串不符合 empty-string-as 规则时的对比值，保证差异可见
+	CompareEmptyStringMismatch = "<EMPTY-STRING-AS-MISMATCH>"
+
+	// 差异数据块二分定位最大递归深度
+	CompareBisectMaxDepth = 32
+
+	// 差异数据输出格式
+	CompareDiffFormatJSON = "json"
+	CompareDiffFormatCSV  = "csv"
+
+	// 差异数据类型，下游缺失、下游多余、字段值不一致
+	CompareDiffTypeMissing = "MISSING"
+	CompareDiffTypeExtra   = "EXTRA"
+	CompareDiffTypeChanged = "CHANGED"
+
+	// 差异数据自动修复状态，仅输出不执行
+	CompareFixStatusDryRun = "DRYRUN"
+)
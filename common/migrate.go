@@ -15,6 +15,8 @@ limitations under the License.
 */
 package common
 
+import "time"
+
 // 数据全量/实时同步 Oracle 版本要求
 // 要求 oracle 11g 及以上
 const RequireOracleDBVersion = "11"
@@ -32,6 +34,82 @@ const (
 	MigrateOperationDropTable     = "DROP TABLE"
 )
 
+// FULL 模式默认 chunk 行数以及自适应 chunk 默认单 chunk 数据量，单位 MB
+const (
+	FullDefaultChunkSize        = 100000
+	FullAdaptiveChunkTargetSize = 64
+)
+
+// 上游 Oracle 数据抽取限流，活跃会话数检查缓存时长以及最大退避时长
+const (
+	ThrottleActiveSessionCheckInterval = 10 * time.Second
+	ThrottleMaxBackoffInterval         = 5 * time.Minute
+	ThrottleDefaultBackoffInterval     = 30
+)
+
+// CSV 模式数据文件格式以及压缩方式
+const (
+	CSVFileFormatCSV     = "csv"
+	CSVFileFormatParquet = "parquet"
+
+	CSVCompressGzip   = "gzip"
+	CSVCompressZstd   = "zstd"
+	CSVCompressSnappy = "snappy"
+)
+
+// CSV 模式输出目录布局
+// default: ${output-dir}/${source_schema}/${source_table}/${target_schema}.${table}.${n}.csv
+// lightning: ${output-dir}/${target_schema}.${table}.${n}.csv，附带 schema 文件以及 metadata，兼容 TiDB Lightning/Dumpling
+const (
+	CSVLayoutDefault   = "default"
+	CSVLayoutLightning = "lightning"
+
+	CSVLightningMetadataFile = "metadata"
+)
+
+// CSV 模式数据文件输出后端
+const (
+	CSVStorageLocal = "local"
+	CSVStorageS3    = "s3"
+
+	CSVStorageS3DefaultRegion = "us-east-1"
+)
+
+// IMPORT 模式数据文件导入方式
+const (
+	CSVImportMethodLoad   = "LOAD"
+	CSVImportMethodInsert = "INSERT"
+)
+
+// CSV 模式 RAW/LONG RAW/BLOB 二进制字段编码方式
+const (
+	CSVBinaryEncodingHex    = "hex"
+	CSVBinaryEncodingBase64 = "base64"
+)
+
+// CSV 模式 NULL 值表示，默认 NULL，empty 代表空字段
+const (
+	CSVNullValueDefault = "NULL"
+	CSVNullValueEmpty   = "empty"
+)
+
+// Oracle 空字符串与 NULL 不区分，字符类型字段 NULL 写入下游方式
+// null: 写入 NULL，empty: 写入空字符串 ''
+const (
+	EmptyStringAsNull  = "null"
+	EmptyStringAsEmpty = "empty"
+)
+
+// Oracle LOB 字段分段读取大小，单位 byte，以及默认单字段大小上限，单位 MB
+const (
+	OracleLOBReadPieceSize    = 1 << 20
+	OracleLOBDefaultSizeLimit = 64
+)
+
+// LOAD DATA LOCAL INFILE 被禁用错误码
+// 1148 ER_NOT_ALLOWED_COMMAND，3948 ER_CLIENT_LOCAL_FILES_DISABLED
+var MySQLLoadDataDisabledErrCode = []uint16{1148, 3948}
+
 // 用于控制当程序消费追平到当前 CURRENT 重做日志，
 // 当值 == 0 启用 filterOracleIncrRecord 大于或者等于逻辑
 // 当值 == 1 启用 filterOracleIncrRecord 大于逻辑，避免已被消费得日志一直被重复消费
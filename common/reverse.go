@@ -28,11 +28,31 @@ const (
 	MySQLVersionDelimiter = "-"
 	// MySQL 字符集
 	MySQLCharacterSet = "UTF8MB4"
+	// MySQL 目标端可选字符集
+	MySQLCharacterSetGBK    = "GBK"
+	MySQLCharacterSetLatin1 = "LATIN1"
+	MySQLCharacterSetBinary = "BINARY"
+	// MySQL/TiDB InnoDB 索引最大字节长度
+	MySQLIndexMaxLength = 3072
 
 	// 允许 Oracle 表、字段 Collation
 	// 需要 oracle 12.2g 及以上
 	OracleTableColumnCollationDBVersion = "12.2"
 
+	// Oracle 标识列 GENERATED AS IDENTITY
+	// 需要 oracle 12c 及以上
+	OracleIdentityColumnDBVersion = "12"
+
+	// MySQL 不可见列版本 >= 8.0.23，TiDB 不支持
+	MySQLInvisibleColumnVersion = "8.0.23"
+	// MySQL 函数索引 functional key parts 版本 >= 8.0.13
+	MySQLFunctionalKeyPartVersion = "8.0.13"
+
+	// Oracle 索引字段表达式分隔符，表达式可能包含逗号
+	OracleIndexColumnExprDelimiter = "|+|"
+	// 函数索引转换虚拟生成列名后缀，${INDEX_NAME}_VC${N}，仅存在于下游
+	ReverseIndexGenColumnSuffix = "_VC"
+
 	// Oracle 用户、表、字段默认使用 DB 排序规则
 	OracleUserTableColumnDefaultCollation = "USING_NLS_COMP"
 
@@ -59,6 +79,49 @@ const (
 	TiDBClusteredIndexOFFValue     = "OFF"
 )
 
+/*
+O2M/T Oracle Table Type Reverse
+*/
+const (
+	// Oracle 表类型 -> GetOracleSchemaTableType
+	OracleTableTypeHeap                 = "HEAP"
+	OracleTableTypeIOT                  = "IOT"
+	OracleTableTypeClustered            = "CLUSTERED"
+	OracleTableTypePartitioned          = "PARTITIONED"
+	OracleTableTypeSessionTemporary     = "SESSION TEMPORARY"
+	OracleTableTypeTransactionTemporary = "TRANSACTION TEMPORARY"
+
+	// TiDB 全局临时表版本 >= 5.3.0，仅支持 ON COMMIT DELETE ROWS
+	TiDBGlobalTemporaryVersion = "5.3.0"
+	// TiDB 聚簇索引版本 >= 5.0.0
+	TiDBClusteredIndexVersion = "5.0.0"
+	// TiDB 表达式索引版本 >= 5.2.0，仅支持 tidb_allow_function_for_expression_index 函数
+	TiDBExpressionIndexVersion = "5.2.0"
+
+	// reverse 表类型转换决策
+	ReverseTableDecisionNormal            = "Create Table"
+	ReverseTableDecisionGlobalTemporary   = "Create Global Temporary Table"
+	ReverseTableDecisionTemporaryTemplate = "Create Temporary Table Template"
+	ReverseTableDecisionClusteredPK       = "Create Clustered Primary Key Table"
+)
+
+/*
+O2M/T Oracle Materialized View Refresh
+*/
+const (
+	// Oracle 物化视图刷新方式
+	MViewRefreshMethodComplete = "COMPLETE"
+	MViewRefreshMethodFast     = "FAST"
+	MViewRefreshMethodForce    = "FORCE"
+	MViewRefreshMethodNever    = "NEVER"
+)
+
+// Oracle 物化视图查询语句 MySQL/TiDB 不兼容关键字，命中需人工确认转换后的查询语句
+var OracleMViewQueryIncompatibleKeywords = []string{"||", "(+)", "CONNECT BY", "START WITH", "ROWNUM", "MINUS", "DECODE(", "TO_CHAR(", "TO_DATE(", "TO_NUMBER(", "NVL2(", "PRIOR "}
+
+// TiDB 表达式索引默认允许函数
+var TiDBExpressionIndexAllowFunctions = []string{"LOWER", "UPPER", "MD5", "REVERSE"}
+
 // alter-primary-key = fase 主键整型数据类型列表
 var TiDBIntegerPrimaryKeyList = []string{"TINYINT", "SMALLINT", "INT", "BIGINT", "DECIMAL"}
 
@@ -81,6 +144,27 @@ var OracleCollationMap = map[string]string{
 	"BINARY": "utf8mb4_bin",
 }
 
+// MySQL 目标端字符集单字符最大字节数
+var MySQLCharacterSetMaxBytes = map[string]int{
+	MySQLCharacterSet:       4,
+	MySQLCharacterSetGBK:    2,
+	MySQLCharacterSetLatin1: 1,
+	MySQLCharacterSetBinary: 1,
+}
+
+// MySQL 目标端字符集对应 Oracle 字符集，用于源端数据能否转换校验
+// MySQL latin1 实际为 cp1252
+var MySQLCharacterSetOracleMap = map[string]string{
+	MySQLCharacterSetGBK:    "ZHS16GBK",
+	MySQLCharacterSetLatin1: "WE8MSWIN1252",
+}
+
+// MySQL 目标端字符集不区分大小写默认排序规则，区分大小写统一 ${charset}_bin
+var MySQLCharacterSetCICollationMap = map[string]string{
+	MySQLCharacterSetGBK:    "gbk_chinese_ci",
+	MySQLCharacterSetLatin1: "latin1_swedish_ci",
+}
+
 // ORACLE 字符集映射规则
 var OracleDBCharacterSetMap = map[string]string{
 	"AL32UTF8":  "UTF8MB4",
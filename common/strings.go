@@ -17,6 +17,8 @@ package common
 
 import (
 	"bytes"
+	"encoding/base64"
+	"encoding/hex"
 	"fmt"
 	"golang.org/x/text/encoding/simplifiedchinese"
 	"golang.org/x/text/transform"
@@ -26,6 +28,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 	"unicode"
 	"unicode/utf8"
 
@@ -318,6 +321,14 @@ func VersionOrdinal(version string) string {
 	return string(vo)
 }
 
+// 用于获取 TiDB 版本号，5.7.25-TiDB-v6.1.0 -> 6.1.0
+func TiDBVersion(version string) string {
+	if idx := strings.Index(strings.ToUpper(version), "TIDB-V"); idx != -1 {
+		return strings.Split(version[idx+len("TIDB-V"):], "-")[0]
+	}
+	return version
+}
+
 // 用于对比 struct 是否相等
 func DiffStructArray(structA, structB interface{}) ([]interface{}, []interface{}, bool) {
 	var (
@@ -397,6 +408,22 @@ func Utf8ToGbk(s []byte) ([]byte, error) {
 	return d, nil
 }
 
+// 二进制字段值按 hex/base64 编码为字符串
+func BinaryEncode(bs []byte, encoding string) string {
+	if strings.EqualFold(encoding, CSVBinaryEncodingBase64) {
+		return base64.StdEncoding.EncodeToString(bs)
+	}
+	return hex.EncodeToString(bs)
+}
+
+// hex/base64 编码字符串解码为二进制字段值
+func BinaryDecode(s string, encoding string) ([]byte, error) {
+	if strings.EqualFold(encoding, CSVBinaryEncodingBase64) {
+		return base64.StdEncoding.DecodeString(s)
+	}
+	return hex.DecodeString(s)
+}
+
 // 如果存在特殊字符，直接在特殊字符前添加\
 /**
 判断是否为字母： unicode.IsLetter(v)
@@ -466,3 +493,23 @@ func PathExist(path string) error {
 	}
 	return err
 }
+
+// ParseTimeWindow 解析时间窗口 HH:MM-HH:MM，返回开始以及结束时间距零点时长，开始时间大于结束时间代表跨天
+func ParseTimeWindow(window string) (time.Duration, time.Duration, error) {
+	times := strings.Split(strings.TrimSpace(window), "-")
+	if len(times) != 2 {
+		return 0, 0, fmt.Errorf("time window [%s] format isn't support, only support [HH:MM-HH:MM]", window)
+	}
+	var durations []time.Duration
+	for _, t := range times {
+		clock, err := time.Parse("15:04", strings.TrimSpace(t))
+		if err != nil {
+			return 0, 0, fmt.Errorf("time window [%s] format isn't support, only support [HH:MM-HH:MM]: %v", window, err)
+		}
+		durations = append(durations, time.Duration(clock.Hour())*time.Hour+time.Duration(clock.Minute())*time.Minute)
+	}
+	if durations[0] == durations[1] {
+		return 0, 0, fmt.Errorf("time window [%s] start time can't be equal to end time", window)
+	}
+	return durations[0], durations[1], nil
+}
@@ -23,11 +23,22 @@ const (
 	MySQLMaxConn         = 1024
 	MySQLConnMaxLifeTime = 300 * time.Second
 	MySQLConnMaxIdleTime = 200 * time.Second
+	// prepare 语句绑定变量上限
+	MySQLMaxPlaceholders = 65535
 )
 
 // 任务并发通道 Channle Size
 const ChannelBufferSize = 1024
 
+// chunk_error_detail 错误 SQL 记录长度上限以及单个绑定变量值记录长度上限，单位 byte
+const (
+	ChunkErrorSQLMaxLength   = 65536
+	ChunkErrorValueMaxLength = 256
+)
+
+// LOB 表数据读写通道 Size，单 batch 内存约为 2 倍 lob-size-limit，限制 LOB 表缓冲 batch 数
+const LOBChannelBufferSize = 2
+
 // 任务模式
 const (
 	TaskModePrepare = "PREPARE"
@@ -38,6 +49,8 @@ const (
 	TaskModeCSV     = "CSV"
 	TaskModeFull    = "FULL"
 	TaskModeAll     = "ALL"
+	TaskModeRefresh = "REFRESH"
+	TaskModeImport  = "IMPORT"
 )
 
 // 任务状态
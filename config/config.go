@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"github.com/wentaojin/transferdb/common"
 	"os"
+	"regexp"
 	"strings"
 
 	"github.com/BurntSushi/toml"
@@ -28,39 +29,80 @@ import (
 
 // 程序配置文件
 type Config struct {
-	*flag.FlagSet `json:"-"`
-	AppConfig     AppConfig     `toml:"app" json:"app"`
-	ReverseConfig ReverseConfig `toml:"reverse" json:"reverse"`
-	CheckConfig   CheckConfig   `toml:"check" json:"check"`
-	FullConfig    FullConfig    `toml:"full" json:"full"`
-	CSVConfig     CSVConfig     `toml:"csv" json:"csv"`
-	AllConfig     AllConfig     `toml:"all" json:"all"`
-	OracleConfig  OracleConfig  `toml:"oracle" json:"oracle"`
-	MySQLConfig   MySQLConfig   `toml:"mysql" json:"mysql"`
-	MetaConfig    MetaConfig    `toml:"meta" json:"meta"`
-	LogConfig     LogConfig     `toml:"log" json:"log"`
-	DiffConfig    DiffConfig    `toml:"compare" json:"compare"`
-	ConfigFile    string        `json:"config-file"`
-	PrintVersion  bool
-	TaskMode      string `json:"task-mode"`
-	DBTypeS       string `json:"db-type-s"`
-	DBTypeT       string `json:"db-type-t"`
+	*flag.FlagSet  `json:"-"`
+	AppConfig      AppConfig      `toml:"app" json:"app"`
+	ReverseConfig  ReverseConfig  `toml:"reverse" json:"reverse"`
+	CheckConfig    CheckConfig    `toml:"check" json:"check"`
+	FullConfig     FullConfig     `toml:"full" json:"full"`
+	CSVConfig      CSVConfig      `toml:"csv" json:"csv"`
+	AllConfig      AllConfig      `toml:"all" json:"all"`
+	OracleConfig   OracleConfig   `toml:"oracle" json:"oracle"`
+	MySQLConfig    MySQLConfig    `toml:"mysql" json:"mysql"`
+	MetaConfig     MetaConfig     `toml:"meta" json:"meta"`
+	LogConfig      LogConfig      `toml:"log" json:"log"`
+	DiffConfig     DiffConfig     `toml:"compare" json:"compare"`
+	RefreshConfig  RefreshConfig  `toml:"refresh" json:"refresh"`
+	ThrottleConfig ThrottleConfig `toml:"throttle" json:"throttle"`
+	ConfigFile     string         `json:"config-file"`
+	PrintVersion   bool
+	TaskMode       string `json:"task-mode"`
+	DBTypeS        string `json:"db-type-s"`
+	DBTypeT        string `json:"db-type-t"`
 }
 
 type AppConfig struct {
 	InsertBatchSize  int    `toml:"insert-batch-size" json:"insert-batch-size"`
 	SlowlogThreshold int    `toml:"slowlog-threshold" json:"slowlog-threshold"`
 	PprofPort        string `toml:"pprof-port" json:"pprof-port"`
+	LOBSizeLimit     int    `toml:"lob-size-limit" json:"lob-size-limit"`
+	// Oracle 空字符串等同 NULL，字符类型字段 NULL 写入下游方式 null/empty，表级配置优先
+	EmptyStringAs          string                   `toml:"empty-string-as" json:"empty-string-as"`
+	EmptyStringTableConfig []EmptyStringTableConfig `toml:"empty-string-table-config" json:"empty-string-table-config"`
+	// 分区表按分区/子分区抽取，表级配置指定分区迁移以及对比
+	PartitionWise        bool                   `toml:"partition-wise" json:"partition-wise"`
+	PartitionTableConfig []PartitionTableConfig `toml:"partition-table-config" json:"partition-table-config"`
+}
+
+type EmptyStringTableConfig struct {
+	SourceTable   string `toml:"source-table" json:"source-table"`
+	EmptyStringAs string `toml:"empty-string-as" json:"empty-string-as"`
+}
+
+type PartitionTableConfig struct {
+	SourceTable      string   `toml:"source-table" json:"source-table"`
+	Partitions       []string `toml:"partitions" json:"partitions"`
+	RecentPartitions int      `toml:"recent-partitions" json:"recent-partitions"`
 }
 
 type DiffConfig struct {
-	ChunkSize         int           `toml:"chunk-size" json:"chunk-size"`
-	DiffThreads       int           `toml:"diff-threads" json:"diff-threads"`
-	OnlyCheckRows     bool          `toml:"only-check-rows" json:"only-check-rows"`
-	EnableCheckpoint  bool          `toml:"enable-checkpoint" json:"enable-checkpoint"`
-	IgnoreStructCheck bool          `toml:"ignore-struct-check" json:"ignore-struct-check"`
-	FixSqlDir         string        `toml:"fix-sql-dir" json:"fix-sql-dir"`
-	TableConfig       []TableConfig `toml:"table-config" json:"table-config"`
+	ChunkSize     int  `toml:"chunk-size" json:"chunk-size"`
+	DiffThreads   int  `toml:"diff-threads" json:"diff-threads"`
+	OnlyCheckRows bool `toml:"only-check-rows" json:"only-check-rows"`
+	// 关闭服务端聚合校验，逐行拉取数据对比
+	DisableChecksumPushdown bool `toml:"disable-checksum-pushdown" json:"disable-checksum-pushdown"`
+	// 差异数据块二分定位，数据块行数小于等于该值停止二分，逐行对比
+	BisectMinRows     int    `toml:"bisect-min-rows" json:"bisect-min-rows"`
+	EnableCheckpoint  bool   `toml:"enable-checkpoint" json:"enable-checkpoint"`
+	IgnoreStructCheck bool   `toml:"ignore-struct-check" json:"ignore-struct-check"`
+	FixSqlDir         string `toml:"fix-sql-dir" json:"fix-sql-dir"`
+	// 差异数据输出格式 json/csv，与修复 SQL 文件同目录输出
+	FixDiffFormat string `toml:"fix-diff-format" json:"fix-diff-format"`
+	// 差异数据自动修复，按批次单事务执行修复 SQL 并重新校验
+	AutoFix          bool `toml:"auto-fix" json:"auto-fix"`
+	AutoFixDryRun    bool `toml:"auto-fix-dry-run" json:"auto-fix-dry-run"`
+	AutoFixBatchSize int  `toml:"auto-fix-batch-size" json:"auto-fix-batch-size"`
+	// 在线校验，all 模式同步期间上游 AS OF SCN 查询并等待增量同步 checkpoint 超过该 SCN 再校验
+	LiveValidation     bool `toml:"live-validation" json:"live-validation"`
+	LiveWaitTimeout    int  `toml:"live-wait-timeout" json:"live-wait-timeout"`
+	LiveRecheckDelay   int  `toml:"live-recheck-delay" json:"live-recheck-delay"`
+	LiveRecheckTimes   int  `toml:"live-recheck-times" json:"live-recheck-times"`
+	LiveSampleInterval int  `toml:"live-sample-interval" json:"live-sample-interval"`
+	// 抽样校验数据块百分比，1-99 代表随机抽样校验，存在不一致数据块的表升级全量校验
+	SamplePercent int `toml:"sample-percent" json:"sample-percent"`
+	// 数据行对比浮点数容差以及时间小数秒精度
+	FloatTolerance float64       `toml:"float-tolerance" json:"float-tolerance"`
+	TimePrecision  int           `toml:"time-precision" json:"time-precision"`
+	TableConfig    []TableConfig `toml:"table-config" json:"table-config"`
 }
 
 type ReverseConfig struct {
@@ -68,11 +110,25 @@ type ReverseConfig struct {
 	DirectWrite      bool   `toml:"direct-write" json:"direct-write"`
 	DDLReverseDir    string `toml:"ddl-reverse-dir" json:"ddl-reverse-dir"`
 	DDLCompatibleDir string `toml:"ddl-compatible-dir" json:"ddl-compatible-dir"`
+	// 下游不支持函数索引时，是否以虚拟生成列 + 索引方式转换
+	FunctionIndexGenColumn bool `toml:"function-index-gen-column" json:"function-index-gen-column"`
+	// 目标端非 utf8mb4 字符集，抽样校验源端字符数据能否转换的行数，0 代表不校验
+	CharsetCheckRows int `toml:"charset-check-rows" json:"charset-check-rows"`
+	// 自定义函数索引表达式转换规则，优先内置规则匹配
+	IndexExprRules []IndexExprRule `toml:"index-expr-rule" json:"index-expr-rule"`
+}
+
+type IndexExprRule struct {
+	Pattern    string   `toml:"pattern" json:"pattern"`
+	Replace    string   `toml:"replace" json:"replace"`
+	DataTypes  []string `toml:"data-types" json:"data-types"`
+	ColumnType string   `toml:"column-type" json:"column-type"`
 }
 
 type CheckConfig struct {
 	CheckThreads int    `toml:"check-threads" json:"check-threads"`
 	CheckSQLDir  string `toml:"check-sql-dir" json:"check-sql-dir"`
+	FailSeverity string `toml:"fail-severity" json:"fail-severity"`
 }
 
 type TableConfig struct {
@@ -82,18 +138,39 @@ type TableConfig struct {
 }
 
 type CSVConfig struct {
-	Header           bool   `toml:"header" json:"header"`
-	Separator        string `toml:"separator" json:"separator"`
-	Terminator       string `toml:"terminator" json:"terminator"`
-	Delimiter        string `toml:"delimiter" json:"delimiter"`
-	EscapeBackslash  bool   `toml:"escape-backslash" json:"escape-backslash"`
-	Charset          string `toml:"charset" json:"charset"`
-	Rows             int    `toml:"rows" json:"rows"`
-	OutputDir        string `toml:"output-dir" json:"output-dir"`
-	TaskThreads      int    `toml:"task-threads" json:"task-threads"`
-	TableThreads     int    `toml:"table-threads" json:"table-threads"`
-	SQLThreads       int    `toml:"sql-threads" json:"sql-threads"`
-	EnableCheckpoint bool   `toml:"enable-checkpoint" json:"enable-checkpoint"`
+	Header           bool     `toml:"header" json:"header"`
+	Separator        string   `toml:"separator" json:"separator"`
+	Terminator       string   `toml:"terminator" json:"terminator"`
+	Delimiter        string   `toml:"delimiter" json:"delimiter"`
+	EscapeBackslash  bool     `toml:"escape-backslash" json:"escape-backslash"`
+	Charset          string   `toml:"charset" json:"charset"`
+	Rows             int      `toml:"rows" json:"rows"`
+	OutputDir        string   `toml:"output-dir" json:"output-dir"`
+	TaskThreads      int      `toml:"task-threads" json:"task-threads"`
+	TableThreads     int      `toml:"table-threads" json:"table-threads"`
+	SQLThreads       int      `toml:"sql-threads" json:"sql-threads"`
+	EnableCheckpoint bool     `toml:"enable-checkpoint" json:"enable-checkpoint"`
+	FileFormat       string   `toml:"file-format" json:"file-format"`
+	Compress         string   `toml:"compress" json:"compress"`
+	FileSize         int      `toml:"file-size" json:"file-size"`
+	Layout           string   `toml:"layout" json:"layout"`
+	ImportThreads    int      `toml:"import-threads" json:"import-threads"`
+	DisableLoadData  bool     `toml:"disable-load-data" json:"disable-load-data"`
+	Storage          string   `toml:"storage" json:"storage"`
+	S3Config         S3Config `toml:"s3" json:"s3"`
+	BinaryEncoding   string   `toml:"binary-encoding" json:"binary-encoding"`
+	NullValue        string   `toml:"null-value" json:"null-value"`
+}
+
+type S3Config struct {
+	Endpoint       string `toml:"endpoint" json:"endpoint"`
+	Region         string `toml:"region" json:"region"`
+	Bucket         string `toml:"bucket" json:"bucket"`
+	AccessKey      string `toml:"access-key" json:"access-key"`
+	SecretKey      string `toml:"secret-key" json:"secret-key"`
+	ForcePathStyle bool   `toml:"force-path-style" json:"force-path-style"`
+	PartSize       int    `toml:"part-size" json:"part-size"`
+	Concurrency    int    `toml:"concurrency" json:"concurrency"`
 }
 
 type FullConfig struct {
@@ -103,6 +180,28 @@ type FullConfig struct {
 	SQLThreads       int  `toml:"sql-threads" json:"sql-threads"`
 	ApplyThreads     int  `toml:"apply-threads" json:"apply-threads"`
 	EnableCheckpoint bool `toml:"enable-checkpoint" json:"enable-checkpoint"`
+	// 按表段大小以及平均行长自适应计算 chunk 行数，单 chunk 数据量 chunk-target-size MB，小于 small-table-size MB 的表不切分
+	AdaptiveChunk   bool `toml:"adaptive-chunk" json:"adaptive-chunk"`
+	ChunkTargetSize int  `toml:"chunk-target-size" json:"chunk-target-size"`
+	SmallTableSize  int  `toml:"small-table-size" json:"small-table-size"`
+	// 所有表 chunk 按表大小从大到小进入统一队列，table-threads * sql-threads 全局并发动态分配
+	AdaptiveSchedule bool `toml:"adaptive-schedule" json:"adaptive-schedule"`
+}
+
+type RefreshConfig struct {
+	RefreshThreads  int    `toml:"refresh-threads" json:"refresh-threads"`
+	RefreshInterval int    `toml:"refresh-interval" json:"refresh-interval"`
+	RefreshMethod   string `toml:"refresh-method" json:"refresh-method"`
+}
+
+// 上游 Oracle 数据抽取限流以及负载保护，作用于 FULL/CSV/ALL 全量以及 COMPARE 模式
+type ThrottleConfig struct {
+	MaxRowsPerSecond       int    `toml:"max-rows-per-second" json:"max-rows-per-second"`
+	MaxBytesPerSecond      int    `toml:"max-bytes-per-second" json:"max-bytes-per-second"`
+	MaxSessions            int    `toml:"max-sessions" json:"max-sessions"`
+	ScheduleWindow         string `toml:"schedule-window" json:"schedule-window"`
+	ActiveSessionThreshold int    `toml:"active-session-threshold" json:"active-session-threshold"`
+	BackoffInterval        int    `toml:"backoff-interval" json:"backoff-interval"`
 }
 
 type AllConfig struct {
@@ -138,6 +237,7 @@ type MySQLConfig struct {
 	MetaSchema    string `toml:"meta-schema" json:"meta-schema"`
 	SchemaName    string `toml:"schema-name" json:"schema-name"`
 	TableOption   string `toml:"table-option" json:"table-option"`
+	Charset       string `toml:"charset" json:"charset"`
 	Overwrite     bool   `toml:"overwrite" json:"overwrite"`
 }
 
@@ -168,7 +268,7 @@ func NewConfig() *Config {
 	}
 	fs.BoolVar(&cfg.PrintVersion, "V", false, "print version information and exit")
 	fs.StringVar(&cfg.ConfigFile, "config", "./config.toml", "path to the configuration file")
-	fs.StringVar(&cfg.TaskMode, "mode", "", "specify the program running mode: [prepare assess reverse full csv all check compare]")
+	fs.StringVar(&cfg.TaskMode, "mode", "", "specify the program running mode: [prepare assess reverse full csv import all check compare refresh]")
 	fs.StringVar(&cfg.DBTypeS, "source", "oracle", "specify the source db type")
 	fs.StringVar(&cfg.DBTypeT, "target", "mysql", "specify the target db type")
 	return cfg
@@ -218,11 +318,155 @@ func (c *Config) AdjustConfig() error {
 	c.OracleConfig.PDBName = common.StringUPPER(c.OracleConfig.PDBName)
 	c.MySQLConfig.SchemaName = common.StringUPPER(c.MySQLConfig.SchemaName)
 
+	if c.AppConfig.LOBSizeLimit < 0 {
+		return fmt.Errorf("app config lob-size-limit [%d] can't be less than 0", c.AppConfig.LOBSizeLimit)
+	}
+	if c.AppConfig.LOBSizeLimit == 0 {
+		c.AppConfig.LOBSizeLimit = common.OracleLOBDefaultSizeLimit
+	}
+	c.AppConfig.EmptyStringAs = strings.ToLower(c.AppConfig.EmptyStringAs)
+	switch c.AppConfig.EmptyStringAs {
+	case "":
+		c.AppConfig.EmptyStringAs = common.EmptyStringAsNull
+	case common.EmptyStringAsNull, common.EmptyStringAsEmpty:
+	default:
+		return fmt.Errorf("app config empty-string-as is not support: [%s], only support [null/empty]", c.AppConfig.EmptyStringAs)
+	}
+	for i, t := range c.AppConfig.EmptyStringTableConfig {
+		c.AppConfig.EmptyStringTableConfig[i].SourceTable = common.StringUPPER(t.SourceTable)
+		c.AppConfig.EmptyStringTableConfig[i].EmptyStringAs = strings.ToLower(t.EmptyStringAs)
+		switch c.AppConfig.EmptyStringTableConfig[i].EmptyStringAs {
+		case common.EmptyStringAsNull, common.EmptyStringAsEmpty:
+		default:
+			return fmt.Errorf("app config table [%s] empty-string-as is not support: [%s], only support [null/empty]", t.SourceTable, t.EmptyStringAs)
+		}
+	}
+
+	for i, t := range c.AppConfig.PartitionTableConfig {
+		if t.SourceTable == "" {
+			return fmt.Errorf("app config partition-table-config source-table can't be null")
+		}
+		if t.RecentPartitions < 0 {
+			return fmt.Errorf("app config table [%s] recent-partitions [%d] can't be less than 0", t.SourceTable, t.RecentPartitions)
+		}
+		if len(t.Partitions) == 0 && t.RecentPartitions == 0 {
+			return fmt.Errorf("app config table [%s] partitions and recent-partitions can't be both null", t.SourceTable)
+		}
+		c.AppConfig.PartitionTableConfig[i].SourceTable = common.StringUPPER(t.SourceTable)
+		for j, p := range t.Partitions {
+			c.AppConfig.PartitionTableConfig[i].Partitions[j] = common.StringUPPER(p)
+		}
+	}
+
+	if c.ReverseConfig.CharsetCheckRows < 0 {
+		return fmt.Errorf("reverse config charset-check-rows [%d] can't be less than 0", c.ReverseConfig.CharsetCheckRows)
+	}
+	for i, r := range c.ReverseConfig.IndexExprRules {
+		if r.Pattern == "" {
+			return fmt.Errorf("reverse config index-expr-rule pattern can't be null")
+		}
+		if _, err := regexp.Compile(r.Pattern); err != nil {
+			return fmt.Errorf("reverse config index-expr-rule pattern [%s] compile failed: %v", r.Pattern, err)
+		}
+		for j, d := range r.DataTypes {
+			c.ReverseConfig.IndexExprRules[i].DataTypes[j] = common.StringUPPER(d)
+		}
+	}
+
+	if c.FullConfig.ChunkSize <= 0 {
+		c.FullConfig.ChunkSize = common.FullDefaultChunkSize
+	}
+	if c.FullConfig.ChunkTargetSize <= 0 {
+		c.FullConfig.ChunkTargetSize = common.FullAdaptiveChunkTargetSize
+	}
+	if c.FullConfig.SmallTableSize < 0 {
+		return fmt.Errorf("full config small-table-size [%d] can't be less than 0", c.FullConfig.SmallTableSize)
+	}
+
+	c.RefreshConfig.RefreshMethod = common.StringUPPER(c.RefreshConfig.RefreshMethod)
+	if c.RefreshConfig.RefreshThreads <= 0 {
+		c.RefreshConfig.RefreshThreads = 1
+	}
+
+	if c.ThrottleConfig.MaxRowsPerSecond < 0 || c.ThrottleConfig.MaxBytesPerSecond < 0 || c.ThrottleConfig.MaxSessions < 0 || c.ThrottleConfig.ActiveSessionThreshold < 0 {
+		return fmt.Errorf("throttle config max-rows-per-second [%d], max-bytes-per-second [%d], max-sessions [%d] and active-session-threshold [%d] can't be less than 0",
+			c.ThrottleConfig.MaxRowsPerSecond, c.ThrottleConfig.MaxBytesPerSecond, c.ThrottleConfig.MaxSessions, c.ThrottleConfig.ActiveSessionThreshold)
+	}
+	if c.ThrottleConfig.ScheduleWindow != "" {
+		if _, _, err := common.ParseTimeWindow(c.ThrottleConfig.ScheduleWindow); err != nil {
+			return fmt.Errorf("throttle config schedule-window failed: %v", err)
+		}
+	}
+	if c.ThrottleConfig.BackoffInterval <= 0 {
+		c.ThrottleConfig.BackoffInterval = common.ThrottleDefaultBackoffInterval
+	}
+
+	if c.DiffConfig.BisectMinRows <= 0 {
+		c.DiffConfig.BisectMinRows = 1000
+	}
+
+	if c.DiffConfig.AutoFixBatchSize <= 0 {
+		c.DiffConfig.AutoFixBatchSize = 100
+	}
+
+	if c.DiffConfig.LiveWaitTimeout <= 0 {
+		c.DiffConfig.LiveWaitTimeout = 600
+	}
+	if c.DiffConfig.LiveRecheckDelay <= 0 {
+		c.DiffConfig.LiveRecheckDelay = 30
+	}
+	if c.DiffConfig.LiveRecheckTimes < 0 {
+		c.DiffConfig.LiveRecheckTimes = 0
+	}
+
+	if c.DiffConfig.SamplePercent < 0 || c.DiffConfig.SamplePercent > 100 {
+		return fmt.Errorf("diff config sample-percent [%d] is not support, only support [0-100]", c.DiffConfig.SamplePercent)
+	}
+
+	if c.DiffConfig.FloatTolerance < 0 {
+		return fmt.Errorf("diff config float-tolerance [%v] can't be less than 0", c.DiffConfig.FloatTolerance)
+	}
+	if c.DiffConfig.TimePrecision < 0 || c.DiffConfig.TimePrecision > 6 {
+		return fmt.Errorf("diff config time-precision [%d] is not support, only support [0-6]", c.DiffConfig.TimePrecision)
+	}
+
+	c.DiffConfig.FixDiffFormat = strings.ToLower(c.DiffConfig.FixDiffFormat)
+	switch c.DiffConfig.FixDiffFormat {
+	case "":
+		c.DiffConfig.FixDiffFormat = common.CompareDiffFormatJSON
+	case common.CompareDiffFormatJSON, common.CompareDiffFormatCSV:
+	default:
+		return fmt.Errorf("diff config fix-diff-format is not support: [%s], only support [json/csv]", c.DiffConfig.FixDiffFormat)
+	}
+
+	c.CheckConfig.FailSeverity = common.StringUPPER(c.CheckConfig.FailSeverity)
+	if c.CheckConfig.FailSeverity != "" {
+		if _, ok := common.CheckSeverityLevel[c.CheckConfig.FailSeverity]; !ok {
+			return fmt.Errorf("check config fail-severity is not support: [%s], only support [info/warn/error]", c.CheckConfig.FailSeverity)
+		}
+	}
+
 	err := c.adjustCSVConfig()
 	if err != nil {
 		return err
 	}
 
+	err = c.adjustMySQLConfig()
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (c *Config) adjustMySQLConfig() error {
+	if c.MySQLConfig.Charset == "" {
+		c.MySQLConfig.Charset = common.MySQLCharacterSet
+	}
+	c.MySQLConfig.Charset = common.StringUPPER(c.MySQLConfig.Charset)
+	if _, ok := common.MySQLCharacterSetMaxBytes[c.MySQLConfig.Charset]; !ok {
+		return fmt.Errorf("target db table charset is not support: [%s], only support [utf8mb4/gbk/latin1/binary]", c.MySQLConfig.Charset)
+	}
 	return nil
 }
 
@@ -247,9 +491,127 @@ func (c *Config) adjustCSVConfig() error {
 	if !isSupport {
 		return fmt.Errorf("target db character is not support: [%s]", c.CSVConfig.Charset)
 	}
+
+	c.CSVConfig.FileFormat = strings.ToLower(c.CSVConfig.FileFormat)
+	switch c.CSVConfig.FileFormat {
+	case "":
+		c.CSVConfig.FileFormat = common.CSVFileFormatCSV
+	case common.CSVFileFormatCSV, common.CSVFileFormatParquet:
+	default:
+		return fmt.Errorf("csv config file-format is not support: [%s], only support [csv/parquet]", c.CSVConfig.FileFormat)
+	}
+	c.CSVConfig.Compress = strings.ToLower(c.CSVConfig.Compress)
+	switch c.CSVConfig.Compress {
+	case "", common.CSVCompressGzip, common.CSVCompressZstd, common.CSVCompressSnappy:
+	default:
+		return fmt.Errorf("csv config compress is not support: [%s], only support [gzip/zstd/snappy]", c.CSVConfig.Compress)
+	}
+	if c.CSVConfig.FileSize < 0 {
+		return fmt.Errorf("csv config file-size [%d] can't be less than 0", c.CSVConfig.FileSize)
+	}
+	c.CSVConfig.Storage = strings.ToLower(c.CSVConfig.Storage)
+	switch c.CSVConfig.Storage {
+	case "":
+		c.CSVConfig.Storage = common.CSVStorageLocal
+	case common.CSVStorageLocal:
+	case common.CSVStorageS3:
+		if c.CSVConfig.S3Config.Bucket == "" {
+			return fmt.Errorf("csv config storage [%s] bucket can't be null, please configure", c.CSVConfig.Storage)
+		}
+		if c.CSVConfig.S3Config.Region == "" {
+			c.CSVConfig.S3Config.Region = common.CSVStorageS3DefaultRegion
+		}
+		// multipart 分片最小 5MB
+		if c.CSVConfig.S3Config.PartSize < 5 {
+			c.CSVConfig.S3Config.PartSize = 5
+		}
+		if c.CSVConfig.S3Config.Concurrency <= 0 {
+			c.CSVConfig.S3Config.Concurrency = 4
+		}
+	default:
+		return fmt.Errorf("csv config storage is not support: [%s], only support [local/s3]", c.CSVConfig.Storage)
+	}
+	if c.CSVConfig.ImportThreads <= 0 {
+		c.CSVConfig.ImportThreads = 1
+	}
+	// NULL 值表示，empty 代表空字段
+	switch {
+	case c.CSVConfig.NullValue == "":
+		c.CSVConfig.NullValue = common.CSVNullValueDefault
+	case strings.EqualFold(c.CSVConfig.NullValue, common.CSVNullValueEmpty):
+		c.CSVConfig.NullValue = ""
+	}
+	if c.CSVConfig.NullValue != "" && (strings.Contains(c.CSVConfig.NullValue, c.CSVConfig.Separator) ||
+		strings.Contains(c.CSVConfig.NullValue, c.CSVConfig.Terminator) ||
+		(c.CSVConfig.Delimiter != "" && strings.Contains(c.CSVConfig.NullValue, c.CSVConfig.Delimiter))) {
+		return fmt.Errorf("csv config null-value [%s] can't contain separator, terminator or delimiter", c.CSVConfig.NullValue)
+	}
+	// null-value 为空字段且 delimiter 为空时，empty-string-as 为 empty 的空字符串与 NULL 无法区分
+	if c.CSVConfig.NullValue == "" && c.CSVConfig.Delimiter == "" {
+		if c.AppConfig.EmptyStringAs == common.EmptyStringAsEmpty {
+			return fmt.Errorf("csv config null-value [empty] and delimiter [] can't distinguish empty string from NULL when app config empty-string-as is [empty]")
+		}
+		for _, t := range c.AppConfig.EmptyStringTableConfig {
+			if t.EmptyStringAs == common.EmptyStringAsEmpty {
+				return fmt.Errorf("csv config null-value [empty] and delimiter [] can't distinguish empty string from NULL when app config table [%s] empty-string-as is [empty]", t.SourceTable)
+			}
+		}
+	}
+	c.CSVConfig.BinaryEncoding = strings.ToLower(c.CSVConfig.BinaryEncoding)
+	switch c.CSVConfig.BinaryEncoding {
+	case "":
+		c.CSVConfig.BinaryEncoding = common.CSVBinaryEncodingHex
+	case common.CSVBinaryEncodingHex, common.CSVBinaryEncodingBase64:
+	default:
+		return fmt.Errorf("csv config binary-encoding is not support: [%s], only support [hex/base64]", c.CSVConfig.BinaryEncoding)
+	}
+	c.CSVConfig.Layout = strings.ToLower(c.CSVConfig.Layout)
+	switch c.CSVConfig.Layout {
+	case "":
+		c.CSVConfig.Layout = common.CSVLayoutDefault
+	case common.CSVLayoutDefault, common.CSVLayoutLightning:
+	default:
+		return fmt.Errorf("csv config layout is not support: [%s], only support [default/lightning]", c.CSVConfig.Layout)
+	}
+	// lightning 按文件名 ${db}.${table}.${n}.csv 识别数据文件，滚动文件名不兼容，单文件大小由 rows 控制
+	if strings.EqualFold(c.CSVConfig.Layout, common.CSVLayoutLightning) && c.CSVConfig.FileSize > 0 {
+		return fmt.Errorf("csv config layout [%s] isn't support file-size [%d], please set file-size 0 and adjust rows", c.CSVConfig.Layout, c.CSVConfig.FileSize)
+	}
 	return nil
 }
 
+// GetEmptyStringAs 源端表字符类型字段 NULL 写入下游方式，表级配置优先
+func (c AppConfig) GetEmptyStringAs(sourceTable string) string {
+	for _, t := range c.EmptyStringTableConfig {
+		if strings.EqualFold(t.SourceTable, sourceTable) {
+			return t.EmptyStringAs
+		}
+	}
+	if c.EmptyStringAs == "" {
+		return common.EmptyStringAsNull
+	}
+	return c.EmptyStringAs
+}
+
+// IsPartitionWise 源端分区表是否按分区/子分区抽取，指定分区的表默认按分区抽取
+func (c AppConfig) IsPartitionWise(sourceTable string) bool {
+	if c.PartitionWise {
+		return true
+	}
+	_, ok := c.GetPartitionTableConfig(sourceTable)
+	return ok
+}
+
+// GetPartitionTableConfig 源端表指定分区配置
+func (c AppConfig) GetPartitionTableConfig(sourceTable string) (PartitionTableConfig, bool) {
+	for _, t := range c.PartitionTableConfig {
+		if strings.EqualFold(t.SourceTable, sourceTable) {
+			return t, true
+		}
+	}
+	return PartitionTableConfig{}, false
+}
+
 func (c *Config) String() string {
 	cfg, err := json.Marshal(c)
 	if err != nil {
@@ -0,0 +1,118 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package meta
+
+import (
+	"context"
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// csv 数据文件导入元数据表
+// 数据文件来源于 csv 模式 full_sync_meta csv_file 记录，按文件记录导入状态用于断点续传
+type CSVImportMeta struct {
+	ID           uint   `gorm:"primary_key;autoIncrement;comment:'自增编号'" json:"id"`
+	DBTypeS      string `gorm:"type:varchar(30);index:idx_dbtype_st_map,unique;comment:'源数据库类型'" json:"db_type_s"`
+	DBTypeT      string `gorm:"type:varchar(30);index:idx_dbtype_st_map,unique;comment:'目标数据库类型'" json:"db_type_t"`
+	SchemaNameS  string `gorm:"type:varchar(100);not null;index:idx_dbtype_st_map,unique;comment:'源端 schema'" json:"schema_name_s"`
+	TableNameS   string `gorm:"type:varchar(100);not null;comment:'源端表名'" json:"table_name_s"`
+	SchemaNameT  string `gorm:"type:varchar(100);not null;comment:'目标端 schema'" json:"schema_name_t"`
+	TableNameT   string `gorm:"type:varchar(100);not null;comment:'目标端表名'" json:"table_name_t"`
+	CSVFile      string `gorm:"type:varchar(300);not null;index:idx_dbtype_st_map,unique;comment:'csv 文件名'" json:"csv_file"`
+	CSVChecksum  string `gorm:"type:varchar(64);comment:'csv 文件导出 sha256 校验和'" json:"csv_checksum"`
+	ColumnDetail string `gorm:"type:text;comment:'csv 文件导出查询字段信息'" json:"column_detail"`
+	ImportMethod string `gorm:"type:varchar(30);comment:'导入方式 LOAD/INSERT'" json:"import_method"`
+	ImportRows   int64  `gorm:"comment:'导入写入行数'" json:"import_rows"`
+	TaskStatus   string `gorm:"type:varchar(30);not null;comment:'文件导入状态'" json:"task_status"`
+	ErrorDetail  string `gorm:"type:longtext;comment:'错误详情'" json:"error_detail"`
+	*BaseModel
+}
+
+func NewCSVImportMetaModel(m *Meta) *CSVImportMeta {
+	return &CSVImportMeta{
+		BaseModel: &BaseModel{
+			Meta: m,
+		},
+	}
+}
+
+func (rw *CSVImportMeta) ParseSchemaTable() (string, error) {
+	stmt := &gorm.Statement{DB: rw.GormDB}
+	err := stmt.Parse(rw)
+	if err != nil {
+		return "", fmt.Errorf("parse struct [CSVImportMeta] get table_name failed: %v", err)
+	}
+	return stmt.Schema.Table, nil
+}
+
+// 已存在记录保持原有导入状态，用于断点续传
+func (rw *CSVImportMeta) CreateCSVImportMeta(ctx context.Context, createS *CSVImportMeta) error {
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return err
+	}
+	if err = rw.DB(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(createS).Error; err != nil {
+		return fmt.Errorf("create table [%s] record failed: %v", table, err)
+	}
+	return nil
+}
+
+func (rw *CSVImportMeta) DetailCSVImportMeta(ctx context.Context, detailS *CSVImportMeta) ([]CSVImportMeta, error) {
+	var importMetas []CSVImportMeta
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return importMetas, err
+	}
+	if err = rw.DB(ctx).Where(detailS).Find(&importMetas).Error; err != nil {
+		return importMetas, fmt.Errorf("detail table [%s] record failed: %v", table, err)
+	}
+	return importMetas, nil
+}
+
+func (rw *CSVImportMeta) UpdateCSVImportMeta(ctx context.Context, detailS *CSVImportMeta, updates map[string]interface{}) error {
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return err
+	}
+	err = rw.DB(ctx).Model(&CSVImportMeta{}).
+		Where("db_type_s = ? AND db_type_t = ? AND schema_name_s = ? AND csv_file = ?",
+			common.StringUPPER(detailS.DBTypeS),
+			common.StringUPPER(detailS.DBTypeT),
+			common.StringUPPER(detailS.SchemaNameS),
+			detailS.CSVFile).
+		Updates(updates).Error
+	if err != nil {
+		return fmt.Errorf("update table [%s] record failed: %v", table, err)
+	}
+	return nil
+}
+
+func (rw *CSVImportMeta) DeleteCSVImportMetaBySchema(ctx context.Context, deleteS *CSVImportMeta) error {
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return err
+	}
+	err = rw.DB(ctx).Where("db_type_s = ? AND db_type_t = ? AND schema_name_s = ?",
+		common.StringUPPER(deleteS.DBTypeS),
+		common.StringUPPER(deleteS.DBTypeT),
+		common.StringUPPER(deleteS.SchemaNameS)).Delete(&CSVImportMeta{}).Error
+	if err != nil {
+		return fmt.Errorf("delete table [%s] reocrd failed: %v", table, err)
+	}
+	return nil
+}
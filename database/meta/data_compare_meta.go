@@ -17,6 +17,7 @@ package meta
 
 import (
 	"context"
+	"crypto/md5"
 	"fmt"
 	"github.com/wentaojin/transferdb/common"
 	"gorm.io/gorm"
@@ -25,21 +26,23 @@ import (
 // 数据校验元数据表
 type DataCompareMeta struct {
 	ID            uint   `gorm:"primary_key;autoIncrement;comment:'自增编号'" json:"id"`
-	DBTypeS       string `gorm:"type:varchar(30);index:idx_dbtype_st_obj,unique;comment:'源数据库类型'" json:"db_type_s"`
-	DBTypeT       string `gorm:"type:varchar(30);index:idx_dbtype_st_obj,unique;comment:'目标数据库类型'" json:"db_type_t"`
-	SchemaNameS   string `gorm:"type:varchar(100);not null;index:idx_dbtype_st_obj,unique;comment:'源端 schema'" json:"schema_name_s"`
-	TableNameS    string `gorm:"type:varchar(100);not null;index:idx_dbtype_st_obj,unique;comment:'源端表名'" json:"table_name_s"`
+	DBTypeS       string `gorm:"type:varchar(30);index:idx_dbtype_st_range,unique;comment:'源数据库类型'" json:"db_type_s"`
+	DBTypeT       string `gorm:"type:varchar(30);index:idx_dbtype_st_range,unique;comment:'目标数据库类型'" json:"db_type_t"`
+	SchemaNameS   string `gorm:"type:varchar(100);not null;index:idx_dbtype_st_range,unique;comment:'源端 schema'" json:"schema_name_s"`
+	TableNameS    string `gorm:"type:varchar(100);not null;index:idx_dbtype_st_range,unique;comment:'源端表名'" json:"table_name_s"`
 	ColumnDetailS string `gorm:"type:text;comment:'源端查询字段信息'" json:"column_detail_s"`
 	SchemaNameT   string `gorm:"type:varchar(100);not null;comment:'目标端 schema'" json:"schema_name_t"`
 	TableNameT    string `gorm:"type:varchar(100);not null;comment:'目标端表名'" json:"table_name_t"`
 	ColumnDetailT string `gorm:"type:text;comment:'目标端查询字段信息'" json:"column_detail_t"`
 	WhereColumn   string `gorm:"comment:'查询类型字段列'" json:"where_column"`
-	WhereRange    string `gorm:"type:varchar(300);not null;index:idx_dbtype_st_obj,unique;comment:'查询 where 条件'" json:"where_range"`
-	TaskMode      string `gorm:"type:varchar(30);not null;index:idx_dbtype_st_obj,unique;comment:'任务模式'" json:"task_mode"`
+	WhereRange    string `gorm:"type:text;not null;comment:'查询 where 条件'" json:"where_range"`
+	WhereRangeMD5 string `gorm:"type:varchar(32);not null;index:idx_dbtype_st_range,unique;comment:'查询 where 条件 MD5，用于唯一约束'" json:"where_range_md5"`
+	TaskMode      string `gorm:"type:varchar(30);not null;index:idx_dbtype_st_range,unique;comment:'任务模式'" json:"task_mode"`
 	TaskStatus    string `gorm:"type:varchar(30);not null;comment:'数据对比状态,only waiting,success,failed'" json:"task_status"`
 	IsPartition   string `gorm:"comment:'是否是分区表'" json:"is_partition"` // 同步转换统一转换成非分区表，此处只做标志
 	InfoDetail    string `gorm:"type:text;not null;comment:'信息详情'" json:"info_detail"`
 	ErrorDetail   string `gorm:"type:text;not null;comment:'错误详情'" json:"error_detail"`
+	BisectDetail  string `gorm:"type:text;comment:'差异数据块二分定位步骤'" json:"bisect_detail"`
 	*BaseModel
 }
 
@@ -51,6 +54,16 @@ func NewDataCompareMetaModel(m *Meta) *DataCompareMeta {
 	}
 }
 
+// BeforeCreate where 条件长度不受限（分区范围叠加 chunk 范围），唯一约束使用 where 条件 MD5
+func (rw *DataCompareMeta) BeforeCreate(db *gorm.DB) (err error) {
+	rw.WhereRangeMD5 = genWhereRangeMD5(rw.WhereRange)
+	return rw.BaseModel.BeforeCreate(db)
+}
+
+func genWhereRangeMD5(whereRange string) string {
+	return fmt.Sprintf("%x", md5.Sum([]byte(whereRange)))
+}
+
 func (rw *DataCompareMeta) ParseSchemaTable() (string, error) {
 	stmt := &gorm.Statement{DB: rw.GormDB}
 	err := stmt.Parse(rw)
@@ -106,19 +119,36 @@ func (rw *DataCompareMeta) TruncateDataCompareMeta(ctx context.Context) error {
 	return nil
 }
 
+func (rw *DataCompareMeta) DeleteDataCompareMetaByTable(ctx context.Context, deleteS *DataCompareMeta) error {
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return err
+	}
+	if err = rw.DB(ctx).Where("db_type_s = ? AND db_type_t = ? AND schema_name_s = ? AND table_name_s = ? AND task_mode = ?",
+		common.StringUPPER(deleteS.DBTypeS),
+		common.StringUPPER(deleteS.DBTypeT),
+		common.StringUPPER(deleteS.SchemaNameS),
+		common.StringUPPER(deleteS.TableNameS),
+		common.StringUPPER(deleteS.TaskMode)).
+		Delete(&DataCompareMeta{}).Error; err != nil {
+		return fmt.Errorf("delete table [%s] record failed: %v", table, err)
+	}
+	return nil
+}
+
 func (rw *DataCompareMeta) UpdateDataCompareMeta(ctx context.Context, deleteS *DataCompareMeta, updates map[string]interface{}) error {
 	table, err := rw.ParseSchemaTable()
 	if err != nil {
 		return err
 	}
 	if err = rw.DB(ctx).Model(DataCompareMeta{}).
-		Where("db_type_s = ? AND db_type_t = ? AND schema_name_s = ? AND table_name_s = ? AND task_mode = ? AND where_range = ?",
+		Where("db_type_s = ? AND db_type_t = ? AND schema_name_s = ? AND table_name_s = ? AND task_mode = ? AND where_range_md5 = ?",
 			common.StringUPPER(deleteS.DBTypeS),
 			common.StringUPPER(deleteS.DBTypeT),
 			common.StringUPPER(deleteS.SchemaNameS),
 			common.StringUPPER(deleteS.TableNameS),
 			common.StringUPPER(deleteS.TaskMode),
-			deleteS.WhereRange).
+			genWhereRangeMD5(deleteS.WhereRange)).
 		Updates(updates).Error; err != nil {
 		return fmt.Errorf("update table [%s] record failed: %v", table, err)
 	}
@@ -0,0 +1,80 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package meta
+
+import (
+	"context"
+	"fmt"
+	"gorm.io/gorm"
+)
+
+// 数据校验差异自动修复审计表
+type DataFixMeta struct {
+	ID          uint   `gorm:"primary_key;autoIncrement;comment:'自增编号'" json:"id"`
+	DBTypeS     string `gorm:"type:varchar(30);index:idx_dbtype_st_fix;comment:'源数据库类型'" json:"db_type_s"`
+	DBTypeT     string `gorm:"type:varchar(30);index:idx_dbtype_st_fix;comment:'目标数据库类型'" json:"db_type_t"`
+	SchemaNameS string `gorm:"type:varchar(100);not null;index:idx_dbtype_st_fix;comment:'源端 schema'" json:"schema_name_s"`
+	TableNameS  string `gorm:"type:varchar(100);not null;index:idx_dbtype_st_fix;comment:'源端表名'" json:"table_name_s"`
+	SchemaNameT string `gorm:"type:varchar(100);not null;comment:'目标端 schema'" json:"schema_name_t"`
+	TableNameT  string `gorm:"type:varchar(100);not null;comment:'目标端表名'" json:"table_name_t"`
+	TaskMode    string `gorm:"type:varchar(30);not null;index:idx_dbtype_st_fix;comment:'任务模式'" json:"task_mode"`
+	WhereRange  string `gorm:"type:text;not null;comment:'查询 where 条件'" json:"where_range"`
+	FixBatch    int    `gorm:"comment:'修复批次'" json:"fix_batch"`
+	FixSQL      string `gorm:"type:longtext;not null;comment:'修复 SQL'" json:"fix_sql"`
+	FixStatus   string `gorm:"type:varchar(30);not null;comment:'修复状态,only dryrun,success,failed'" json:"fix_status"`
+	ErrorDetail string `gorm:"type:longtext;comment:'错误详情'" json:"error_detail"`
+	*BaseModel
+}
+
+func NewDataFixMetaModel(m *Meta) *DataFixMeta {
+	return &DataFixMeta{
+		BaseModel: &BaseModel{
+			Meta: m,
+		},
+	}
+}
+
+func (rw *DataFixMeta) ParseSchemaTable() (string, error) {
+	stmt := &gorm.Statement{DB: rw.GormDB}
+	err := stmt.Parse(rw)
+	if err != nil {
+		return "", fmt.Errorf("parse struct [DataFixMeta] get table_name failed: %v", err)
+	}
+	return stmt.Schema.Table, nil
+}
+
+func (rw *DataFixMeta) CreateDataFixMeta(ctx context.Context, createS *DataFixMeta) error {
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return err
+	}
+	if err = rw.DB(ctx).Create(createS).Error; err != nil {
+		return fmt.Errorf("create table [%s] record failed: %v", table, err)
+	}
+	return nil
+}
+
+func (rw *DataFixMeta) DetailDataFixMeta(ctx context.Context, detailS *DataFixMeta) ([]DataFixMeta, error) {
+	var dfMetas []DataFixMeta
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return dfMetas, err
+	}
+	if err = rw.DB(ctx).Where(detailS).Find(&dfMetas).Error; err != nil {
+		return dfMetas, fmt.Errorf("detail table [%s] record failed: %v", table, err)
+	}
+	return dfMetas, nil
+}
@@ -98,11 +98,15 @@ func (m *Meta) DB(ctx context.Context) *gorm.DB {
 }
 
 func (m *Meta) MigrateTables() (err error) {
+	if err = m.migrateDataCompareMetaWhereRange(); err != nil {
+		return err
+	}
 	return m.migrateStream(
 		new(ColumnDatatypeRule),
 		new(TableDatatypeRule),
 		new(SchemaDatatypeRule),
 		new(DataCompareMeta),
+		new(DataFixMeta),
 		new(WaitSyncMeta),
 		new(FullSyncMeta),
 		new(IncrSyncMeta),
@@ -113,6 +117,8 @@ func (m *Meta) MigrateTables() (err error) {
 		new(BuildinDatatypeRule),
 		new(TableNameRule),
 		new(ChunkErrorDetail),
+		new(MviewRefreshMeta),
+		new(CSVImportMeta),
 	)
 }
 
@@ -152,6 +158,35 @@ func (m *Meta) InitDefaultValue(ctx context.Context) error {
 	return nil
 }
 
+// migrateDataCompareMetaWhereRange data_compare_meta where_range 调整为 text，唯一索引改为 where_range_md5
+// 历史元数据需先补充 where_range_md5 列并回填 MD5，再由 AutoMigrate 创建唯一索引，保证断点续传数据可继续更新
+func (m *Meta) migrateDataCompareMetaWhereRange() (err error) {
+	migrator := m.GormDB.Migrator()
+	if !migrator.HasTable(&DataCompareMeta{}) {
+		return nil
+	}
+	if migrator.HasIndex(&DataCompareMeta{}, "idx_dbtype_st_obj") {
+		if err = migrator.DropIndex(&DataCompareMeta{}, "idx_dbtype_st_obj"); err != nil {
+			return fmt.Errorf("error on drop table [data_compare_meta] index [idx_dbtype_st_obj]: %v", err)
+		}
+	}
+	if !migrator.HasColumn(&DataCompareMeta{}, "WhereRangeMD5") {
+		if err = migrator.AddColumn(&DataCompareMeta{}, "WhereRangeMD5"); err != nil {
+			return fmt.Errorf("error on add table [data_compare_meta] column [where_range_md5]: %v", err)
+		}
+	}
+	// 回填历史数据（含升级中断遗留的空值）
+	result := m.GormDB.Exec("UPDATE data_compare_meta SET where_range_md5 = MD5(where_range) WHERE where_range_md5 = '' OR where_range_md5 IS NULL")
+	if result.Error != nil {
+		return fmt.Errorf("error on backfill table [data_compare_meta] column [where_range_md5]: %v", result.Error)
+	}
+	if result.RowsAffected > 0 {
+		zap.L().Warn("backfill data_compare_meta where_range_md5",
+			zap.Int64("rows", result.RowsAffected))
+	}
+	return nil
+}
+
 func (m *Meta) migrateStream(models ...interface{}) (err error) {
 	for _, model := range models {
 		err = m.GormDB.AutoMigrate(model)
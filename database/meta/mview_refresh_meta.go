@@ -0,0 +1,128 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package meta
+
+import (
+	"context"
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 物化视图刷新元数据表
+// 物化视图以普通表形式 reverse 以及 full 迁移，转换后的查询语句用于 refresh 模式下游刷新
+type MviewRefreshMeta struct {
+	ID             uint   `gorm:"primary_key;autoIncrement;comment:'自增编号'" json:"id"`
+	DBTypeS        string `gorm:"type:varchar(30);index:idx_dbtype_st_map,unique;comment:'源数据库类型'" json:"db_type_s"`
+	DBTypeT        string `gorm:"type:varchar(30);index:idx_dbtype_st_map,unique;comment:'目标数据库类型'" json:"db_type_t"`
+	SchemaNameS    string `gorm:"type:varchar(100);not null;index:idx_dbtype_st_map,unique;comment:'源端 schema'" json:"schema_name_s"`
+	MviewNameS     string `gorm:"type:varchar(100);not null;index:idx_dbtype_st_map,unique;comment:'源端物化视图名'" json:"mview_name_s"`
+	SchemaNameT    string `gorm:"type:varchar(100);not null;comment:'目标端 schema'" json:"schema_name_t"`
+	TableNameT     string `gorm:"type:varchar(100);not null;comment:'目标端表名'" json:"table_name_t"`
+	RefreshMethodS string `gorm:"type:varchar(30);comment:'源端物化视图刷新方式'" json:"refresh_method_s"`
+	RefreshMethodT string `gorm:"type:varchar(30);comment:'目标端刷新方式 COMPLETE/FAST'" json:"refresh_method_t"`
+	QueryS         string `gorm:"type:longtext;comment:'源端物化视图查询语句'" json:"query_s"`
+	QueryT         string `gorm:"type:longtext;comment:'目标端转换查询语句'" json:"query_t"`
+	IsCompatible   string `gorm:"type:varchar(10);comment:'转换查询语句是否完全兼容，N 需人工确认 query_t'" json:"is_compatible"`
+	TaskStatus     string `gorm:"type:varchar(30);not null;comment:'刷新任务状态'" json:"task_status"`
+	LastRefreshAt  string `gorm:"type:varchar(30);comment:'最近一次刷新完成时间'" json:"last_refresh_at"`
+	ErrorDetail    string `gorm:"type:longtext;comment:'错误详情'" json:"error_detail"`
+	*BaseModel
+}
+
+func NewMviewRefreshMetaModel(m *Meta) *MviewRefreshMeta {
+	return &MviewRefreshMeta{
+		BaseModel: &BaseModel{
+			Meta: m,
+		},
+	}
+}
+
+func (rw *MviewRefreshMeta) ParseSchemaTable() (string, error) {
+	stmt := &gorm.Statement{DB: rw.GormDB}
+	err := stmt.Parse(rw)
+	if err != nil {
+		return "", fmt.Errorf("parse struct [MviewRefreshMeta] get table_name failed: %v", err)
+	}
+	return stmt.Schema.Table, nil
+}
+
+// 重复 reverse 以最新物化视图定义为准，刷新状态以及错误信息重置
+func (rw *MviewRefreshMeta) CreateMviewRefreshMeta(ctx context.Context, createS *MviewRefreshMeta) error {
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return err
+	}
+	if err = rw.DB(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{
+			{Name: "db_type_s"},
+			{Name: "db_type_t"},
+			{Name: "schema_name_s"},
+			{Name: "mview_name_s"},
+		},
+		DoUpdates: clause.AssignmentColumns([]string{"schema_name_t", "table_name_t", "refresh_method_s", "refresh_method_t", "query_s", "query_t", "is_compatible", "task_status", "error_detail"}),
+	}).Create(createS).Error; err != nil {
+		return fmt.Errorf("create table [%s] record failed: %v", table, err)
+	}
+	return nil
+}
+
+func (rw *MviewRefreshMeta) DetailMviewRefreshMeta(ctx context.Context, detailS *MviewRefreshMeta) ([]MviewRefreshMeta, error) {
+	var mvMetas []MviewRefreshMeta
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return mvMetas, err
+	}
+	if err = rw.DB(ctx).Where(detailS).Find(&mvMetas).Error; err != nil {
+		return mvMetas, fmt.Errorf("detail table [%s] record failed: %v", table, err)
+	}
+	return mvMetas, nil
+}
+
+func (rw *MviewRefreshMeta) UpdateMviewRefreshMeta(ctx context.Context, detailS *MviewRefreshMeta, updates map[string]interface{}) error {
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return err
+	}
+	err = rw.DB(ctx).Model(&MviewRefreshMeta{}).
+		Where("db_type_s = ? AND db_type_t = ? AND schema_name_s = ? AND mview_name_s = ?",
+			common.StringUPPER(detailS.DBTypeS),
+			common.StringUPPER(detailS.DBTypeT),
+			common.StringUPPER(detailS.SchemaNameS),
+			common.StringUPPER(detailS.MviewNameS)).
+		Updates(updates).Error
+	if err != nil {
+		return fmt.Errorf("update table [%s] record failed: %v", table, err)
+	}
+	return nil
+}
+
+func (rw *MviewRefreshMeta) DeleteMviewRefreshMeta(ctx context.Context, deleteS *MviewRefreshMeta) error {
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return err
+	}
+	err = rw.DB(ctx).Where("db_type_s = ? AND db_type_t = ? AND schema_name_s = ? AND mview_name_s = ?",
+		common.StringUPPER(deleteS.DBTypeS),
+		common.StringUPPER(deleteS.DBTypeT),
+		common.StringUPPER(deleteS.SchemaNameS),
+		common.StringUPPER(deleteS.MviewNameS)).Delete(&MviewRefreshMeta{}).Error
+	if err != nil {
+		return fmt.Errorf("delete table [%s] reocrd failed: %v", table, err)
+	}
+	return nil
+}
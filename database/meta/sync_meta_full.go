@@ -20,24 +20,29 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/wentaojin/transferdb/common"
+	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
 // 全量同步元数据表
 type FullSyncMeta struct {
-	ID            uint   `gorm:"primary_key;autoIncrement;comment:'自增编号'" json:"id"`
-	DBTypeS       string `gorm:"type:varchar(30);index:idx_dbtype_st_map,unique;index:idx_schema_mode;comment:'源数据库类型'" json:"db_type_s"`
-	DBTypeT       string `gorm:"type:varchar(30);index:idx_dbtype_st_map,unique;index:idx_schema_mode;comment:'目标数据库类型'" json:"db_type_t"`
-	SchemaNameS   string `gorm:"type:varchar(100);not null;index:idx_dbtype_st_map,unique;index:idx_schema_mode;comment:'源端 schema'" json:"schema_name_s"`
-	TableNameS    string `gorm:"type:varchar(100);not null;index:idx_dbtype_st_map,unique;comment:'源端表名'" json:"table_name_s"`
-	SchemaNameT   string `gorm:"type:varchar(100);not null;comment:'目标端 schema'" json:"schema_name_t"`
-	TableNameT    string `gorm:"type:varchar(100);not null;comment:'目标端表名'" json:"table_name_t"`
-	GlobalScnS    uint64 `gorm:"comment:'源端全局 SCN'" json:"global_scn_s"`
-	ColumnDetailS string `gorm:"type:text;comment:'源端查询字段信息'" json:"column_detail_s"`
-	ChunkDetailS  string `gorm:"type:varchar(300);not null;index:idx_dbtype_st_map,unique;comment:'表 chunk 切分信息'" json:"chunk_detail_s"`
-	TaskMode      string `gorm:"type:varchar(30);not null;index:idx_dbtype_st_map,unique;index:idx_schema_mode;comment:'任务模式'" json:"task_mode"`
-	TaskStatus    string `gorm:"type:varchar(30);not null;comment:'任务 chunk 状态'" json:"task_status"`
-	CSVFile       string `gorm:"type:varchar(300);comment:'csv 文件名'" json:"csv_file"`
+	ID                uint   `gorm:"primary_key;autoIncrement;comment:'自增编号'" json:"id"`
+	DBTypeS           string `gorm:"type:varchar(30);index:idx_dbtype_st_map,unique;index:idx_schema_mode;comment:'源数据库类型'" json:"db_type_s"`
+	DBTypeT           string `gorm:"type:varchar(30);index:idx_dbtype_st_map,unique;index:idx_schema_mode;comment:'目标数据库类型'" json:"db_type_t"`
+	SchemaNameS       string `gorm:"type:varchar(100);not null;index:idx_dbtype_st_map,unique;index:idx_schema_mode;comment:'源端 schema'" json:"schema_name_s"`
+	TableNameS        string `gorm:"type:varchar(100);not null;index:idx_dbtype_st_map,unique;comment:'源端表名'" json:"table_name_s"`
+	SchemaNameT       string `gorm:"type:varchar(100);not null;comment:'目标端 schema'" json:"schema_name_t"`
+	TableNameT        string `gorm:"type:varchar(100);not null;comment:'目标端表名'" json:"table_name_t"`
+	GlobalScnS        uint64 `gorm:"comment:'源端全局 SCN'" json:"global_scn_s"`
+	ColumnDetailS     string `gorm:"type:text;comment:'源端查询字段信息'" json:"column_detail_s"`
+	ChunkDetailS      string `gorm:"type:varchar(300);not null;index:idx_dbtype_st_map,unique;comment:'表 chunk 切分信息'" json:"chunk_detail_s"`
+	PartitionNameS    string `gorm:"type:varchar(100);comment:'源端 chunk 所在分区'" json:"partition_name_s"`
+	SubPartitionNameS string `gorm:"type:varchar(100);comment:'源端 chunk 所在子分区'" json:"subpartition_name_s"`
+	TaskMode          string `gorm:"type:varchar(30);not null;index:idx_dbtype_st_map,unique;index:idx_schema_mode;comment:'任务模式'" json:"task_mode"`
+	TaskStatus        string `gorm:"type:varchar(30);not null;comment:'任务 chunk 状态'" json:"task_status"`
+	CSVFile           string `gorm:"type:varchar(300);comment:'csv 文件名'" json:"csv_file"`
+	CSVFiles          string `gorm:"type:text;comment:'csv 文件 file-size 滚动生成的全部文件名，逗号分隔'" json:"csv_files"`
+	CSVChecksum       string `gorm:"type:text;comment:'csv 文件 sha256 校验和，滚动文件逗号分隔'" json:"csv_checksum"`
 	*BaseModel
 }
 
@@ -85,6 +90,23 @@ func (rw *FullSyncMeta) DetailFullSyncMeta(ctx context.Context, detailS *FullSyn
 	return dsMetas, nil
 }
 
+func (rw *FullSyncMeta) DeleteFullSyncMetaBySchemaTable(ctx context.Context, deleteS *FullSyncMeta) error {
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return err
+	}
+	err = rw.DB(ctx).Where("db_type_s = ? AND db_type_t = ? AND schema_name_s = ? AND table_name_s = ? AND task_mode = ?",
+		common.StringUPPER(deleteS.DBTypeS),
+		common.StringUPPER(deleteS.DBTypeT),
+		common.StringUPPER(deleteS.SchemaNameS),
+		common.StringUPPER(deleteS.TableNameS),
+		deleteS.TaskMode).Delete(&FullSyncMeta{}).Error
+	if err != nil {
+		return fmt.Errorf("delete table [%s] reocrd failed: %v", table, err)
+	}
+	return nil
+}
+
 func (rw *FullSyncMeta) DeleteFullSyncMetaBySchemaTableChunk(ctx context.Context, deleteS *FullSyncMeta) error {
 	table, err := rw.ParseSchemaTable()
 	if err != nil {
@@ -172,6 +194,65 @@ func (rw *FullSyncMeta) CountsFullSyncMetaByTaskTable(ctx context.Context, dataE
 	return countsErr, nil
 }
 
+// CountsFullSyncMetaByPartition 分区 chunk 各状态数，用于分区粒度进度
+func (rw *FullSyncMeta) CountsFullSyncMetaByPartition(ctx context.Context, detailS *FullSyncMeta) (map[string]int64, error) {
+	var counts []struct {
+		TaskStatus string
+		Counts     int64
+	}
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return nil, err
+	}
+	if err := rw.DB(ctx).Model(&FullSyncMeta{}).Select("task_status, COUNT(1) AS counts").
+		Where(`db_type_s = ? AND db_type_t = ? AND schema_name_s = ? AND table_name_s = ? AND task_mode = ? AND partition_name_s = ?`,
+			common.StringUPPER(detailS.DBTypeS),
+			common.StringUPPER(detailS.DBTypeT),
+			common.StringUPPER(detailS.SchemaNameS),
+			common.StringUPPER(detailS.TableNameS),
+			common.StringUPPER(detailS.TaskMode),
+			detailS.PartitionNameS).
+		Group("task_status").Scan(&counts).Error; err != nil {
+		return nil, fmt.Errorf("get table [%s] partition counts failed: %v", table, err)
+	}
+	statusCounts := make(map[string]int64)
+	for _, c := range counts {
+		statusCounts[c.TaskStatus] = c.Counts
+	}
+	return statusCounts, nil
+}
+
+// LogFullSyncMetaPartitionProgress 分区粒度 chunk 进度，非分区粒度 chunk 忽略
+func (rw *FullSyncMeta) LogFullSyncMetaPartitionProgress(ctx context.Context, detailS *FullSyncMeta) error {
+	if detailS.PartitionNameS == "" {
+		return nil
+	}
+	statusCounts, err := rw.CountsFullSyncMetaByPartition(ctx, &FullSyncMeta{
+		DBTypeS:        detailS.DBTypeS,
+		DBTypeT:        detailS.DBTypeT,
+		SchemaNameS:    detailS.SchemaNameS,
+		TableNameS:     detailS.TableNameS,
+		TaskMode:       detailS.TaskMode,
+		PartitionNameS: detailS.PartitionNameS,
+	})
+	if err != nil {
+		return err
+	}
+	var chunkTotals int64
+	for _, c := range statusCounts {
+		chunkTotals += c
+	}
+	zap.L().Info("source schema table partition chunk progress",
+		zap.String("schema", detailS.SchemaNameS),
+		zap.String("table", detailS.TableNameS),
+		zap.String("partition", detailS.PartitionNameS),
+		zap.String("task mode", detailS.TaskMode),
+		zap.Int64("chunk totals", chunkTotals),
+		zap.Int64("chunk success", statusCounts[common.TaskStatusSuccess]),
+		zap.Int64("chunk failed", statusCounts[common.TaskStatusFailed]))
+	return nil
+}
+
 func (rw *FullSyncMeta) String() string {
 	jsonStr, _ := json.Marshal(rw)
 	return string(jsonStr)
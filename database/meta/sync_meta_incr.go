@@ -109,6 +109,27 @@ func (rw *IncrSyncMeta) GetIncrSyncMetaMinTableScnSBySchema(ctx context.Context,
 	return sourceTableSCN, nil
 }
 
+// GetIncrSyncMetaGlobalScnSByTable 表增量同步 checkpoint，表不存在记录返回 false
+func (rw *IncrSyncMeta) GetIncrSyncMetaGlobalScnSByTable(ctx context.Context, detailS *IncrSyncMeta) (uint64, bool, error) {
+	var globalSCN []uint64
+	table, err := rw.ParseSchemaTable()
+	if err != nil {
+		return 0, false, err
+	}
+	if err = rw.DB(ctx).Model(&IncrSyncMeta{}).Where("db_type_s = ? AND db_type_t = ? AND schema_name_s = ? AND table_name_s = ?",
+		common.StringUPPER(detailS.DBTypeS),
+		common.StringUPPER(detailS.DBTypeT),
+		common.StringUPPER(detailS.SchemaNameS),
+		common.StringUPPER(detailS.TableNameS),
+	).Pluck("global_scn_s", &globalSCN).Error; err != nil {
+		return 0, false, fmt.Errorf("get table [%s] column [global_scn_s] value failed: %v", table, err)
+	}
+	if len(globalSCN) == 0 {
+		return 0, false, nil
+	}
+	return globalSCN[0], true, nil
+}
+
 func (rw *IncrSyncMeta) DetailIncrSyncMetaBySchema(ctx context.Context, detailS *IncrSyncMeta) ([]IncrSyncMeta, error) {
 	var incrMetas []IncrSyncMeta
 	table, err := rw.ParseSchemaTable()
@@ -17,9 +17,13 @@ package mysql
 
 import (
 	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"regexp"
 	"strings"
 )
 
+var indexGenColumnRegex = regexp.MustCompile(common.ReverseIndexGenColumnSuffix + `[0-9]+$`)
+
 func (m *MySQL) GetMySQLDBVersion() (string, error) {
 	_, res, err := Query(m.Ctx, m.MySQLDB, `select version() AS VERSION`)
 	if err != nil {
@@ -61,7 +65,8 @@ func (m *MySQL) GetMySQLTableColumn(schemaName, tableName string) ([]map[string]
 		IFNULL(COLUMN_DEFAULT,'') DATA_DEFAULT,
 		IFNULL(COLUMN_COMMENT,'') COMMENTS,
 		IFNULL(CHARACTER_SET_NAME,'UNKNOWN') CHARACTER_SET_NAME,
-		IFNULL(COLLATION_NAME,'UNKNOWN') COLLATION_NAME
+		IFNULL(COLLATION_NAME,'UNKNOWN') COLLATION_NAME,
+		IFNULL(EXTRA,'') EXTRA
  FROM information_schema.COLUMNS
  WHERE UPPER(TABLE_SCHEMA) = UPPER('%s')
    AND UPPER(TABLE_NAME) = UPPER('%s')
@@ -73,6 +78,17 @@ func (m *MySQL) GetMySQLTableColumn(schemaName, tableName string) ([]map[string]
 	return res, nil
 }
 
+// IsMySQLGeneratedColumn 虚拟生成列、存储生成列不允许写入，EXTRA DEFAULT_GENERATED 代表表达式默认值，非生成列
+func IsMySQLGeneratedColumn(extra string) bool {
+	extra = strings.ToUpper(extra)
+	return strings.Contains(extra, "VIRTUAL GENERATED") || strings.Contains(extra, "STORED GENERATED")
+}
+
+// IsMySQLIndexGenColumn reverse 函数索引转换的虚拟生成列，上游不存在
+func IsMySQLIndexGenColumn(columnName, extra string) bool {
+	return IsMySQLGeneratedColumn(extra) && indexGenColumnRegex.MatchString(strings.ToUpper(columnName))
+}
+
 func (m *MySQL) GetMySQLTableColumnComment(schemaName, tableName string) ([]map[string]string, error) {
 	var (
 		res []map[string]string
@@ -57,6 +57,67 @@ func (m *MySQL) GetMySQLTableActualRows(mysqlQuery string) (int64, error) {
 	return rowsCount, nil
 }
 
+// GetMySQLTableChunkChecksum 数据块服务端聚合校验，返回 行数:校验值1:校验值2
+func (m *MySQL) GetMySQLTableChunkChecksum(querySQL string) (string, error) {
+	_, res, err := Query(m.Ctx, m.MySQLDB, querySQL)
+	if err != nil {
+		return "", err
+	}
+	if len(res) == 0 {
+		return "", fmt.Errorf("mysql checksum sql [%v] result isn't exist", querySQL)
+	}
+	return common.StringsBuilder(res[0]["ROWS_COUNT"], ":", res[0]["CHECKSUM1"], ":", res[0]["CHECKSUM2"]), nil
+}
+
+// GetMySQLTableRangeMedian 数据块范围内字段非 NULL 数据按排序表达式取中位数，范围无数据返回空
+func (m *MySQL) GetMySQLTableRangeMedian(schemaName, tableName, columnName, columnExpr, orderExpr, whereRange string) (string, error) {
+	_, res, err := Query(m.Ctx, m.MySQLDB, common.StringsBuilder(
+		"SELECT COUNT(1) AS CNT FROM ", schemaName, ".", tableName, " WHERE (", whereRange, ") AND ", columnName, " IS NOT NULL"))
+	if err != nil {
+		return "", err
+	}
+	rows, err := strconv.ParseInt(res[0]["CNT"], 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("mysql table [%s.%s] range rows [%s] parse failed: %v", schemaName, tableName, res[0]["CNT"], err)
+	}
+	if rows == 0 {
+		return "", nil
+	}
+
+	_, res, err = Query(m.Ctx, m.MySQLDB, common.StringsBuilder(
+		"SELECT ", columnExpr, " AS V FROM ", schemaName, ".", tableName, " WHERE (", whereRange, ") AND ", columnName, " IS NOT NULL",
+		" ORDER BY ", orderExpr, " LIMIT ", strconv.FormatInt((rows-1)/2, 10), ",1"))
+	if err != nil {
+		return "", err
+	}
+	if len(res) == 0 {
+		return "", nil
+	}
+	return res[0]["V"], nil
+}
+
+// GetMySQLTableChunksByIndexSample 按索引字段顺序每 chunkSize 行采样一次边界值，返回边界值按字段顺序 B0、B1... 命名
+// 依赖窗口函数，需要 MySQL 8.0 或者 TiDB，orderExprs 为排序表达式，与 columnList 一一对应
+func (m *MySQL) GetMySQLTableChunksByIndexSample(schemaName, tableName string, columnList, columnExprs, orderExprs []string, chunkSize int) ([]map[string]string, error) {
+	var (
+		notNulls    []string
+		boundaryCol []string
+	)
+	for i, col := range columnList {
+		notNulls = append(notNulls, common.StringsBuilder(col, " IS NOT NULL"))
+		boundaryCol = append(boundaryCol, common.StringsBuilder(columnExprs[i], " AS B", strconv.Itoa(i)))
+	}
+	querySQL := common.StringsBuilder(`SELECT `, strings.Join(boundaryCol, ","), ` FROM (SELECT `, strings.Join(columnList, ","),
+		`, ROW_NUMBER() OVER (ORDER BY `, strings.Join(orderExprs, ","), `) RN FROM `, schemaName, `.`, tableName,
+		` WHERE `, strings.Join(notNulls, " AND "), `) t WHERE MOD(RN, `, strconv.Itoa(chunkSize), `) = 0 ORDER BY RN`)
+
+	_, res, err := Query(m.Ctx, m.MySQLDB, querySQL)
+	if err != nil {
+		return res, fmt.Errorf("mysql table [%s.%s] index sample boundary failed: %v, sql: %v", schemaName, tableName, err, querySQL)
+	}
+	return res, nil
+}
+
 func (m *MySQL) GetMySQLDataRowStrings(querySQL string) ([]string, *strset.Set, uint32, error) {
 	var (
 		cols     []string
@@ -113,84 +174,11 @@ func (m *MySQL) GetMySQLDataRowStrings(querySQL string) ([]string, *strset.Set,
 		}
 
 		for i, raw := range rawResult {
-			// ORACLE/MySQL 空字符串以及 NULL 统一NULL处理，忽略 MySQL 空字符串与 NULL 区别
-			if raw == nil {
-				rowsTMP = append(rowsTMP, fmt.Sprintf("%v", `NULL`))
-			} else if string(raw) == "" {
-				rowsTMP = append(rowsTMP, fmt.Sprintf("%v", `NULL`))
-			} else {
-				switch columnTypes[i] {
-				case "int8":
-					r, err := common.StrconvIntBitSize(string(raw), 8)
-					if err != nil {
-						return cols, stringSet, crc32Value, err
-					}
-					rowsTMP = append(rowsTMP, fmt.Sprintf("%v", r))
-				case "int16":
-					r, err := common.StrconvIntBitSize(string(raw), 16)
-					if err != nil {
-						return cols, stringSet, crc32Value, err
-					}
-					rowsTMP = append(rowsTMP, fmt.Sprintf("%v", r))
-				case "int32", "sql.NullInt32":
-					r, err := common.StrconvIntBitSize(string(raw), 32)
-					if err != nil {
-						return cols, stringSet, crc32Value, err
-					}
-					rowsTMP = append(rowsTMP, fmt.Sprintf("%v", r))
-				case "int64", "sql.NullInt64":
-					r, err := common.StrconvIntBitSize(string(raw), 64)
-					if err != nil {
-						return cols, stringSet, crc32Value, err
-					}
-					rowsTMP = append(rowsTMP, fmt.Sprintf("%v", r))
-				case "uint8":
-					r, err := common.StrconvUintBitSize(string(raw), 8)
-					if err != nil {
-						return cols, stringSet, crc32Value, err
-					}
-					rowsTMP = append(rowsTMP, fmt.Sprintf("%v", r))
-				case "uint16":
-					r, err := common.StrconvUintBitSize(string(raw), 16)
-					if err != nil {
-						return cols, stringSet, crc32Value, err
-					}
-					rowsTMP = append(rowsTMP, fmt.Sprintf("%v", r))
-				case "uint32":
-					r, err := common.StrconvUintBitSize(string(raw), 32)
-					if err != nil {
-						return cols, stringSet, crc32Value, err
-					}
-					rowsTMP = append(rowsTMP, fmt.Sprintf("%v", r))
-				case "uint64":
-					r, err := common.StrconvUintBitSize(string(raw), 64)
-					if err != nil {
-						return cols, stringSet, crc32Value, err
-					}
-					rowsTMP = append(rowsTMP, fmt.Sprintf("%v", r))
-				case "float32":
-					r, err := common.StrconvFloatBitSize(string(raw), 32)
-					if err != nil {
-						return cols, stringSet, crc32Value, err
-					}
-					rowsTMP = append(rowsTMP, fmt.Sprintf("%v", r))
-				case "float64", "sql.NullFloat64":
-					r, err := common.StrconvFloatBitSize(string(raw), 64)
-					if err != nil {
-						return cols, stringSet, crc32Value, err
-					}
-					rowsTMP = append(rowsTMP, fmt.Sprintf("%v", r))
-				case "rune":
-					r, err := common.StrconvRune(string(raw))
-					if err != nil {
-						return cols, stringSet, crc32Value, err
-					}
-					rowsTMP = append(rowsTMP, fmt.Sprintf("%v", r))
-				default:
-					// 特殊字符
-					rowsTMP = append(rowsTMP, fmt.Sprintf("'%v'", common.SpecialLettersUsingMySQL(raw)))
-				}
+			v, err := genMySQLRowValue(raw, columnTypes[i])
+			if err != nil {
+				return cols, stringSet, crc32Value, err
 			}
+			rowsTMP = append(rowsTMP, v)
 		}
 
 		rowS := exstrings.Join(rowsTMP, ",")
@@ -209,3 +197,164 @@ func (m *MySQL) GetMySQLDataRowStrings(querySQL string) ([]string, *strset.Set,
 
 	return cols, stringSet, crc32SUM, err
 }
+
+// GetMySQLDataRowFixValues 差异数据行修复字段值，前 compareCounts 个字段按数据行对比格式输出用于匹配差异数据行
+// 其余字段输出原始值 SQL 字面量，NULL 输出 NULL，二进制输出十六进制
+func (m *MySQL) GetMySQLDataRowFixValues(querySQL string, compareCounts int) ([][]string, [][]string, error) {
+	var compareRows, fixRows [][]string
+
+	rows, err := m.MySQLDB.QueryContext(m.Ctx, querySQL)
+	if err != nil {
+		return compareRows, fixRows, fmt.Errorf("general sql [%v] query failed: [%v]", querySQL, err.Error())
+	}
+	defer rows.Close()
+
+	colTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return compareRows, fixRows, err
+	}
+	var columnTypes, databaseTypes []string
+	for _, ct := range colTypes {
+		columnTypes = append(columnTypes, ct.ScanType().String())
+		databaseTypes = append(databaseTypes, ct.DatabaseTypeName())
+	}
+
+	rawResult := make([][]byte, len(colTypes))
+	scans := make([]interface{}, len(colTypes))
+	for i := range rawResult {
+		scans[i] = &rawResult[i]
+	}
+
+	for rows.Next() {
+		if err = rows.Scan(scans...); err != nil {
+			return compareRows, fixRows, fmt.Errorf("general sql [%v] query rows.Scan failed: [%v]", querySQL, err.Error())
+		}
+
+		var compareValues, fixValues []string
+		for i, raw := range rawResult {
+			if i < compareCounts {
+				v, err := genMySQLRowValue(raw, columnTypes[i])
+				if err != nil {
+					return compareRows, fixRows, err
+				}
+				compareValues = append(compareValues, v)
+				continue
+			}
+			switch {
+			case raw == nil:
+				fixValues = append(fixValues, "NULL")
+			case strings.Contains(databaseTypes[i], "BLOB") || strings.Contains(databaseTypes[i], "BINARY"):
+				fixValues = append(fixValues, fmt.Sprintf("X'%X'", raw))
+			default:
+				fixValues = append(fixValues, common.StringsBuilder("'", common.SpecialLettersUsingMySQL(raw), "'"))
+			}
+		}
+		compareRows = append(compareRows, compareValues)
+		fixRows = append(fixRows, fixValues)
+	}
+
+	if err = rows.Err(); err != nil {
+		return compareRows, fixRows, fmt.Errorf("general sql [%v] query rows.Next failed: [%v]", querySQL, err.Error())
+	}
+	return compareRows, fixRows, nil
+}
+
+// genMySQLRowValue 数据行对比字段值格式化，NULL 与空字符串统一 NULL
+func genMySQLRowValue(raw []byte, columnType string) (string, error) {
+	// ORACLE/MySQL 空字符串以及 NULL 统一NULL处理，忽略 MySQL 空字符串与 NULL 区别
+	if raw == nil {
+		return fmt.Sprintf("%v", `NULL`), nil
+	} else if string(raw) == "" {
+		return fmt.Sprintf("%v", `NULL`), nil
+	} else {
+		switch columnType {
+		case "int8":
+			r, err := common.StrconvIntBitSize(string(raw), 8)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("%v", r), nil
+		case "int16":
+			r, err := common.StrconvIntBitSize(string(raw), 16)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("%v", r), nil
+		case "int32", "sql.NullInt32":
+			r, err := common.StrconvIntBitSize(string(raw), 32)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("%v", r), nil
+		case "int64", "sql.NullInt64":
+			r, err := common.StrconvIntBitSize(string(raw), 64)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("%v", r), nil
+		case "uint8":
+			r, err := common.StrconvUintBitSize(string(raw), 8)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("%v", r), nil
+		case "uint16":
+			r, err := common.StrconvUintBitSize(string(raw), 16)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("%v", r), nil
+		case "uint32":
+			r, err := common.StrconvUintBitSize(string(raw), 32)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("%v", r), nil
+		case "uint64":
+			r, err := common.StrconvUintBitSize(string(raw), 64)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("%v", r), nil
+		case "float32":
+			r, err := common.StrconvFloatBitSize(string(raw), 32)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("%v", r), nil
+		case "float64", "sql.NullFloat64":
+			r, err := common.StrconvFloatBitSize(string(raw), 64)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("%v", r), nil
+		case "rune":
+			r, err := common.StrconvRune(string(raw))
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("%v", r), nil
+		default:
+			// 特殊字符
+			return fmt.Sprintf("'%v'", common.SpecialLettersUsingMySQL(raw)), nil
+		}
+	}
+}
+
+// ApplyMySQLFixSQL 单事务执行数据修复 SQL，任一失败整体回滚
+func (m *MySQL) ApplyMySQLFixSQL(fixSQLs []string) error {
+	txn, err := m.MySQLDB.BeginTx(m.Ctx, nil)
+	if err != nil {
+		return err
+	}
+	for _, fixSQL := range fixSQLs {
+		if _, err = txn.ExecContext(m.Ctx, fixSQL); err != nil {
+			_ = txn.Rollback()
+			return fmt.Errorf("fix sql [%v] exec failed: %v", fixSQL, err)
+		}
+	}
+	if err = txn.Commit(); err != nil {
+		return fmt.Errorf("fix sql commit failed: %v", err)
+	}
+	return nil
+}
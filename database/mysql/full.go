@@ -16,7 +16,9 @@ limitations under the License.
 package mysql
 
 import (
+	"database/sql"
 	"fmt"
+	"sync"
 )
 
 func (m *MySQL) TruncateMySQLTable(targetSchema string, targetTable string) error {
@@ -34,3 +36,51 @@ func (m *MySQL) WriteMySQLTable(sql string) error {
 	}
 	return nil
 }
+
+// MySQLStmtCache 按 prepare SQL 缓存 prepared statement，同一张表相同 batch 行数写入复用，减少 prepare 往返
+type MySQLStmtCache struct {
+	mysql *MySQL
+	mu    sync.Mutex
+	stmts map[string]*sql.Stmt
+}
+
+func (m *MySQL) NewMySQLStmtCache() *MySQLStmtCache {
+	return &MySQLStmtCache{
+		mysql: m,
+		stmts: make(map[string]*sql.Stmt),
+	}
+}
+
+// WriteMySQLTableRows 复用缓存 prepared statement 绑定变量 batch 写入
+func (c *MySQLStmtCache) WriteMySQLTableRows(prepareSQL string, args []interface{}) error {
+	c.mu.Lock()
+	stmt, ok := c.stmts[prepareSQL]
+	if !ok {
+		var err error
+		stmt, err = c.mysql.MySQLDB.PrepareContext(c.mysql.Ctx, prepareSQL)
+		if err != nil {
+			c.mu.Unlock()
+			return fmt.Errorf("mysql prepare statement failed: %v", err)
+		}
+		c.stmts[prepareSQL] = stmt
+	}
+	c.mu.Unlock()
+
+	_, err := stmt.ExecContext(c.mysql.Ctx, args...)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (c *MySQLStmtCache) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for s, stmt := range c.stmts {
+		if err := stmt.Close(); err != nil {
+			return fmt.Errorf("mysql close prepare statement failed: %v", err)
+		}
+		delete(c.stmts, s)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package mysql
+
+import (
+	"errors"
+	"fmt"
+	driver "github.com/go-sql-driver/mysql"
+	"github.com/wentaojin/transferdb/common"
+	"io"
+)
+
+// LoadMySQLTableData LOAD DATA LOCAL INFILE 'Reader::<readerName>' 导入，返回写入行数
+func (m *MySQL) LoadMySQLTableData(loadSQL, readerName string, reader io.Reader) (int64, error) {
+	driver.RegisterReaderHandler(readerName, func() io.Reader {
+		return reader
+	})
+	defer driver.DeregisterReaderHandler(readerName)
+
+	res, err := m.MySQLDB.ExecContext(m.Ctx, loadSQL)
+	if err != nil {
+		return 0, err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("load data get rows affected failed: %v", err)
+	}
+	return rows, nil
+}
+
+// WriteMySQLTableRows 绑定变量 batch 写入
+func (m *MySQL) WriteMySQLTableRows(prepareSQL string, args []interface{}) error {
+	_, err := m.MySQLDB.ExecContext(m.Ctx, prepareSQL, args...)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// IsMySQLLoadDataDisabled 下游是否禁用 LOAD DATA LOCAL INFILE
+func IsMySQLLoadDataDisabled(err error) bool {
+	var mysqlErr *driver.MySQLError
+	if errors.As(err, &mysqlErr) {
+		for _, code := range common.MySQLLoadDataDisabledErrCode {
+			if mysqlErr.Number == code {
+				return true
+			}
+		}
+	}
+	return false
+}
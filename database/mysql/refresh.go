@@ -0,0 +1,93 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package mysql
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RefreshMySQLTableComplete 物化视图全量重建，单事务内删除重新写入，刷新期间读取一致
+func (m *MySQL) RefreshMySQLTableComplete(schemaName, tableName string, columns []string, query string) error {
+	colList := genMySQLRefreshColumnList(columns, "")
+
+	txn, err := m.MySQLDB.BeginTx(m.Ctx, nil)
+	if err != nil {
+		return err
+	}
+	deleteSQL := fmt.Sprintf("DELETE FROM `%s`.`%s`", schemaName, tableName)
+	if _, err = txn.ExecContext(m.Ctx, deleteSQL); err != nil {
+		_ = txn.Rollback()
+		return fmt.Errorf("complete refresh sql [%v] exec failed: %v", deleteSQL, err)
+	}
+	insertSQL := fmt.Sprintf("INSERT INTO `%s`.`%s` (%s) SELECT %s FROM (%s) mv",
+		schemaName, tableName, colList, genMySQLRefreshColumnList(columns, "mv"), query)
+	if _, err = txn.ExecContext(m.Ctx, insertSQL); err != nil {
+		_ = txn.Rollback()
+		return fmt.Errorf("complete refresh sql [%v] exec failed: %v", insertSQL, err)
+	}
+	if err = txn.Commit(); err != nil {
+		return fmt.Errorf("complete refresh table [%s.%s] commit failed: %v", schemaName, tableName, err)
+	}
+	return nil
+}
+
+// RefreshMySQLTableFast 物化视图基于主键增量刷新，仅变更存在差异的数据行
+func (m *MySQL) RefreshMySQLTableFast(schemaName, tableName string, columns, pkColumns []string, query string) error {
+	var (
+		updates []string
+		conds   []string
+	)
+	for _, c := range columns {
+		updates = append(updates, fmt.Sprintf("`%s` = VALUES(`%s`)", c, c))
+	}
+	for _, c := range pkColumns {
+		conds = append(conds, fmt.Sprintf("mv.`%s` <=> t.`%s`", c, c))
+	}
+
+	txn, err := m.MySQLDB.BeginTx(m.Ctx, nil)
+	if err != nil {
+		return err
+	}
+	upsertSQL := fmt.Sprintf("INSERT INTO `%s`.`%s` (%s) SELECT %s FROM (%s) mv ON DUPLICATE KEY UPDATE %s",
+		schemaName, tableName, genMySQLRefreshColumnList(columns, ""), genMySQLRefreshColumnList(columns, "mv"), query, strings.Join(updates, ","))
+	if _, err = txn.ExecContext(m.Ctx, upsertSQL); err != nil {
+		_ = txn.Rollback()
+		return fmt.Errorf("fast refresh sql [%v] exec failed: %v", upsertSQL, err)
+	}
+	deleteSQL := fmt.Sprintf("DELETE t FROM `%s`.`%s` t WHERE NOT EXISTS (SELECT 1 FROM (%s) mv WHERE %s)",
+		schemaName, tableName, query, strings.Join(conds, " AND "))
+	if _, err = txn.ExecContext(m.Ctx, deleteSQL); err != nil {
+		_ = txn.Rollback()
+		return fmt.Errorf("fast refresh sql [%v] exec failed: %v", deleteSQL, err)
+	}
+	if err = txn.Commit(); err != nil {
+		return fmt.Errorf("fast refresh table [%s.%s] commit failed: %v", schemaName, tableName, err)
+	}
+	return nil
+}
+
+func genMySQLRefreshColumnList(columns []string, alias string) string {
+	var cols []string
+	for _, c := range columns {
+		if alias == "" {
+			cols = append(cols, fmt.Sprintf("`%s`", c))
+		} else {
+			cols = append(cols, fmt.Sprintf("%s.`%s`", alias, c))
+		}
+	}
+	return strings.Join(cols, ",")
+}
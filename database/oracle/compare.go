@@ -131,7 +131,55 @@ FROM user_parallel_execute_chunks WHERE  task_name = '`, taskName, `' ORDER BY c
 	return res, nil
 }
 
+// GetOracleTableColumnDataType 表字段数据类型，返回 字段名 -> 数据类型
+func (o *Oracle) GetOracleTableColumnDataType(schemaName, tableName string, columnList []string) (map[string]string, error) {
+	var colList []string
+	for _, col := range columnList {
+		colList = append(colList, common.StringsBuilder("'", strings.ToUpper(col), "'"))
+	}
+	query := fmt.Sprintf("SELECT COLUMN_NAME,DATA_TYPE FROM DBA_TAB_COLUMNS WHERE OWNER = '%s' AND TABLE_NAME = '%s' AND COLUMN_NAME IN (%s)",
+		strings.ToUpper(schemaName), strings.ToUpper(tableName), strings.Join(colList, ","))
+
+	_, res, err := Query(o.Ctx, o.OracleDB, query)
+	if err != nil {
+		return nil, err
+	}
+	columnTypes := make(map[string]string, len(res))
+	for _, r := range res {
+		columnTypes[strings.ToUpper(r["COLUMN_NAME"])] = strings.ToUpper(r["DATA_TYPE"])
+	}
+	return columnTypes, nil
+}
+
+// GetOracleTableChunksByIndexSample 按索引字段顺序每 chunkSize 行采样一次边界值，返回边界值按字段顺序 B0、B1... 命名
+// columnExprs 为边界值输出表达式，orderExprs 为排序表达式（字符字段按二进制排序，与边界条件比较语义一致），均与 columnList 一一对应
+func (o *Oracle) GetOracleTableChunksByIndexSample(schemaName, tableName string, columnList, columnExprs, orderExprs []string, chunkSize int) ([]map[string]string, error) {
+	var (
+		notNulls    []string
+		boundaryCol []string
+	)
+	for i, col := range columnList {
+		notNulls = append(notNulls, common.StringsBuilder(col, " IS NOT NULL"))
+		boundaryCol = append(boundaryCol, common.StringsBuilder(columnExprs[i], " AS B", strconv.Itoa(i)))
+	}
+	querySQL := common.StringsBuilder(`SELECT `, strings.Join(boundaryCol, ","), ` FROM (SELECT `, strings.Join(columnList, ","),
+		`, ROW_NUMBER() OVER (ORDER BY `, strings.Join(orderExprs, ","), `) RN FROM `, strings.ToUpper(schemaName), `.`, strings.ToUpper(tableName),
+		` WHERE `, strings.Join(notNulls, " AND "), `) WHERE MOD(RN, `, strconv.Itoa(chunkSize), `) = 0 ORDER BY RN`)
+
+	_, res, err := Query(o.Ctx, o.OracleDB, querySQL)
+	if err != nil {
+		return res, fmt.Errorf("oracle table [%s.%s] index sample boundary failed: %v, sql: %v", schemaName, tableName, err, querySQL)
+	}
+	return res, nil
+}
+
 func (o *Oracle) GetOracleTableActualRows(oraQuery string) (int64, error) {
+	release, err := o.Throttle.Acquire()
+	if err != nil {
+		return 0, err
+	}
+	defer release()
+
 	_, res, err := Query(o.Ctx, o.OracleDB, oraQuery)
 	if err != nil {
 		return 0, err
@@ -143,6 +191,50 @@ func (o *Oracle) GetOracleTableActualRows(oraQuery string) (int64, error) {
 	return rowsCount, nil
 }
 
+// GetOracleTableChunkChecksum 数据块服务端聚合校验，返回 行数:校验值1:校验值2
+// 服务端聚合无法逐行限速，按数据块行数事后限速
+func (o *Oracle) GetOracleTableChunkChecksum(querySQL string) (string, error) {
+	release, err := o.Throttle.Acquire()
+	if err != nil {
+		return "", err
+	}
+	_, res, err := Query(o.Ctx, o.OracleDB, querySQL)
+	// 服务端聚合查询已完成，限速等待前释放会话
+	release()
+	if err != nil {
+		return "", err
+	}
+	if len(res) == 0 {
+		return "", fmt.Errorf("oracle checksum sql [%v] result isn't exist", querySQL)
+	}
+	if rowsCount, err := strconv.Atoi(res[0]["ROWS_COUNT"]); err == nil {
+		if err = o.Throttle.WaitRows(rowsCount, 0); err != nil {
+			return "", err
+		}
+	}
+	return common.StringsBuilder(res[0]["ROWS_COUNT"], ":", res[0]["CHECKSUM1"], ":", res[0]["CHECKSUM2"]), nil
+}
+
+// GetOracleTableRangeMedian 数据块范围内字段非 NULL 数据按排序表达式取中位数，范围无数据返回空
+func (o *Oracle) GetOracleTableRangeMedian(fromTable, columnName, columnExpr, orderExpr, whereRange string) (string, error) {
+	release, err := o.Throttle.Acquire()
+	if err != nil {
+		return "", err
+	}
+	defer release()
+
+	_, res, err := Query(o.Ctx, o.OracleDB, common.StringsBuilder(
+		"SELECT V FROM (SELECT ", columnExpr, " AS V, ROW_NUMBER() OVER (ORDER BY ", orderExpr, ") AS RN, COUNT(1) OVER () AS CNT FROM ", fromTable,
+		" WHERE (", whereRange, ") AND ", columnName, " IS NOT NULL) WHERE RN = CEIL(CNT / 2)"))
+	if err != nil {
+		return "", err
+	}
+	if len(res) == 0 {
+		return "", nil
+	}
+	return res[0]["V"], nil
+}
+
 func (o *Oracle) GetOracleDataRowStrings(querySQL string) ([]string, *strset.Set, uint32, error) {
 	var (
 		cols     []string
@@ -156,6 +248,12 @@ func (o *Oracle) GetOracleDataRowStrings(querySQL string) ([]string, *strset.Set
 
 	stringSet := set.NewStringSet()
 
+	release, err := o.Throttle.Acquire()
+	if err != nil {
+		return cols, stringSet, crc32Value, err
+	}
+	defer release()
+
 	rows, err = o.OracleDB.Query(querySQL)
 	if err != nil {
 		return cols, stringSet, crc32Value, fmt.Errorf("general sql [%v] query failed: [%v]", querySQL, err.Error())
@@ -192,68 +290,16 @@ func (o *Oracle) GetOracleDataRowStrings(querySQL string) ([]string, *strset.Set
 		if err != nil {
 			return cols, stringSet, crc32Value, fmt.Errorf("general sql [%v] query rows.Scan failed: [%v]", querySQL, err.Error())
 		}
+		if err = o.Throttle.WaitRows(1, rowBytes(rawResult)); err != nil {
+			return cols, stringSet, crc32Value, err
+		}
 
 		for i, raw := range rawResult {
-			// ORACLE/MySQL 空字符串以及 NULL 统一NULL处理，忽略 MySQL 空字符串与 NULL 区别
-			if raw == nil {
-				rowsTMP = append(rowsTMP, fmt.Sprintf("%v", `NULL`))
-			} else if string(raw) == "" {
-				rowsTMP = append(rowsTMP, fmt.Sprintf("%v", `NULL`))
-			} else {
-				switch columnTypes[i] {
-				case "int64":
-					r, err := common.StrconvIntBitSize(string(raw), 64)
-					if err != nil {
-						return cols, stringSet, crc32Value, err
-					}
-					rowsTMP = append(rowsTMP, fmt.Sprintf("%v", r))
-				case "uint64":
-					r, err := common.StrconvUintBitSize(string(raw), 64)
-					if err != nil {
-						return cols, stringSet, crc32Value, err
-					}
-					rowsTMP = append(rowsTMP, fmt.Sprintf("%v", r))
-				case "float32":
-					r, err := common.StrconvFloatBitSize(string(raw), 32)
-					if err != nil {
-						return cols, stringSet, crc32Value, err
-					}
-					rowsTMP = append(rowsTMP, fmt.Sprintf("%v", r))
-				case "float64":
-					r, err := common.StrconvFloatBitSize(string(raw), 64)
-					if err != nil {
-						return cols, stringSet, crc32Value, err
-					}
-					rowsTMP = append(rowsTMP, fmt.Sprintf("%v", r))
-				case "rune":
-					r, err := common.StrconvRune(string(raw))
-					if err != nil {
-						return cols, stringSet, crc32Value, err
-					}
-					rowsTMP = append(rowsTMP, fmt.Sprintf("%v", r))
-				case "godror.Number":
-					r, err := decimal.NewFromString(string(raw))
-					if err != nil {
-						return cols, stringSet, crc32Value, err
-					}
-					if r.IsInteger() {
-						si, err := common.StrconvIntBitSize(string(raw), 64)
-						if err != nil {
-							return cols, stringSet, crc32Value, err
-						}
-						rowsTMP = append(rowsTMP, fmt.Sprintf("%v", si))
-					} else {
-						rf, err := common.StrconvFloatBitSize(string(raw), 64)
-						if err != nil {
-							return cols, stringSet, crc32Value, err
-						}
-						rowsTMP = append(rowsTMP, fmt.Sprintf("%v", rf))
-					}
-				default:
-					// 特殊字符
-					rowsTMP = append(rowsTMP, fmt.Sprintf("'%v'", common.SpecialLettersUsingMySQL(raw)))
-				}
+			v, err := genOracleRowValue(raw, columnTypes[i])
+			if err != nil {
+				return cols, stringSet, crc32Value, err
 			}
+			rowsTMP = append(rowsTMP, v)
 		}
 
 		rowS := exstrings.Join(rowsTMP, ",")
@@ -272,3 +318,143 @@ func (o *Oracle) GetOracleDataRowStrings(querySQL string) ([]string, *strset.Set
 
 	return cols, stringSet, crc32SUM, err
 }
+
+// GetOracleDataRowFixValues 差异数据行修复字段值，前 compareCounts 个字段按数据行对比格式输出用于匹配差异数据行
+// 其余字段按字段类型输出 MySQL 字面量，二进制输出十六进制，字符字段空值按 empty-string-as 规则输出空字符串或者 NULL
+func (o *Oracle) GetOracleDataRowFixValues(querySQL string, compareCounts int, emptyStringAs string) ([][]string, [][]string, error) {
+	var compareRows, fixRows [][]string
+
+	release, err := o.Throttle.Acquire()
+	if err != nil {
+		return compareRows, fixRows, err
+	}
+	defer release()
+
+	rows, err := o.OracleDB.QueryContext(o.Ctx, querySQL)
+	if err != nil {
+		return compareRows, fixRows, fmt.Errorf("general sql [%v] query failed: [%v]", querySQL, err.Error())
+	}
+	defer rows.Close()
+
+	colTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return compareRows, fixRows, err
+	}
+	var columnTypes, databaseTypes []string
+	for _, ct := range colTypes {
+		columnTypes = append(columnTypes, ct.ScanType().String())
+		databaseTypes = append(databaseTypes, ct.DatabaseTypeName())
+	}
+
+	rawResult := make([][]byte, len(colTypes))
+	scans := make([]interface{}, len(colTypes))
+	for i := range rawResult {
+		scans[i] = &rawResult[i]
+	}
+
+	for rows.Next() {
+		if err = rows.Scan(scans...); err != nil {
+			return compareRows, fixRows, fmt.Errorf("general sql [%v] query rows.Scan failed: [%v]", querySQL, err.Error())
+		}
+		if err = o.Throttle.WaitRows(1, rowBytes(rawResult)); err != nil {
+			return compareRows, fixRows, err
+		}
+
+		var compareValues, fixValues []string
+		for i, raw := range rawResult {
+			if i < compareCounts {
+				v, err := genOracleRowValue(raw, columnTypes[i])
+				if err != nil {
+					return compareRows, fixRows, err
+				}
+				compareValues = append(compareValues, v)
+				continue
+			}
+			switch {
+			case len(raw) == 0:
+				if emptyStringAs == common.EmptyStringAsEmpty && isOracleCharacterType(databaseTypes[i]) {
+					fixValues = append(fixValues, "''")
+				} else {
+					fixValues = append(fixValues, "NULL")
+				}
+			case isOracleBinaryType(databaseTypes[i]):
+				fixValues = append(fixValues, fmt.Sprintf("X'%X'", raw))
+			case columnTypes[i] == "int64" || columnTypes[i] == "uint64" || columnTypes[i] == "float32" || columnTypes[i] == "float64" || columnTypes[i] == "godror.Number":
+				fixValues = append(fixValues, string(raw))
+			default:
+				fixValues = append(fixValues, common.StringsBuilder("'", common.SpecialLettersUsingMySQL(raw), "'"))
+			}
+		}
+		compareRows = append(compareRows, compareValues)
+		fixRows = append(fixRows, fixValues)
+	}
+
+	if err = rows.Err(); err != nil {
+		return compareRows, fixRows, fmt.Errorf("general sql [%v] query rows.Next failed: [%v]", querySQL, err.Error())
+	}
+	return compareRows, fixRows, nil
+}
+
+// genOracleRowValue 数据行对比字段值格式化，NULL 与空字符串统一 NULL
+func genOracleRowValue(raw []byte, columnType string) (string, error) {
+	// ORACLE/MySQL 空字符串以及 NULL 统一NULL处理，忽略 MySQL 空字符串与 NULL 区别
+	if raw == nil {
+		return fmt.Sprintf("%v", `NULL`), nil
+	} else if string(raw) == "" {
+		return fmt.Sprintf("%v", `NULL`), nil
+	} else {
+		switch columnType {
+		case "int64":
+			r, err := common.StrconvIntBitSize(string(raw), 64)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("%v", r), nil
+		case "uint64":
+			r, err := common.StrconvUintBitSize(string(raw), 64)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("%v", r), nil
+		case "float32":
+			r, err := common.StrconvFloatBitSize(string(raw), 32)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("%v", r), nil
+		case "float64":
+			r, err := common.StrconvFloatBitSize(string(raw), 64)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("%v", r), nil
+		case "rune":
+			r, err := common.StrconvRune(string(raw))
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("%v", r), nil
+		case "godror.Number":
+			r, err := decimal.NewFromString(string(raw))
+			if err != nil {
+				return "", err
+			}
+			if r.IsInteger() {
+				si, err := common.StrconvIntBitSize(string(raw), 64)
+				if err != nil {
+					return "", err
+				}
+				return fmt.Sprintf("%v", si), nil
+			} else {
+				rf, err := common.StrconvFloatBitSize(string(raw), 64)
+				if err != nil {
+					return "", err
+				}
+				return fmt.Sprintf("%v", rf), nil
+			}
+		default:
+			// 特殊字符
+			return fmt.Sprintf("'%v'", common.SpecialLettersUsingMySQL(raw)), nil
+		}
+	}
+}
@@ -16,11 +16,14 @@ limitations under the License.
 package oracle
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"github.com/godror/godror"
 	"github.com/shopspring/decimal"
 	"github.com/wentaojin/transferdb/common"
 	"github.com/wentaojin/transferdb/config"
+	"io"
 	"strings"
 )
 
@@ -38,6 +41,36 @@ func (o *Oracle) GetOracleCurrentSnapshotSCN() (uint64, error) {
 	return globalSCN, nil
 }
 
+// GetOracleSchemaTableSizeStatistics 表段大小、LOB 段大小以及统计信息行数、平均行长，用于 chunk 自适应切分以及表调度排序
+func (o *Oracle) GetOracleSchemaTableSizeStatistics(schemaName string) ([]map[string]string, error) {
+	querySQL := fmt.Sprintf(`SELECT T.TABLE_NAME,
+       NVL(T.NUM_ROWS,0) AS NUM_ROWS,
+       NVL(T.AVG_ROW_LEN,0) AS AVG_ROW_LEN,
+       NVL(S.BYTES,0) AS BYTES,
+       NVL(L.BYTES,0) AS LOB_BYTES
+  FROM DBA_TABLES T
+  LEFT JOIN (SELECT SEGMENT_NAME, SUM(BYTES) AS BYTES
+               FROM DBA_SEGMENTS
+              WHERE UPPER(OWNER) = UPPER('%s')
+                AND SEGMENT_TYPE IN ('TABLE', 'TABLE PARTITION', 'TABLE SUBPARTITION')
+              GROUP BY SEGMENT_NAME) S
+    ON T.TABLE_NAME = S.SEGMENT_NAME
+  LEFT JOIN (SELECT B.TABLE_NAME, SUM(G.BYTES) AS BYTES
+               FROM DBA_LOBS B, DBA_SEGMENTS G
+              WHERE B.OWNER = G.OWNER
+                AND B.SEGMENT_NAME = G.SEGMENT_NAME
+                AND UPPER(B.OWNER) = UPPER('%s')
+                AND G.SEGMENT_TYPE IN ('LOBSEGMENT', 'LOB PARTITION', 'LOB SUBPARTITION')
+              GROUP BY B.TABLE_NAME) L
+    ON T.TABLE_NAME = L.TABLE_NAME
+ WHERE UPPER(T.OWNER) = UPPER('%s')`, schemaName, schemaName, schemaName)
+	_, res, err := Query(o.Ctx, o.OracleDB, querySQL)
+	if err != nil {
+		return res, err
+	}
+	return res, nil
+}
+
 func (o *Oracle) StartOracleChunkCreateTask(taskName string) error {
 	querySQL := common.StringsBuilder(`SELECT COUNT(1) COUNT FROM dba_parallel_execute_chunks WHERE TASK_NAME='`, taskName, `'`)
 	_, res, err := Query(o.Ctx, o.OracleDB, querySQL)
@@ -120,17 +153,25 @@ func (o *Oracle) GetOracleTableRowsColumnCSV(querySQL string) ([]string, error)
 	return columns, nil
 }
 
-func (o *Oracle) GetOracleTableRowsDataCSV(querySQL string, insertBatchSize int, csvCfg config.CSVConfig, dataChan chan []map[string]string) error {
+func (o *Oracle) GetOracleTableRowsDataCSV(querySQL string, insertBatchSize, lobSizeLimit int, emptyStringAs string, csvCfg config.CSVConfig, dataChan chan []map[string]string) error {
 	var (
-		err         error
-		columnNames []string
-		columnTypes []string
+		err           error
+		columnNames   []string
+		columnTypes   []string
+		databaseTypes []string
 	)
 	// 临时数据存放
 	var rowsTMP []map[string]string
 	rowsMap := make(map[string]string)
 
-	rows, err := o.OracleDB.QueryContext(o.Ctx, querySQL)
+	// 上游数据抽取限流
+	release, err := o.Throttle.Acquire()
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	rows, err := o.OracleDB.QueryContext(o.Ctx, querySQL, godror.LobAsReader())
 	if err != nil {
 		return err
 	}
@@ -145,15 +186,12 @@ func (o *Oracle) GetOracleTableRowsDataCSV(querySQL string, insertBatchSize int,
 		columnNames = append(columnNames, ct.Name())
 		// 数据库字段类型 DatabaseTypeName() 映射 go 类型 ScanType()
 		columnTypes = append(columnTypes, ct.ScanType().String())
+		databaseTypes = append(databaseTypes, ct.DatabaseTypeName())
 	}
 
 	// 数据 SCAN
-	columnNums := len(columnNames)
-	rawResult := make([][]byte, columnNums)
-	dest := make([]interface{}, columnNums)
-	for i := range rawResult {
-		dest[i] = &rawResult[i]
-	}
+	rawResult, lobResult, dest := newOracleRowsDest(databaseTypes)
+	lobReader := newOracleLOBReader(lobSizeLimit)
 
 	// 表行数读取
 	for rows.Next() {
@@ -161,17 +199,33 @@ func (o *Oracle) GetOracleTableRowsDataCSV(querySQL string, insertBatchSize int,
 		if err != nil {
 			return err
 		}
+		if err = lobReader.read(columnNames, databaseTypes, rawResult, lobResult); err != nil {
+			return err
+		}
+		if err = o.Throttle.WaitRows(1, rowBytes(rawResult)); err != nil {
+			return err
+		}
 
 		for i, raw := range rawResult {
 			// 注意 Oracle/Mysql NULL VS 空字符串区别
 			// Oracle 空字符串与 NULL 归于一类，统一 NULL 处理 （is null 可以查询 NULL 以及空字符串值，空字符串查询无法查询到空字符串值）
 			// Mysql 空字符串与 NULL 非一类，NULL 是 NULL，空字符串是空字符串（is null 只查询 NULL 值，空字符串查询只查询到空字符串值）
-			// 按照 Oracle 特性来，转换同步统一转换成 NULL 即可，但需要注意业务逻辑中空字符串得写入，需要变更
-			// Oracle/Mysql 对于 'NULL' 统一字符 NULL 处理，查询出来转成 NULL,所以需要判断处理
-			if raw == nil {
-				rowsMap[columnNames[i]] = fmt.Sprintf("%v", `NULL`)
-			} else if string(raw) == "" {
-				rowsMap[columnNames[i]] = fmt.Sprintf("%v", `NULL`)
+			// NULL 按 null-value 输出且不加 delimiter，字符类型字段 empty-string-as = empty 输出空字符串
+			// 字符串值 'NULL' 由 delimiter 包裹与 NULL 区分，delimiter 为空时建议 null-value 设置 \N 并开启 escape-backslash
+			if len(raw) == 0 {
+				if emptyStringAs == common.EmptyStringAsEmpty && isOracleCharacterType(databaseTypes[i]) {
+					rowsMap[columnNames[i]] = common.StringsBuilder(csvCfg.Delimiter, csvCfg.Delimiter)
+				} else {
+					rowsMap[columnNames[i]] = csvCfg.NullValue
+				}
+			} else if isOracleBinaryType(databaseTypes[i]) {
+				// 二进制字段按 hex/base64 编码，不做字符集转换以及转义
+				bs := common.BinaryEncode(raw, csvCfg.BinaryEncoding)
+				if csvCfg.Delimiter == "" {
+					rowsMap[columnNames[i]] = bs
+				} else {
+					rowsMap[columnNames[i]] = common.StringsBuilder(csvCfg.Delimiter, bs, csvCfg.Delimiter)
+				}
 			} else {
 				switch columnTypes[i] {
 				case "int64":
@@ -248,7 +302,7 @@ func (o *Oracle) GetOracleTableRowsDataCSV(querySQL string, insertBatchSize int,
 		rowsMap = make(map[string]string)
 
 		// batch 批次
-		if len(rowsTMP) == insertBatchSize {
+		if lobReader.isBatchFull(len(rowsTMP), insertBatchSize) {
 
 			dataChan <- rowsTMP
 
@@ -270,6 +324,84 @@ func (o *Oracle) GetOracleTableRowsDataCSV(querySQL string, insertBatchSize int,
 	return nil
 }
 
+// GetOracleTableRowsDataParquet 按查询字段顺序返回原始行数据 -> 用于 CSV 模式 parquet 格式
+// NULL 以及空字符串返回 nil，字符类型字段 empty-string-as = empty 返回空字符串，其余字段值不做转义以及字符集处理，由 parquet 按字段类型写入
+func (o *Oracle) GetOracleTableRowsDataParquet(querySQL string, insertBatchSize, lobSizeLimit int, emptyStringAs string, dataChan chan [][]*string) error {
+	// 上游数据抽取限流
+	release, err := o.Throttle.Acquire()
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	rows, err := o.OracleDB.QueryContext(o.Ctx, querySQL, godror.LobAsReader())
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	colTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return fmt.Errorf("failed to parquet get rows columnTypes: %v", err)
+	}
+	var (
+		columnNames   []string
+		databaseTypes []string
+	)
+	for _, ct := range colTypes {
+		columnNames = append(columnNames, ct.Name())
+		databaseTypes = append(databaseTypes, ct.DatabaseTypeName())
+	}
+
+	// 数据 SCAN
+	columnNums := len(columnNames)
+	rawResult, lobResult, dest := newOracleRowsDest(databaseTypes)
+	lobReader := newOracleLOBReader(lobSizeLimit)
+
+	var rowsTMP [][]*string
+	for rows.Next() {
+		if err = rows.Scan(dest...); err != nil {
+			return err
+		}
+		if err = lobReader.read(columnNames, databaseTypes, rawResult, lobResult); err != nil {
+			return err
+		}
+		if err = o.Throttle.WaitRows(1, rowBytes(rawResult)); err != nil {
+			return err
+		}
+
+		rowValues := make([]*string, columnNums)
+		for i, raw := range rawResult {
+			// Oracle 空字符串与 NULL 归于一类，统一 NULL 处理
+			if len(raw) == 0 {
+				if emptyStringAs == common.EmptyStringAsEmpty && isOracleCharacterType(databaseTypes[i]) {
+					rowValues[i] = new(string)
+				}
+				continue
+			}
+			val := string(raw)
+			rowValues[i] = &val
+		}
+		rowsTMP = append(rowsTMP, rowValues)
+
+		// batch 批次
+		if lobReader.isBatchFull(len(rowsTMP), insertBatchSize) {
+			dataChan <- rowsTMP
+			rowsTMP = make([][]*string, 0)
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	// 非 batch 批次
+	if len(rowsTMP) > 0 {
+		dataChan <- rowsTMP
+	}
+	return nil
+}
+
 // 获取表字段名以及行数据 -> 用于 FULL/ALL
 func (o *Oracle) GetOracleTableRowsColumn(querySQL string) ([]string, error) {
 	var (
@@ -295,17 +427,24 @@ func (o *Oracle) GetOracleTableRowsColumn(querySQL string) ([]string, error) {
 	return columns, nil
 }
 
-func (o *Oracle) GetOracleTableRowsData(querySQL string, insertBatchSize int, dataChan chan []map[string]string) error {
+func (o *Oracle) GetOracleTableRowsData(querySQL string, insertBatchSize, lobSizeLimit int, emptyStringAs string, dataChan chan []map[string]interface{}) error {
 	var (
 		err  error
 		cols []string
 	)
 
 	// 临时数据存放
-	var rowsTMP []map[string]string
-	rowsMap := make(map[string]string)
+	var rowsTMP []map[string]interface{}
+	rowsMap := make(map[string]interface{})
 
-	rows, err := o.OracleDB.QueryContext(o.Ctx, querySQL)
+	// 上游数据抽取限流
+	release, err := o.Throttle.Acquire()
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	rows, err := o.OracleDB.QueryContext(o.Ctx, querySQL, godror.LobAsReader())
 	if err != nil {
 		return err
 	}
@@ -340,12 +479,8 @@ func (o *Oracle) GetOracleTableRowsData(querySQL string, insertBatchSize int, da
 	}
 
 	// 数据 Scan
-	columns := len(cols)
-	rawResult := make([][]byte, columns)
-	dest := make([]interface{}, columns)
-	for i := range rawResult {
-		dest[i] = &rawResult[i]
-	}
+	rawResult, lobResult, dest := newOracleRowsDest(databaseTypes)
+	lobReader := newOracleLOBReader(lobSizeLimit)
 
 	// 表行数读取
 	for rows.Next() {
@@ -353,17 +488,27 @@ func (o *Oracle) GetOracleTableRowsData(querySQL string, insertBatchSize int, da
 		if err != nil {
 			return err
 		}
+		if err = lobReader.read(columnNames, databaseTypes, rawResult, lobResult); err != nil {
+			return err
+		}
+		if err = o.Throttle.WaitRows(1, rowBytes(rawResult)); err != nil {
+			return err
+		}
 
 		for i, raw := range rawResult {
 			// 注意 Oracle/Mysql NULL VS 空字符串区别
 			// Oracle 空字符串与 NULL 归于一类，统一 NULL 处理 （is null 可以查询 NULL 以及空字符串值，空字符串查询无法查询到空字符串值）
 			// Mysql 空字符串与 NULL 非一类，NULL 是 NULL，空字符串是空字符串（is null 只查询 NULL 值，空字符串查询只查询到空字符串值）
-			// 按照 Oracle 特性来，转换同步统一转换成 NULL 即可，但需要注意业务逻辑中空字符串得写入，需要变更
-			// Oracle/Mysql 对于 'NULL' 统一字符 NULL 处理，查询出来转成 NULL,所以需要判断处理
-			if raw == nil {
-				rowsMap[cols[i]] = fmt.Sprintf("%v", `NULL`)
-			} else if string(raw) == "" {
-				rowsMap[cols[i]] = fmt.Sprintf("%v", `NULL`)
+			// 字段值按类型返回用于绑定变量写入，NULL 返回 nil，字符类型字段 empty-string-as = empty 返回空字符串
+			// 字符串无需转义，字符串值 'NULL' 按字符串写入，二进制返回原始字节
+			if len(raw) == 0 {
+				if emptyStringAs == common.EmptyStringAsEmpty && isOracleCharacterType(databaseTypes[i]) {
+					rowsMap[cols[i]] = ""
+				} else {
+					rowsMap[cols[i]] = nil
+				}
+			} else if isOracleBinaryType(databaseTypes[i]) {
+				rowsMap[cols[i]] = raw
 			} else {
 				switch columnTypes[i] {
 				case "int64":
@@ -371,40 +516,39 @@ func (o *Oracle) GetOracleTableRowsData(querySQL string, insertBatchSize int, da
 					if err != nil {
 						return fmt.Errorf("column [%s] strconv failed, %v", columnNames[i], err)
 					}
-					rowsMap[cols[i]] = fmt.Sprintf("%v", r)
+					rowsMap[cols[i]] = r
 				case "uint64":
 					r, err := common.StrconvUintBitSize(string(raw), 64)
 					if err != nil {
 						return fmt.Errorf("column [%s] strconv failed, %v", columnNames[i], err)
 					}
-					rowsMap[cols[i]] = fmt.Sprintf("%v", r)
+					rowsMap[cols[i]] = r
 				case "float32":
 					r, err := common.StrconvFloatBitSize(string(raw), 32)
 					if err != nil {
 						return fmt.Errorf("column [%s] strconv failed, %v", columnNames[i], err)
 					}
-					rowsMap[cols[i]] = fmt.Sprintf("%v", r)
+					rowsMap[cols[i]] = r
 				case "float64":
 					r, err := common.StrconvFloatBitSize(string(raw), 64)
 					if err != nil {
 						return fmt.Errorf("column [%s] strconv failed, %v", columnNames[i], err)
 					}
-					rowsMap[cols[i]] = fmt.Sprintf("%v", r)
+					rowsMap[cols[i]] = r
 				case "rune":
 					r, err := common.StrconvRune(string(raw))
 					if err != nil {
 						return fmt.Errorf("column [%s] strconv failed, %v", columnNames[i], err)
 					}
-					rowsMap[cols[i]] = fmt.Sprintf("%v", r)
+					rowsMap[cols[i]] = r
 				case "godror.Number":
 					r, err := decimal.NewFromString(string(raw))
 					if err != nil {
 						return fmt.Errorf("column [%s] NewFromString strconv failed, %v", columnNames[i], err)
 					}
-					rowsMap[cols[i]] = fmt.Sprintf("%v", r)
+					rowsMap[cols[i]] = r.String()
 				default:
-					// 特殊字符
-					rowsMap[cols[i]] = fmt.Sprintf("'%v'", common.SpecialLettersUsingMySQL(raw))
+					rowsMap[cols[i]] = string(raw)
 				}
 			}
 		}
@@ -413,15 +557,15 @@ func (o *Oracle) GetOracleTableRowsData(querySQL string, insertBatchSize int, da
 		rowsTMP = append(rowsTMP, rowsMap)
 
 		// MAP 清空
-		rowsMap = make(map[string]string)
+		rowsMap = make(map[string]interface{})
 
 		// batch 批次
-		if len(rowsTMP) == insertBatchSize {
+		if lobReader.isBatchFull(len(rowsTMP), insertBatchSize) {
 
 			dataChan <- rowsTMP
 
 			// 数组清空
-			rowsTMP = make([]map[string]string, 0)
+			rowsTMP = make([]map[string]interface{}, 0)
 		}
 	}
 
@@ -436,3 +580,144 @@ func (o *Oracle) GetOracleTableRowsData(querySQL string, insertBatchSize int, da
 
 	return nil
 }
+
+// isOracleBinaryType 二进制字段类型，按原始字节编码迁移，不做字符集转换以及转义
+func isOracleBinaryType(databaseType string) bool {
+	switch databaseType {
+	case "RAW", "LONG RAW", "BLOB":
+		return true
+	default:
+		return false
+	}
+}
+
+// isOracleCharacterType 字符类型字段，用于 empty-string-as 空字符串处理
+func isOracleCharacterType(databaseType string) bool {
+	switch databaseType {
+	case "VARCHAR2", "NVARCHAR2", "CHAR", "NCHAR", "LONG", "CLOB", "NCLOB":
+		return true
+	default:
+		return false
+	}
+}
+
+func isOracleLOBType(databaseType string) bool {
+	switch databaseType {
+	case "CLOB", "NCLOB", "BLOB":
+		return true
+	default:
+		return false
+	}
+}
+
+// newOracleRowsDest 行数据 SCAN 目标
+// 查询需以 godror.LobAsReader() 执行，LOB 字段 SCAN 为 *godror.Lob 由 oracleLOBReader 分段读取，其余字段 SCAN 为 []byte
+func newOracleRowsDest(databaseTypes []string) ([][]byte, []interface{}, []interface{}) {
+	rawResult := make([][]byte, len(databaseTypes))
+	lobResult := make([]interface{}, len(databaseTypes))
+	dest := make([]interface{}, len(databaseTypes))
+	for i, t := range databaseTypes {
+		if isOracleLOBType(t) {
+			dest[i] = &lobResult[i]
+		} else {
+			dest[i] = &rawResult[i]
+		}
+	}
+	return rawResult, lobResult, dest
+}
+
+// oracleLOBReader 按 OracleLOBReadPieceSize 分段读取 LOB 字段值，单字段超过 lob-size-limit 报错，避免大 LOB 无上限物化内存
+// LOB 字段值整体物化于 batch 行数据内，batch 累计 LOB 字节数达到 lob-size-limit 提前下发，单 batch 内存约为 2 倍 lob-size-limit
+type oracleLOBReader struct {
+	limit      int64
+	piece      []byte
+	batchBytes int64
+}
+
+func newOracleLOBReader(lobSizeLimit int) *oracleLOBReader {
+	if lobSizeLimit <= 0 {
+		lobSizeLimit = common.OracleLOBDefaultSizeLimit
+	}
+	return &oracleLOBReader{
+		limit: int64(lobSizeLimit) << 20,
+		piece: make([]byte, common.OracleLOBReadPieceSize),
+	}
+}
+
+// read 读取当前行 LOB 字段值写入 rawResult，LONG/LONG RAW 由驱动整体读取，仅校验大小
+func (l *oracleLOBReader) read(columnNames, databaseTypes []string, rawResult [][]byte, lobResult []interface{}) error {
+	for i, t := range databaseTypes {
+		if !isOracleLOBType(t) {
+			if t == "LONG" || t == "LONG RAW" {
+				if int64(len(rawResult[i])) > l.limit {
+					return fmt.Errorf("column [%s] datatype [%s] size [%d] exceeds lob-size-limit [%d] bytes", columnNames[i], t, len(rawResult[i]), l.limit)
+				}
+				l.batchBytes += int64(len(rawResult[i]))
+			}
+			continue
+		}
+		switch v := lobResult[i].(type) {
+		case nil:
+			rawResult[i] = nil
+		case *godror.Lob:
+			var (
+				buf  bytes.Buffer
+				size int64
+			)
+			for {
+				n, err := v.Read(l.piece)
+				if n > 0 {
+					size += int64(n)
+					if size > l.limit {
+						return fmt.Errorf("column [%s] datatype [%s] size exceeds lob-size-limit [%d] bytes", columnNames[i], t, l.limit)
+					}
+					buf.Write(l.piece[:n])
+				}
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					return fmt.Errorf("column [%s] datatype [%s] lob read failed: %v", columnNames[i], t, err)
+				}
+			}
+			rawResult[i] = buf.Bytes()
+		case string:
+			rawResult[i] = []byte(v)
+		case []byte:
+			rawResult[i] = v
+		default:
+			return fmt.Errorf("column [%s] datatype [%s] scan type [%T] is not support", columnNames[i], t, v)
+		}
+		l.batchBytes += int64(len(rawResult[i]))
+	}
+	return nil
+}
+
+// isBatchFull batch 行数达到 insert-batch-size 或者累计 LOB 字节数达到 lob-size-limit 则下发，下发后重新计数
+func (l *oracleLOBReader) isBatchFull(rows, batchSize int) bool {
+	if rows == batchSize || l.batchBytes >= l.limit {
+		l.batchBytes = 0
+		return true
+	}
+	return false
+}
+
+// IsOracleTableRowsLOB 查询字段是否存在 LOB/LONG 字段，存在则缩小数据读写通道缓冲，限制 LOB 表内存占用
+func (o *Oracle) IsOracleTableRowsLOB(querySQL string) (bool, error) {
+	rows, err := o.OracleDB.QueryContext(o.Ctx, querySQL)
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	colTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows columnTypes: %v", err)
+	}
+	for _, ct := range colTypes {
+		if isOracleLOBType(ct.DatabaseTypeName()) || ct.DatabaseTypeName() == "LONG" || ct.DatabaseTypeName() == "LONG RAW" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
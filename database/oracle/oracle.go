@@ -31,6 +31,8 @@ import (
 type Oracle struct {
 	Ctx      context.Context
 	OracleDB *sql.DB
+	// 数据抽取限流，nil 不限流
+	Throttle *Throttle
 }
 
 // 创建 oracle 数据库引擎
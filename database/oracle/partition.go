@@ -0,0 +1,249 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package oracle
+
+import (
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"strings"
+)
+
+// GetOracleTablePartitionSegments 分区表数据段，组合分区按子分区，非组合分区 SUBPARTITION_NAME 为空
+func (o *Oracle) GetOracleTablePartitionSegments(schemaName, tableName string) ([]map[string]string, error) {
+	querySQL := fmt.Sprintf(`SELECT P.PARTITION_NAME,
+       P.PARTITION_POSITION,
+       S.SUBPARTITION_NAME
+  FROM DBA_TAB_PARTITIONS P
+  LEFT JOIN DBA_TAB_SUBPARTITIONS S
+    ON P.TABLE_OWNER = S.TABLE_OWNER
+   AND P.TABLE_NAME = S.TABLE_NAME
+   AND P.PARTITION_NAME = S.PARTITION_NAME
+ WHERE UPPER(P.TABLE_OWNER) = UPPER('%s')
+   AND UPPER(P.TABLE_NAME) = UPPER('%s')
+ ORDER BY P.PARTITION_POSITION, S.SUBPARTITION_POSITION`, schemaName, tableName)
+	_, res, err := Query(o.Ctx, o.OracleDB, querySQL)
+	if err != nil {
+		return res, err
+	}
+	for _, r := range res {
+		if r["SUBPARTITION_NAME"] == "NULLABLE" {
+			r["SUBPARTITION_NAME"] = ""
+		}
+	}
+	return res, nil
+}
+
+// GetOracleTableSelectPartitionSegments 指定分区数据段
+// partitions 分区或者子分区名，recentPartitions 按分区位置最近 N 个分区，均未指定返回全部数据段
+func (o *Oracle) GetOracleTableSelectPartitionSegments(schemaName, tableName string, partitions []string, recentPartitions int) ([]map[string]string, error) {
+	segments, err := o.GetOracleTablePartitionSegments(schemaName, tableName)
+	if err != nil {
+		return nil, err
+	}
+	if len(segments) == 0 {
+		return nil, fmt.Errorf("oracle table [%s.%s] partition isn't exist", schemaName, tableName)
+	}
+	if len(partitions) == 0 && recentPartitions == 0 {
+		return segments, nil
+	}
+
+	var partitionNames []string
+	for _, s := range segments {
+		if !common.IsContainString(partitionNames, s["PARTITION_NAME"]) {
+			partitionNames = append(partitionNames, s["PARTITION_NAME"])
+		}
+	}
+	for _, p := range partitions {
+		var isExist bool
+		for _, s := range segments {
+			if strings.EqualFold(s["PARTITION_NAME"], p) || strings.EqualFold(s["SUBPARTITION_NAME"], p) {
+				isExist = true
+				break
+			}
+		}
+		if !isExist {
+			return nil, fmt.Errorf("oracle table [%s.%s] partition or subpartition [%s] isn't exist", schemaName, tableName, p)
+		}
+	}
+
+	var recentNames []string
+	if recentPartitions > 0 {
+		if recentPartitions >= len(partitionNames) {
+			recentNames = partitionNames
+		} else {
+			recentNames = partitionNames[len(partitionNames)-recentPartitions:]
+		}
+	}
+
+	var selectSegments []map[string]string
+	for _, s := range segments {
+		if common.IsContainString(recentNames, s["PARTITION_NAME"]) ||
+			common.IsContainString(partitions, common.StringUPPER(s["PARTITION_NAME"])) ||
+			(s["SUBPARTITION_NAME"] != "" && common.IsContainString(partitions, common.StringUPPER(s["SUBPARTITION_NAME"]))) {
+			selectSegments = append(selectSegments, s)
+		}
+	}
+	return selectSegments, nil
+}
+
+// StartOracleCreateChunkBySegments 仅按指定分区/子分区数据段 extent 切分 ROWID chunk，未指定数据段不参与切分
+// 同一数据段 extent 按 (文件号, 块号) 排序累计块数分组，每组块数按统计信息每块平均行数折算 chunkSize 行
+func (o *Oracle) StartOracleCreateChunkBySegments(taskName, schemaName, tableName string, segments []map[string]string, chunkSize string) error {
+	var segmentNames []string
+	for _, s := range segments {
+		if s["SUBPARTITION_NAME"] != "" {
+			segmentNames = append(segmentNames, common.StringsBuilder("'", s["SUBPARTITION_NAME"], "'"))
+		} else {
+			segmentNames = append(segmentNames, common.StringsBuilder("'", s["PARTITION_NAME"], "'"))
+		}
+	}
+	if len(segmentNames) == 0 {
+		return fmt.Errorf("oracle table [%s.%s] select partition segments is null", schemaName, tableName)
+	}
+
+	chunkQuery := fmt.Sprintf(`SELECT DBMS_ROWID.ROWID_CREATE(1, DATA_OBJECT_ID, MIN_FNO, MIN_BLOCK, 0) AS START_ID,
+       DBMS_ROWID.ROWID_CREATE(1, DATA_OBJECT_ID, MAX_FNO, MAX_BLOCK, 32767) AS END_ID
+  FROM (SELECT DATA_OBJECT_ID,
+               BUCKET,
+               MIN(RELATIVE_FNO) KEEP(DENSE_RANK FIRST ORDER BY RELATIVE_FNO, BLOCK_ID) AS MIN_FNO,
+               MIN(BLOCK_ID) KEEP(DENSE_RANK FIRST ORDER BY RELATIVE_FNO, BLOCK_ID) AS MIN_BLOCK,
+               MAX(RELATIVE_FNO) KEEP(DENSE_RANK LAST ORDER BY RELATIVE_FNO, BLOCK_ID) AS MAX_FNO,
+               MAX(BLOCK_ID + BLOCKS - 1) KEEP(DENSE_RANK LAST ORDER BY RELATIVE_FNO, BLOCK_ID) AS MAX_BLOCK
+          FROM (SELECT O.DATA_OBJECT_ID,
+                       E.RELATIVE_FNO,
+                       E.BLOCK_ID,
+                       E.BLOCKS,
+                       TRUNC((SUM(E.BLOCKS) OVER(PARTITION BY O.DATA_OBJECT_ID ORDER BY E.RELATIVE_FNO, E.BLOCK_ID) - E.BLOCKS) /
+                             (SELECT GREATEST(1, CEIL(%s / GREATEST(1, NVL(T.NUM_ROWS, 0) / GREATEST(NVL(T.BLOCKS, 0), 1))))
+                                FROM DBA_TABLES T
+                               WHERE T.OWNER = '%s'
+                                 AND T.TABLE_NAME = '%s')) AS BUCKET
+                  FROM DBA_EXTENTS E, DBA_OBJECTS O
+                 WHERE E.OWNER = O.OWNER
+                   AND E.SEGMENT_NAME = O.OBJECT_NAME
+                   AND E.PARTITION_NAME = O.SUBOBJECT_NAME
+                   AND E.SEGMENT_TYPE = O.OBJECT_TYPE
+                   AND O.OWNER = '%s'
+                   AND O.OBJECT_NAME = '%s'
+                   AND O.SUBOBJECT_NAME IN (%s))
+         GROUP BY DATA_OBJECT_ID, BUCKET)`, chunkSize, schemaName, tableName, schemaName, tableName, strings.Join(segmentNames, ","))
+
+	chunkSQL := common.StringsBuilder(`BEGIN
+  DBMS_PARALLEL_EXECUTE.CREATE_CHUNKS_BY_SQL (task_name => '`, taskName, `',
+                                              sql_stmt  => '`, strings.ReplaceAll(chunkQuery, "'", "''"), `',
+                                              by_rowid  => TRUE);
+END;`)
+	if _, err := o.OracleDB.ExecContext(o.Ctx, chunkSQL); err != nil {
+		return fmt.Errorf("oracle DBMS_PARALLEL_EXECUTE create_chunks_by_sql task failed: %v, sql: %v", err, chunkSQL)
+	}
+	return nil
+}
+
+// GetOracleTablePartitionChunksByRowID 分区表 ROWID chunk 按所在数据段标记分区以及子分区，仅返回指定数据段 chunk
+// 指定数据段无数据（不存在 chunk）返回首个数据段 1 = 1 chunk，保证表至少存在一个 chunk
+func (o *Oracle) GetOracleTablePartitionChunksByRowID(taskName, schemaName, tableName string, segments []map[string]string) ([]map[string]string, error) {
+	querySQL := fmt.Sprintf(`SELECT 'ROWID BETWEEN ''' || C.START_ROWID || ''' AND ''' || C.END_ROWID || '''' CMD,
+       O.OBJECT_TYPE,
+       O.SUBOBJECT_NAME
+  FROM DBA_PARALLEL_EXECUTE_CHUNKS C,
+       DBA_OBJECTS O
+ WHERE C.TASK_NAME = '%s'
+   AND O.DATA_OBJECT_ID = DBMS_ROWID.ROWID_OBJECT(C.START_ROWID)
+   AND UPPER(O.OWNER) = UPPER('%s')
+   AND UPPER(O.OBJECT_NAME) = UPPER('%s')
+ ORDER BY C.CHUNK_ID`, taskName, schemaName, tableName)
+	_, res, err := Query(o.Ctx, o.OracleDB, querySQL)
+	if err != nil {
+		return nil, err
+	}
+
+	// 数据段 -> 所属分区
+	segmentPartitions := make(map[string]string)
+	for _, s := range segments {
+		if s["SUBPARTITION_NAME"] == "" {
+			segmentPartitions[s["PARTITION_NAME"]] = s["PARTITION_NAME"]
+		} else {
+			segmentPartitions[s["SUBPARTITION_NAME"]] = s["PARTITION_NAME"]
+		}
+	}
+
+	var chunks []map[string]string
+	for _, r := range res {
+		partitionName, ok := segmentPartitions[r["SUBOBJECT_NAME"]]
+		if !ok {
+			continue
+		}
+		var subPartitionName string
+		if strings.EqualFold(r["OBJECT_TYPE"], "TABLE SUBPARTITION") {
+			subPartitionName = r["SUBOBJECT_NAME"]
+		}
+		chunks = append(chunks, map[string]string{
+			"CMD":               r["CMD"],
+			"PARTITION_NAME":    partitionName,
+			"SUBPARTITION_NAME": subPartitionName,
+		})
+	}
+
+	if len(chunks) == 0 && len(segments) > 0 {
+		chunks = append(chunks, map[string]string{
+			"CMD":               "1 = 1",
+			"PARTITION_NAME":    segments[0]["PARTITION_NAME"],
+			"SUBPARTITION_NAME": segments[0]["SUBPARTITION_NAME"],
+		})
+	}
+	return chunks, nil
+}
+
+// GetOracleTablePartitionHighValues 分区类型、分区键以及按分区位置排序的分区边界值
+func (o *Oracle) GetOracleTablePartitionHighValues(schemaName, tableName string) ([]map[string]string, error) {
+	querySQL := fmt.Sprintf(`SELECT T.PARTITIONING_TYPE,
+       K.COLUMN_LIST,
+       P.PARTITION_NAME,
+       P.PARTITION_POSITION,
+       P.HIGH_VALUE
+  FROM DBA_PART_TABLES T,
+       DBA_TAB_PARTITIONS P,
+       (SELECT OWNER, NAME, LISTAGG(COLUMN_NAME, ',') WITHIN GROUP(ORDER BY COLUMN_POSITION) AS COLUMN_LIST
+          FROM DBA_PART_KEY_COLUMNS
+         WHERE OBJECT_TYPE = 'TABLE'
+           AND UPPER(OWNER) = UPPER('%s')
+           AND UPPER(NAME) = UPPER('%s')
+         GROUP BY OWNER, NAME) K
+ WHERE T.OWNER = P.TABLE_OWNER
+   AND T.TABLE_NAME = P.TABLE_NAME
+   AND T.OWNER = K.OWNER
+   AND T.TABLE_NAME = K.NAME
+   AND UPPER(T.OWNER) = UPPER('%s')
+   AND UPPER(T.TABLE_NAME) = UPPER('%s')
+ ORDER BY P.PARTITION_POSITION`, schemaName, tableName, schemaName, tableName)
+	_, res, err := Query(o.Ctx, o.OracleDB, querySQL)
+	if err != nil {
+		return res, err
+	}
+	return res, nil
+}
+
+// GenOraclePartitionTable 数据抽取表名，分区粒度 chunk 指定 PARTITION/SUBPARTITION
+func GenOraclePartitionTable(schemaName, tableName, partitionName, subPartitionName string) string {
+	table := common.StringsBuilder(schemaName, `.`, tableName)
+	if subPartitionName != "" {
+		return common.StringsBuilder(table, ` SUBPARTITION (`, subPartitionName, `)`)
+	}
+	if partitionName != "" {
+		return common.StringsBuilder(table, ` PARTITION (`, partitionName, `)`)
+	}
+	return table
+}
@@ -17,6 +17,7 @@ package oracle
 
 import (
 	"fmt"
+	"github.com/wentaojin/transferdb/common"
 	"regexp"
 	"strings"
 )
@@ -84,6 +85,21 @@ func (o *Oracle) GetOracleSchemaMaterializedView(schemaName string) ([]string, e
 	return tables, nil
 }
 
+func (o *Oracle) GetOracleSchemaMaterializedViewDetail(schemaName string) ([]map[string]string, error) {
+	// 物化视图定义以及刷新方式
+	_, res, err := Query(o.Ctx, o.OracleDB, fmt.Sprintf(`SELECT MVIEW_NAME,
+       QUERY,
+       REFRESH_METHOD,
+       REFRESH_MODE,
+       BUILD_MODE
+  FROM DBA_MVIEWS
+ WHERE UPPER(OWNER) = UPPER('%s')`, schemaName))
+	if err != nil {
+		return res, err
+	}
+	return res, nil
+}
+
 // ORACLE XML 限制
 // func (e *Engine) GetOracleTableColumn(schemaName string, tableName string, oraCollation bool) ([]map[string]string, error) {
 //	var querySQL string
@@ -313,7 +329,9 @@ func (o *Oracle) GetOracleSchemaTableNormalIndex(schemaName string, tableName st
 	temp.ITYP_OWNER,
 	temp.ITYP_NAME,
 	temp.PARAMETERS,
-	LISTAGG ( temp.COLUMN_NAME, ',' ) WITHIN GROUP ( ORDER BY temp.COLUMN_POSITION ) AS COLUMN_LIST 
+	LISTAGG ( temp.COLUMN_NAME, ',' ) WITHIN GROUP ( ORDER BY temp.COLUMN_POSITION ) AS COLUMN_LIST,
+	LISTAGG ( temp.COLUMN_NAME, '|+|' ) WITHIN GROUP ( ORDER BY temp.COLUMN_POSITION ) AS COLUMN_EXPR_LIST,
+	LISTAGG ( temp.DESCEND, ',' ) WITHIN GROUP ( ORDER BY temp.COLUMN_POSITION ) AS DESCEND_LIST 
 FROM
 	(
 SELECT
@@ -358,7 +376,8 @@ WHERE
 xs.INDEX_OWNER = T.INDEX_OWNER
 	AND xs.INDEX_NAME = T.INDEX_NAME
 AND xs.COLUMN_POSITION = T.COLUMN_POSITION)) COLUMN_NAME,
-		T.COLUMN_POSITION
+		T.COLUMN_POSITION,
+		T.DESCEND
 	FROM
 		DBA_IND_COLUMNS T,
 		DBA_INDEXES I 
@@ -403,7 +422,9 @@ func (o *Oracle) GetOracleSchemaTableUniqueIndex(schemaName string, tableName st
 	temp.ITYP_OWNER,
 	temp.ITYP_NAME,
 	temp.PARAMETERS,
-	LISTAGG ( temp.COLUMN_NAME, ',' ) WITHIN GROUP ( ORDER BY temp.COLUMN_POSITION ) AS COLUMN_LIST 
+	LISTAGG ( temp.COLUMN_NAME, ',' ) WITHIN GROUP ( ORDER BY temp.COLUMN_POSITION ) AS COLUMN_LIST,
+	LISTAGG ( temp.COLUMN_NAME, '|+|' ) WITHIN GROUP ( ORDER BY temp.COLUMN_POSITION ) AS COLUMN_EXPR_LIST,
+	LISTAGG ( temp.DESCEND, ',' ) WITHIN GROUP ( ORDER BY temp.COLUMN_POSITION ) AS DESCEND_LIST 
 FROM
 	(
 SELECT
@@ -448,7 +469,8 @@ WHERE
 xs.INDEX_OWNER = T.INDEX_OWNER
 	AND xs.INDEX_NAME = T.INDEX_NAME
 AND xs.COLUMN_POSITION = T.COLUMN_POSITION)) COLUMN_NAME,
-		T.COLUMN_POSITION
+		T.COLUMN_POSITION,
+		T.DESCEND
 FROM
 	DBA_INDEXES I,
 	DBA_IND_COLUMNS T 
@@ -623,6 +645,85 @@ func (o *Oracle) GetOracleSchemaTableColumn(schemaName string, tableName string,
 	return queryRes, nil
 }
 
+// GetOracleSchemaTableColumnAttr 字段属性：虚拟列、不可见列、标识列
+func (o *Oracle) GetOracleSchemaTableColumnAttr(schemaName string, tableName string) ([]map[string]string, error) {
+	oraDBVersion, err := o.GetOracleDBVersion()
+	if err != nil {
+		return nil, err
+	}
+	// IDENTITY_COLUMN oracle 12c 及以上
+	identityColumn := `'NO' AS IDENTITY_COLUMN`
+	if common.VersionOrdinal(oraDBVersion) >= common.VersionOrdinal(common.OracleIdentityColumnDBVersion) {
+		identityColumn = `t.IDENTITY_COLUMN`
+	}
+	_, res, err := Query(o.Ctx, o.OracleDB, fmt.Sprintf(`SELECT t.COLUMN_NAME,
+       t.VIRTUAL_COLUMN,
+       t.HIDDEN_COLUMN,
+       %s,
+       t.DATA_DEFAULT
+  FROM DBA_TAB_COLS t
+ WHERE t.USER_GENERATED = 'YES'
+   AND UPPER(t.OWNER) = UPPER('%s')
+   AND UPPER(t.TABLE_NAME) = UPPER('%s')`, identityColumn, schemaName, tableName))
+	if err != nil {
+		return res, err
+	}
+	return res, nil
+}
+
+// GetOracleSchemaTableVirtualColumn 虚拟列，数据迁移写入字段需排除
+func (o *Oracle) GetOracleSchemaTableVirtualColumn(schemaName string, tableName string) ([]string, error) {
+	_, res, err := Query(o.Ctx, o.OracleDB, fmt.Sprintf(`SELECT t.COLUMN_NAME
+  FROM DBA_TAB_COLS t
+ WHERE t.USER_GENERATED = 'YES'
+   AND t.VIRTUAL_COLUMN = 'YES'
+   AND UPPER(t.OWNER) = UPPER('%s')
+   AND UPPER(t.TABLE_NAME) = UPPER('%s')`, schemaName, tableName))
+	if err != nil {
+		return []string{}, err
+	}
+	var columns []string
+	for _, r := range res {
+		columns = append(columns, r["COLUMN_NAME"])
+	}
+	return columns, nil
+}
+
+// GetOracleSchemaTableCharsetIncompatibleColumn 字符数据无法无损转换目标字符集的字段
+// CONVERT 无法转换字符替换为 ?，往返转换结果不一致代表数据无法转换，仅扫描前 sampleRows 行数据
+func (o *Oracle) GetOracleSchemaTableCharsetIncompatibleColumn(schemaName, tableName string, columns []string, targetCharset string, sampleRows int) ([]string, error) {
+	if len(columns) == 0 {
+		return []string{}, nil
+	}
+	_, res, err := Query(o.Ctx, o.OracleDB, `SELECT VALUE FROM NLS_DATABASE_PARAMETERS WHERE PARAMETER = 'NLS_CHARACTERSET'`)
+	if err != nil {
+		return []string{}, err
+	}
+	if len(res) == 0 {
+		return []string{}, fmt.Errorf("oracle db nls_characterset isn't exist")
+	}
+	dbCharset := res[0]["VALUE"]
+
+	var exprs []string
+	for i, c := range columns {
+		exprs = append(exprs, fmt.Sprintf(`SUM(CASE WHEN "%s" IS NOT NULL AND CONVERT(CONVERT("%s", '%s', '%s'), '%s', '%s') <> "%s" THEN 1 ELSE 0 END) AS C%d`,
+			c, c, targetCharset, dbCharset, dbCharset, targetCharset, c, i))
+	}
+	_, res, err = Query(o.Ctx, o.OracleDB, fmt.Sprintf(`SELECT %s FROM (SELECT * FROM "%s"."%s" WHERE ROWNUM <= %d)`, strings.Join(exprs, ","), schemaName, tableName, sampleRows))
+	if err != nil {
+		return []string{}, err
+	}
+
+	var incompColumns []string
+	for i, c := range columns {
+		val := res[0][fmt.Sprintf("C%d", i)]
+		if val != "0" && val != "" && val != "NULLABLE" {
+			incompColumns = append(incompColumns, c)
+		}
+	}
+	return incompColumns, nil
+}
+
 func (o *Oracle) GetOracleSchemaTableColumnComment(schemaName string, tableName string) ([]map[string]string, error) {
 	var querySQL string
 
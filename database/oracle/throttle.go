@@ -0,0 +1,252 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package oracle
+
+import (
+	"context"
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Throttle 上游 Oracle 数据抽取限流以及负载保护
+// 1、max-rows-per-second/max-bytes-per-second 任务级全局行数以及字节数限速
+// 2、max-sessions 限制同时执行数据抽取查询的 Oracle 会话数
+// 3、schedule-window 时间窗口外暂停新的数据抽取查询，已运行的查询不中断
+// 4、active-session-threshold Oracle 活跃会话数超过阈值时退避等待
+type Throttle struct {
+	ctx         context.Context
+	oracle      *Oracle
+	cfg         config.ThrottleConfig
+	rows        *rate.Limiter
+	bytes       *rate.Limiter
+	sessions    chan struct{}
+	windowStart time.Duration
+	windowEnd   time.Duration
+
+	mu             sync.Mutex
+	checkTime      time.Time
+	activeSessions int
+}
+
+func NewThrottle(ctx context.Context, oracle *Oracle, cfg config.ThrottleConfig) (*Throttle, error) {
+	t := &Throttle{
+		ctx:    ctx,
+		oracle: oracle,
+		cfg:    cfg,
+	}
+	if cfg.MaxRowsPerSecond > 0 {
+		t.rows = rate.NewLimiter(rate.Limit(cfg.MaxRowsPerSecond), cfg.MaxRowsPerSecond)
+	}
+	if cfg.MaxBytesPerSecond > 0 {
+		t.bytes = rate.NewLimiter(rate.Limit(cfg.MaxBytesPerSecond), cfg.MaxBytesPerSecond)
+	}
+	if cfg.MaxSessions > 0 {
+		t.sessions = make(chan struct{}, cfg.MaxSessions)
+	}
+	if cfg.ScheduleWindow != "" {
+		start, end, err := common.ParseTimeWindow(cfg.ScheduleWindow)
+		if err != nil {
+			return nil, err
+		}
+		t.windowStart, t.windowEnd = start, end
+	}
+	// 预检查活跃会话视图查询权限
+	if cfg.ActiveSessionThreshold > 0 {
+		if _, err := oracle.GetOracleActiveSessionCount(); err != nil {
+			return nil, fmt.Errorf("oracle throttle active-session-threshold check failed: %v", err)
+		}
+	}
+	return t, nil
+}
+
+// Acquire 数据抽取查询前等待时间窗口、活跃会话退避以及会话数限制，返回会话释放函数
+func (t *Throttle) Acquire() (func(), error) {
+	if t == nil {
+		return func() {}, nil
+	}
+	if err := t.waitScheduleWindow(); err != nil {
+		return func() {}, err
+	}
+	if err := t.waitActiveSession(); err != nil {
+		return func() {}, err
+	}
+	if t.sessions == nil {
+		return func() {}, nil
+	}
+	select {
+	case t.sessions <- struct{}{}:
+		return func() { <-t.sessions }, nil
+	case <-t.ctx.Done():
+		return func() {}, t.ctx.Err()
+	}
+}
+
+// WaitRows 按已抽取行数以及字节数限速
+func (t *Throttle) WaitRows(rows, bytes int) error {
+	if t == nil {
+		return nil
+	}
+	if err := waitLimiter(t.ctx, t.rows, rows); err != nil {
+		return err
+	}
+	return waitLimiter(t.ctx, t.bytes, bytes)
+}
+
+func (t *Throttle) waitScheduleWindow() error {
+	if t.cfg.ScheduleWindow == "" {
+		return nil
+	}
+	wait := t.untilScheduleWindow(time.Now())
+	if wait == 0 {
+		return nil
+	}
+	zap.L().Warn("oracle extraction outside schedule window, waiting",
+		zap.String("schedule window", t.cfg.ScheduleWindow),
+		zap.String("wait", wait.String()))
+	return sleepContext(t.ctx, wait)
+}
+
+// untilScheduleWindow 距下一次时间窗口开始时长，处于时间窗口内返回 0
+func (t *Throttle) untilScheduleWindow(now time.Time) time.Duration {
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	current := now.Sub(midnight)
+
+	var inWindow bool
+	if t.windowStart < t.windowEnd {
+		inWindow = current >= t.windowStart && current < t.windowEnd
+	} else {
+		// 跨天时间窗口，例如 22:00-06:00
+		inWindow = current >= t.windowStart || current < t.windowEnd
+	}
+	if inWindow {
+		return 0
+	}
+	if current < t.windowStart {
+		return t.windowStart - current
+	}
+	return 24*time.Hour - current + t.windowStart
+}
+
+// waitActiveSession 活跃会话数超过阈值，按 backoff-interval 指数退避等待，最长 common.ThrottleMaxBackoffInterval
+func (t *Throttle) waitActiveSession() error {
+	if t.cfg.ActiveSessionThreshold <= 0 {
+		return nil
+	}
+	backoff := time.Duration(t.cfg.BackoffInterval) * time.Second
+	for {
+		counts, err := t.activeSessionCount()
+		if err != nil {
+			return err
+		}
+		if counts <= t.cfg.ActiveSessionThreshold {
+			return nil
+		}
+		zap.L().Warn("oracle active session count exceeds threshold, backoff",
+			zap.Int("active sessions", counts),
+			zap.Int("threshold", t.cfg.ActiveSessionThreshold),
+			zap.String("backoff", backoff.String()))
+		if err = sleepContext(t.ctx, backoff); err != nil {
+			return err
+		}
+		if next := backoff * 2; next <= common.ThrottleMaxBackoffInterval {
+			backoff = next
+		}
+	}
+}
+
+// activeSessionCount 活跃会话数，缓存 common.ThrottleActiveSessionCheckInterval 避免并发查询
+func (t *Throttle) activeSessionCount() (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if time.Since(t.checkTime) < common.ThrottleActiveSessionCheckInterval {
+		return t.activeSessions, nil
+	}
+	counts, err := t.oracle.GetOracleActiveSessionCount()
+	if err != nil {
+		return 0, err
+	}
+	t.checkTime = time.Now()
+	t.activeSessions = counts
+	return counts, nil
+}
+
+// GetOracleActiveSessionCount 活跃会话数，与 GetOracleMaxActiveSessionCount 同为 dba_hist_active_sess_history
+// RAC 各实例最近一次采样活跃会话数汇总，排除 transferdb 自身会话（同一用户以及客户端程序）
+func (o *Oracle) GetOracleActiveSessionCount() (int, error) {
+	_, res, err := Query(o.Ctx, o.OracleDB, `SELECT NVL(SUM(SESSION_COUNT),0) AS SESSION_COUNT
+  FROM (SELECT H.INSTANCE_NUMBER, COUNT(*) AS SESSION_COUNT
+          FROM DBA_HIST_ACTIVE_SESS_HISTORY H
+         WHERE (H.DBID, H.INSTANCE_NUMBER, H.SAMPLE_ID) IN
+               (SELECT DBID, INSTANCE_NUMBER, MAX(SAMPLE_ID)
+                  FROM DBA_HIST_ACTIVE_SESS_HISTORY
+                 WHERE DBID = (SELECT DBID FROM V$DATABASE)
+                 GROUP BY DBID, INSTANCE_NUMBER)
+           AND NOT (H.USER_ID = UID AND NVL(H.PROGRAM,'-') =
+                    (SELECT NVL(PROGRAM,'-') FROM V$SESSION WHERE SID = SYS_CONTEXT('USERENV','SID')))
+         GROUP BY H.INSTANCE_NUMBER)`)
+	if err != nil {
+		return 0, err
+	}
+	counts, err := strconv.Atoi(res[0]["SESSION_COUNT"])
+	if err != nil {
+		return 0, fmt.Errorf("get oracle active session count [%s] strconv failed: %v", res[0]["SESSION_COUNT"], err)
+	}
+	return counts, nil
+}
+
+func waitLimiter(ctx context.Context, limiter *rate.Limiter, n int) error {
+	if limiter == nil {
+		return nil
+	}
+	// WaitN 单次不能超过 burst，按 burst 拆分等待
+	for n > 0 {
+		m := n
+		if m > limiter.Burst() {
+			m = limiter.Burst()
+		}
+		if err := limiter.WaitN(ctx, m); err != nil {
+			return err
+		}
+		n -= m
+	}
+	return nil
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// 行数据字节数，用于 max-bytes-per-second 限速
+func rowBytes(rawResult [][]byte) int {
+	var bytes int
+	for _, raw := range rawResult {
+		bytes += len(raw)
+	}
+	return bytes
+}
@@ -287,6 +287,9 @@ func (r *Check) Check() error {
 		return err
 	}
 
+	// 结构化检查结果
+	results := check.NewResults()
+
 	g := &errgroup.Group{}
 	g.SetLimit(r.cfg.CheckConfig.CheckThreads)
 
@@ -314,8 +317,9 @@ func (r *Check) Check() error {
 			if err != nil {
 				return err
 			}
-			err = NewChecker(r.ctx, oracleTableInfo, mysqlTableInfo,
-				r.cfg.DBTypeS, r.cfg.DBTypeT, mysqlDBVersion, r.cfg.MySQLConfig.DBType, r.metaDB).Writer(f)
+			checker := NewChecker(r.ctx, oracleTableInfo, mysqlTableInfo,
+				r.cfg.DBTypeS, r.cfg.DBTypeT, mysqlDBVersion, r.cfg.MySQLConfig.DBType, r.metaDB)
+			err = checker.Writer(f)
 			if err != nil {
 				// skip error and continue
 				errMeta := meta.NewCommonModel(r.metaDB).CreateErrorDetailAndUpdateWaitSyncMetaTaskStatus(r.ctx, &meta.ErrorLogDetail{
@@ -341,6 +345,7 @@ func (r *Check) Check() error {
 					return errMeta
 				}
 			} else {
+				results.Append(checker.Results...)
 				errMeta := meta.NewWaitSyncMetaModel(r.metaDB).UpdateWaitSyncMeta(r.ctx, &meta.WaitSyncMeta{
 					DBTypeS:     r.cfg.DBTypeS,
 					DBTypeT:     r.cfg.DBTypeT,
@@ -388,7 +393,29 @@ func (r *Check) Check() error {
 		return err
 	}
 
-	zap.L().Info("check", zap.String("output", filepath.Join(r.cfg.CheckConfig.CheckSQLDir, fmt.Sprintf("check_%s.sql", r.cfg.OracleConfig.SchemaName))))
+	// 结构化检查结果输出 json/html，断点跳过表以及失败表标记为部分结果
+	var failedTables []string
+	for _, t := range failedTotals {
+		failedTables = append(failedTables, t.TableNameS)
+	}
+	report := check.NewReport(r.cfg.OracleConfig.SchemaName, r.cfg.MySQLConfig.SchemaName, results, interTables, failedTables)
+	jsonFile := filepath.Join(r.cfg.CheckConfig.CheckSQLDir, fmt.Sprintf("check_%s.json", r.cfg.OracleConfig.SchemaName))
+	if err = check.GenJSONReport(report, jsonFile); err != nil {
+		return err
+	}
+	htmlFile := filepath.Join(r.cfg.CheckConfig.CheckSQLDir, fmt.Sprintf("check_%s.html", r.cfg.OracleConfig.SchemaName))
+	if err = check.GenHTMLReport(report, htmlFile); err != nil {
+		return err
+	}
+
+	zap.L().Info("check", zap.String("output", checkFile),
+		zap.String("json report", jsonFile),
+		zap.String("html report", htmlFile),
+		zap.Int("info", report.Info),
+		zap.Int("warn", report.Warn),
+		zap.Int("error", report.Error),
+		zap.Bool("partial", report.Partial),
+		zap.Strings("skip tables", report.SkipTables))
 	if len(failedTotals) == 0 {
 		zap.L().Info("check table oracle to mysql finished",
 			zap.Int("table totals", len(waitSyncMetas)),
@@ -404,5 +431,18 @@ func (r *Check) Check() error {
 			zap.String("cost", time.Now().Sub(startTime).String()))
 	}
 
+	// 存在大于等于 fail-severity 级别的检查结果，返回错误用于 CI 卡点
+	// 存在检查失败表，检查结果不完整，同样返回错误
+	if r.cfg.CheckConfig.FailSeverity != "" {
+		if len(failedTables) > 0 {
+			return fmt.Errorf("check schema [%s] exist [%d] tables check failed, results are incomplete, please see table [error_log_detail]",
+				r.cfg.OracleConfig.SchemaName, len(failedTables))
+		}
+		if counts := results.CountsAboveSeverity(r.cfg.CheckConfig.FailSeverity); counts > 0 {
+			return fmt.Errorf("check schema [%s] exist [%d] results above severity [%s], please see report [%s]",
+				r.cfg.OracleConfig.SchemaName, counts, r.cfg.CheckConfig.FailSeverity, htmlFile)
+		}
+	}
+
 	return nil
 }
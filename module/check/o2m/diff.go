@@ -38,6 +38,8 @@ type Diff struct {
 	MySQLDBVersion  string     `json:"mysqldb_version"`
 	MySQLDBType     string     `json:"mysqldb_type"`
 	MetaDB          *meta.Meta `json:"-"`
+	// 结构化检查结果，用于 json/html 报告输出
+	Results []check.Result `json:"-"`
 }
 
 func NewChecker(ctx context.Context, oracleTableInfo, mysqlTableInfo *Table, dbTypeS, dbTypeT, mysqlDBVersion, targetDBType string, metaDB *meta.Meta) *Diff {
@@ -77,6 +79,9 @@ func (c *Diff) CheckPartitionTableType() string {
 		builder.WriteString(fmt.Sprintf("%v\n", t.Render()))
 		builder.WriteString("*/\n")
 
+		c.addResult(common.CheckObjectPartition, "PARTITION TYPE",
+			fmt.Sprintf("%t", c.OracleTableINFO.IsPartition), fmt.Sprintf("%t", c.MySQLTableINFO.IsPartition), common.CheckSeverityWarn, "")
+
 		zap.L().Warn("table type different",
 			zap.String("oracle table", fmt.Sprintf("%s.%s partition [%t]", c.OracleTableINFO.SchemaName, c.OracleTableINFO.TableCharacterSet, c.OracleTableINFO.IsPartition)),
 			zap.String("mysql table", fmt.Sprintf("%s.%s partition [%t]", c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName, c.MySQLTableINFO.IsPartition)))
@@ -104,7 +109,10 @@ func (c *Diff) CheckTableComment() string {
 		builder.WriteString(fmt.Sprintf("%v\n", t.Render()))
 
 		builder.WriteString("*/\n")
-		builder.WriteString(fmt.Sprintf("ALTER TABLE %s.%s COMMENT '%s';\n", c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName, c.OracleTableINFO.TableComment))
+		fixSQL := fmt.Sprintf("ALTER TABLE %s.%s COMMENT '%s';", c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName, c.OracleTableINFO.TableComment)
+		builder.WriteString(fixSQL + "\n")
+
+		c.addResult(common.CheckObjectTable, "COMMENT", c.OracleTableINFO.TableComment, c.MySQLTableINFO.TableComment, common.CheckSeverityInfo, fixSQL)
 	}
 	return builder.String()
 }
@@ -152,9 +160,15 @@ func (c *Diff) CheckTableCharacterSetAndCollation() string {
 		} else {
 			mysqlCharacterSet = common.OracleDBCharacterSetMap[c.OracleTableINFO.TableCharacterSet]
 		}
-		builder.WriteString(fmt.Sprintf("ALTER TABLE %s.%s CHARACTER SET %s COLLATE %s;\n\n", c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName,
+		fixSQL := fmt.Sprintf("ALTER TABLE %s.%s CHARACTER SET %s COLLATE %s;", c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName,
 			strings.ToLower(mysqlCharacterSet),
-			strings.ToLower(common.OracleCollationMap[c.OracleTableINFO.TableCollation])))
+			strings.ToLower(common.OracleCollationMap[c.OracleTableINFO.TableCollation]))
+		builder.WriteString(fixSQL + "\n\n")
+
+		c.addResult(common.CheckObjectTable, "CHARACTER AND COLLATION",
+			fmt.Sprintf("character set [%s] collation [%s]", c.OracleTableINFO.TableCharacterSet, c.OracleTableINFO.TableCollation),
+			fmt.Sprintf("character set [%s] collation [%s]", c.MySQLTableINFO.TableCharacterSet, c.MySQLTableINFO.TableCollation),
+			common.CheckSeverityWarn, fixSQL)
 	}
 
 	return builder.String()
@@ -219,10 +233,16 @@ func (c *Diff) CheckColumnCharacterSetAndCollation() string {
 			} else {
 				mysqlCharacterSet = common.OracleDBCharacterSetMap[c.OracleTableINFO.Columns[strings.ToUpper(mysqlColName)].CharacterSet]
 			}
-			sqlStrings = append(sqlStrings, fmt.Sprintf("ALTER TABLE %s.%s MODIFY %s %s(%s) CHARACTER SET %s COLLATE %s;",
+			fixSQL := fmt.Sprintf("ALTER TABLE %s.%s MODIFY %s %s(%s) CHARACTER SET %s COLLATE %s;",
 				c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName, mysqlColName, mysqlColInfo.DataType, mysqlColInfo.DataLength,
 				strings.ToLower(mysqlCharacterSet),
-				strings.ToLower(common.OracleCollationMap[c.OracleTableINFO.Columns[strings.ToUpper(mysqlColName)].Collation])))
+				strings.ToLower(common.OracleCollationMap[c.OracleTableINFO.Columns[strings.ToUpper(mysqlColName)].Collation]))
+			sqlStrings = append(sqlStrings, fixSQL)
+
+			c.addResult(common.CheckObjectColumn, common.StringsBuilder(mysqlColName, " CHARACTER AND COLLATION"),
+				fmt.Sprintf("character set [%s] collation [%s]", c.OracleTableINFO.Columns[strings.ToUpper(mysqlColName)].CharacterSet, c.OracleTableINFO.Columns[strings.ToUpper(mysqlColName)].Collation),
+				fmt.Sprintf("character set [%s] collation [%s]", mysqlColInfo.CharacterSet, mysqlColInfo.Collation),
+				common.CheckSeverityWarn, fixSQL)
 		}
 
 		builder.WriteString(fmt.Sprintf("%v\n", t.Render()))
@@ -254,7 +274,10 @@ func (c *Diff) CheckColumnCharacterSetAndCollation() string {
 				})
 			}
 
-			sqlStrings = append(sqlStrings, fmt.Sprintf("ALTER TABLE %s.%s DROP COLUMN %s;", c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName, mysqlColName))
+			fixSQL := fmt.Sprintf("ALTER TABLE %s.%s DROP COLUMN %s;", c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName, mysqlColName)
+			sqlStrings = append(sqlStrings, fixSQL)
+
+			c.addResult(common.CheckObjectColumn, mysqlColName, "", mysqlColInfo.DataType, common.CheckSeverityWarn, fixSQL)
 		}
 
 		builder.WriteString(fmt.Sprintf("%v\n", t.Render()))
@@ -309,7 +332,10 @@ func (c *Diff) CheckColumnCounts() (string, error) {
 						fmt.Sprintf("%s(%s)", oracleColInfo.DataType, oracleColInfo.DataLength), "Add MySQL Table Column"},
 				})
 			}
-			sqlStrings = append(sqlStrings, fmt.Sprintf("ALTER TABLE %s.%s ADD COLUMN %s;", c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName, columnMeta))
+			fixSQL := fmt.Sprintf("ALTER TABLE %s.%s ADD COLUMN %s;", c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName, columnMeta)
+			sqlStrings = append(sqlStrings, fixSQL)
+
+			c.addResult(common.CheckObjectColumn, oracleColName, oracleColInfo.DataType, "", common.CheckSeverityError, fixSQL)
 		}
 
 		builder.WriteString(fmt.Sprintf("%v\n", t.Render()))
@@ -349,10 +375,14 @@ func (c *Diff) CheckPrimaryAndUniqueKey() (string, error) {
 			if ok {
 				switch value.ConstraintType {
 				case "PK":
-					builder.WriteString(fmt.Sprintf("ALTER TABLE %s.%s ADD PRIMARY KEY(%s);\n", c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName, value.ConstraintColumn))
+					fixSQL := fmt.Sprintf("ALTER TABLE %s.%s ADD PRIMARY KEY(%s);", c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName, value.ConstraintColumn)
+					builder.WriteString(fixSQL + "\n")
+					c.addResult(common.CheckObjectConstraint, "PRIMARY KEY", value.ConstraintColumn, "", common.CheckSeverityError, fixSQL)
 					continue
 				case "UK":
-					builder.WriteString(fmt.Sprintf("ALTER TABLE %s.%s ADD UNIQUE(%s);\n", c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName, value.ConstraintColumn))
+					fixSQL := fmt.Sprintf("ALTER TABLE %s.%s ADD UNIQUE(%s);", c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName, value.ConstraintColumn)
+					builder.WriteString(fixSQL + "\n")
+					c.addResult(common.CheckObjectConstraint, "UNIQUE KEY", value.ConstraintColumn, "", common.CheckSeverityError, fixSQL)
 					continue
 				default:
 					return builder.String(), fmt.Errorf("table constraint primary and unique key diff failed: not support type [%s]", value.ConstraintType)
@@ -396,7 +426,10 @@ func (c *Diff) CheckForeignKey() (string, error) {
 			for _, fk := range addDiffFK {
 				value, ok := fk.(ConstraintForeign)
 				if ok {
-					builder.WriteString(fmt.Sprintf("ALTER TABLE %s.%s ADD FOREIGN KEY(%s) REFERENCES %s.%s(%s）ON DELETE %s;\n", c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName, value.ColumnName, c.MySQLTableINFO.SchemaName, value.ReferencedTableName, value.ReferencedColumnName, value.DeleteRule))
+					fixSQL := fmt.Sprintf("ALTER TABLE %s.%s ADD FOREIGN KEY(%s) REFERENCES %s.%s(%s）ON DELETE %s;", c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName, value.ColumnName, c.MySQLTableINFO.SchemaName, value.ReferencedTableName, value.ReferencedColumnName, value.DeleteRule)
+					builder.WriteString(fixSQL + "\n")
+					c.addResult(common.CheckObjectConstraint, "FOREIGN KEY",
+						fmt.Sprintf("%s REFERENCES %s(%s)", value.ColumnName, value.ReferencedTableName, value.ReferencedColumnName), "", common.CheckSeverityWarn, fixSQL)
 					continue
 				}
 				return builder.String(), fmt.Errorf("oracle table [%s] constraint foreign key [%v] assert ConstraintForeign failed, type: [%v]", c.OracleTableINFO.TableName, fk, reflect.TypeOf(fk))
@@ -444,7 +477,9 @@ func (c *Diff) CheckCheckKey() (string, error) {
 				for _, ck := range addDiffCK {
 					value, ok := ck.(ConstraintCheck)
 					if ok {
-						builder.WriteString(fmt.Sprintf("ALTER TABLE %s.%s ADD CONSTRAINT %s CHECK(%s);\n", c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName, fmt.Sprintf("%s_check_key", c.MySQLTableINFO.TableName), value.ConstraintExpression))
+						fixSQL := fmt.Sprintf("ALTER TABLE %s.%s ADD CONSTRAINT %s CHECK(%s);", c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName, fmt.Sprintf("%s_check_key", c.MySQLTableINFO.TableName), value.ConstraintExpression)
+						builder.WriteString(fixSQL + "\n")
+						c.addResult(common.CheckObjectConstraint, "CHECK KEY", value.ConstraintExpression, "", common.CheckSeverityWarn, fixSQL)
 						continue
 					}
 					return builder.String(), fmt.Errorf("oracle table [%s] constraint check key [%v] assert ConstraintCheck failed, type: [%v]", c.OracleTableINFO.TableName, ck, reflect.TypeOf(ck))
@@ -464,6 +499,11 @@ func (c *Diff) CheckIndex() (string, error) {
 
 	var builder strings.Builder
 	var createIndexSQL []string
+	addIndexSQL := func(idx Index, indexSQL string) {
+		createIndexSQL = append(createIndexSQL, indexSQL)
+		c.addResult(common.CheckObjectIndex, idx.IndexName,
+			fmt.Sprintf("%s %s (%s)", idx.Uniqueness, idx.IndexType, idx.IndexColumn), "", common.CheckSeverityWarn, strings.TrimSuffix(indexSQL, "\n"))
+	}
 	addDiffIndex, _, isOK := common.DiffStructArray(c.OracleTableINFO.Indexes, c.MySQLTableINFO.Indexes)
 	if len(addDiffIndex) != 0 && !isOK {
 		for _, idx := range addDiffIndex {
@@ -478,7 +518,7 @@ func (c *Diff) CheckIndex() (string, error) {
 						}
 					}
 					if len(equalArray) == 0 {
-						createIndexSQL = append(createIndexSQL, fmt.Sprintf("CREATE UNIQUE INDEX %s ON %s.%s (%s);\n",
+						addIndexSQL(value, fmt.Sprintf("CREATE UNIQUE INDEX %s ON %s.%s (%s);\n",
 							value.IndexName, c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName, value.IndexColumn))
 					}
 					continue
@@ -492,7 +532,7 @@ func (c *Diff) CheckIndex() (string, error) {
 						}
 					}
 					if len(equalArray) == 0 {
-						createIndexSQL = append(createIndexSQL, fmt.Sprintf("CREATE UNIQUE INDEX %s ON %s.%s (%s);\n",
+						addIndexSQL(value, fmt.Sprintf("CREATE UNIQUE INDEX %s ON %s.%s (%s);\n",
 							value.IndexName, c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName, value.IndexColumn))
 					}
 					continue
@@ -506,28 +546,28 @@ func (c *Diff) CheckIndex() (string, error) {
 						}
 					}
 					if len(equalArray) == 0 {
-						createIndexSQL = append(createIndexSQL, fmt.Sprintf("CREATE INDEX %s ON %s.%s (%s);\n",
+						addIndexSQL(value, fmt.Sprintf("CREATE INDEX %s ON %s.%s (%s);\n",
 							value.IndexName, c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName, value.IndexColumn))
 					}
 					continue
 				}
 				if value.Uniqueness == "NONUNIQUE" && value.IndexType == "BITMAP" {
-					createIndexSQL = append(createIndexSQL, fmt.Sprintf("CREATE BITMAP INDEX %s ON %s.%s (%s);\n",
+					addIndexSQL(value, fmt.Sprintf("CREATE BITMAP INDEX %s ON %s.%s (%s);\n",
 						value.IndexName, c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName, value.IndexColumn))
 					continue
 				}
 				if value.Uniqueness == "NONUNIQUE" && value.IndexType == "FUNCTION-BASED NORMAL" {
-					createIndexSQL = append(createIndexSQL, fmt.Sprintf("CREATE INDEX %s ON %s.%s (%s);\n",
+					addIndexSQL(value, fmt.Sprintf("CREATE INDEX %s ON %s.%s (%s);\n",
 						value.IndexName, c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName, value.IndexColumn))
 					continue
 				}
 				if value.Uniqueness == "NONUNIQUE" && value.IndexType == "FUNCTION-BASED BITMAP" {
-					createIndexSQL = append(createIndexSQL, fmt.Sprintf("CREATE BITMAP INDEX %s ON %s.%s (%s);\n",
+					addIndexSQL(value, fmt.Sprintf("CREATE BITMAP INDEX %s ON %s.%s (%s);\n",
 						value.IndexName, c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName, value.IndexColumn))
 					continue
 				}
 				if value.Uniqueness == "NONUNIQUE" && value.IndexType == "DOMAIN" {
-					createIndexSQL = append(createIndexSQL,
+					addIndexSQL(value,
 						fmt.Sprintf("CREATE INDEX %s ON %s.%s (%s) INDEXTYPE IS %s.%s PARAMETERS ('%s');\n",
 							value.IndexName, c.MySQLTableINFO.SchemaName, c.MySQLTableINFO.TableName, value.IndexColumn,
 							value.DomainIndexOwner, value.DomainIndexName, value.DomainParameters))
@@ -593,6 +633,7 @@ func (c *Diff) CheckPartitionTable() (string, error) {
 						return builder.String(), err
 					}
 					builder.WriteString(fmt.Sprintf("# oracle partition info: %s, ", partJSON))
+					c.addResult(common.CheckObjectPartition, value.PartitionKey, string(partJSON), "", common.CheckSeverityWarn, "")
 					continue
 				}
 				return builder.String(), fmt.Errorf("oracle table [%s] paritions [%v] assert Partition failed, type: [%v]", c.OracleTableINFO.TableName, part, reflect.TypeOf(part))
@@ -630,6 +671,8 @@ func (c *Diff) CheckColumn() (string, error) {
 			if diffColumnMsg != "" && len(tableRows) != 0 {
 				diffColumnMsgs = append(diffColumnMsgs, diffColumnMsg)
 				tableRowArray = append(tableRowArray, tableRows)
+				c.addResult(common.CheckObjectColumn, oracleColName,
+					fmt.Sprintf("%v", tableRows[2]), fmt.Sprintf("%v", tableRows[3]), common.CheckSeverityError, strings.TrimSpace(diffColumnMsg))
 			}
 			continue
 		}
@@ -669,14 +712,14 @@ func (c *Diff) Writer(f *check.File) error {
 
 	var builder strings.Builder
 
-	if !strings.EqualFold(c.CheckPartitionTableType(), "") {
-		builder.WriteString(c.CheckPartitionTableType())
+	if partitionType := c.CheckPartitionTableType(); !strings.EqualFold(partitionType, "") {
+		builder.WriteString(partitionType)
 	}
-	if !strings.EqualFold(c.CheckTableComment(), "") {
-		builder.WriteString(c.CheckTableComment())
+	if comment := c.CheckTableComment(); !strings.EqualFold(comment, "") {
+		builder.WriteString(comment)
 	}
-	if !strings.EqualFold(c.CheckTableCharacterSetAndCollation(), "") {
-		builder.WriteString(c.CheckTableCharacterSetAndCollation())
+	if characterSet := c.CheckTableCharacterSetAndCollation(); !strings.EqualFold(characterSet, "") {
+		builder.WriteString(characterSet)
 	}
 
 	counts, err := c.CheckColumnCounts()
@@ -746,6 +789,21 @@ func (c *Diff) Writer(f *check.File) error {
 	return nil
 }
 
+func (c *Diff) addResult(objectType, attribute, sourceValue, targetValue, severity, fixSQL string) {
+	c.Results = append(c.Results, check.Result{
+		SchemaNameS: c.OracleTableINFO.SchemaName,
+		TableNameS:  c.OracleTableINFO.TableName,
+		SchemaNameT: c.MySQLTableINFO.SchemaName,
+		TableNameT:  c.MySQLTableINFO.TableName,
+		ObjectType:  objectType,
+		Attribute:   attribute,
+		SourceValue: sourceValue,
+		TargetValue: targetValue,
+		Severity:    severity,
+		FixSQL:      fixSQL,
+	})
+}
+
 func (c *Diff) String() string {
 	jsonStr, _ := json.Marshal(c)
 	return string(jsonStr)
@@ -202,6 +202,10 @@ func GetOracleTableColumn(schemaName, tableName string, oracle *oracle.Oracle, s
 	columns := make(map[string]Column, len(columnInfo))
 
 	for _, rowCol := range columnInfo {
+		// 函数索引转换的虚拟生成列，上游不存在，忽略
+		if mysql.IsMySQLIndexGenColumn(rowCol["COLUMN_NAME"], rowCol["EXTRA"]) {
+			continue
+		}
 		var (
 			nullable    string
 			dataDefault string
@@ -460,8 +464,8 @@ func NewMySQLTableINFO(schemaName, tableName, targetDBType string, mysql *mysql.
 	return mysqlTable, version, nil
 }
 
-func getMySQLTableColumn(schemaName, tableName string, mysql *mysql.MySQL) (map[string]Column, error) {
-	columnInfo, err := mysql.GetMySQLTableColumn(schemaName, tableName)
+func getMySQLTableColumn(schemaName, tableName string, mysqlDB *mysql.MySQL) (map[string]Column, error) {
+	columnInfo, err := mysqlDB.GetMySQLTableColumn(schemaName, tableName)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,114 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package check
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"html/template"
+	"os"
+)
+
+//go:embed template
+var fs embed.FS
+
+type ReportSummary struct {
+	SchemaNameS string `json:"schema_name_s"`
+	SchemaNameT string `json:"schema_name_t"`
+	Total       int    `json:"total"`
+	Info        int    `json:"info"`
+	Warn        int    `json:"warn"`
+	Error       int    `json:"error"`
+	// 断点续传跳过的历史检查成功表以及检查失败表结果不在报告内，报告为部分结果
+	Partial      bool     `json:"partial"`
+	SkipTables   []string `json:"skip_tables"`
+	FailedTables []string `json:"failed_tables"`
+}
+
+type Report struct {
+	*ReportSummary
+	Results []Result `json:"results"`
+}
+
+func NewReport(schemaNameS, schemaNameT string, results *Results, skipTables, failedTables []string) *Report {
+	results.Sort()
+	counts := results.CountsBySeverity()
+	return &Report{
+		ReportSummary: &ReportSummary{
+			SchemaNameS:  schemaNameS,
+			SchemaNameT:  schemaNameT,
+			Total:        len(results.Items),
+			Info:         counts[common.CheckSeverityInfo],
+			Warn:         counts[common.CheckSeverityWarn],
+			Error:        counts[common.CheckSeverityError],
+			Partial:      len(skipTables) > 0 || len(failedTables) > 0,
+			SkipTables:   skipTables,
+			FailedTables: failedTables,
+		},
+		Results: results.Items,
+	}
+}
+
+func GenJSONReport(report *Report, reportFile string) error {
+	file, err := os.OpenFile(reportFile, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	if err = encoder.Encode(report); err != nil {
+		return fmt.Errorf("check report json encode failed: %v", err)
+	}
+	return nil
+}
+
+func GenHTMLReport(report *Report, reportFile string) error {
+	file, err := os.OpenFile(reportFile, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	tf, err := template.ParseFS(fs, "template/*.html")
+	if err != nil {
+		return fmt.Errorf("template parse FS failed: %v", err)
+	}
+
+	if err = tf.ExecuteTemplate(file, "report_header", nil); err != nil {
+		return fmt.Errorf("template FS Execute [report_header] template HTML failed: %v", err)
+	}
+
+	if err = tf.ExecuteTemplate(file, "report_body", nil); err != nil {
+		return fmt.Errorf("template FS Execute [report_body] template HTML failed: %v", err)
+	}
+
+	if err = tf.ExecuteTemplate(file, "report_summary", report.ReportSummary); err != nil {
+		return fmt.Errorf("template FS Execute [report_summary] template HTML failed: %v", err)
+	}
+
+	if err = tf.ExecuteTemplate(file, "report_result", report.Results); err != nil {
+		return fmt.Errorf("template FS Execute [report_result] template HTML failed: %v", err)
+	}
+
+	if err = tf.ExecuteTemplate(file, "report_footer", nil); err != nil {
+		return fmt.Errorf("template FS Execute [report_footer] template HTML failed: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package check
+
+import (
+	"github.com/wentaojin/transferdb/common"
+	"sort"
+	"sync"
+)
+
+// Result 表结构检查结果
+type Result struct {
+	SchemaNameS string `json:"schema_name_s"`
+	TableNameS  string `json:"table_name_s"`
+	SchemaNameT string `json:"schema_name_t"`
+	TableNameT  string `json:"table_name_t"`
+	ObjectType  string `json:"object_type"`
+	Attribute   string `json:"attribute"`
+	SourceValue string `json:"source_value"`
+	TargetValue string `json:"target_value"`
+	Severity    string `json:"severity"`
+	FixSQL      string `json:"fix_sql"`
+}
+
+// Results 多表并发检查结果汇总
+type Results struct {
+	Mutex *sync.Mutex
+	Items []Result
+}
+
+func NewResults() *Results {
+	return &Results{
+		Mutex: &sync.Mutex{},
+	}
+}
+
+func (r *Results) Append(items ...Result) {
+	r.Mutex.Lock()
+	defer r.Mutex.Unlock()
+	r.Items = append(r.Items, items...)
+}
+
+// Sort 按表、对象类型以及属性排序，保证输出稳定
+func (r *Results) Sort() {
+	r.Mutex.Lock()
+	defer r.Mutex.Unlock()
+	sort.SliceStable(r.Items, func(i, j int) bool {
+		if r.Items[i].TableNameS != r.Items[j].TableNameS {
+			return r.Items[i].TableNameS < r.Items[j].TableNameS
+		}
+		if r.Items[i].ObjectType != r.Items[j].ObjectType {
+			return r.Items[i].ObjectType < r.Items[j].ObjectType
+		}
+		return r.Items[i].Attribute < r.Items[j].Attribute
+	})
+}
+
+// CountsBySeverity 各级别检查结果数
+func (r *Results) CountsBySeverity() map[string]int {
+	r.Mutex.Lock()
+	defer r.Mutex.Unlock()
+	counts := make(map[string]int)
+	for _, item := range r.Items {
+		counts[item.Severity]++
+	}
+	return counts
+}
+
+// CountsAboveSeverity 大于等于指定级别的检查结果数
+func (r *Results) CountsAboveSeverity(severity string) int {
+	r.Mutex.Lock()
+	defer r.Mutex.Unlock()
+	counts := 0
+	for _, item := range r.Items {
+		if common.CheckSeverityLevel[item.Severity] >= common.CheckSeverityLevel[severity] {
+			counts++
+		}
+	}
+	return counts
+}
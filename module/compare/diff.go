@@ -0,0 +1,134 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package compare
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"strings"
+)
+
+// RowDiff 差异数据行，按主键或者唯一键区分下游缺失、多余以及字段值不一致
+type RowDiff struct {
+	SchemaName  string       `json:"schema_name"`
+	TableName   string       `json:"table_name"`
+	WhereRange  string       `json:"where_range"`
+	DiffType    string       `json:"diff_type"`
+	KeyValues   []string     `json:"key_values"`
+	ColumnDiffs []ColumnDiff `json:"column_diffs"`
+}
+
+type ColumnDiff struct {
+	Column      string `json:"column"`
+	SourceValue string `json:"source_value"`
+	TargetValue string `json:"target_value"`
+}
+
+// JSON 每行一条差异记录
+func (d RowDiff) JSON() (string, error) {
+	jsonStr, err := json.Marshal(d)
+	if err != nil {
+		return "", err
+	}
+	return string(jsonStr) + "\n", nil
+}
+
+// CSV 每个字段差异一行
+func (d RowDiff) CSV() (string, error) {
+	var b strings.Builder
+	w := csv.NewWriter(&b)
+	for _, c := range d.ColumnDiffs {
+		if err := w.Write([]string{d.SchemaName, d.TableName, d.WhereRange, d.DiffType, strings.Join(d.KeyValues, " AND "), c.Column, c.SourceValue, c.TargetValue}); err != nil {
+			return "", err
+		}
+	}
+	w.Flush()
+	return b.String(), w.Error()
+}
+
+// RowDiffCSVHeader 差异数据 CSV 文件表头
+func RowDiffCSVHeader() string {
+	return "SCHEMA_NAME,TABLE_NAME,WHERE_RANGE,DIFF_TYPE,KEY_VALUES,COLUMN_NAME,SOURCE_VALUE,TARGET_VALUE\n"
+}
+
+// GenKeyRows 数据行按键值分组，键值重复返回 false
+func GenKeyRows(columns []string, rows []string, keyIdx []int) (map[string][]string, bool, error) {
+	keyRows := make(map[string][]string, len(rows))
+	for _, row := range rows {
+		values := SplitRowValues(row)
+		if len(values) != len(columns) {
+			return nil, false, fmt.Errorf("column counts [%d] isn't match values counts [%d]", len(columns), len(values))
+		}
+		var keys []string
+		for _, i := range keyIdx {
+			keys = append(keys, values[i])
+		}
+		key := strings.Join(keys, ",")
+		if _, ok := keyRows[key]; ok {
+			return nil, false, nil
+		}
+		keyRows[key] = values
+	}
+	return keyRows, true, nil
+}
+
+// SplitRowValues 数据行拆分字段值，忽略引号内逗号以及转义字符
+func SplitRowValues(row string) []string {
+	var (
+		values   []string
+		inQuote  bool
+		escaped  bool
+		startIdx int
+	)
+	for i, c := range row {
+		switch {
+		case escaped:
+			escaped = false
+		case c == '\\' && inQuote:
+			escaped = true
+		case c == '\'':
+			inQuote = !inQuote
+		case c == ',' && !inQuote:
+			values = append(values, row[startIdx:i])
+			startIdx = i + 1
+		}
+	}
+	return append(values, row[startIdx:])
+}
+
+// WriteRowDiffs 差异数据按 fix-diff-format 输出
+func (f *File) WriteRowDiffs(diffFormat string, rowDiffs []RowDiff) error {
+	for _, d := range rowDiffs {
+		var (
+			diffStr string
+			err     error
+		)
+		if strings.EqualFold(diffFormat, common.CompareDiffFormatCSV) {
+			diffStr, err = d.CSV()
+		} else {
+			diffStr, err = d.JSON()
+		}
+		if err != nil {
+			return err
+		}
+		if _, err = f.CWriteString(diffStr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
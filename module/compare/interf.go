@@ -31,6 +31,7 @@ type Reporter interface {
 	CheckOracleRows(oracleQuery string) (int64, error)
 	CheckMySQLRows(mysqlQuery string) (int64, error)
 	ReportCheckRows() (string, error)
+	ReportCheckChecksum() (bool, error)
 	ReportCheckCRC32() (string, error)
 	Report() (string, error)
 }
@@ -0,0 +1,297 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package m2o
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
+	"github.com/wentaojin/transferdb/database/meta"
+	"github.com/wentaojin/transferdb/database/mysql"
+	"github.com/wentaojin/transferdb/database/oracle"
+	"github.com/wentaojin/transferdb/module/compare"
+	"go.uber.org/zap"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Chunk 数据对比，上游 MySQL 切分数据块
+type Chunk struct {
+	Ctx              context.Context `json:"-"`
+	ChunkID          int             `json:"chunk_id"`
+	SourceTable      string          `json:"source_table"`
+	TargetTable      string          `json:"target_table"`
+	IsPartition      string          `json:"is_partition"`
+	SourceColumnInfo string          `json:"source_column_info"`
+	TargetColumnInfo string          `json:"target_column_info"`
+	WhereColumn      string          `json:"where_column"`
+	WhereRange       string          `json:"where_range"` // chunk split need
+	Cfg              *config.Config  `json:"-"`
+	Oracle           *oracle.Oracle  `json:"-"`
+	MySQL            *mysql.MySQL    `json:"-"`
+	MetaDB           *meta.Meta      `json:"-"`
+}
+
+func NewChunk(ctx context.Context, cfg *config.Config, oracle *oracle.Oracle, mysql *mysql.MySQL, metaDB *meta.Meta,
+	chunkID int, sourceTable, targetTable string, isPartition string, sourceColumnInfo, targetColumnInfo string,
+	whereColumn string) *Chunk {
+	return &Chunk{
+		Ctx:              ctx,
+		ChunkID:          chunkID,
+		SourceTable:      sourceTable,
+		TargetTable:      targetTable,
+		IsPartition:      isPartition,
+		SourceColumnInfo: sourceColumnInfo,
+		TargetColumnInfo: targetColumnInfo,
+		WhereColumn:      whereColumn,
+		Oracle:           oracle,
+		MySQL:            mysql,
+		MetaDB:           metaDB,
+		Cfg:              cfg,
+	}
+}
+
+func (c *Chunk) CustomTableConfig() (customColumn string, customRange string, err error) {
+	// 获取配置文件自定义配置，同张表 Range 优先级 > indexFields
+	for _, tableCfg := range c.Cfg.DiffConfig.TableConfig {
+		if strings.EqualFold(c.SourceTable, tableCfg.SourceTable) {
+			if tableCfg.Range != "" {
+				return customColumn, tableCfg.Range, nil
+			}
+			return tableCfg.IndexFields, customRange, nil
+		}
+	}
+	return customColumn, customRange, nil
+}
+
+func (c *Chunk) Split() error {
+	startTime := time.Now()
+
+	// 配置文件参数优先级
+	// onlyCheckRows > configRange > configIndexFiled > DBFilter Index Column
+	if c.Cfg.DiffConfig.OnlyCheckRows {
+		// SELECT COUNT(1) FROM TAB WHERE 1=1
+		c.SourceColumnInfo = "COUNT(1)"
+		c.TargetColumnInfo = "COUNT(1)"
+		c.WhereColumn = ""
+		return c.createChunks([]string{"1 = 1"})
+	}
+
+	customColumn, customRange, err := c.CustomTableConfig()
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(customRange, "") {
+		c.WhereColumn = ""
+		return c.createChunks([]string{customRange})
+	}
+	if !strings.EqualFold(customColumn, "") {
+		c.WhereColumn = customColumn
+	}
+
+	whereRanges, err := c.splitByIndexSample()
+	if err != nil {
+		// MySQL 8.0 以下版本不支持窗口函数，全表单个 chunk
+		zap.L().Warn("split mysql table chunk by index sample failed, fallback whole table",
+			zap.String("schema", c.Cfg.MySQLConfig.SchemaName),
+			zap.String("table", c.SourceTable),
+			zap.String("where column", c.WhereColumn),
+			zap.Error(err))
+		c.WhereColumn = ""
+		whereRanges = []string{"1 = 1"}
+	}
+	if err = c.createChunks(whereRanges); err != nil {
+		return err
+	}
+
+	zap.L().Info("pre split mysql and oracle table chunk finished",
+		zap.String("schema", c.Cfg.MySQLConfig.SchemaName),
+		zap.String("table", c.SourceTable),
+		zap.Int("chunks", len(whereRanges)),
+		zap.String("cost", time.Now().Sub(startTime).String()))
+	return nil
+}
+
+// splitByIndexSample 上游按索引字段顺序每 chunk-size 行采样边界值切分 chunk，边界条件上下游通用
+func (c *Chunk) splitByIndexSample() ([]string, error) {
+	columnInfo, err := c.MySQL.GetMySQLTableColumn(c.Cfg.MySQLConfig.SchemaName, c.SourceTable)
+	if err != nil {
+		return nil, err
+	}
+	columnTypes := make(map[string]string)
+	for _, colsInfo := range columnInfo {
+		columnTypes[common.StringUPPER(colsInfo["COLUMN_NAME"])] = common.StringUPPER(colsInfo["DATA_TYPE"])
+	}
+
+	columns := strings.Split(c.WhereColumn, ",")
+	var columnExprs, orderExprs []string
+	for _, col := range columns {
+		dataType, ok := columnTypes[common.StringUPPER(col)]
+		if !ok || !isIndexSampleDataType(dataType) {
+			return nil, fmt.Errorf("where column [%s] isn't exist or data type [%s] isn't support", col, dataType)
+		}
+		columnExprs = append(columnExprs, genIndexSampleColumnExpr(col, dataType))
+		// 字符字段按二进制排序采样，与范围条件以及下游 Oracle 二进制比较语义一致
+		if isStringDataType(dataType) {
+			orderExprs = append(orderExprs, common.StringsBuilder("CONVERT(", col, " USING utf8mb4) COLLATE utf8mb4_bin"))
+		} else {
+			orderExprs = append(orderExprs, col)
+		}
+	}
+
+	res, err := c.MySQL.GetMySQLTableChunksByIndexSample(c.Cfg.MySQLConfig.SchemaName, c.SourceTable, columns, columnExprs, orderExprs, c.Cfg.DiffConfig.ChunkSize)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		boundaries [][]string
+		lastKey    string
+	)
+	for _, r := range res {
+		var (
+			values    []string
+			isSupport = true
+		)
+		for i, col := range columns {
+			v, ok := genIndexSampleLiteral(r[common.StringsBuilder("B", strconv.Itoa(i))], columnTypes[common.StringUPPER(col)])
+			if !ok {
+				isSupport = false
+				break
+			}
+			values = append(values, v)
+		}
+		if !isSupport {
+			continue
+		}
+		// 非唯一索引相邻边界值相同，跳过空 chunk
+		key := strings.Join(values, ",")
+		if key == lastKey {
+			continue
+		}
+		lastKey = key
+		boundaries = append(boundaries, values)
+	}
+	return compare.GenIndexSampleRanges(columns, boundaries), nil
+}
+
+func (c *Chunk) createChunks(whereRanges []string) error {
+	var fullMetas []meta.DataCompareMeta
+	for _, whereRange := range whereRanges {
+		fullMetas = append(fullMetas, meta.DataCompareMeta{
+			DBTypeS:       c.Cfg.DBTypeS,
+			DBTypeT:       c.Cfg.DBTypeT,
+			SchemaNameS:   common.StringUPPER(c.Cfg.MySQLConfig.SchemaName),
+			TableNameS:    common.StringUPPER(c.SourceTable),
+			SchemaNameT:   common.StringUPPER(c.Cfg.OracleConfig.SchemaName),
+			TableNameT:    common.StringUPPER(c.TargetTable),
+			ColumnDetailS: c.SourceColumnInfo,
+			ColumnDetailT: c.TargetColumnInfo,
+			WhereRange:    whereRange,
+			WhereColumn:   c.WhereColumn,
+			IsPartition:   c.IsPartition,
+			TaskMode:      c.Cfg.TaskMode,
+			TaskStatus:    common.TaskStatusWaiting})
+	}
+
+	// 元数据库信息 batch 写入
+	err := meta.NewCommonModel(c.MetaDB).BatchCreateDataCompareMetaAndUpdateWaitSyncMeta(c.Ctx,
+		fullMetas, c.Cfg.AppConfig.InsertBatchSize, &meta.WaitSyncMeta{
+			DBTypeS:          c.Cfg.DBTypeS,
+			DBTypeT:          c.Cfg.DBTypeT,
+			SchemaNameS:      common.StringUPPER(c.Cfg.MySQLConfig.SchemaName),
+			TableNameS:       common.StringUPPER(c.SourceTable),
+			TaskMode:         c.Cfg.TaskMode,
+			GlobalScnS:       common.TaskTableDefaultSourceGlobalSCN,
+			ChunkTotalNums:   int64(len(fullMetas)),
+			ChunkSuccessNums: 0,
+			ChunkFailedNums:  0,
+			IsPartition:      c.IsPartition,
+		})
+	if err != nil {
+		return fmt.Errorf("create table [%s.%s] data_diff_meta [batch size] failed: %v", c.Cfg.MySQLConfig.SchemaName, c.SourceTable, err)
+	}
+	return nil
+}
+
+func (c *Chunk) String() string {
+	jsonStr, _ := json.Marshal(c)
+	return string(jsonStr)
+}
+
+func isNumberDataType(dataType string) bool {
+	switch common.StringUPPER(dataType) {
+	case "TINYINT", "SMALLINT", "MEDIUMINT", "INT", "INTEGER", "BIGINT", "DECIMAL", "NUMERIC":
+		return true
+	default:
+		return false
+	}
+}
+
+func isStringDataType(dataType string) bool {
+	switch common.StringUPPER(dataType) {
+	case "CHAR", "VARCHAR":
+		return true
+	default:
+		return false
+	}
+}
+
+// isIndexSampleDataType 索引顺序采样切分 chunk 支持的字段数据类型，浮点数字面量上下游精度不一致不支持
+func isIndexSampleDataType(dataType string) bool {
+	switch common.StringUPPER(dataType) {
+	case "CHAR", "VARCHAR", "DATE", "DATETIME", "TIMESTAMP":
+		return true
+	default:
+		return isNumberDataType(dataType)
+	}
+}
+
+// genIndexSampleColumnExpr 边界值输出表达式，日期时间统一格式化
+func genIndexSampleColumnExpr(column, dataType string) string {
+	switch common.StringUPPER(dataType) {
+	case "DATE":
+		return common.StringsBuilder("DATE_FORMAT(", column, ",'%Y-%m-%d %H:%i:%s')")
+	case "DATETIME", "TIMESTAMP":
+		return common.StringsBuilder("DATE_FORMAT(", column, ",'%Y-%m-%d %H:%i:%s.%f')")
+	case "CHAR", "VARCHAR":
+		return column
+	default:
+		return common.StringsBuilder("CAST(", column, " AS CHAR)")
+	}
+}
+
+// genIndexSampleLiteral 边界值转换上下游通用 SQL 字面量，日期时间使用 ANSI TIMESTAMP 字面量
+// 字符边界值含反斜杠上下游转义语义不一致，跳过该边界合并相邻 chunk
+func genIndexSampleLiteral(value, dataType string) (string, bool) {
+	if strings.EqualFold(value, "NULLABLE") {
+		return "", false
+	}
+	switch common.StringUPPER(dataType) {
+	case "DATE", "DATETIME", "TIMESTAMP":
+		return common.StringsBuilder("TIMESTAMP '", value, "'"), true
+	case "CHAR", "VARCHAR":
+		if strings.Contains(value, `\`) {
+			return "", false
+		}
+		return common.StringsBuilder("'", strings.ReplaceAll(value, "'", "''"), "'"), true
+	default:
+		return value, true
+	}
+}
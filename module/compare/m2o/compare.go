@@ -0,0 +1,576 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package m2o
+
+import (
+	"context"
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
+	"github.com/wentaojin/transferdb/database/meta"
+	"github.com/wentaojin/transferdb/database/mysql"
+	"github.com/wentaojin/transferdb/database/oracle"
+	"github.com/wentaojin/transferdb/module/compare"
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+type M2O struct {
+	ctx    context.Context
+	cfg    *config.Config
+	mysql  *mysql.MySQL
+	oracle *oracle.Oracle
+	metaDB *meta.Meta
+	// 服务端聚合校验，STANDARD_HASH 需要 oracle 12c 及以上
+	checksumPushdown bool
+}
+
+func NewCompare(ctx context.Context, cfg *config.Config) (*M2O, error) {
+	oracleDB, err := oracle.NewOracleDBEngine(ctx, cfg.OracleConfig)
+	if err != nil {
+		return nil, err
+	}
+	oracleDB.Throttle, err = oracle.NewThrottle(ctx, oracleDB, cfg.ThrottleConfig)
+	if err != nil {
+		return nil, err
+	}
+	mysqlDB, err := mysql.NewMySQLDBEngine(ctx, cfg.MySQLConfig)
+	if err != nil {
+		return nil, err
+	}
+	metaDB, err := meta.NewMetaDBEngine(ctx, cfg.MetaConfig, cfg.AppConfig.SlowlogThreshold)
+	if err != nil {
+		return nil, err
+	}
+	return &M2O{
+		ctx:    ctx,
+		cfg:    cfg,
+		mysql:  mysqlDB,
+		oracle: oracleDB,
+		metaDB: metaDB,
+	}, nil
+}
+
+func (r *M2O) NewCompare() error {
+	startTime := time.Now()
+	zap.L().Info("diff table mysql to oracle start",
+		zap.String("schema", r.cfg.MySQLConfig.SchemaName))
+
+	oraDBVersion, err := r.oracle.GetOracleDBVersion()
+	if err != nil {
+		return err
+	}
+	if common.VersionOrdinal(oraDBVersion) < common.VersionOrdinal(common.RequireOracleDBVersion) {
+		return fmt.Errorf("oracle db version [%v] is less than 11g, can't be using transferdb tools", oraDBVersion)
+	}
+	if !r.cfg.DiffConfig.DisableChecksumPushdown && !r.cfg.DiffConfig.OnlyCheckRows {
+		if common.VersionOrdinal(oraDBVersion) >= common.VersionOrdinal(common.OracleStandardHashDBVersion) {
+			r.checksumPushdown = true
+		} else {
+			zap.L().Warn("oracle db version isn't support standard_hash, checksum pushdown would be disabled",
+				zap.String("db version", oraDBVersion))
+		}
+	}
+
+	// 获取配置文件待校验表列表
+	exporters, err := filterCFGTable(r.cfg, r.mysql)
+	if err != nil {
+		return err
+	}
+	if len(exporters) == 0 {
+		zap.L().Warn("there are no table objects in the mysql schema",
+			zap.String("schema", r.cfg.MySQLConfig.SchemaName))
+		return nil
+	}
+
+	schemaNameS := common.StringUPPER(r.cfg.MySQLConfig.SchemaName)
+
+	// 关于全量断点恢复
+	if !r.cfg.DiffConfig.EnableCheckpoint {
+		err = meta.NewDataCompareMetaModel(r.metaDB).TruncateDataCompareMeta(r.ctx)
+		if err != nil {
+			return err
+		}
+		for _, tableName := range exporters {
+			err = meta.NewWaitSyncMetaModel(r.metaDB).DeleteWaitSyncMeta(r.ctx, &meta.WaitSyncMeta{
+				DBTypeS:     r.cfg.DBTypeS,
+				DBTypeT:     r.cfg.DBTypeT,
+				SchemaNameS: schemaNameS,
+				TableNameS:  common.StringUPPER(tableName),
+				TaskMode:    r.cfg.TaskMode,
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	// 判断 [wait_sync_meta] 是否存在错误记录，是否可进行 COMPARE
+	errTotals, err := meta.NewWaitSyncMetaModel(r.metaDB).CountsErrWaitSyncMetaBySchema(r.ctx, &meta.WaitSyncMeta{
+		DBTypeS:     r.cfg.DBTypeS,
+		DBTypeT:     r.cfg.DBTypeT,
+		SchemaNameS: schemaNameS,
+		TaskMode:    r.cfg.TaskMode,
+		TaskStatus:  common.TaskStatusFailed,
+	})
+	if err != nil {
+		return err
+	}
+	if errTotals > 0 {
+		return fmt.Errorf(`compare schema [%s] mode [%s] table task failed: meta table [wait_sync_meta] exist failed error, please: firstly check meta table [wait_sync_meta] and [data_compare_meta] log record; secondly if need resume, update meta table [wait_sync_meta] column [task_status] table status RUNNING (Need UPPER); finally rerunning`, schemaNameS, r.cfg.TaskMode)
+	}
+
+	// 判断并记录待校验表列表
+	for _, tableName := range exporters {
+		waitSyncMetas, err := meta.NewWaitSyncMetaModel(r.metaDB).DetailWaitSyncMeta(r.ctx, &meta.WaitSyncMeta{
+			DBTypeS:     r.cfg.DBTypeS,
+			DBTypeT:     r.cfg.DBTypeT,
+			SchemaNameS: schemaNameS,
+			TableNameS:  common.StringUPPER(tableName),
+			TaskMode:    r.cfg.TaskMode,
+		})
+		if err != nil {
+			return err
+		}
+		if len(waitSyncMetas) == 0 {
+			err = meta.NewWaitSyncMetaModel(r.metaDB).CreateWaitSyncMeta(r.ctx, &meta.WaitSyncMeta{
+				DBTypeS:        r.cfg.DBTypeS,
+				DBTypeT:        r.cfg.DBTypeT,
+				SchemaNameS:    schemaNameS,
+				TableNameS:     common.StringUPPER(tableName),
+				TaskMode:       r.cfg.TaskMode,
+				TaskStatus:     common.TaskStatusWaiting,
+				GlobalScnS:     common.TaskTableDefaultSourceGlobalSCN,
+				ChunkTotalNums: common.TaskTableDefaultSplitChunkNums,
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	// 获取等待校验以及未校验完成的表列表
+	var waitSyncTables, partSyncTables, panicTables []string
+	waitSyncMetas, err := meta.NewWaitSyncMetaModel(r.metaDB).DetailWaitSyncMeta(r.ctx, &meta.WaitSyncMeta{
+		DBTypeS:        r.cfg.DBTypeS,
+		DBTypeT:        r.cfg.DBTypeT,
+		SchemaNameS:    schemaNameS,
+		TaskMode:       r.cfg.TaskMode,
+		TaskStatus:     common.TaskStatusWaiting,
+		GlobalScnS:     common.TaskTableDefaultSourceGlobalSCN,
+		ChunkTotalNums: common.TaskTableDefaultSplitChunkNums,
+	})
+	if err != nil {
+		return err
+	}
+	for _, t := range waitSyncMetas {
+		waitSyncTables = append(waitSyncTables, common.StringUPPER(t.TableNameS))
+	}
+
+	partWaitSyncMetas, err := meta.NewWaitSyncMetaModel(r.metaDB).QueryWaitSyncMetaByPartTask(r.ctx, &meta.WaitSyncMeta{
+		DBTypeS:     r.cfg.DBTypeS,
+		DBTypeT:     r.cfg.DBTypeT,
+		SchemaNameS: schemaNameS,
+		TaskMode:    r.cfg.TaskMode,
+		TaskStatus:  common.TaskStatusRunning,
+	})
+	if err != nil {
+		return err
+	}
+	for _, t := range partWaitSyncMetas {
+		// 判断 running 状态表 chunk 数是否一致，一致可断点续传
+		chunkCounts, err := meta.NewDataCompareMetaModel(r.metaDB).CountsDataCompareMetaByTaskTable(r.ctx, &meta.DataCompareMeta{
+			DBTypeS:     t.DBTypeS,
+			DBTypeT:     t.DBTypeT,
+			SchemaNameS: t.SchemaNameS,
+			TaskMode:    t.TaskMode,
+			TaskStatus:  t.TaskStatus,
+		})
+		if err != nil {
+			return err
+		}
+		if chunkCounts != t.ChunkTotalNums {
+			panicTables = append(panicTables, t.TableNameS)
+		} else {
+			partSyncTables = append(partSyncTables, t.TableNameS)
+		}
+	}
+	if len(panicTables) > 0 {
+		zap.L().Error("compare table mysql to oracle checkpoint error",
+			zap.String("schema", r.cfg.MySQLConfig.SchemaName),
+			zap.Int("part sync tables", len(partSyncTables)),
+			zap.Strings("panic tables", panicTables))
+		return fmt.Errorf("checkpoint isn't consistent, can't be resume, please reruning [enable-checkpoint = fase]")
+	}
+
+	// 元数据表名统一大写，上游 MySQL 访问还原原始表名（lower_case_table_names=0 区分大小写）
+	originTableMap := make(map[string]string)
+	for _, t := range exporters {
+		originTableMap[common.StringUPPER(t)] = t
+	}
+	waitSyncTables = genOriginTables(waitSyncTables, originTableMap)
+	partSyncTables = genOriginTables(partSyncTables, originTableMap)
+
+	// 判断下游是否存在 ORACLE 表
+	tableNameRules, err := meta.NewTableNameRuleModel(r.metaDB).DetailTableNameRule(r.ctx, &meta.TableNameRule{
+		DBTypeS:     r.cfg.DBTypeS,
+		DBTypeT:     r.cfg.DBTypeT,
+		SchemaNameS: r.cfg.MySQLConfig.SchemaName,
+		SchemaNameT: r.cfg.OracleConfig.SchemaName,
+	})
+	if err != nil {
+		return err
+	}
+	tableNameRuleMap := make(map[string]string)
+	for _, tr := range tableNameRules {
+		tableNameRuleMap[common.StringUPPER(tr.TableNameS)] = common.StringUPPER(tr.TableNameT)
+	}
+
+	oracleTables, err := r.oracle.GetOracleSchemaTable(common.StringUPPER(r.cfg.OracleConfig.SchemaName))
+	if err != nil {
+		return err
+	}
+	var targetTables []string
+	for _, t := range exporters {
+		if val, ok := tableNameRuleMap[common.StringUPPER(t)]; ok {
+			targetTables = append(targetTables, val)
+		} else {
+			targetTables = append(targetTables, common.StringUPPER(t))
+		}
+	}
+	diffItems := common.FilterDifferenceStringItems(targetTables, oracleTables)
+	if len(diffItems) != 0 {
+		return fmt.Errorf("table [%v] target db isn't exists, please create table", diffItems)
+	}
+
+	partTableTasks := NewCompareTableTask(r.ctx, r.cfg, partSyncTables, r.mysql, r.oracle, tableNameRuleMap)
+	waitTableTasks := NewCompareTableTask(r.ctx, r.cfg, waitSyncTables, r.mysql, r.oracle, tableNameRuleMap)
+
+	// 数据对比
+	err = common.PathExist(r.cfg.DiffConfig.FixSqlDir)
+	if err != nil {
+		return err
+	}
+	checkFile := filepath.Join(r.cfg.DiffConfig.FixSqlDir, fmt.Sprintf("compare_%s.sql", r.cfg.MySQLConfig.SchemaName))
+	f, err := compare.NewWriter(checkFile)
+	if err != nil {
+		return err
+	}
+	// 差异数据文件 json/csv
+	diffFile := filepath.Join(r.cfg.DiffConfig.FixSqlDir, fmt.Sprintf("compare_%s.%s", r.cfg.MySQLConfig.SchemaName, r.cfg.DiffConfig.FixDiffFormat))
+	df, err := compare.NewWriter(diffFile)
+	if err != nil {
+		return err
+	}
+	if strings.EqualFold(r.cfg.DiffConfig.FixDiffFormat, common.CompareDiffFormatCSV) {
+		if _, err = df.CWriteString(compare.RowDiffCSVHeader()); err != nil {
+			return err
+		}
+	}
+
+	// 优先存在断点的表校验
+	// partTableTask -> waitTableTasks
+	if len(partTableTasks) > 0 {
+		if err = r.compareTableTasks(f, df, partTableTasks); err != nil {
+			return err
+		}
+	}
+	if len(waitTableTasks) > 0 {
+		if err = r.splitTableTasks(waitTableTasks); err != nil {
+			return err
+		}
+		if err = r.compareTableTasks(f, df, waitTableTasks); err != nil {
+			return err
+		}
+	}
+
+	if err = f.Close(); err != nil {
+		return err
+	}
+	if err = df.Close(); err != nil {
+		return err
+	}
+
+	// 任务详情
+	succTotals, err := meta.NewWaitSyncMetaModel(r.metaDB).DetailWaitSyncMeta(r.ctx, &meta.WaitSyncMeta{
+		DBTypeS:     r.cfg.DBTypeS,
+		DBTypeT:     r.cfg.DBTypeT,
+		SchemaNameS: schemaNameS,
+		TaskMode:    r.cfg.TaskMode,
+		TaskStatus:  common.TaskStatusSuccess,
+	})
+	if err != nil {
+		return err
+	}
+	failedTotals, err := meta.NewWaitSyncMetaModel(r.metaDB).DetailWaitSyncMeta(r.ctx, &meta.WaitSyncMeta{
+		DBTypeS:     r.cfg.DBTypeS,
+		DBTypeT:     r.cfg.DBTypeT,
+		SchemaNameS: schemaNameS,
+		TaskMode:    r.cfg.TaskMode,
+		TaskStatus:  common.TaskStatusFailed,
+	})
+	if err != nil {
+		return err
+	}
+
+	zap.L().Info("compare", zap.String("fix sql file output", checkFile))
+	if len(failedTotals) == 0 {
+		zap.L().Info("compare table mysql to oracle finished",
+			zap.Int("table totals", len(exporters)),
+			zap.Int("table success", len(succTotals)),
+			zap.Int("table failed", len(failedTotals)),
+			zap.String("cost", time.Now().Sub(startTime).String()))
+	} else {
+		zap.L().Warn("compare table mysql to oracle finished",
+			zap.Int("table totals", len(exporters)),
+			zap.Int("table success", len(succTotals)),
+			zap.Int("table failed", len(failedTotals)),
+			zap.String("failed tips", "failed detail, please see table [data_compare_meta]"),
+			zap.String("cost", time.Now().Sub(startTime).String()))
+	}
+	return nil
+}
+
+// splitTableTasks 上游 MySQL 表切分数据块
+func (r *M2O) splitTableTasks(waitTableTasks []*Task) error {
+	var chunks []*Chunk
+	for cid, task := range waitTableTasks {
+		sourceColumnInfo, targetColumnInfo, err := task.AdjustDBSelectColumn()
+		if err != nil {
+			return err
+		}
+		whereColumn, err := task.FilterDBWhereColumn()
+		if err != nil {
+			return err
+		}
+		isPartition, err := task.IsPartitionTable()
+		if err != nil {
+			return err
+		}
+		chunks = append(chunks, NewChunk(r.ctx, r.cfg, r.oracle, r.mysql, r.metaDB,
+			cid, task.sourceTableName, task.targetTableName, isPartition, sourceColumnInfo, targetColumnInfo,
+			whereColumn))
+	}
+
+	g := &errgroup.Group{}
+	g.SetLimit(r.cfg.DiffConfig.DiffThreads)
+	for _, chunk := range chunks {
+		c := chunk
+		g.Go(func() error {
+			return IChunker(c)
+		})
+	}
+	return g.Wait()
+}
+
+func (r *M2O) compareTableTasks(f, df *compare.File, tableTasks []*Task) error {
+	schemaNameS := common.StringUPPER(r.cfg.MySQLConfig.SchemaName)
+	for _, task := range tableTasks {
+		diffStartTime := time.Now()
+		tableNameS := common.StringUPPER(task.sourceTableName)
+
+		err := meta.NewWaitSyncMetaModel(r.metaDB).UpdateWaitSyncMeta(r.ctx, &meta.WaitSyncMeta{
+			DBTypeS:     r.cfg.DBTypeS,
+			DBTypeT:     r.cfg.DBTypeT,
+			SchemaNameS: schemaNameS,
+			TableNameS:  tableNameS,
+			TaskMode:    r.cfg.TaskMode,
+		}, map[string]interface{}{
+			"TaskStatus": common.TaskStatusRunning,
+		})
+		if err != nil {
+			return err
+		}
+
+		waitCompareMetas, err := meta.NewDataCompareMetaModel(r.metaDB).DetailDataCompareMeta(r.ctx, &meta.DataCompareMeta{
+			DBTypeS:     r.cfg.DBTypeS,
+			DBTypeT:     r.cfg.DBTypeT,
+			SchemaNameS: schemaNameS,
+			TableNameS:  tableNameS,
+			TaskMode:    r.cfg.TaskMode,
+			TaskStatus:  common.TaskStatusWaiting,
+		})
+		if err != nil {
+			return err
+		}
+		failedCompareMetas, err := meta.NewDataCompareMetaModel(r.metaDB).DetailDataCompareMeta(r.ctx, &meta.DataCompareMeta{
+			DBTypeS:     r.cfg.DBTypeS,
+			DBTypeT:     r.cfg.DBTypeT,
+			SchemaNameS: schemaNameS,
+			TableNameS:  tableNameS,
+			TaskMode:    r.cfg.TaskMode,
+			TaskStatus:  common.TaskStatusFailed,
+		})
+		if err != nil {
+			return err
+		}
+		waitCompareMetas = append(waitCompareMetas, failedCompareMetas...)
+
+		normalizer, err := task.GenColumnNormalizer()
+		if err != nil {
+			return err
+		}
+		columnTypesT, err := task.GenTargetColumnTypes()
+		if err != nil {
+			return err
+		}
+		if err = r.compareChunks(f, df, waitCompareMetas, task.sourceTableName, normalizer, columnTypesT); err != nil {
+			return err
+		}
+
+		// 更新 wait_sync_meta 记录
+		failedTotalErrs, err := meta.NewDataCompareMetaModel(r.metaDB).CountsErrorDataCompareMeta(r.ctx, &meta.DataCompareMeta{
+			DBTypeS:     r.cfg.DBTypeS,
+			DBTypeT:     r.cfg.DBTypeT,
+			SchemaNameS: schemaNameS,
+			TableNameS:  tableNameS,
+			TaskMode:    r.cfg.TaskMode,
+			TaskStatus:  common.TaskStatusFailed,
+		})
+		if err != nil {
+			return fmt.Errorf("get meta table [data_compare_meta] counts failed, error: %v", err)
+		}
+		successTotalErrs, err := meta.NewDataCompareMetaModel(r.metaDB).CountsErrorDataCompareMeta(r.ctx, &meta.DataCompareMeta{
+			DBTypeS:     r.cfg.DBTypeS,
+			DBTypeT:     r.cfg.DBTypeT,
+			SchemaNameS: schemaNameS,
+			TableNameS:  tableNameS,
+			TaskMode:    r.cfg.TaskMode,
+			TaskStatus:  common.TaskStatusSuccess,
+		})
+		if err != nil {
+			return fmt.Errorf("get meta table [data_compare_meta] counts failed, error: %v", err)
+		}
+
+		// 不存在错误，清理 data_compare_meta 记录, 更新 wait_sync_meta 记录
+		if failedTotalErrs == 0 {
+			err = meta.NewCommonModel(r.metaDB).DeleteTableDataCompareMetaAndUpdateWaitSyncMeta(r.ctx,
+				&meta.DataCompareMeta{
+					DBTypeS:     r.cfg.DBTypeS,
+					DBTypeT:     r.cfg.DBTypeT,
+					SchemaNameS: schemaNameS,
+					TableNameS:  tableNameS,
+					TaskMode:    r.cfg.TaskMode,
+				}, &meta.WaitSyncMeta{
+					DBTypeS:          r.cfg.DBTypeS,
+					DBTypeT:          r.cfg.DBTypeT,
+					SchemaNameS:      schemaNameS,
+					TableNameS:       tableNameS,
+					TaskMode:         r.cfg.TaskMode,
+					TaskStatus:       common.TaskStatusSuccess,
+					ChunkSuccessNums: successTotalErrs,
+					ChunkFailedNums:  0,
+				})
+			if err != nil {
+				return err
+			}
+			zap.L().Info("diff single table mysql to oracle finished",
+				zap.String("schema", r.cfg.MySQLConfig.SchemaName),
+				zap.String("table", task.sourceTableName),
+				zap.String("cost", time.Now().Sub(diffStartTime).String()))
+			continue
+		}
+
+		// 若存在错误，修改表状态，skip 清理，统一忽略，最后显示
+		err = meta.NewWaitSyncMetaModel(r.metaDB).UpdateWaitSyncMeta(r.ctx, &meta.WaitSyncMeta{
+			DBTypeS:     r.cfg.DBTypeS,
+			DBTypeT:     r.cfg.DBTypeT,
+			SchemaNameS: schemaNameS,
+			TableNameS:  tableNameS,
+			TaskMode:    r.cfg.TaskMode,
+		}, map[string]interface{}{
+			"TaskStatus":       common.TaskStatusFailed,
+			"ChunkSuccessNums": successTotalErrs,
+			"ChunkFailedNums":  failedTotalErrs,
+		})
+		if err != nil {
+			return err
+		}
+		zap.L().Warn("update mysql [wait_sync_meta] meta",
+			zap.String("schema", r.cfg.MySQLConfig.SchemaName),
+			zap.String("table", task.sourceTableName),
+			zap.String("mode", r.cfg.TaskMode),
+			zap.String("updated", "table check exist error, skip"),
+			zap.String("cost", time.Now().Sub(diffStartTime).String()))
+	}
+	return nil
+}
+
+func (r *M2O) compareChunks(f, df *compare.File, waitCompareMetas []meta.DataCompareMeta, sourceTableName string, normalizer *compare.ColumnNormalizer, columnTypesT map[string]string) error {
+	g := &errgroup.Group{}
+	g.SetLimit(r.cfg.DiffConfig.DiffThreads)
+
+	for _, compareMeta := range waitCompareMetas {
+		newReport := NewReport(compareMeta, r.mysql, r.oracle, r.cfg.DiffConfig.OnlyCheckRows, r.checksumPushdown)
+		newReport.Normalizer = normalizer
+		newReport.ColumnTypesT = columnTypesT
+		newReport.SchemaNameMySQL = r.cfg.MySQLConfig.SchemaName
+		newReport.TableNameMySQL = sourceTableName
+		g.Go(func() error {
+			updates := map[string]interface{}{
+				"TaskStatus": common.TaskStatusSuccess,
+			}
+			report, err := IReport(newReport)
+			switch {
+			case err != nil:
+				updates["TaskStatus"] = common.TaskStatusFailed
+				updates["InfoDetail"] = newReport.String()
+				updates["ErrorDetail"] = err.Error()
+			case !strings.EqualFold(report, ""):
+				errMsg := fmt.Errorf("schema table data chunk isn't euqal")
+				if _, err = f.CWriteString(report); err != nil {
+					errMsg = fmt.Errorf("fix sql file write failed: %v", err.Error())
+				}
+				if err = df.WriteRowDiffs(r.cfg.DiffConfig.FixDiffFormat, newReport.RowDiffs); err != nil {
+					errMsg = fmt.Errorf("fix diff file write failed: %v", err.Error())
+				}
+				updates["TaskStatus"] = common.TaskStatusFailed
+				updates["InfoDetail"] = newReport.String()
+				updates["ErrorDetail"] = errMsg.Error()
+			}
+			// error skip, continue
+			return meta.NewDataCompareMetaModel(r.metaDB).UpdateDataCompareMeta(r.ctx, &meta.DataCompareMeta{
+				DBTypeS:     newReport.DataCompareMeta.DBTypeS,
+				DBTypeT:     newReport.DataCompareMeta.DBTypeT,
+				SchemaNameS: newReport.DataCompareMeta.SchemaNameS,
+				TableNameS:  newReport.DataCompareMeta.TableNameS,
+				TaskMode:    newReport.DataCompareMeta.TaskMode,
+				WhereRange:  newReport.DataCompareMeta.WhereRange,
+			}, updates)
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return fmt.Errorf("compare table task failed, update table [data_compare_meta] failed: %v", err)
+	}
+	return nil
+}
+
+// genOriginTables 元数据大写表名还原为上游原始表名
+func genOriginTables(tables []string, originTableMap map[string]string) []string {
+	var originTables []string
+	for _, t := range tables {
+		if val, ok := originTableMap[common.StringUPPER(t)]; ok {
+			originTables = append(originTables, val)
+		} else {
+			originTables = append(originTables, t)
+		}
+	}
+	return originTables
+}
@@ -0,0 +1,359 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package m2o
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/scylladb/go-set/strset"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/database/meta"
+	"github.com/wentaojin/transferdb/database/mysql"
+	"github.com/wentaojin/transferdb/database/oracle"
+	"github.com/wentaojin/transferdb/module/compare"
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+	"strings"
+)
+
+type Report struct {
+	DataCompareMeta  meta.DataCompareMeta `json:"data_compare_meta"`
+	Mysql            *mysql.MySQL         `json:"-"`
+	Oracle           *oracle.Oracle       `json:"-"`
+	OnlyCheckRows    bool                 `json:"only_check_rows"`
+	ChecksumPushdown bool                 `json:"checksum_pushdown"`
+	RowDiffs         []compare.RowDiff    `json:"-"`
+	// 下游 Oracle 字段数据类型，生成 Oracle 语法修复 SQL
+	ColumnTypesT map[string]string `json:"-"`
+	// 数据行对比前字段值规范化
+	Normalizer *compare.ColumnNormalizer `json:"-"`
+	// 上游 MySQL 库表名保留原始大小写（lower_case_table_names=0 区分大小写），元数据记录统一大写
+	SchemaNameMySQL string `json:"-"`
+	TableNameMySQL  string `json:"-"`
+}
+
+func NewReport(dataCompareMeta meta.DataCompareMeta, mysql *mysql.MySQL, oracle *oracle.Oracle, onlyCheckRows, checksumPushdown bool) *Report {
+	return &Report{
+		DataCompareMeta:  dataCompareMeta,
+		Mysql:            mysql,
+		Oracle:           oracle,
+		OnlyCheckRows:    onlyCheckRows,
+		ChecksumPushdown: checksumPushdown,
+	}
+}
+
+// genMySQLSchemaTable 上游 MySQL 库表名，未指定原始库表名沿用元数据记录
+func (r *Report) genMySQLSchemaTable() (string, string) {
+	if r.SchemaNameMySQL == "" || r.TableNameMySQL == "" {
+		return r.DataCompareMeta.SchemaNameS, r.DataCompareMeta.TableNameS
+	}
+	return r.SchemaNameMySQL, r.TableNameMySQL
+}
+
+func (r *Report) genMySQLTable() string {
+	schemaName, tableName := r.genMySQLSchemaTable()
+	return common.StringsBuilder(schemaName, ".", tableName)
+}
+
+// genMySQLWhereRange 上游字符字段比较按二进制排序规则，与下游 Oracle 二进制比较语义一致
+func (r *Report) genMySQLWhereRange(whereRange string) string {
+	if r.Normalizer == nil {
+		return whereRange
+	}
+	stringColumns := make(map[string]bool)
+	for column, dataType := range r.Normalizer.DataTypes {
+		if isStringDataType(dataType) {
+			stringColumns[column] = true
+		}
+	}
+	return compare.GenBinaryCollateRange(whereRange, stringColumns)
+}
+
+func (r *Report) GenDBQuery() (oracleQuery string, mysqlQuery string) {
+	mysqlQuery = common.StringsBuilder(
+		"SELECT ", r.DataCompareMeta.ColumnDetailS, " FROM ", r.genMySQLTable(), " WHERE ", r.genMySQLWhereRange(r.DataCompareMeta.WhereRange))
+	oracleQuery = common.StringsBuilder(
+		"SELECT ", r.DataCompareMeta.ColumnDetailT, " FROM ", r.DataCompareMeta.SchemaNameT, ".", r.DataCompareMeta.TableNameT, " WHERE ", r.DataCompareMeta.WhereRange)
+	if r.DataCompareMeta.WhereColumn != "" {
+		mysqlQuery = common.StringsBuilder(mysqlQuery, " ORDER BY ", r.DataCompareMeta.WhereColumn, " DESC")
+		oracleQuery = common.StringsBuilder(oracleQuery, " ORDER BY ", r.DataCompareMeta.WhereColumn, " DESC")
+	}
+	return
+}
+
+// GenDBChecksumQuery 数据块服务端聚合校验语句，与 o2m 校验算法一致，按下游 Oracle 字段数据类型计算字段 MD5
+func (r *Report) GenDBChecksumQuery() (oracleQuery string, mysqlQuery string, err error) {
+	oraColumns := compare.GenSelectColumnNames(r.DataCompareMeta.ColumnDetailT)
+	var dataTypes []string
+	for _, c := range oraColumns {
+		dataTypes = append(dataTypes, r.ColumnTypesT[common.StringUPPER(c)])
+	}
+	oracleQuery, mysqlQuery, err = compare.GenChecksumQuery(oraColumns, compare.GenSelectColumnNames(r.DataCompareMeta.ColumnDetailS), dataTypes,
+		common.StringsBuilder("SELECT ", r.DataCompareMeta.ColumnDetailT, " FROM ", r.DataCompareMeta.SchemaNameT, ".", r.DataCompareMeta.TableNameT, " WHERE ", r.DataCompareMeta.WhereRange),
+		common.StringsBuilder("SELECT ", r.DataCompareMeta.ColumnDetailS, " FROM ", r.genMySQLTable(), " WHERE ", r.genMySQLWhereRange(r.DataCompareMeta.WhereRange)))
+	if err != nil {
+		return "", "", fmt.Errorf("mysql table [%s] oracle table [%s] %v", r.DataCompareMeta.TableNameS, r.DataCompareMeta.TableNameT, err)
+	}
+	return oracleQuery, mysqlQuery, nil
+}
+
+func (r *Report) CheckOracleRows(oracleQuery string) (int64, error) {
+	rows, err := r.Oracle.GetOracleTableActualRows(oracleQuery)
+	if err != nil {
+		return rows, err
+	}
+	return rows, nil
+}
+
+func (r *Report) CheckMySQLRows(mysqlQuery string) (int64, error) {
+	rows, err := r.Mysql.GetMySQLTableActualRows(mysqlQuery)
+	if err != nil {
+		return rows, err
+	}
+	return rows, nil
+}
+
+func (r *Report) ReportCheckRows() (string, error) {
+	oracleQuery, mysqlQuery := r.GenDBQuery()
+	g := &errgroup.Group{}
+	var mysqlRows, oracleRows int64
+
+	g.Go(func() error {
+		rows, err := r.CheckMySQLRows(mysqlQuery)
+		if err != nil {
+			return err
+		}
+		mysqlRows = rows
+		return nil
+	})
+	g.Go(func() error {
+		rows, err := r.CheckOracleRows(oracleQuery)
+		if err != nil {
+			return err
+		}
+		oracleRows = rows
+		return nil
+	})
+	if err := g.Wait(); err != nil {
+		return "", err
+	}
+
+	zap.L().Info("mysql table chunk diff rows",
+		zap.String("mysql schema", r.DataCompareMeta.SchemaNameS),
+		zap.String("oracle schema", r.DataCompareMeta.SchemaNameT),
+		zap.String("mysql table", r.DataCompareMeta.TableNameS),
+		zap.String("oracle table", r.DataCompareMeta.TableNameT),
+		zap.Int64("mysql rows count", mysqlRows),
+		zap.Int64("oracle rows count", oracleRows),
+		zap.Bool("equal", mysqlRows == oracleRows))
+	if mysqlRows == oracleRows {
+		return "", nil
+	}
+
+	sw := table.NewWriter()
+	sw.SetStyle(table.StyleLight)
+	sw.AppendHeader(table.Row{"SOURCE TABLE", "SOURCE SQL", "SOURCE COUNTS", "TARGET TABLE", "TARGET SQL", "TARGET TABLE COUNTS", "RANGE"})
+	sw.AppendRows([]table.Row{
+		{
+			r.genMySQLTable(),
+			mysqlQuery,
+			mysqlRows,
+			common.StringsBuilder(r.DataCompareMeta.SchemaNameT, ".", r.DataCompareMeta.TableNameT),
+			oracleQuery,
+			oracleRows,
+			r.DataCompareMeta.WhereRange,
+		},
+	})
+	return fmt.Sprintf("/* \n\tmysql and oracle table range [%s] data rows aren't equal\n", r.DataCompareMeta.WhereRange) + sw.Render() + "\n*/\n", nil
+}
+
+// ReportCheckChecksum 数据块服务端聚合校验，返回校验值是否一致
+func (r *Report) ReportCheckChecksum() (bool, error) {
+	oracleQuery, mysqlQuery, err := r.GenDBChecksumQuery()
+	if err != nil {
+		return false, err
+	}
+	g := &errgroup.Group{}
+	var mysqlChecksum, oraChecksum string
+
+	g.Go(func() error {
+		checksum, err := r.Mysql.GetMySQLTableChunkChecksum(mysqlQuery)
+		if err != nil {
+			return fmt.Errorf("get mysql table chunk checksum failed: %v", err)
+		}
+		mysqlChecksum = checksum
+		return nil
+	})
+	g.Go(func() error {
+		checksum, err := r.Oracle.GetOracleTableChunkChecksum(oracleQuery)
+		if err != nil {
+			return fmt.Errorf("get oracle table chunk checksum failed: %v", err)
+		}
+		oraChecksum = checksum
+		return nil
+	})
+	if err := g.Wait(); err != nil {
+		return false, err
+	}
+
+	zap.L().Info("mysql table chunk checksum",
+		zap.String("mysql schema", r.DataCompareMeta.SchemaNameS),
+		zap.String("oracle schema", r.DataCompareMeta.SchemaNameT),
+		zap.String("mysql table", r.DataCompareMeta.TableNameS),
+		zap.String("oracle table", r.DataCompareMeta.TableNameT),
+		zap.String("range", r.DataCompareMeta.WhereRange),
+		zap.String("mysql checksum", mysqlChecksum),
+		zap.String("oracle checksum", oraChecksum))
+	return strings.EqualFold(mysqlChecksum, oraChecksum), nil
+}
+
+func (r *Report) ReportCheckCRC32() (string, error) {
+	oracleQuery, mysqlQuery := r.GenDBQuery()
+	g := &errgroup.Group{}
+	var mysqlReport, oraReport compare.DBSummary
+
+	g.Go(func() error {
+		columns, stringSet, crc32Val, err := r.Mysql.GetMySQLDataRowStrings(mysqlQuery)
+		if err != nil {
+			return fmt.Errorf("get mysql data row strings failed: %v", err)
+		}
+		mysqlReport = compare.DBSummary{Columns: columns, StringSet: stringSet, Crc32Val: crc32Val}
+		return nil
+	})
+	g.Go(func() error {
+		columns, stringSet, crc32Val, err := r.Oracle.GetOracleDataRowStrings(oracleQuery)
+		if err != nil {
+			return fmt.Errorf("get oracle data row strings failed: %v", err)
+		}
+		oraReport = compare.DBSummary{Columns: columns, StringSet: stringSet, Crc32Val: crc32Val}
+		return nil
+	})
+	if err := g.Wait(); err != nil {
+		return "", err
+	}
+
+	// 按字段类型规范化上下游字段值，避免格式差异导致误报
+	if r.Normalizer != nil {
+		mysqlReport = r.Normalizer.NormalizeSummary(mysqlReport)
+		oraReport = r.Normalizer.NormalizeSummary(oraReport)
+	}
+
+	zap.L().Info("mysql table chunk diff",
+		zap.String("mysql schema", r.DataCompareMeta.SchemaNameS),
+		zap.String("oracle schema", r.DataCompareMeta.SchemaNameT),
+		zap.String("mysql table", r.DataCompareMeta.TableNameS),
+		zap.String("oracle table", r.DataCompareMeta.TableNameT),
+		zap.Uint32("mysql crc32 values", mysqlReport.Crc32Val),
+		zap.Uint32("oracle crc32 values", oraReport.Crc32Val),
+		zap.String("mysql sql", mysqlQuery),
+		zap.String("oracle sql", oracleQuery))
+	if mysqlReport.Crc32Val == oraReport.Crc32Val {
+		return "", nil
+	}
+
+	sourceMore := strset.Difference(mysqlReport.StringSet, oraReport.StringSet).List()
+	targetMore := strset.Difference(oraReport.StringSet, mysqlReport.StringSet).List()
+
+	// 浮点数字段按容差绝对差值对比
+	if r.Normalizer != nil {
+		sourceMore, targetMore = r.Normalizer.MatchFloatTolerance(mysqlReport.Columns, sourceMore, targetMore)
+		if len(sourceMore) == 0 && len(targetMore) == 0 {
+			return "", nil
+		}
+	}
+
+	// 存在主键或者唯一键按键值对比，否则按整行数据对比
+	keyIdx, err := r.genTableKeyColumns(mysqlReport.Columns)
+	if err != nil {
+		return "", err
+	}
+	if len(keyIdx) > 0 {
+		fixSQL, ok, err := r.genRowDiffFixSQL(mysqlReport.Columns, sourceMore, targetMore, keyIdx)
+		if err != nil {
+			return "", err
+		}
+		if ok {
+			return fixSQL, nil
+		}
+	}
+
+	//上游存在，下游不存在 INSERT 下游
+	//上游不存在，下游存在 DELETE 下游
+	var fixSQL strings.Builder
+	columns := mysqlReport.Columns
+	targetTable := common.StringsBuilder(r.DataCompareMeta.SchemaNameT, ".", r.DataCompareMeta.TableNameT)
+	sourceFix, targetFix, err := r.genFixValues(sourceMore, targetMore)
+	if err != nil {
+		return "", err
+	}
+	if len(targetMore) > 0 {
+		fixSQL.WriteString(fmt.Sprintf("/*\n oracle table [%s] chunk [%s] data rows are more, extra rows [%d]\n*/\n", targetTable, r.DataCompareMeta.WhereRange, len(targetMore)))
+		for _, t := range targetMore {
+			values := compare.SplitRowValues(t)
+			if len(columns) != len(values) {
+				return "", fmt.Errorf("oracle schema [%s] table [%s] column counts [%d] isn't match values counts [%d]", r.DataCompareMeta.SchemaNameT, r.DataCompareMeta.TableNameT, len(columns), len(values))
+			}
+			if whereCond, ok := r.genFixWhereCondition(columns, nil, values, targetFix); ok {
+				fixSQL.WriteString(common.StringsBuilder("DELETE FROM ", targetTable, " WHERE ", whereCond, ";\n"))
+			} else {
+				r.appendFixSkip(&fixSQL, columns, values)
+			}
+			r.appendRowDiff(common.CompareDiffTypeExtra, columns, nil, nil, values)
+		}
+	}
+	if len(sourceMore) > 0 {
+		fixSQL.WriteString(fmt.Sprintf("/*\n oracle table [%s] chunk [%s] data rows are less, missing rows [%d]\n*/\n", targetTable, r.DataCompareMeta.WhereRange, len(sourceMore)))
+		for _, s := range sourceMore {
+			values := compare.SplitRowValues(s)
+			if len(columns) != len(values) {
+				return "", fmt.Errorf("mysql schema [%s] table [%s] column counts [%d] isn't match values counts [%d]", r.DataCompareMeta.SchemaNameS, r.DataCompareMeta.TableNameS, len(columns), len(values))
+			}
+			if fixColumns, fixValues, ok := r.genFixColumnValues(columns, values, sourceFix); ok {
+				fixSQL.WriteString(r.genOracleInsertSQL(targetTable, fixColumns, fixValues))
+			} else {
+				r.appendFixSkip(&fixSQL, columns, values)
+			}
+			r.appendRowDiff(common.CompareDiffTypeMissing, columns, nil, values, nil)
+		}
+	}
+	return fixSQL.String(), nil
+}
+
+func (r *Report) Report() (string, error) {
+	if r.OnlyCheckRows {
+		return r.ReportCheckRows()
+	}
+	// 服务端聚合校验一致直接跳过，不一致或者校验失败拉取数据行对比，浮点数字段按容差对比不支持服务端聚合校验
+	if r.ChecksumPushdown && (r.Normalizer == nil || !r.Normalizer.HasFloatColumn()) {
+		isEqual, err := r.ReportCheckChecksum()
+		if err != nil {
+			zap.L().Warn("mysql table chunk checksum pushdown failed, fallback row data compare",
+				zap.String("mysql schema", r.DataCompareMeta.SchemaNameS),
+				zap.String("mysql table", r.DataCompareMeta.TableNameS),
+				zap.String("range", r.DataCompareMeta.WhereRange),
+				zap.Error(err))
+		} else if isEqual {
+			return "", nil
+		}
+	}
+	return r.ReportCheckCRC32()
+}
+
+func (r *Report) String() string {
+	jsonStr, _ := json.Marshal(r)
+	return string(jsonStr)
+}
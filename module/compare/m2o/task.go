@@ -0,0 +1,267 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package m2o
+
+import (
+	"context"
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
+	"github.com/wentaojin/transferdb/database/mysql"
+	"github.com/wentaojin/transferdb/database/oracle"
+	"github.com/wentaojin/transferdb/module/compare"
+	"strconv"
+	"strings"
+)
+
+type Task struct {
+	ctx             context.Context
+	cfg             *config.Config
+	sourceTableName string
+	targetTableName string
+	mysql           *mysql.MySQL
+	oracle          *oracle.Oracle
+}
+
+func NewCompareTableTask(ctx context.Context, cfg *config.Config, compareTables []string, mysql *mysql.MySQL, oracle *oracle.Oracle, tableNameRule map[string]string) []*Task {
+	var tasks []*Task
+	for _, table := range compareTables {
+		// 库名、表名规则
+		var targetTableName string
+		if val, ok := tableNameRule[common.StringUPPER(table)]; ok {
+			targetTableName = val
+		} else {
+			targetTableName = common.StringUPPER(table)
+		}
+		tasks = append(tasks, &Task{
+			ctx:             ctx,
+			cfg:             cfg,
+			sourceTableName: table,
+			targetTableName: targetTableName,
+			mysql:           mysql,
+			oracle:          oracle,
+		})
+	}
+	return tasks
+}
+
+// 字段查询以 MySQL 字段为主，上下游输出相同字符格式
+// Date/Datetime/Timestamp 字段类型格式化，下游 Oracle DATE 类型忽略小数秒
+func (t *Task) AdjustDBSelectColumn() (sourceColumnInfo string, targetColumnInfo string, err error) {
+	var (
+		sourceColumnInfos, targetColumnInfos []string
+	)
+	columnInfo, err := t.mysql.GetMySQLTableColumn(t.cfg.MySQLConfig.SchemaName, t.sourceTableName)
+	if err != nil {
+		return sourceColumnInfo, targetColumnInfo, err
+	}
+	targetColumnTypes, err := t.GenTargetColumnTypes()
+	if err != nil {
+		return sourceColumnInfo, targetColumnInfo, err
+	}
+	emptyStringAs := t.cfg.AppConfig.GetEmptyStringAs(t.sourceTableName)
+
+	for _, colsInfo := range columnInfo {
+		colName := colsInfo["COLUMN_NAME"]
+		switch common.StringUPPER(colsInfo["DATA_TYPE"]) {
+		// 数字
+		case "TINYINT", "SMALLINT", "MEDIUMINT", "INT", "INTEGER", "BIGINT", "DECIMAL", "NUMERIC", "FLOAT", "DOUBLE", "REAL", "YEAR":
+			sourceColumnInfos = append(sourceColumnInfos, common.StringsBuilder("IF(INSTR(CAST(", colName, " AS CHAR),'.') > 0,TRIM(TRAILING '.' FROM TRIM(TRAILING '0' FROM CAST(", colName, " AS CHAR))),CAST(", colName, " AS CHAR)) AS ", colName))
+			targetColumnInfos = append(targetColumnInfos, common.StringsBuilder("REGEXP_REPLACE(TO_CHAR(", colName, "),'^(-?)\\.','\\10.') AS ", colName))
+		// 字符
+		case "CHAR", "VARCHAR", "TINYTEXT", "TEXT", "MEDIUMTEXT", "LONGTEXT", "ENUM", "SET", "JSON":
+			// 下游 NULL 与空字符串不区分，上游按 empty-string-as 规则预期 NULL 或者空字符串，不符合规则的值转换成差异标识
+			if emptyStringAs == common.EmptyStringAsEmpty {
+				sourceColumnInfos = append(sourceColumnInfos, common.StringsBuilder("IF(", colName, " IS NULL,'", common.CompareEmptyStringMismatch, "',IF(CHAR_LENGTH(", colName, ") = 0,NULL,", colName, ")) AS ", colName))
+			} else {
+				sourceColumnInfos = append(sourceColumnInfos, common.StringsBuilder("IF(CHAR_LENGTH(", colName, ") = 0,'", common.CompareEmptyStringMismatch, "',", colName, ") AS ", colName))
+			}
+			targetColumnInfos = append(targetColumnInfos, common.StringsBuilder("NVL(", colName, ",'') AS ", colName))
+		// 时间
+		case "DATE":
+			sourceColumnInfos = append(sourceColumnInfos, common.StringsBuilder("DATE_FORMAT(", colName, ",'%Y-%m-%d %H:%i:%s') AS ", colName))
+			targetColumnInfos = append(targetColumnInfos, common.StringsBuilder("TO_CHAR(", colName, ",'yyyy-MM-dd HH24:mi:ss') AS ", colName))
+		case "DATETIME", "TIMESTAMP":
+			// 时间精度大于 0 且下游 TIMESTAMP 上下游按精度舍入小数秒后输出
+			isTimestamp := strings.HasPrefix(targetColumnTypes[common.StringUPPER(colName)], "TIMESTAMP")
+			switch {
+			case t.cfg.DiffConfig.TimePrecision > 0 && isTimestamp:
+				precision := strconv.Itoa(t.cfg.DiffConfig.TimePrecision)
+				sourceColumnInfos = append(sourceColumnInfos, common.StringsBuilder("SUBSTRING(DATE_FORMAT(CAST(", colName, " AS DATETIME(", precision, ")),'%Y-%m-%d %H:%i:%s.%f'),1,", strconv.Itoa(20+t.cfg.DiffConfig.TimePrecision), ") AS ", colName))
+				targetColumnInfos = append(targetColumnInfos, common.StringsBuilder("TO_CHAR(CAST(", colName, " AS TIMESTAMP(", precision, ")),'yyyy-MM-dd HH24:mi:ss.FF", precision, "') AS ", colName))
+			default:
+				sourceColumnInfos = append(sourceColumnInfos, common.StringsBuilder("DATE_FORMAT(", colName, ",'%Y-%m-%d %H:%i:%s') AS ", colName))
+				targetColumnInfos = append(targetColumnInfos, common.StringsBuilder("TO_CHAR(", colName, ",'yyyy-MM-dd HH24:mi:ss') AS ", colName))
+			}
+		case "TIME":
+			sourceColumnInfos = append(sourceColumnInfos, common.StringsBuilder("CAST(", colName, " AS CHAR) AS ", colName))
+			targetColumnInfos = append(targetColumnInfos, common.StringsBuilder("TO_CHAR(", colName, ") AS ", colName))
+		// 默认其他类型
+		default:
+			sourceColumnInfos = append(sourceColumnInfos, colName)
+			targetColumnInfos = append(targetColumnInfos, colName)
+		}
+	}
+
+	sourceColumnInfo = strings.Join(sourceColumnInfos, ",")
+	targetColumnInfo = strings.Join(targetColumnInfos, ",")
+
+	return sourceColumnInfo, targetColumnInfo, nil
+}
+
+// 筛选 chunk 切分字段以及判断表是否存在主键/唯一键/唯一索引
+// 第一优先级配置文件指定字段【忽略是否存在索引】
+// 第二优先级主键/唯一键/唯一索引/普通索引单列数字字段
+// 第三优先级主键/唯一键/唯一索引/普通索引字符、日期或者联合字段，逗号分隔
+func (t *Task) FilterDBWhereColumn() (string, error) {
+	columnInfo, err := t.mysql.GetMySQLTableColumn(t.cfg.MySQLConfig.SchemaName, t.sourceTableName)
+	if err != nil {
+		return "", err
+	}
+	columnTypes := make(map[string]string)
+	for _, colsInfo := range columnInfo {
+		columnTypes[common.StringUPPER(colsInfo["COLUMN_NAME"])] = common.StringUPPER(colsInfo["DATA_TYPE"])
+	}
+
+	// PK > UK > 唯一索引 > 普通索引
+	var keyArr []string
+	pkInfo, err := t.mysql.GetMySQLTablePrimaryKey(t.cfg.MySQLConfig.SchemaName, t.sourceTableName)
+	if err != nil {
+		return "", err
+	}
+	for _, pk := range pkInfo {
+		keyArr = append(keyArr, pk["COLUMN_LIST"])
+	}
+	ukInfo, err := t.mysql.GetMySQLTableUniqueKey(t.cfg.MySQLConfig.SchemaName, t.sourceTableName)
+	if err != nil {
+		return "", err
+	}
+	for _, uk := range ukInfo {
+		keyArr = append(keyArr, uk["COLUMN_LIST"])
+	}
+	indexInfo, err := t.mysql.GetMySQLTableIndex(t.cfg.MySQLConfig.SchemaName, t.sourceTableName, t.cfg.DBTypeS)
+	if err != nil {
+		return "", err
+	}
+	var nonUkIndex []string
+	for _, idx := range indexInfo {
+		// 函数索引不参与切分
+		if idx["COLUMN_EXPRESSION"] != "" && !strings.EqualFold(idx["COLUMN_EXPRESSION"], "NULLABLE") {
+			continue
+		}
+		if strings.EqualFold(idx["UNIQUENESS"], "UNIQUE") {
+			keyArr = append(keyArr, idx["COLUMN_LIST"])
+		} else {
+			nonUkIndex = append(nonUkIndex, idx["COLUMN_LIST"])
+		}
+	}
+
+	// 如果表不存在主键/唯一键/唯一索引，直接返回报错中断，因为可能导致数据校验不准
+	if len(keyArr) == 0 {
+		return "", fmt.Errorf("mysql schema [%s] table [%s] pk/uk/unique index isn't exist, it's not support, please skip", t.cfg.MySQLConfig.SchemaName, t.sourceTableName)
+	}
+	keyArr = append(keyArr, nonUkIndex...)
+
+	for _, key := range keyArr {
+		columns := strings.Split(key, ",")
+		if len(columns) == 1 && isNumberDataType(columnTypes[common.StringUPPER(columns[0])]) {
+			return columns[0], nil
+		}
+	}
+	for _, key := range keyArr {
+		isSupport := true
+		for _, col := range strings.Split(key, ",") {
+			if !isIndexSampleDataType(columnTypes[common.StringUPPER(col)]) {
+				isSupport = false
+				break
+			}
+		}
+		if isSupport {
+			return key, nil
+		}
+	}
+	return "", fmt.Errorf("mysql schema [%s] table [%s] pk/uk/index number, string or date datatype column isn't exist, please skip or fixed", t.cfg.MySQLConfig.SchemaName, t.sourceTableName)
+}
+
+func (t *Task) IsPartitionTable() (string, error) {
+	isOK, err := t.mysql.IsMySQLPartitionTable(t.cfg.MySQLConfig.SchemaName, t.sourceTableName)
+	if err != nil {
+		return "", err
+	}
+	if isOK {
+		return "YES", nil
+	}
+	return "NO", nil
+}
+
+// GenTargetColumnTypes 下游 Oracle 表字段数据类型，用于生成 Oracle 语法修复 SQL
+func (t *Task) GenTargetColumnTypes() (map[string]string, error) {
+	columnInfo, err := t.mysql.GetMySQLTableColumn(t.cfg.MySQLConfig.SchemaName, t.sourceTableName)
+	if err != nil {
+		return nil, err
+	}
+	var columns []string
+	for _, colsInfo := range columnInfo {
+		columns = append(columns, colsInfo["COLUMN_NAME"])
+	}
+	return t.oracle.GetOracleTableColumnDataType(t.cfg.OracleConfig.SchemaName, t.targetTableName, columns)
+}
+
+// GenColumnNormalizer 按上游 MySQL 字段类型确定字段规范化规则
+func (t *Task) GenColumnNormalizer() (*compare.ColumnNormalizer, error) {
+	columnInfo, err := t.mysql.GetMySQLTableColumn(t.cfg.MySQLConfig.SchemaName, t.sourceTableName)
+	if err != nil {
+		return nil, err
+	}
+	keyColumns, err := getMySQLTableKeyColumns(t.mysql, t.cfg.MySQLConfig.SchemaName, t.sourceTableName)
+	if err != nil {
+		return nil, err
+	}
+	n := &compare.ColumnNormalizer{
+		ColumnKinds:    make(map[string]string),
+		DataTypes:      make(map[string]string),
+		KeyColumns:     keyColumns,
+		EmptyStringAs:  t.cfg.AppConfig.GetEmptyStringAs(t.sourceTableName),
+		FloatTolerance: t.cfg.DiffConfig.FloatTolerance,
+		TimePrecision:  t.cfg.DiffConfig.TimePrecision,
+	}
+	var fixColumnDetails []string
+	for _, colsInfo := range columnInfo {
+		colName := common.StringUPPER(colsInfo["COLUMN_NAME"])
+		n.DataTypes[colName] = common.StringUPPER(colsInfo["DATA_TYPE"])
+		// 修复字段排除生成列，DATE 字段补齐时间输出，与下游 TO_DATE 格式一致
+		if !strings.Contains(common.StringUPPER(colsInfo["EXTRA"]), "GENERATED") {
+			n.FixColumns = append(n.FixColumns, colsInfo["COLUMN_NAME"])
+			if n.DataTypes[colName] == "DATE" {
+				fixColumnDetails = append(fixColumnDetails, common.StringsBuilder("DATE_FORMAT(", colsInfo["COLUMN_NAME"], ",'%Y-%m-%d %H:%i:%s') AS ", colsInfo["COLUMN_NAME"]))
+			} else {
+				fixColumnDetails = append(fixColumnDetails, colsInfo["COLUMN_NAME"])
+			}
+		}
+		switch common.StringUPPER(colsInfo["DATA_TYPE"]) {
+		case "CHAR":
+			n.ColumnKinds[colName] = compare.NormalizeKindChar
+		case "FLOAT", "DOUBLE", "REAL":
+			n.ColumnKinds[colName] = compare.NormalizeKindFloat
+		case "TINYINT", "SMALLINT", "MEDIUMINT", "INT", "INTEGER", "BIGINT", "DECIMAL", "NUMERIC", "YEAR":
+			n.ColumnKinds[colName] = compare.NormalizeKindNumber
+		case "DATETIME", "TIMESTAMP":
+			n.ColumnKinds[colName] = compare.NormalizeKindTime
+		}
+	}
+	n.FixColumnDetailS = strings.Join(fixColumnDetails, ",")
+	return n, nil
+}
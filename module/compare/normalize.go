@@ -0,0 +1,280 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package compare
+
+import (
+	"github.com/scylladb/go-set/strset"
+	"github.com/shopspring/decimal"
+	"github.com/wentaojin/transferdb/common"
+	"hash/crc32"
+	"math"
+	"strings"
+	"time"
+)
+
+const (
+	NormalizeKindNumber = "NUMBER"
+	NormalizeKindFloat  = "FLOAT"
+	NormalizeKindTime   = "TIME"
+	NormalizeKindChar   = "CHAR"
+)
+
+// DBSummary 数据块数据行集合以及 CRC32 汇总
+type DBSummary struct {
+	Columns   []string
+	StringSet *strset.Set
+	Crc32Val  uint32
+	Rows      int64
+}
+
+// ColumnNormalizer 数据行对比前按字段类型规范化上下游字段值
+// NUMBER 去除末尾 0，时间按精度舍入小数秒，CHAR 去除末尾填充空格，浮点数差值不超过容差视为一致
+// 查询字段已按相同规则格式化（服务端聚合校验同样生效），此处兜底处理驱动输出格式差异
+type ColumnNormalizer struct {
+	ColumnKinds    map[string]string
+	DataTypes      map[string]string
+	KeyColumns     [][]string
+	FloatTolerance float64
+	TimePrecision  int
+
+	// 差异数据修复字段，排除虚拟列，FixColumnDetailS 上游修复字段查询，与数据迁移字段格式化一致
+	FixColumns       []string
+	FixColumnDetailS string
+	EmptyStringAs    string
+}
+
+// NormalizeSummary 规范化数据行并重新计算 CRC32
+func (n *ColumnNormalizer) NormalizeSummary(summary DBSummary) DBSummary {
+	var kinds []string
+	for _, c := range summary.Columns {
+		kinds = append(kinds, n.ColumnKinds[common.StringUPPER(c)])
+	}
+
+	stringSet := strset.New()
+	var crc32Val uint32
+	for _, row := range summary.StringSet.List() {
+		row = n.NormalizeRow(kinds, row)
+		if !stringSet.Has(row) {
+			stringSet.Add(row)
+			crc32Val += crc32.ChecksumIEEE([]byte(row))
+		}
+	}
+	return DBSummary{
+		Columns:   summary.Columns,
+		StringSet: stringSet,
+		Crc32Val:  crc32Val,
+		Rows:      summary.Rows,
+	}
+}
+
+func (n *ColumnNormalizer) NormalizeRow(kinds []string, row string) string {
+	values := SplitRowValues(row)
+	if len(values) != len(kinds) {
+		return row
+	}
+	for i, v := range values {
+		values[i] = n.normalizeValue(kinds[i], v)
+	}
+	return strings.Join(values, ",")
+}
+
+// GenFixRows 差异数据修复字段值按规范化后的对比数据行索引
+func (n *ColumnNormalizer) GenFixRows(columns []string, compareRows, fixRows [][]string) map[string][]string {
+	var kinds []string
+	for _, c := range columns {
+		kinds = append(kinds, n.ColumnKinds[common.StringUPPER(c)])
+	}
+	rows := make(map[string][]string, len(compareRows))
+	for i, values := range compareRows {
+		rows[n.NormalizeRow(kinds, strings.Join(values, ","))] = fixRows[i]
+	}
+	return rows
+}
+
+func (n *ColumnNormalizer) normalizeValue(kind, value string) string {
+	if kind == "" || value == "NULL" {
+		return value
+	}
+	s, isQuoted := UnquoteRowValue(value)
+
+	switch kind {
+	case NormalizeKindNumber, NormalizeKindFloat:
+		d, err := decimal.NewFromString(s)
+		if err != nil {
+			return value
+		}
+		s = d.String()
+	case NormalizeKindTime:
+		t, ok := parseNormalizeTime(s)
+		if !ok {
+			return value
+		}
+		layout := "2006-01-02 15:04:05"
+		if n.TimePrecision > 0 {
+			layout = common.StringsBuilder(layout, ".", strings.Repeat("0", n.TimePrecision))
+		}
+		s = t.Round(time.Duration(math.Pow10(9 - n.TimePrecision))).Format(layout)
+	case NormalizeKindChar:
+		s = strings.TrimRight(s, " ")
+	}
+
+	if isQuoted {
+		return common.StringsBuilder("'", common.SpecialLettersUsingMySQL([]byte(s)), "'")
+	}
+	return s
+}
+
+// HasFloatColumn 表是否存在浮点数字段
+func (n *ColumnNormalizer) HasFloatColumn() bool {
+	for _, kind := range n.ColumnKinds {
+		if kind == NormalizeKindFloat {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeTimeLayouts 时间字段值格式，带时区的值按本地时间对比，与数据迁移去除时区一致
+var normalizeTimeLayouts = []string{
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999 -07:00",
+	"2006-01-02 15:04:05.999999999 MST",
+}
+
+func parseNormalizeTime(s string) (time.Time, bool) {
+	for _, layout := range normalizeTimeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// MatchFloatTolerance 浮点数字段差值绝对值不超过容差且其余字段相同的上下游数据行视为一致，返回剩余差异数据行
+func (n *ColumnNormalizer) MatchFloatTolerance(columns, sourceMore, targetMore []string) ([]string, []string) {
+	if n.FloatTolerance <= 0 || len(sourceMore) == 0 || len(targetMore) == 0 {
+		return sourceMore, targetMore
+	}
+	var (
+		kinds    []string
+		hasFloat bool
+	)
+	for _, c := range columns {
+		kind := n.ColumnKinds[common.StringUPPER(c)]
+		if kind == NormalizeKindFloat {
+			hasFloat = true
+		}
+		kinds = append(kinds, kind)
+	}
+	if !hasFloat {
+		return sourceMore, targetMore
+	}
+
+	// 按非浮点数字段值分组下游数据行
+	targetRows := make(map[string][]int)
+	targetValues := make([][]string, len(targetMore))
+	for i, t := range targetMore {
+		targetValues[i] = SplitRowValues(t)
+		key, ok := genFloatToleranceKey(kinds, targetValues[i])
+		if ok {
+			targetRows[key] = append(targetRows[key], i)
+		}
+	}
+
+	tolerance := decimal.NewFromFloat(n.FloatTolerance)
+	matched := make(map[int]bool)
+	var sourceLeft []string
+	for _, s := range sourceMore {
+		sourceValues := SplitRowValues(s)
+		key, ok := genFloatToleranceKey(kinds, sourceValues)
+		isMatched := false
+		if ok {
+			for _, i := range targetRows[key] {
+				if !matched[i] && isFloatToleranceEqual(kinds, sourceValues, targetValues[i], tolerance) {
+					matched[i] = true
+					isMatched = true
+					break
+				}
+			}
+		}
+		if !isMatched {
+			sourceLeft = append(sourceLeft, s)
+		}
+	}
+
+	var targetLeft []string
+	for i, t := range targetMore {
+		if !matched[i] {
+			targetLeft = append(targetLeft, t)
+		}
+	}
+	return sourceLeft, targetLeft
+}
+
+func genFloatToleranceKey(kinds, values []string) (string, bool) {
+	if len(values) != len(kinds) {
+		return "", false
+	}
+	var keys []string
+	for i, v := range values {
+		if kinds[i] != NormalizeKindFloat {
+			keys = append(keys, v)
+		}
+	}
+	return strings.Join(keys, ","), true
+}
+
+func isFloatToleranceEqual(kinds, sourceValues, targetValues []string, tolerance decimal.Decimal) bool {
+	for i, kind := range kinds {
+		if kind != NormalizeKindFloat || sourceValues[i] == targetValues[i] {
+			continue
+		}
+		s, _ := UnquoteRowValue(sourceValues[i])
+		t, _ := UnquoteRowValue(targetValues[i])
+		sd, err := decimal.NewFromString(s)
+		if err != nil {
+			return false
+		}
+		td, err := decimal.NewFromString(t)
+		if err != nil {
+			return false
+		}
+		if sd.Sub(td).Abs().GreaterThan(tolerance) {
+			return false
+		}
+	}
+	return true
+}
+
+// UnquoteRowValue 去除字符字段值引号以及转义字符
+func UnquoteRowValue(value string) (string, bool) {
+	if len(value) < 2 || value[0] != '\'' || value[len(value)-1] != '\'' {
+		return value, false
+	}
+	var (
+		b       strings.Builder
+		escaped bool
+	)
+	for _, c := range value[1 : len(value)-1] {
+		if !escaped && c == '\\' {
+			escaped = true
+			continue
+		}
+		escaped = false
+		b.WriteRune(c)
+	}
+	return b.String(), true
+}
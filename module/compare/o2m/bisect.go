@@ -0,0 +1,177 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package o2m
+
+import (
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"go.uber.org/zap"
+	"strconv"
+	"strings"
+)
+
+// ReportCheckBisect 服务端聚合校验不一致数据块按对比字段中位数递归二分，支持数字、字符以及日期字段
+// 仅对校验值不一致且行数小于等于 bisect-min-rows 的子范围拉取数据行对比
+// 下游不存在 ROWID，二分统一按对比字段值范围拆分，上下游条件一致，联合字段按引导字段二分
+func (r *Report) ReportCheckBisect() (string, error) {
+	diffRanges, err := r.bisectRange(r.DataCompareMeta.WhereRange, 0, "")
+	if err != nil {
+		return "", err
+	}
+
+	var fixSQL strings.Builder
+	for _, whereRange := range diffRanges {
+		subReport := r.newBisectReport(whereRange)
+		fix, err := subReport.ReportCheckCRC32()
+		if err != nil {
+			return "", err
+		}
+		fixSQL.WriteString(fix)
+		r.RowDiffs = append(r.RowDiffs, subReport.RowDiffs...)
+		r.FixSQLs = append(r.FixSQLs, subReport.FixSQLs...)
+	}
+	return fixSQL.String(), nil
+}
+
+// bisectRange 返回校验值不一致的最小子范围，parentChecksum 上层范围上下游校验值
+func (r *Report) bisectRange(whereRange string, depth int, parentChecksum string) ([]string, error) {
+	oraChecksum, mysqlChecksum, err := r.newBisectReport(whereRange).genChecksum()
+	if err != nil {
+		return nil, err
+	}
+	isEqual := strings.EqualFold(oraChecksum, mysqlChecksum)
+	r.BisectSteps = append(r.BisectSteps, fmt.Sprintf("depth [%d] range [%s] oracle checksum [%s] mysql checksum [%s] equal [%v]",
+		depth, whereRange, oraChecksum, mysqlChecksum, isEqual))
+	if isEqual {
+		return nil, nil
+	}
+
+	if genChecksumRows(oraChecksum, mysqlChecksum) <= int64(r.BisectMinRows) || depth >= common.CompareBisectMaxDepth {
+		return []string{whereRange}, nil
+	}
+	// 重复值过多中位数无法拆分，上下游行数与上层范围一致，停止二分
+	checksum := common.StringsBuilder(strings.Split(oraChecksum, ":")[0], ":", strings.Split(mysqlChecksum, ":")[0])
+	if checksum == parentChecksum {
+		return []string{whereRange}, nil
+	}
+
+	midValue, ok, err := r.genBisectMidValue(whereRange)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return []string{whereRange}, nil
+	}
+
+	column := r.genBisectColumn()
+	subRanges := []string{
+		common.StringsBuilder("(", whereRange, ") AND ", column, " <= ", midValue),
+		common.StringsBuilder("(", whereRange, ") AND ", column, " > ", midValue),
+	}
+	// 对比字段 NULL 数据不落在任何二分范围，首次二分单独校验
+	if depth == 0 {
+		subRanges = append(subRanges, common.StringsBuilder("(", whereRange, ") AND ", column, " IS NULL"))
+	}
+
+	var diffRanges []string
+	for _, subRange := range subRanges {
+		ranges, err := r.bisectRange(subRange, depth+1, checksum)
+		if err != nil {
+			return nil, err
+		}
+		diffRanges = append(diffRanges, ranges...)
+	}
+
+	zap.L().Info("oracle table chunk bisect",
+		zap.String("oracle schema", r.DataCompareMeta.SchemaNameS),
+		zap.String("oracle table", r.DataCompareMeta.TableNameS),
+		zap.String("range", whereRange),
+		zap.Int("depth", depth),
+		zap.Strings("diff ranges", diffRanges))
+	return diffRanges, nil
+}
+
+// genBisectMidValue 上游对比字段中位数作为二分中间值，上游范围无数据取下游中位数，范围无法继续拆分返回 false
+func (r *Report) genBisectMidValue(whereRange string) (string, bool, error) {
+	if r.Normalizer == nil {
+		return "", false, nil
+	}
+	column := r.genBisectColumn()
+	dataType := r.Normalizer.DataTypes[common.StringUPPER(column)]
+	if !isIndexSampleDataType(dataType) {
+		return "", false, nil
+	}
+
+	oraOrder, mysqlOrder := column, column
+	if isStringDataType(dataType) {
+		oraOrder = common.StringsBuilder("NLSSORT(", column, ",'NLS_SORT=BINARY')")
+		mysqlOrder = common.StringsBuilder("CONVERT(", column, " USING utf8mb4) COLLATE utf8mb4_bin")
+	}
+	midValue, err := r.Oracle.GetOracleTableRangeMedian(r.genOracleTable(), column, genIndexSampleColumnExpr(column, dataType), oraOrder, whereRange)
+	if err != nil {
+		return "", false, err
+	}
+	if midValue == "" {
+		midValue, err = r.Mysql.GetMySQLTableRangeMedian(r.DataCompareMeta.SchemaNameT, r.DataCompareMeta.TableNameT, column,
+			genBisectMySQLColumnExpr(column, dataType), mysqlOrder, r.genMySQLWhereRange(whereRange))
+		if err != nil {
+			return "", false, err
+		}
+	}
+	if midValue == "" {
+		return "", false, nil
+	}
+	literal, ok := genIndexSampleLiteral(midValue, dataType)
+	return literal, ok, nil
+}
+
+// genBisectColumn 二分字段，联合字段取引导字段，引导字段重复值过多时由上层行数判断停止二分
+func (r *Report) genBisectColumn() string {
+	return strings.TrimSpace(strings.Split(r.DataCompareMeta.WhereColumn, ",")[0])
+}
+
+// genBisectMySQLColumnExpr 下游中位数输出表达式，与上游 genIndexSampleColumnExpr 输出格式一致
+func genBisectMySQLColumnExpr(column, dataType string) string {
+	switch {
+	case dataType == "DATE" || strings.HasPrefix(dataType, "TIMESTAMP"):
+		return common.StringsBuilder("DATE_FORMAT(", column, ",'%Y-%m-%d %H:%i:%s.%f')")
+	case dataType == "NUMBER":
+		return common.StringsBuilder("CAST(", column, " AS CHAR)")
+	default:
+		return column
+	}
+}
+
+func (r *Report) newBisectReport(whereRange string) *Report {
+	compareMeta := r.DataCompareMeta
+	compareMeta.WhereRange = whereRange
+	subReport := NewReport(compareMeta, r.Mysql, r.Oracle, r.OnlyCheckRows, r.ChecksumPushdown, r.BisectMinRows)
+	subReport.SourceSCN = r.SourceSCN
+	subReport.Normalizer = r.Normalizer
+	return subReport
+}
+
+// genChecksumRows 校验值行数，取上下游较大值
+func genChecksumRows(oraChecksum, mysqlChecksum string) int64 {
+	var rows int64
+	for _, checksum := range []string{oraChecksum, mysqlChecksum} {
+		n, err := strconv.ParseInt(strings.Split(checksum, ":")[0], 10, 64)
+		if err == nil && n > rows {
+			rows = n
+		}
+	}
+	return rows
+}
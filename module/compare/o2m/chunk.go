@@ -41,7 +41,8 @@ type Chunk struct {
 	SourceColumnInfo string          `json:"source_column_info"`
 	TargetColumnInfo string          `json:"target_column_info"`
 	WhereColumn      string          `json:"where_column"`
-	WhereRange       string          `json:"where_range"` // chunk split need
+	WhereRange       string          `json:"where_range"`     // chunk split need
+	PartitionRange   string          `json:"partition_range"` // partition compare need
 	Cfg              *config.Config  `json:"-"`
 	Oracle           *oracle.Oracle  `json:"-"`
 	MySQL            *mysql.MySQL    `json:"-"`
@@ -118,7 +119,7 @@ func (c *Chunk) Split() error {
 		c.WhereColumn = ""
 		c.WhereRange = "1 = 1"
 
-		err := meta.NewCommonModel(c.MetaDB).CreateDataCompareMetaAndUpdateWaitSyncMeta(c.Ctx, &meta.DataCompareMeta{
+		err := c.createDataCompareMeta(&meta.DataCompareMeta{
 			DBTypeS:       c.Cfg.DBTypeS,
 			DBTypeT:       c.Cfg.DBTypeT,
 			SchemaNameS:   common.StringUPPER(c.Cfg.OracleConfig.SchemaName),
@@ -163,7 +164,7 @@ func (c *Chunk) Split() error {
 		// select xxx from tab where age > 1 and age < 10
 		c.WhereRange = customRange
 		c.WhereColumn = ""
-		err = meta.NewCommonModel(c.MetaDB).CreateDataCompareMetaAndUpdateWaitSyncMeta(c.Ctx, &meta.DataCompareMeta{
+		err = c.createDataCompareMeta(&meta.DataCompareMeta{
 			DBTypeS:       c.Cfg.DBTypeS,
 			DBTypeT:       c.Cfg.DBTypeT,
 			SchemaNameS:   common.StringUPPER(c.Cfg.OracleConfig.SchemaName),
@@ -200,8 +201,8 @@ func (c *Chunk) Split() error {
 	if err != nil {
 		return err
 	}
-	// 统计信息数据行数 0，直接全表扫
-	if tableRowsByStatistics == 0 {
+	// 统计信息数据行数 0 或者表不存在可用切分字段，直接全表扫
+	if tableRowsByStatistics == 0 || (customColumn == "" && c.WhereColumn == "") {
 		zap.L().Warn("get oracle table rows",
 			zap.String("schema", common.StringUPPER(c.Cfg.OracleConfig.SchemaName)),
 			zap.String("table", c.SourceTable),
@@ -209,7 +210,7 @@ func (c *Chunk) Split() error {
 			zap.Int("statistics rows", tableRowsByStatistics))
 		c.WhereRange = "1 = 1"
 		c.WhereColumn = ""
-		err = meta.NewCommonModel(c.MetaDB).CreateDataCompareMetaAndUpdateWaitSyncMeta(c.Ctx, &meta.DataCompareMeta{
+		err = c.createDataCompareMeta(&meta.DataCompareMeta{
 			DBTypeS:       c.Cfg.DBTypeS,
 			DBTypeT:       c.Cfg.DBTypeT,
 			SchemaNameS:   common.StringUPPER(c.Cfg.OracleConfig.SchemaName),
@@ -252,6 +253,22 @@ func (c *Chunk) Split() error {
 		c.WhereColumn = customColumn
 	}
 
+	// 字符、日期以及联合索引字段按索引顺序采样切分
+	isNumber, columnTypes, err := c.isNumberWhereColumn()
+	if err != nil {
+		return err
+	}
+	if !isNumber {
+		if err = c.SplitByIndexSample(columnTypes); err != nil {
+			return err
+		}
+		zap.L().Info("pre split oracle and mysql table chunk finished",
+			zap.String("schema", c.Cfg.OracleConfig.SchemaName),
+			zap.String("table", c.SourceTable),
+			zap.String("cost", time.Now().Sub(startTime).String()))
+		return nil
+	}
+
 	taskName := common.StringsBuilder(common.StringUPPER(c.Cfg.OracleConfig.SchemaName), `_`, c.SourceTable, `_`, `TASK`, strconv.Itoa(c.ChunkID))
 
 	if err = c.Oracle.StartOracleChunkCreateTask(taskName); err != nil {
@@ -278,7 +295,7 @@ func (c *Chunk) Split() error {
 
 		c.WhereRange = "1 = 1"
 		c.WhereColumn = ""
-		err = meta.NewCommonModel(c.MetaDB).CreateDataCompareMetaAndUpdateWaitSyncMeta(c.Ctx, &meta.DataCompareMeta{
+		err = c.createDataCompareMeta(&meta.DataCompareMeta{
 			DBTypeS:       c.Cfg.DBTypeS,
 			DBTypeT:       c.Cfg.DBTypeT,
 			SchemaNameS:   common.StringUPPER(c.Cfg.OracleConfig.SchemaName),
@@ -369,7 +386,7 @@ func (c *Chunk) Split() error {
 	}
 
 	// 元数据库信息 batch 写入
-	err = meta.NewCommonModel(c.MetaDB).BatchCreateDataCompareMetaAndUpdateWaitSyncMeta(c.Ctx,
+	err = c.batchCreateDataCompareMeta(
 		fullMetas, c.Cfg.AppConfig.InsertBatchSize, &meta.WaitSyncMeta{
 			DBTypeS:          c.Cfg.DBTypeS,
 			DBTypeT:          c.Cfg.DBTypeT,
@@ -0,0 +1,181 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or impliec.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package o2m
+
+import (
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/database/meta"
+	"github.com/wentaojin/transferdb/module/compare"
+	"go.uber.org/zap"
+	"strconv"
+	"strings"
+)
+
+// isIndexSampleDataType 索引顺序采样切分 chunk 支持的字段数据类型
+func isIndexSampleDataType(dataType string) bool {
+	dataType = common.StringUPPER(dataType)
+	switch {
+	case dataType == "NUMBER", dataType == "CHAR", dataType == "NCHAR", dataType == "VARCHAR2", dataType == "NVARCHAR2", dataType == "DATE":
+		return true
+	case strings.HasPrefix(dataType, "TIMESTAMP") && !strings.Contains(dataType, "TIME ZONE"):
+		return true
+	default:
+		return false
+	}
+}
+
+// isNumberWhereColumn 单列 NUMBER 字段沿用 DBMS_PARALLEL_EXECUTE 按 NUMBER 字段切分
+func (c *Chunk) isNumberWhereColumn() (bool, map[string]string, error) {
+	columns := strings.Split(c.WhereColumn, ",")
+	columnTypes, err := c.Oracle.GetOracleTableColumnDataType(c.Cfg.OracleConfig.SchemaName, c.SourceTable, columns)
+	if err != nil {
+		return false, columnTypes, err
+	}
+	for _, col := range columns {
+		if _, ok := columnTypes[common.StringUPPER(col)]; !ok {
+			return false, columnTypes, fmt.Errorf("oracle table [%s.%s] where column [%s] isn't exist", c.Cfg.OracleConfig.SchemaName, c.SourceTable, col)
+		}
+	}
+	return len(columns) == 1 && columnTypes[common.StringUPPER(columns[0])] == "NUMBER", columnTypes, nil
+}
+
+// SplitByIndexSample 字符、日期以及联合索引字段按索引顺序每 chunk-size 行采样边界值切分 chunk
+// 边界条件以 c1 > v1 OR (c1 = v1 AND c2 > v2) 形式展开，上下游通用
+// 字符边界值含反斜杠上下游转义语义不一致，跳过该边界合并相邻 chunk
+func (c *Chunk) SplitByIndexSample(columnTypes map[string]string) error {
+	columns := strings.Split(c.WhereColumn, ",")
+	var columnExprs, orderExprs []string
+	for _, col := range columns {
+		columnExprs = append(columnExprs, genIndexSampleColumnExpr(col, columnTypes[common.StringUPPER(col)]))
+		if isStringDataType(columnTypes[common.StringUPPER(col)]) {
+			orderExprs = append(orderExprs, common.StringsBuilder("NLSSORT(", col, ",'NLS_SORT=BINARY')"))
+		} else {
+			orderExprs = append(orderExprs, col)
+		}
+	}
+
+	res, err := c.Oracle.GetOracleTableChunksByIndexSample(c.Cfg.OracleConfig.SchemaName, c.SourceTable, columns, columnExprs, orderExprs, c.Cfg.DiffConfig.ChunkSize)
+	if err != nil {
+		return err
+	}
+
+	var (
+		boundaries [][]string
+		lastKey    string
+	)
+	for _, r := range res {
+		var (
+			values    []string
+			isSupport = true
+		)
+		for i, col := range columns {
+			v, ok := genIndexSampleLiteral(r[common.StringsBuilder("B", strconv.Itoa(i))], columnTypes[common.StringUPPER(col)])
+			if !ok {
+				isSupport = false
+				break
+			}
+			values = append(values, v)
+		}
+		if !isSupport {
+			continue
+		}
+		// 非唯一索引相邻边界值相同，跳过空 chunk
+		key := strings.Join(values, ",")
+		if key == lastKey {
+			continue
+		}
+		lastKey = key
+		boundaries = append(boundaries, values)
+	}
+
+	whereRanges := compare.GenIndexSampleRanges(columns, boundaries)
+
+	var fullMetas []meta.DataCompareMeta
+	for _, whereRange := range whereRanges {
+		fullMetas = append(fullMetas, meta.DataCompareMeta{
+			DBTypeS:       c.Cfg.DBTypeS,
+			DBTypeT:       c.Cfg.DBTypeT,
+			SchemaNameS:   common.StringUPPER(c.Cfg.OracleConfig.SchemaName),
+			TableNameS:    common.StringUPPER(c.SourceTable),
+			SchemaNameT:   common.StringUPPER(c.Cfg.MySQLConfig.SchemaName),
+			TableNameT:    common.StringUPPER(c.TargetTable),
+			ColumnDetailS: c.SourceColumnInfo,
+			ColumnDetailT: c.TargetColumnInfo,
+			WhereRange:    whereRange,
+			WhereColumn:   c.WhereColumn,
+			IsPartition:   c.IsPartition,
+			TaskMode:      c.Cfg.TaskMode,
+			TaskStatus:    common.TaskStatusWaiting})
+	}
+
+	zap.L().Info("split oracle table chunk by index sample",
+		zap.String("schema", common.StringUPPER(c.Cfg.OracleConfig.SchemaName)),
+		zap.String("table", c.SourceTable),
+		zap.String("where column", c.WhereColumn),
+		zap.Int("boundaries", len(boundaries)),
+		zap.Int("chunks", len(fullMetas)))
+
+	err = c.batchCreateDataCompareMeta(
+		fullMetas, c.Cfg.AppConfig.InsertBatchSize, &meta.WaitSyncMeta{
+			DBTypeS:          c.Cfg.DBTypeS,
+			DBTypeT:          c.Cfg.DBTypeT,
+			SchemaNameS:      common.StringUPPER(c.Cfg.OracleConfig.SchemaName),
+			TableNameS:       common.StringUPPER(c.SourceTable),
+			TaskMode:         c.Cfg.TaskMode,
+			GlobalScnS:       c.SourceGlobalSCN,
+			ChunkTotalNums:   int64(len(fullMetas)),
+			ChunkSuccessNums: 0,
+			ChunkFailedNums:  0,
+			IsPartition:      c.IsPartition,
+		})
+	if err != nil {
+		return fmt.Errorf("create table [%s.%s] data_diff_meta [batch size] failed: %v", common.StringUPPER(c.Cfg.OracleConfig.SchemaName), c.SourceTable, err)
+	}
+	return nil
+}
+
+// genIndexSampleColumnExpr 边界值输出表达式，日期时间统一格式化
+func genIndexSampleColumnExpr(column, dataType string) string {
+	switch {
+	case dataType == "DATE":
+		return common.StringsBuilder("TO_CHAR(", column, ",'YYYY-MM-DD HH24:MI:SS')")
+	case strings.HasPrefix(dataType, "TIMESTAMP"):
+		return common.StringsBuilder("TO_CHAR(", column, ",'YYYY-MM-DD HH24:MI:SS.FF6')")
+	case dataType == "NUMBER":
+		return common.StringsBuilder("TO_CHAR(", column, ")")
+	default:
+		return column
+	}
+}
+
+// genIndexSampleLiteral 边界值转换上下游通用 SQL 字面量，日期时间使用 ANSI TIMESTAMP 字面量
+func genIndexSampleLiteral(value, dataType string) (string, bool) {
+	if strings.EqualFold(value, "NULLABLE") {
+		return "", false
+	}
+	switch {
+	case dataType == "NUMBER":
+		return value, true
+	case dataType == "DATE" || strings.HasPrefix(dataType, "TIMESTAMP"):
+		return common.StringsBuilder("TIMESTAMP '", value, "'"), true
+	default:
+		if strings.Contains(value, `\`) {
+			return "", false
+		}
+		return common.StringsBuilder("'", strings.ReplaceAll(value, "'", "''"), "'"), true
+	}
+}
@@ -37,6 +37,12 @@ type O2M struct {
 	oracle *oracle.Oracle
 	mysql  *mysql.MySQL
 	metaDB *meta.Meta
+	// 服务端聚合校验，STANDARD_HASH 需要 oracle 12c 及以上
+	checksumPushdown bool
+	// 在线校验持续采样已完成轮次
+	sampleRound int
+	// 数据校验字段规范化，buildin_datatype_rule 上游数据类型映射下游数据类型
+	datatypeRules map[string]string
 }
 
 func NewCompare(ctx context.Context, cfg *config.Config) (*O2M, error) {
@@ -44,6 +50,10 @@ func NewCompare(ctx context.Context, cfg *config.Config) (*O2M, error) {
 	if err != nil {
 		return nil, err
 	}
+	oracleDB.Throttle, err = oracle.NewThrottle(ctx, oracleDB, cfg.ThrottleConfig)
+	if err != nil {
+		return nil, err
+	}
 	mysqlDB, err := mysql.NewMySQLDBEngine(ctx, cfg.MySQLConfig)
 	if err != nil {
 		return nil, err
@@ -62,6 +72,28 @@ func NewCompare(ctx context.Context, cfg *config.Config) (*O2M, error) {
 }
 
 func (r *O2M) NewCompare() error {
+	if !r.cfg.DiffConfig.LiveValidation || r.cfg.DiffConfig.LiveSampleInterval <= 0 {
+		return r.compare()
+	}
+	// 在线校验持续采样，每轮重新切分数据块校验，直至任务退出
+	for {
+		if err := r.compare(); err != nil {
+			return err
+		}
+		r.sampleRound++
+		zap.L().Info("live compare table oracle to mysql round finished",
+			zap.String("schema", r.cfg.OracleConfig.SchemaName),
+			zap.Int("round", r.sampleRound),
+			zap.Int("next round interval seconds", r.cfg.DiffConfig.LiveSampleInterval))
+		select {
+		case <-r.ctx.Done():
+			return nil
+		case <-time.After(time.Duration(r.cfg.DiffConfig.LiveSampleInterval) * time.Second):
+		}
+	}
+}
+
+func (r *O2M) compare() error {
 	startTime := time.Now()
 	zap.L().Info("diff table oracle to mysql start",
 		zap.String("schema", r.cfg.OracleConfig.SchemaName))
@@ -75,6 +107,14 @@ func (r *O2M) NewCompare() error {
 	if common.VersionOrdinal(oraDBVersion) < common.VersionOrdinal(common.RequireOracleDBVersion) {
 		return fmt.Errorf("oracle db version [%v] is less than 11g, can't be using transferdb tools", oraDBVersion)
 	}
+	if !r.cfg.DiffConfig.DisableChecksumPushdown && !r.cfg.DiffConfig.OnlyCheckRows {
+		if common.VersionOrdinal(oraDBVersion) >= common.VersionOrdinal(common.OracleStandardHashDBVersion) {
+			r.checksumPushdown = true
+		} else {
+			zap.L().Warn("oracle db version isn't support standard_hash, checksum pushdown would be disabled",
+				zap.String("db version", oraDBVersion))
+		}
+	}
 
 	// 获取配置文件待同步表列表
 	exporters, err := filterCFGTable(r.cfg, r.oracle)
@@ -88,14 +128,28 @@ func (r *O2M) NewCompare() error {
 		return nil
 	}
 
-	// 关于全量断点恢复
-	if !r.cfg.DiffConfig.EnableCheckpoint {
-		err = meta.NewDataCompareMetaModel(r.metaDB).TruncateDataCompareMeta(r.ctx)
-		if err != nil {
-			return err
+	// 关于全量断点恢复，持续采样非首轮仅清理当前任务表数据块重新校验，保留其他任务校验记录
+	if !r.cfg.DiffConfig.EnableCheckpoint || r.sampleRound > 0 {
+		if r.sampleRound == 0 {
+			err = meta.NewDataCompareMetaModel(r.metaDB).TruncateDataCompareMeta(r.ctx)
+			if err != nil {
+				return err
+			}
 		}
 
 		for _, tableName := range exporters {
+			if r.sampleRound > 0 {
+				err = meta.NewDataCompareMetaModel(r.metaDB).DeleteDataCompareMetaByTable(r.ctx, &meta.DataCompareMeta{
+					DBTypeS:     r.cfg.DBTypeS,
+					DBTypeT:     r.cfg.DBTypeT,
+					SchemaNameS: r.cfg.OracleConfig.SchemaName,
+					TableNameS:  tableName,
+					TaskMode:    r.cfg.TaskMode,
+				})
+				if err != nil {
+					return err
+				}
+			}
 			err = meta.NewWaitSyncMetaModel(r.metaDB).DeleteWaitSyncMeta(r.ctx, &meta.WaitSyncMeta{
 				DBTypeS:     r.cfg.DBTypeS,
 				DBTypeT:     r.cfg.DBTypeT,
@@ -377,6 +431,18 @@ func (r *O2M) NewCompare() error {
 		return err
 	}
 
+	// 差异数据文件 json/csv
+	diffFile := filepath.Join(r.cfg.DiffConfig.FixSqlDir, fmt.Sprintf("compare_%s.%s", r.cfg.OracleConfig.SchemaName, r.cfg.DiffConfig.FixDiffFormat))
+	df, err := compare.NewWriter(diffFile)
+	if err != nil {
+		return err
+	}
+	if strings.EqualFold(r.cfg.DiffConfig.FixDiffFormat, common.CompareDiffFormatCSV) {
+		if _, err = df.CWriteString(compare.RowDiffCSVHeader()); err != nil {
+			return err
+		}
+	}
+
 	// 优先存在断点的表校验
 	// partTableTask -> waitTableTasks
 	if len(partTableTasks) > 0 {
@@ -384,7 +450,7 @@ func (r *O2M) NewCompare() error {
 		if err != nil {
 			return err
 		}
-		err = r.comparePartTableTasks(f, partTableTasks)
+		err = r.comparePartTableTasks(f, df, partTableTasks)
 		if err != nil {
 			return err
 		}
@@ -394,7 +460,7 @@ func (r *O2M) NewCompare() error {
 		if err != nil {
 			return err
 		}
-		err = r.compareWaitTableTasks(f, waitTableTasks)
+		err = r.compareWaitTableTasks(f, df, waitTableTasks)
 		if err != nil {
 			return err
 		}
@@ -404,6 +470,10 @@ func (r *O2M) NewCompare() error {
 	if err != nil {
 		return err
 	}
+	err = df.Close()
+	if err != nil {
+		return err
+	}
 
 	// 任务详情
 	succTotals, err := meta.NewWaitSyncMetaModel(r.metaDB).DetailWaitSyncMeta(r.ctx, &meta.WaitSyncMeta{
@@ -445,7 +515,7 @@ func (r *O2M) NewCompare() error {
 	return nil
 }
 
-func (r *O2M) comparePartTableTasks(f *compare.File, partTableTasks []*Task) error {
+func (r *O2M) comparePartTableTasks(f, df *compare.File, partTableTasks []*Task) error {
 	for _, task := range partTableTasks {
 		// 获取对比记录
 		diffStartTime := time.Now()
@@ -486,84 +556,21 @@ func (r *O2M) comparePartTableTasks(f *compare.File, partTableTasks []*Task) err
 			return err
 		}
 
-		waitCompareMetas = append(waitCompareMetas, failedCompareMetas...)
-
-		// 设置工作池
-		// 设置 goroutine 数
-		g1 := &errgroup.Group{}
-		g1.SetLimit(r.cfg.DiffConfig.DiffThreads)
-
-		for _, compareMeta := range waitCompareMetas {
-			newReport := NewReport(compareMeta, r.mysql, r.oracle, r.cfg.DiffConfig.OnlyCheckRows)
-			g1.Go(func() error {
-				// 数据对比报告
-				report, err := IReport(newReport)
-				if err != nil {
-					// error skip, continue
-					if err = meta.NewDataCompareMetaModel(r.metaDB).UpdateDataCompareMeta(r.ctx, &meta.DataCompareMeta{
-						DBTypeS:     newReport.DataCompareMeta.DBTypeS,
-						DBTypeT:     newReport.DataCompareMeta.DBTypeT,
-						SchemaNameS: newReport.DataCompareMeta.SchemaNameS,
-						TableNameS:  newReport.DataCompareMeta.TableNameS,
-						TaskMode:    newReport.DataCompareMeta.TaskMode,
-						WhereRange:  newReport.DataCompareMeta.WhereRange,
-					}, map[string]interface{}{
-						"TaskStatus":  common.TaskStatusFailed,
-						"InfoDetail":  newReport.String(),
-						"ErrorDetail": err.Error(),
-					}); err != nil {
-						return err
-					}
-
-					return nil
-				}
-
-				// 数据对比是否不一致
-				if !strings.EqualFold(report, "") {
-					var errMsg error
-					errMsg = fmt.Errorf("schema table data chunk isn't euqal")
-
-					if _, err := f.CWriteString(report); err != nil {
-						errMsg = fmt.Errorf("fix sql file write failed: %v", err.Error())
-					}
-					// error skip, continue
-					if err = meta.NewDataCompareMetaModel(r.metaDB).UpdateDataCompareMeta(r.ctx, &meta.DataCompareMeta{
-						DBTypeS:     newReport.DataCompareMeta.DBTypeS,
-						DBTypeT:     newReport.DataCompareMeta.DBTypeT,
-						SchemaNameS: newReport.DataCompareMeta.SchemaNameS,
-						TableNameS:  newReport.DataCompareMeta.TableNameS,
-						TaskMode:    newReport.DataCompareMeta.TaskMode,
-						WhereRange:  newReport.DataCompareMeta.WhereRange,
-					}, map[string]interface{}{
-						"TaskStatus":  common.TaskStatusFailed,
-						"InfoDetail":  newReport.String(),
-						"ErrorDetail": errMsg.Error(),
-					}); err != nil {
-						return err
-					}
-
-					return nil
-				}
-
-				err = meta.NewDataCompareMetaModel(r.metaDB).UpdateDataCompareMeta(r.ctx, &meta.DataCompareMeta{
-					DBTypeS:     newReport.DataCompareMeta.DBTypeS,
-					DBTypeT:     newReport.DataCompareMeta.DBTypeT,
-					SchemaNameS: newReport.DataCompareMeta.SchemaNameS,
-					TableNameS:  newReport.DataCompareMeta.TableNameS,
-					TaskMode:    newReport.DataCompareMeta.TaskMode,
-					WhereRange:  newReport.DataCompareMeta.WhereRange,
-				}, map[string]interface{}{
-					"TaskStatus": common.TaskStatusSuccess,
-				})
-				if err != nil {
-					return err
-				}
-				return nil
-			})
+		// 抽样校验，随机选取等待数据块校验，上次校验失败数据块始终重新校验，存在不一致数据块升级全量校验
+		compareMetas, skipMetas := waitCompareMetas, []meta.DataCompareMeta{}
+		if r.isSampleCompare() {
+			compareMetas, skipMetas = sampleCompareMetas(waitCompareMetas, r.cfg.DiffConfig.SamplePercent)
 		}
-
-		if err = g1.Wait(); err != nil {
-			return fmt.Errorf("compare table task failed, update table [data_compare_meta] failed: %v", err)
+		compareMetas = append(compareMetas, failedCompareMetas...)
+		normalizer, err := r.genColumnNormalizer(task.sourceTableName)
+		if err != nil {
+			return err
+		}
+		if err = r.compareChunks(f, df, compareMetas, normalizer); err != nil {
+			return err
+		}
+		if err = r.escalateSampleCompare(f, df, task.sourceTableName, len(waitCompareMetas)+len(failedCompareMetas), len(compareMetas), skipMetas, normalizer); err != nil {
+			return err
 		}
 
 		// 清理元数据记录
@@ -647,7 +654,7 @@ func (r *O2M) comparePartTableTasks(f *compare.File, partTableTasks []*Task) err
 	return nil
 }
 
-func (r *O2M) compareWaitTableTasks(f *compare.File, waitTableTasks []*Task) error {
+func (r *O2M) compareWaitTableTasks(f, df *compare.File, waitTableTasks []*Task) error {
 	globalSCN, err := r.oracle.GetOracleCurrentSnapshotSCN()
 	if err != nil {
 		return err
@@ -667,9 +674,17 @@ func (r *O2M) compareWaitTableTasks(f *compare.File, waitTableTasks []*Task) err
 		if err != nil {
 			return err
 		}
-		chunks = append(chunks, NewChunk(r.ctx, r.cfg, r.oracle, r.mysql, r.metaDB,
+		chunk := NewChunk(r.ctx, r.cfg, r.oracle, r.mysql, r.metaDB,
 			cid, globalSCN, task.sourceTableName, task.targetTableName, isPartition, sourceColumnInfo, targetColumnInfo,
-			whereColumn))
+			whereColumn)
+		// 分区表指定分区对比
+		if isPartition == "YES" {
+			chunk.PartitionRange, err = task.GenPartitionRange()
+			if err != nil {
+				return err
+			}
+		}
+		chunks = append(chunks, chunk)
 	}
 
 	// chunk split
@@ -690,9 +705,129 @@ func (r *O2M) compareWaitTableTasks(f *compare.File, waitTableTasks []*Task) err
 		return err
 	}
 
-	err = r.comparePartTableTasks(f, waitTableTasks)
+	err = r.comparePartTableTasks(f, df, waitTableTasks)
 	if err != nil {
 		return err
 	}
 	return nil
 }
+
+func (r *O2M) compareChunks(f, df *compare.File, waitCompareMetas []meta.DataCompareMeta, normalizer *compare.ColumnNormalizer) error {
+	// 设置工作池
+	// 设置 goroutine 数
+	g1 := &errgroup.Group{}
+	g1.SetLimit(r.cfg.DiffConfig.DiffThreads)
+
+	for _, compareMeta := range waitCompareMetas {
+		newReport := NewReport(compareMeta, r.mysql, r.oracle, r.cfg.DiffConfig.OnlyCheckRows, r.checksumPushdown, r.cfg.DiffConfig.BisectMinRows)
+		newReport.Normalizer = normalizer
+		g1.Go(func() error {
+			// 数据对比报告
+			var (
+				report string
+				err    error
+			)
+			if r.cfg.DiffConfig.LiveValidation {
+				report, err = r.liveReport(newReport)
+			} else {
+				report, err = IReport(newReport)
+			}
+			if err != nil {
+				// error skip, continue
+				if err = meta.NewDataCompareMetaModel(r.metaDB).UpdateDataCompareMeta(r.ctx, &meta.DataCompareMeta{
+					DBTypeS:     newReport.DataCompareMeta.DBTypeS,
+					DBTypeT:     newReport.DataCompareMeta.DBTypeT,
+					SchemaNameS: newReport.DataCompareMeta.SchemaNameS,
+					TableNameS:  newReport.DataCompareMeta.TableNameS,
+					TaskMode:    newReport.DataCompareMeta.TaskMode,
+					WhereRange:  newReport.DataCompareMeta.WhereRange,
+				}, map[string]interface{}{
+					"TaskStatus":   common.TaskStatusFailed,
+					"InfoDetail":   newReport.String(),
+					"ErrorDetail":  err.Error(),
+					"BisectDetail": strings.Join(newReport.BisectSteps, "\n"),
+				}); err != nil {
+					return err
+				}
+
+				return nil
+			}
+
+			// 数据对比是否不一致
+			if !strings.EqualFold(report, "") {
+				var errMsg error
+				errMsg = fmt.Errorf("schema table data chunk isn't euqal")
+
+				if _, err := f.CWriteString(report); err != nil {
+					errMsg = fmt.Errorf("fix sql file write failed: %v", err.Error())
+				}
+				if err := df.WriteRowDiffs(r.cfg.DiffConfig.FixDiffFormat, newReport.RowDiffs); err != nil {
+					errMsg = fmt.Errorf("fix diff file write failed: %v", err.Error())
+				}
+
+				// 差异数据自动修复，修复后重新校验一致视为成功
+				if r.cfg.DiffConfig.AutoFix && len(newReport.FixSQLs) > 0 {
+					isFixed, err := r.autoFixChunk(newReport)
+					switch {
+					case err != nil:
+						errMsg = fmt.Errorf("schema table data chunk auto fix failed: %v", err.Error())
+					case isFixed:
+						return meta.NewDataCompareMetaModel(r.metaDB).UpdateDataCompareMeta(r.ctx, &meta.DataCompareMeta{
+							DBTypeS:     newReport.DataCompareMeta.DBTypeS,
+							DBTypeT:     newReport.DataCompareMeta.DBTypeT,
+							SchemaNameS: newReport.DataCompareMeta.SchemaNameS,
+							TableNameS:  newReport.DataCompareMeta.TableNameS,
+							TaskMode:    newReport.DataCompareMeta.TaskMode,
+							WhereRange:  newReport.DataCompareMeta.WhereRange,
+						}, map[string]interface{}{
+							"TaskStatus":   common.TaskStatusSuccess,
+							"InfoDetail":   "schema table data chunk auto fixed, detail please see table [data_fix_meta]",
+							"BisectDetail": strings.Join(newReport.BisectSteps, "\n"),
+						})
+					case !r.cfg.DiffConfig.AutoFixDryRun:
+						errMsg = fmt.Errorf("schema table data chunk isn't euqal after auto fix")
+					}
+				}
+				// error skip, continue
+				if err = meta.NewDataCompareMetaModel(r.metaDB).UpdateDataCompareMeta(r.ctx, &meta.DataCompareMeta{
+					DBTypeS:     newReport.DataCompareMeta.DBTypeS,
+					DBTypeT:     newReport.DataCompareMeta.DBTypeT,
+					SchemaNameS: newReport.DataCompareMeta.SchemaNameS,
+					TableNameS:  newReport.DataCompareMeta.TableNameS,
+					TaskMode:    newReport.DataCompareMeta.TaskMode,
+					WhereRange:  newReport.DataCompareMeta.WhereRange,
+				}, map[string]interface{}{
+					"TaskStatus":   common.TaskStatusFailed,
+					"InfoDetail":   newReport.String(),
+					"ErrorDetail":  errMsg.Error(),
+					"BisectDetail": strings.Join(newReport.BisectSteps, "\n"),
+				}); err != nil {
+					return err
+				}
+
+				return nil
+			}
+
+			err = meta.NewDataCompareMetaModel(r.metaDB).UpdateDataCompareMeta(r.ctx, &meta.DataCompareMeta{
+				DBTypeS:     newReport.DataCompareMeta.DBTypeS,
+				DBTypeT:     newReport.DataCompareMeta.DBTypeT,
+				SchemaNameS: newReport.DataCompareMeta.SchemaNameS,
+				TableNameS:  newReport.DataCompareMeta.TableNameS,
+				TaskMode:    newReport.DataCompareMeta.TaskMode,
+				WhereRange:  newReport.DataCompareMeta.WhereRange,
+			}, map[string]interface{}{
+				"TaskStatus":   common.TaskStatusSuccess,
+				"BisectDetail": strings.Join(newReport.BisectSteps, "\n"),
+			})
+			if err != nil {
+				return err
+			}
+			return nil
+		})
+	}
+
+	if err := g1.Wait(); err != nil {
+		return fmt.Errorf("compare table task failed, update table [data_compare_meta] failed: %v", err)
+	}
+	return nil
+}
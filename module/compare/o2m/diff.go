@@ -0,0 +1,318 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package o2m
+
+import (
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/database/oracle"
+	"github.com/wentaojin/transferdb/module/compare"
+	"go.uber.org/zap"
+	"sort"
+	"strings"
+)
+
+// genTableKeyColumns 主键优先，其次唯一键，返回对比字段下标，键字段不在对比字段内或者不存在返回 nil
+// 表级键字段由 genColumnNormalizer 统一获取，数据块不再重复查询
+func (r *Report) genTableKeyColumns(columns []string) ([]int, error) {
+	var (
+		keys [][]string
+		err  error
+	)
+	if r.Normalizer != nil {
+		keys = r.Normalizer.KeyColumns
+	} else {
+		keys, err = GetOracleTableKeyColumns(r.Oracle, r.DataCompareMeta.SchemaNameS, r.DataCompareMeta.TableNameS)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, k := range keys {
+		var keyIdx []int
+		for _, col := range k {
+			for i, c := range columns {
+				if strings.EqualFold(c, col) {
+					keyIdx = append(keyIdx, i)
+					break
+				}
+			}
+		}
+		if len(keyIdx) > 0 && len(keyIdx) == len(k) {
+			return keyIdx, nil
+		}
+	}
+	return nil, nil
+}
+
+// GetOracleTableKeyColumns 表主键字段，不存在主键返回唯一键字段
+func GetOracleTableKeyColumns(oracleDB *oracle.Oracle, schemaName, tableName string) ([][]string, error) {
+	keys, err := oracleDB.GetOracleSchemaTablePrimaryKey(schemaName, tableName)
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		keys, err = oracleDB.GetOracleSchemaTableUniqueKey(schemaName, tableName)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var keyColumns [][]string
+	for _, k := range keys {
+		keyColumns = append(keyColumns, strings.Split(k["COLUMN_LIST"], ","))
+	}
+	return keyColumns, nil
+}
+
+// genRowDiffFixSQL 按键值三方对比差异数据行
+// 上游存在，下游不存在 REPLACE 下游
+// 上游不存在，下游存在 DELETE 下游
+// 上下游均存在，字段值不一致 UPDATE 下游
+// 唯一键值存在重复（NULL）无法区分数据行，返回 false
+func (r *Report) genRowDiffFixSQL(oraReport, mysqlReport compare.DBSummary, sourceMore, targetMore []string, keyIdx []int) (string, bool, error) {
+	sourceRows, ok, err := compare.GenKeyRows(oraReport.Columns, sourceMore, keyIdx)
+	if err != nil {
+		return "", false, fmt.Errorf("oracle schema [%s] table [%s] %v", r.DataCompareMeta.SchemaNameS, r.DataCompareMeta.TableNameS, err)
+	}
+	if !ok {
+		return "", false, nil
+	}
+	targetRows, ok, err := compare.GenKeyRows(mysqlReport.Columns, targetMore, keyIdx)
+	if err != nil {
+		return "", false, fmt.Errorf("mysql schema [%s] table [%s] %v", r.DataCompareMeta.SchemaNameT, r.DataCompareMeta.TableNameT, err)
+	}
+	if !ok {
+		return "", false, nil
+	}
+
+	var (
+		missing, extra, changed []string
+		fixSQL                  strings.Builder
+	)
+	for key := range sourceRows {
+		if _, ok := targetRows[key]; ok {
+			changed = append(changed, key)
+		} else {
+			missing = append(missing, key)
+		}
+	}
+	for key := range targetRows {
+		if _, ok := sourceRows[key]; !ok {
+			extra = append(extra, key)
+		}
+	}
+	sort.Strings(missing)
+	sort.Strings(extra)
+	sort.Strings(changed)
+
+	targetTable := common.StringsBuilder(r.DataCompareMeta.SchemaNameT, ".", r.DataCompareMeta.TableNameT)
+	columns := mysqlReport.Columns
+
+	sourceFix, targetFix, err := r.genFixValues(sourceMore, targetMore)
+	if err != nil {
+		return "", false, err
+	}
+
+	if len(extra) > 0 {
+		fixSQL.WriteString(fmt.Sprintf("/*\n mysql table [%s] chunk [%s] data rows are more, extra rows [%d]\n*/\n", targetTable, r.DataCompareMeta.WhereRange, len(extra)))
+		for _, key := range extra {
+			values := targetRows[key]
+			if whereCond, ok := r.genFixWhereCondition(columns, keyIdx, values, targetFix); ok {
+				r.appendFixSQL(&fixSQL, common.StringsBuilder("DELETE FROM ", targetTable, " WHERE ", whereCond))
+			} else {
+				r.appendFixSkip(&fixSQL, columns, values)
+			}
+			r.appendRowDiff(common.CompareDiffTypeExtra, columns, keyIdx, nil, values)
+		}
+	}
+
+	if len(missing) > 0 {
+		fixSQL.WriteString(fmt.Sprintf("/*\n mysql table [%s] chunk [%s] data rows are less, missing rows [%d]\n*/\n", targetTable, r.DataCompareMeta.WhereRange, len(missing)))
+		for _, key := range missing {
+			values := sourceRows[key]
+			if fixColumns, fixValues, ok := r.genFixColumnValues(columns, values, sourceFix); ok {
+				r.appendFixSQL(&fixSQL, common.StringsBuilder("REPLACE INTO ", targetTable, " (", strings.Join(fixColumns, ","), ") VALUES (", strings.Join(fixValues, ","), ")"))
+			} else {
+				r.appendFixSkip(&fixSQL, columns, values)
+			}
+			r.appendRowDiff(common.CompareDiffTypeMissing, columns, keyIdx, values, nil)
+		}
+	}
+
+	if len(changed) > 0 {
+		fixSQL.WriteString(fmt.Sprintf("/*\n mysql table [%s] chunk [%s] data rows are changed, changed rows [%d]\n*/\n", targetTable, r.DataCompareMeta.WhereRange, len(changed)))
+		for _, key := range changed {
+			sourceValues, targetValues := sourceRows[key], targetRows[key]
+			fixColumns, fixValues, okSource := r.genFixColumnValues(columns, sourceValues, sourceFix)
+			whereCond, okTarget := r.genFixWhereCondition(columns, keyIdx, targetValues, targetFix)
+			var sets []string
+			for i := range columns {
+				if sourceValues[i] == targetValues[i] {
+					continue
+				}
+				for j, c := range fixColumns {
+					if strings.EqualFold(c, columns[i]) {
+						sets = append(sets, common.StringsBuilder(c, "=", fixValues[j]))
+						break
+					}
+				}
+			}
+			switch {
+			case !okSource || !okTarget:
+				r.appendFixSkip(&fixSQL, columns, sourceValues)
+			case len(sets) > 0:
+				r.appendFixSQL(&fixSQL, common.StringsBuilder("UPDATE ", targetTable, " SET ", strings.Join(sets, ","), " WHERE ", whereCond))
+			}
+			r.appendRowDiff(common.CompareDiffTypeChanged, columns, keyIdx, sourceValues, targetValues)
+		}
+	}
+
+	zap.L().Info("oracle table chunk row diff",
+		zap.String("oracle schema", r.DataCompareMeta.SchemaNameS),
+		zap.String("oracle table", r.DataCompareMeta.TableNameS),
+		zap.String("range", r.DataCompareMeta.WhereRange),
+		zap.Int("missing rows", len(missing)),
+		zap.Int("extra rows", len(extra)),
+		zap.Int("changed rows", len(changed)))
+	return fixSQL.String(), true, nil
+}
+
+// genFixValues 差异数据行修复字段值，上游按修复字段查询原始值，下游查询原始键值，按规范化后的对比数据行匹配
+// 修复 SQL 使用原始值，避免对比格式化值以及空字符串差异标识写入下游
+func (r *Report) genFixValues(sourceMore, targetMore []string) (map[string][]string, map[string][]string, error) {
+	if r.Normalizer == nil || len(r.Normalizer.FixColumns) == 0 {
+		return nil, nil, nil
+	}
+	var (
+		sourceFix, targetFix map[string][]string
+		compareRows, fixRows [][]string
+		err                  error
+	)
+	if len(sourceMore) > 0 {
+		compareRows, fixRows, err = r.Oracle.GetOracleDataRowFixValues(common.StringsBuilder(
+			"SELECT ", r.DataCompareMeta.ColumnDetailS, ",", r.Normalizer.FixColumnDetailS, " FROM ", r.genOracleTable(), " WHERE ", r.DataCompareMeta.WhereRange),
+			len(compare.GenSelectColumnNames(r.DataCompareMeta.ColumnDetailS)), r.Normalizer.EmptyStringAs)
+		if err != nil {
+			return nil, nil, err
+		}
+		sourceFix = r.Normalizer.GenFixRows(compare.GenSelectColumnNames(r.DataCompareMeta.ColumnDetailS), compareRows, fixRows)
+	}
+	if len(targetMore) > 0 {
+		compareRows, fixRows, err = r.Mysql.GetMySQLDataRowFixValues(common.StringsBuilder(
+			"SELECT ", r.DataCompareMeta.ColumnDetailT, ",", strings.Join(r.Normalizer.FixColumns, ","), " FROM ", r.DataCompareMeta.SchemaNameT, ".", r.DataCompareMeta.TableNameT,
+			" WHERE ", r.genMySQLWhereRange(r.DataCompareMeta.WhereRange)),
+			len(compare.GenSelectColumnNames(r.DataCompareMeta.ColumnDetailT)))
+		if err != nil {
+			return nil, nil, err
+		}
+		targetFix = r.Normalizer.GenFixRows(compare.GenSelectColumnNames(r.DataCompareMeta.ColumnDetailT), compareRows, fixRows)
+	}
+	return sourceFix, targetFix, nil
+}
+
+// genFixColumnValues 差异数据行修复字段以及原始字段值，未获取原始值返回 false
+func (r *Report) genFixColumnValues(columns, values []string, fixRows map[string][]string) ([]string, []string, bool) {
+	if fixRows == nil {
+		return columns, values, true
+	}
+	fixValues, ok := fixRows[strings.Join(values, ",")]
+	return r.Normalizer.FixColumns, fixValues, ok
+}
+
+// genFixWhereCondition 修复 SQL 键字段条件，使用下游原始值，未获取原始值返回 false
+func (r *Report) genFixWhereCondition(columns []string, keyIdx []int, values []string, fixRows map[string][]string) (string, bool) {
+	fixColumns, fixValues, ok := r.genFixColumnValues(columns, values, fixRows)
+	if !ok {
+		return "", false
+	}
+	if fixRows == nil {
+		return genWhereCondition(fixColumns, fixValues, keyIdx), true
+	}
+	var fixKeyIdx []int
+	for _, i := range keyIdx {
+		for j, c := range fixColumns {
+			if strings.EqualFold(c, columns[i]) {
+				fixKeyIdx = append(fixKeyIdx, j)
+				break
+			}
+		}
+	}
+	if len(fixKeyIdx) != len(keyIdx) {
+		fixKeyIdx = nil
+	}
+	return genWhereCondition(fixColumns, fixValues, fixKeyIdx), true
+}
+
+// appendFixSkip 差异数据行未获取原始值（对比期间数据变更）跳过生成修复 SQL，仅记录注释
+func (r *Report) appendFixSkip(fixSQL *strings.Builder, columns, values []string) {
+	fixSQL.WriteString(fmt.Sprintf("/*\n mysql table [%s.%s] chunk [%s] data row original values not found, maybe changed during compare, please recheck: %s\n*/\n",
+		r.DataCompareMeta.SchemaNameT, r.DataCompareMeta.TableNameT, r.DataCompareMeta.WhereRange, genWhereCondition(columns, values, nil)))
+}
+
+// appendFixSQL 修复 SQL 写入报告，同时记录用于自动修复
+func (r *Report) appendFixSQL(fixSQL *strings.Builder, sql string) {
+	fixSQL.WriteString(common.StringsBuilder(sql, ";\n"))
+	r.FixSQLs = append(r.FixSQLs, sql)
+}
+
+func (r *Report) appendRowDiff(diffType string, columns []string, keyIdx []int, sourceValues, targetValues []string) {
+	rowDiff := compare.RowDiff{
+		SchemaName: r.DataCompareMeta.SchemaNameT,
+		TableName:  r.DataCompareMeta.TableNameT,
+		WhereRange: r.DataCompareMeta.WhereRange,
+		DiffType:   diffType,
+	}
+	keyValues := sourceValues
+	if keyValues == nil {
+		keyValues = targetValues
+	}
+	for _, i := range keyIdx {
+		rowDiff.KeyValues = append(rowDiff.KeyValues, common.StringsBuilder(columns[i], "=", keyValues[i]))
+	}
+	for i, c := range columns {
+		colDiff := compare.ColumnDiff{Column: c}
+		if sourceValues != nil {
+			colDiff.SourceValue = sourceValues[i]
+		}
+		if targetValues != nil {
+			colDiff.TargetValue = targetValues[i]
+		}
+		// 字段值不一致仅记录差异字段
+		if diffType == common.CompareDiffTypeChanged && colDiff.SourceValue == colDiff.TargetValue {
+			continue
+		}
+		rowDiff.ColumnDiffs = append(rowDiff.ColumnDiffs, colDiff)
+	}
+	r.RowDiffs = append(r.RowDiffs, rowDiff)
+}
+
+// genWhereCondition 键字段过滤条件，keyIdx 为空使用全部字段，NULL 值使用 IS NULL
+func genWhereCondition(columns, values []string, keyIdx []int) string {
+	if len(keyIdx) == 0 {
+		for i := range columns {
+			keyIdx = append(keyIdx, i)
+		}
+	}
+	var whereCond []string
+	for _, i := range keyIdx {
+		if values[i] == "NULL" {
+			whereCond = append(whereCond, common.StringsBuilder(columns[i], " IS NULL"))
+		} else {
+			whereCond = append(whereCond, common.StringsBuilder(columns[i], "=", values[i]))
+		}
+	}
+	return strings.Join(whereCond, " AND ")
+}
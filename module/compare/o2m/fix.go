@@ -0,0 +1,119 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package o2m
+
+import (
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/database/meta"
+	"go.uber.org/zap"
+	"strconv"
+	"strings"
+)
+
+// autoFixChunk 差异数据块修复 SQL 按批次单事务执行，执行记录写入 data_fix_meta，执行完成重新校验数据块
+// 试运行仅记录待执行修复 SQL，返回数据块是否修复一致
+func (r *O2M) autoFixChunk(report *Report) (bool, error) {
+	fixStatus := common.TaskStatusSuccess
+	if r.cfg.DiffConfig.AutoFixDryRun {
+		fixStatus = common.CompareFixStatusDryRun
+	}
+
+	for i, fixSQLs := range meta.ArrayStructGroupsOf(report.FixSQLs, int64(r.cfg.DiffConfig.AutoFixBatchSize)) {
+		fixMeta := &meta.DataFixMeta{
+			DBTypeS:     report.DataCompareMeta.DBTypeS,
+			DBTypeT:     report.DataCompareMeta.DBTypeT,
+			SchemaNameS: report.DataCompareMeta.SchemaNameS,
+			TableNameS:  report.DataCompareMeta.TableNameS,
+			SchemaNameT: report.DataCompareMeta.SchemaNameT,
+			TableNameT:  report.DataCompareMeta.TableNameT,
+			TaskMode:    report.DataCompareMeta.TaskMode,
+			WhereRange:  report.DataCompareMeta.WhereRange,
+			FixBatch:    i,
+			FixSQL:      strings.Join(fixSQLs, ";\n"),
+			FixStatus:   fixStatus,
+		}
+
+		var errApply error
+		if !r.cfg.DiffConfig.AutoFixDryRun {
+			if errApply = r.mysql.ApplyMySQLFixSQL(fixSQLs); errApply != nil {
+				fixMeta.FixStatus = common.TaskStatusFailed
+				fixMeta.ErrorDetail = errApply.Error()
+			}
+		}
+		if err := meta.NewDataFixMetaModel(r.metaDB).CreateDataFixMeta(r.ctx, fixMeta); err != nil {
+			return false, err
+		}
+		if errApply != nil {
+			return false, errApply
+		}
+	}
+
+	zap.L().Info("compare table chunk auto fix",
+		zap.String("oracle schema", report.DataCompareMeta.SchemaNameS),
+		zap.String("oracle table", report.DataCompareMeta.TableNameS),
+		zap.String("range", report.DataCompareMeta.WhereRange),
+		zap.Int("fix sql counts", len(report.FixSQLs)),
+		zap.Bool("dry run", r.cfg.DiffConfig.AutoFixDryRun))
+
+	if r.cfg.DiffConfig.AutoFixDryRun {
+		return false, nil
+	}
+
+	// 修复完成重新校验
+	var (
+		recheck string
+		err     error
+	)
+	recheckReport := NewReport(report.DataCompareMeta, r.mysql, r.oracle, r.cfg.DiffConfig.OnlyCheckRows, r.checksumPushdown, r.cfg.DiffConfig.BisectMinRows)
+	recheckReport.Normalizer = report.Normalizer
+	if r.cfg.DiffConfig.LiveValidation {
+		recheck, err = r.liveReport(recheckReport)
+	} else {
+		recheck, err = IReport(recheckReport)
+	}
+	if err != nil {
+		return false, err
+	}
+	return strings.EqualFold(recheck, ""), nil
+}
+
+// genFixSelectColumn 上游修复字段查询格式化，与数据迁移字段格式化一致
+func genFixSelectColumn(columnName, dataType, dataScale string) (string, error) {
+	switch {
+	case dataType == common.BuildInOracleDatatypeXmltype:
+		return common.StringsBuilder("XMLSERIALIZE(CONTENT ", columnName, " AS CLOB) AS ", columnName), nil
+	case dataType == common.BuildInOracleDatatypeDate:
+		return common.StringsBuilder("TO_CHAR(", columnName, ",'yyyy-MM-dd HH24:mi:ss') AS ", columnName), nil
+	case strings.Contains(dataType, "INTERVAL"):
+		return common.StringsBuilder("TO_CHAR(", columnName, ") AS ", columnName), nil
+	case strings.Contains(dataType, "TIMESTAMP"):
+		scale, err := strconv.Atoi(dataScale)
+		if err != nil {
+			return "", fmt.Errorf("oracle timestamp datatype column [%s] scale [%s] strconv.Atoi failed: %v", columnName, dataScale, err)
+		}
+		switch {
+		case scale == 0:
+			return common.StringsBuilder("TO_CHAR(", columnName, ",'yyyy-mm-dd hh24:mi:ss') AS ", columnName), nil
+		case scale > 0 && scale <= 6:
+			return common.StringsBuilder("TO_CHAR(", columnName, ",'yyyy-mm-dd hh24:mi:ss.ff", dataScale, "') AS ", columnName), nil
+		default:
+			return common.StringsBuilder("TO_CHAR(", columnName, ",'yyyy-mm-dd hh24:mi:ss.ff6') AS ", columnName), nil
+		}
+	default:
+		return columnName, nil
+	}
+}
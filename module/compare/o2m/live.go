@@ -0,0 +1,105 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package o2m
+
+import (
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/database/meta"
+	"go.uber.org/zap"
+	"strings"
+	"time"
+)
+
+// liveReport 在线校验
+// 1、获取上游当前 SCN，上游 AS OF SCN 闪回查询
+// 2、等待增量同步 checkpoint（incr_sync_meta global_scn_s）超过该 SCN，确保下游已应用该 SCN 之前变更
+// 3、数据块不一致延迟重新获取 SCN 校验，重新校验仍不一致才视为不一致，排除同步延迟导致的误报
+func (r *O2M) liveReport(newReport *Report) (string, error) {
+	var report string
+	for i := 0; i <= r.cfg.DiffConfig.LiveRecheckTimes; i++ {
+		if i > 0 {
+			zap.L().Warn("live compare table chunk diff isn't equal, delay recheck",
+				zap.String("oracle schema", newReport.DataCompareMeta.SchemaNameS),
+				zap.String("oracle table", newReport.DataCompareMeta.TableNameS),
+				zap.String("range", newReport.DataCompareMeta.WhereRange),
+				zap.Uint64("source scn", newReport.SourceSCN),
+				zap.Int("recheck times", i))
+			select {
+			case <-r.ctx.Done():
+				return report, r.ctx.Err()
+			case <-time.After(time.Duration(r.cfg.DiffConfig.LiveRecheckDelay) * time.Second):
+			}
+		}
+
+		sourceSCN, err := r.oracle.GetOracleCurrentSnapshotSCN()
+		if err != nil {
+			return report, err
+		}
+		if err = r.waitIncrCheckpoint(newReport.DataCompareMeta.TableNameS, sourceSCN); err != nil {
+			return report, err
+		}
+
+		liveReport := NewReport(newReport.DataCompareMeta, r.mysql, r.oracle, r.cfg.DiffConfig.OnlyCheckRows, r.checksumPushdown, r.cfg.DiffConfig.BisectMinRows)
+		liveReport.SourceSCN = sourceSCN
+		liveReport.Normalizer = newReport.Normalizer
+		report, err = IReport(liveReport)
+		*newReport = *liveReport
+		if err != nil {
+			return report, err
+		}
+		if strings.EqualFold(report, "") {
+			return report, nil
+		}
+	}
+	return report, nil
+}
+
+// waitIncrCheckpoint 等待表增量同步 checkpoint 超过 SCN，表不存在增量同步记录以 schema 最小 checkpoint 为准
+func (r *O2M) waitIncrCheckpoint(tableName string, sourceSCN uint64) error {
+	timeout := time.After(time.Duration(r.cfg.DiffConfig.LiveWaitTimeout) * time.Second)
+	for {
+		checkpoint, ok, err := meta.NewIncrSyncMetaModel(r.metaDB).GetIncrSyncMetaGlobalScnSByTable(r.ctx, &meta.IncrSyncMeta{
+			DBTypeS:     r.cfg.DBTypeS,
+			DBTypeT:     r.cfg.DBTypeT,
+			SchemaNameS: common.StringUPPER(r.cfg.OracleConfig.SchemaName),
+			TableNameS:  tableName,
+		})
+		if err != nil {
+			return err
+		}
+		if !ok {
+			checkpoint, err = meta.NewIncrSyncMetaModel(r.metaDB).GetIncrSyncMetaMinGlobalScnSBySchema(r.ctx, &meta.IncrSyncMeta{
+				DBTypeS:     r.cfg.DBTypeS,
+				DBTypeT:     r.cfg.DBTypeT,
+				SchemaNameS: common.StringUPPER(r.cfg.OracleConfig.SchemaName),
+			})
+			if err != nil {
+				return err
+			}
+		}
+		if checkpoint >= sourceSCN {
+			return nil
+		}
+		select {
+		case <-r.ctx.Done():
+			return r.ctx.Err()
+		case <-timeout:
+			return fmt.Errorf("wait meta table [incr_sync_meta] checkpoint [%d] over source scn [%d] timeout [%ds]", checkpoint, sourceSCN, r.cfg.DiffConfig.LiveWaitTimeout)
+		case <-time.After(time.Second):
+		}
+	}
+}
@@ -0,0 +1,91 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package o2m
+
+import (
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/database/meta"
+	"github.com/wentaojin/transferdb/module/compare"
+	"strings"
+)
+
+// genColumnNormalizer 按 buildin_datatype_rule 上游字段类型映射下游字段类型确定字段规范化规则
+func (r *O2M) genColumnNormalizer(tableName string) (*compare.ColumnNormalizer, error) {
+	if r.datatypeRules == nil {
+		rules, err := meta.NewBuildinDatatypeRuleModel(r.metaDB).BatchQueryBuildinDatatype(r.ctx, &meta.BuildinDatatypeRule{
+			DBTypeS: r.cfg.DBTypeS,
+			DBTypeT: r.cfg.DBTypeT,
+		})
+		if err != nil {
+			return nil, err
+		}
+		r.datatypeRules = make(map[string]string)
+		for _, rule := range rules {
+			r.datatypeRules[common.StringUPPER(rule.DatatypeNameS)] = common.StringUPPER(rule.DatatypeNameT)
+		}
+	}
+
+	columnInfo, err := r.oracle.GetOracleSchemaTableColumn(r.cfg.OracleConfig.SchemaName, tableName, false)
+	if err != nil {
+		return nil, err
+	}
+
+	keyColumns, err := GetOracleTableKeyColumns(r.oracle, r.cfg.OracleConfig.SchemaName, tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	virtualColumns, err := r.oracle.GetOracleSchemaTableVirtualColumn(r.cfg.OracleConfig.SchemaName, tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	n := &compare.ColumnNormalizer{
+		ColumnKinds:    make(map[string]string),
+		DataTypes:      make(map[string]string),
+		KeyColumns:     keyColumns,
+		EmptyStringAs:  r.cfg.AppConfig.GetEmptyStringAs(tableName),
+		FloatTolerance: r.cfg.DiffConfig.FloatTolerance,
+		TimePrecision:  r.cfg.DiffConfig.TimePrecision,
+	}
+
+	var fixColumnDetails []string
+	for _, colsInfo := range columnInfo {
+		dataTypeS := common.StringUPPER(colsInfo["DATA_TYPE"])
+		dataTypeT := r.datatypeRules[dataTypeS]
+		n.DataTypes[common.StringUPPER(colsInfo["COLUMN_NAME"])] = dataTypeS
+		if !common.IsContainString(virtualColumns, colsInfo["COLUMN_NAME"]) {
+			fixColumn, err := genFixSelectColumn(colsInfo["COLUMN_NAME"], dataTypeS, colsInfo["DATA_SCALE"])
+			if err != nil {
+				return nil, err
+			}
+			n.FixColumns = append(n.FixColumns, colsInfo["COLUMN_NAME"])
+			fixColumnDetails = append(fixColumnDetails, fixColumn)
+		}
+		switch {
+		case dataTypeS == common.BuildInOracleDatatypeChar || dataTypeS == common.BuildInOracleDatatypeNchar || dataTypeS == common.BuildInOracleDatatypeCharacter:
+			n.ColumnKinds[common.StringUPPER(colsInfo["COLUMN_NAME"])] = compare.NormalizeKindChar
+		case strings.Contains(dataTypeT, "DOUBLE") || strings.Contains(dataTypeT, "FLOAT") || strings.Contains(dataTypeT, "REAL"):
+			n.ColumnKinds[common.StringUPPER(colsInfo["COLUMN_NAME"])] = compare.NormalizeKindFloat
+		case strings.Contains(dataTypeT, "DECIMAL") || strings.Contains(dataTypeT, "NUMERIC") || strings.Contains(dataTypeT, "INT"):
+			n.ColumnKinds[common.StringUPPER(colsInfo["COLUMN_NAME"])] = compare.NormalizeKindNumber
+		case strings.Contains(dataTypeT, "DATETIME") || strings.Contains(dataTypeT, "TIMESTAMP"):
+			n.ColumnKinds[common.StringUPPER(colsInfo["COLUMN_NAME"])] = compare.NormalizeKindTime
+		}
+	}
+	n.FixColumnDetailS = strings.Join(fixColumnDetails, ",")
+	return n, nil
+}
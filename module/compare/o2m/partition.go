@@ -0,0 +1,264 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package o2m
+
+import (
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/database/meta"
+	"regexp"
+	"strings"
+)
+
+var (
+	partitionDateValueRegex      = regexp.MustCompile(`(?i)^TO_DATE\(\s*'\s*([^']*)'`)
+	partitionTimestampValueRegex = regexp.MustCompile(`(?i)^TIMESTAMP\s*'\s*([^']*)'`)
+	partitionNumberValueRegex    = regexp.MustCompile(`^[-+]?[0-9]*\.?[0-9]+([eE][-+]?[0-9]+)?$`)
+)
+
+// GenPartitionRange 指定分区对比范围，上下游统一按分区键条件过滤，未指定分区返回空
+// 1、RANGE 单字段分区按相邻分区合并上下边界，MAXVALUE 分区包含分区键 NULL 数据
+// 2、LIST 单字段分区按分区值，DEFAULT 分区取其他分区值之外数据
+// 3、日期以及时间戳分区边界值转换 TIMESTAMP 'YYYY-MM-DD HH24:MI:SS' 标准字面量，上下游通用
+func (t *Task) GenPartitionRange() (string, error) {
+	partitionCfg, ok := t.cfg.AppConfig.GetPartitionTableConfig(t.sourceTableName)
+	if !ok {
+		return "", nil
+	}
+	partitions, err := t.oracle.GetOracleTablePartitionHighValues(t.cfg.OracleConfig.SchemaName, t.sourceTableName)
+	if err != nil {
+		return "", err
+	}
+	if len(partitions) == 0 {
+		return "", fmt.Errorf("oracle table [%s.%s] partition isn't exist", t.cfg.OracleConfig.SchemaName, t.sourceTableName)
+	}
+
+	partitionType := common.StringUPPER(partitions[0]["PARTITIONING_TYPE"])
+	partitionColumn := partitions[0]["COLUMN_LIST"]
+	if strings.Contains(partitionColumn, ",") || (partitionType != "RANGE" && partitionType != "LIST") {
+		return "", fmt.Errorf("oracle table [%s.%s] partition compare isn't support partitioning type [%s] column [%s], only support single column range or list partition",
+			t.cfg.OracleConfig.SchemaName, t.sourceTableName, partitionType, partitionColumn)
+	}
+
+	// 指定分区位置，对比仅支持分区级别
+	var partitionNames []string
+	for _, p := range partitions {
+		partitionNames = append(partitionNames, common.StringUPPER(p["PARTITION_NAME"]))
+	}
+	selected := make([]bool, len(partitions))
+	for _, p := range partitionCfg.Partitions {
+		idx := -1
+		for i, name := range partitionNames {
+			if name == p {
+				idx = i
+				break
+			}
+		}
+		if idx < 0 {
+			return "", fmt.Errorf("oracle table [%s.%s] partition [%s] isn't exist, partition compare only support partition level",
+				t.cfg.OracleConfig.SchemaName, t.sourceTableName, p)
+		}
+		selected[idx] = true
+	}
+	for i := len(partitions) - partitionCfg.RecentPartitions; i < len(partitions); i++ {
+		if i >= 0 {
+			selected[i] = true
+		}
+	}
+
+	if partitionType == "RANGE" {
+		return genRangePartitionRange(partitionColumn, partitions, selected)
+	}
+	return genListPartitionRange(partitionColumn, partitions, selected)
+}
+
+// genRangePartitionRange 分区 i 范围 [HIGH_VALUE(i-1), HIGH_VALUE(i))，连续分区合并
+func genRangePartitionRange(column string, partitions []map[string]string, selected []bool) (string, error) {
+	highValues := make([]string, len(partitions))
+	for i, p := range partitions {
+		v, err := normalizePartitionValue(p["HIGH_VALUE"])
+		if err != nil {
+			return "", err
+		}
+		highValues[i] = v
+	}
+
+	var ranges []string
+	for i := 0; i < len(partitions); i++ {
+		if !selected[i] {
+			continue
+		}
+		start := i
+		for i+1 < len(partitions) && selected[i+1] {
+			i++
+		}
+
+		var conds []string
+		if start > 0 {
+			conds = append(conds, common.StringsBuilder(column, " >= ", highValues[start-1]))
+		}
+		if !strings.EqualFold(highValues[i], "MAXVALUE") {
+			conds = append(conds, common.StringsBuilder(column, " < ", highValues[i]))
+		}
+		switch {
+		case strings.EqualFold(highValues[i], "MAXVALUE") && len(conds) == 0:
+			ranges = append(ranges, "1 = 1")
+		case strings.EqualFold(highValues[i], "MAXVALUE"):
+			ranges = append(ranges, common.StringsBuilder("(", conds[0], " OR ", column, " IS NULL)"))
+		default:
+			ranges = append(ranges, common.StringsBuilder("(", strings.Join(conds, " AND "), ")"))
+		}
+	}
+	return strings.Join(ranges, " OR "), nil
+}
+
+func genListPartitionRange(column string, partitions []map[string]string, selected []bool) (string, error) {
+	var (
+		allValues  [][]string
+		allHasNull []bool
+	)
+	for _, p := range partitions {
+		var (
+			values  []string
+			hasNull bool
+		)
+		for _, v := range splitPartitionValues(p["HIGH_VALUE"]) {
+			if strings.EqualFold(v, "DEFAULT") {
+				continue
+			}
+			if strings.EqualFold(v, "NULL") {
+				hasNull = true
+				continue
+			}
+			nv, err := normalizePartitionValue(v)
+			if err != nil {
+				return "", err
+			}
+			values = append(values, nv)
+		}
+		allValues = append(allValues, values)
+		allHasNull = append(allHasNull, hasNull)
+	}
+
+	var ranges []string
+	for i, p := range partitions {
+		if !selected[i] {
+			continue
+		}
+		if strings.EqualFold(strings.TrimSpace(p["HIGH_VALUE"]), "DEFAULT") {
+			var (
+				otherValues  []string
+				otherHasNull bool
+			)
+			for j := range partitions {
+				if j == i {
+					continue
+				}
+				otherValues = append(otherValues, allValues[j]...)
+				otherHasNull = otherHasNull || allHasNull[j]
+			}
+			var conds []string
+			if len(otherValues) > 0 {
+				conds = append(conds, common.StringsBuilder(column, " NOT IN (", strings.Join(otherValues, ","), ")"))
+			} else {
+				conds = append(conds, "1 = 1")
+			}
+			if !otherHasNull {
+				conds = append(conds, common.StringsBuilder(column, " IS NULL"))
+			}
+			ranges = append(ranges, common.StringsBuilder("(", strings.Join(conds, " OR "), ")"))
+			continue
+		}
+
+		var conds []string
+		if len(allValues[i]) > 0 {
+			conds = append(conds, common.StringsBuilder(column, " IN (", strings.Join(allValues[i], ","), ")"))
+		}
+		if allHasNull[i] {
+			conds = append(conds, common.StringsBuilder(column, " IS NULL"))
+		}
+		ranges = append(ranges, common.StringsBuilder("(", strings.Join(conds, " OR "), ")"))
+	}
+	return strings.Join(ranges, " OR "), nil
+}
+
+// splitPartitionValues 按逗号拆分分区值，忽略引号以及括号内逗号
+func splitPartitionValues(highValue string) []string {
+	var (
+		values  []string
+		current strings.Builder
+		quoted  bool
+		depth   int
+	)
+	for _, r := range highValue {
+		switch {
+		case r == '\'':
+			quoted = !quoted
+		case !quoted && r == '(':
+			depth++
+		case !quoted && r == ')':
+			depth--
+		case !quoted && depth == 0 && r == ',':
+			values = append(values, strings.TrimSpace(current.String()))
+			current.Reset()
+			continue
+		}
+		current.WriteRune(r)
+	}
+	if strings.TrimSpace(current.String()) != "" {
+		values = append(values, strings.TrimSpace(current.String()))
+	}
+	return values
+}
+
+// normalizePartitionValue 分区边界值转换上下游通用字面量
+func normalizePartitionValue(value string) (string, error) {
+	v := strings.TrimSpace(value)
+	switch {
+	case strings.EqualFold(v, "MAXVALUE"):
+		return "MAXVALUE", nil
+	case partitionDateValueRegex.MatchString(v):
+		return common.StringsBuilder("TIMESTAMP '", strings.TrimSpace(partitionDateValueRegex.FindStringSubmatch(v)[1]), "'"), nil
+	case partitionTimestampValueRegex.MatchString(v):
+		return common.StringsBuilder("TIMESTAMP '", strings.TrimSpace(partitionTimestampValueRegex.FindStringSubmatch(v)[1]), "'"), nil
+	case partitionNumberValueRegex.MatchString(v):
+		return v, nil
+	case len(v) >= 2 && strings.HasPrefix(v, "'") && strings.HasSuffix(v, "'"):
+		return v, nil
+	default:
+		return "", fmt.Errorf("partition high value [%s] isn't support partition compare", value)
+	}
+}
+
+// genPartitionWhereRange 指定分区对比，chunk 范围叠加分区范围
+func (c *Chunk) genPartitionWhereRange(whereRange string) string {
+	if c.PartitionRange == "" {
+		return whereRange
+	}
+	return common.StringsBuilder("(", c.PartitionRange, ") AND (", whereRange, ")")
+}
+
+func (c *Chunk) createDataCompareMeta(dataCompareMeta *meta.DataCompareMeta, waitSyncMeta *meta.WaitSyncMeta) error {
+	dataCompareMeta.WhereRange = c.genPartitionWhereRange(dataCompareMeta.WhereRange)
+	return meta.NewCommonModel(c.MetaDB).CreateDataCompareMetaAndUpdateWaitSyncMeta(c.Ctx, dataCompareMeta, waitSyncMeta)
+}
+
+func (c *Chunk) batchCreateDataCompareMeta(dataCompareMetas []meta.DataCompareMeta, batchSize int, waitSyncMeta *meta.WaitSyncMeta) error {
+	for i := range dataCompareMetas {
+		dataCompareMetas[i].WhereRange = c.genPartitionWhereRange(dataCompareMetas[i].WhereRange)
+	}
+	return meta.NewCommonModel(c.MetaDB).BatchCreateDataCompareMetaAndUpdateWaitSyncMeta(c.Ctx, dataCompareMetas, batchSize, waitSyncMeta)
+}
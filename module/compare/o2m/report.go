@@ -20,57 +20,124 @@ import (
 	"fmt"
 	"github.com/jedib0t/go-pretty/v6/table"
 	"github.com/scylladb/go-set/strset"
-	"github.com/thinkeridea/go-extend/exstrings"
 	"github.com/wentaojin/transferdb/common"
 	"github.com/wentaojin/transferdb/database/meta"
 	"github.com/wentaojin/transferdb/database/mysql"
 	"github.com/wentaojin/transferdb/database/oracle"
+	"github.com/wentaojin/transferdb/module/compare"
 	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
+	"strconv"
 	"strings"
 )
 
-type DBSummary struct {
-	Columns   []string
-	StringSet *strset.Set
-	Crc32Val  uint32
-	Rows      int64
-}
-
 type Report struct {
-	DataCompareMeta meta.DataCompareMeta `json:"data_compare_meta"`
-	Mysql           *mysql.MySQL         `json:"-"`
-	Oracle          *oracle.Oracle       `json:"-"`
-	OnlyCheckRows   bool                 `json:"only_check_rows"`
+	DataCompareMeta  meta.DataCompareMeta `json:"data_compare_meta"`
+	Mysql            *mysql.MySQL         `json:"-"`
+	Oracle           *oracle.Oracle       `json:"-"`
+	OnlyCheckRows    bool                 `json:"only_check_rows"`
+	ChecksumPushdown bool                 `json:"checksum_pushdown"`
+	BisectMinRows    int                  `json:"bisect_min_rows"`
+	BisectSteps      []string             `json:"bisect_steps"`
+	RowDiffs         []compare.RowDiff    `json:"-"`
+	FixSQLs          []string             `json:"-"`
+	// 在线校验，上游按 SCN 闪回查询
+	SourceSCN uint64 `json:"source_scn"`
+	// 数据行对比前字段值规范化
+	Normalizer *compare.ColumnNormalizer `json:"-"`
 }
 
-func NewReport(dataCompareMeta meta.DataCompareMeta, mysql *mysql.MySQL, oracle *oracle.Oracle, onlyCheckRows bool) *Report {
+func NewReport(dataCompareMeta meta.DataCompareMeta, mysql *mysql.MySQL, oracle *oracle.Oracle, onlyCheckRows, checksumPushdown bool, bisectMinRows int) *Report {
 	return &Report{
-		DataCompareMeta: dataCompareMeta,
-		Mysql:           mysql,
-		Oracle:          oracle,
-		OnlyCheckRows:   onlyCheckRows,
+		DataCompareMeta:  dataCompareMeta,
+		Mysql:            mysql,
+		Oracle:           oracle,
+		OnlyCheckRows:    onlyCheckRows,
+		ChecksumPushdown: checksumPushdown,
+		BisectMinRows:    bisectMinRows,
+	}
+}
+
+// genOracleTable 上游查询表，在线校验使用 AS OF SCN 闪回查询
+func (r *Report) genOracleTable() string {
+	if r.SourceSCN > 0 {
+		return common.StringsBuilder(r.DataCompareMeta.SchemaNameS, ".", r.DataCompareMeta.TableNameS, " AS OF SCN ", strconv.FormatUint(r.SourceSCN, 10))
+	}
+	return common.StringsBuilder(r.DataCompareMeta.SchemaNameS, ".", r.DataCompareMeta.TableNameS)
+}
+
+// genMySQLWhereRange 下游查询条件，字符字段比较统一转换 utf8mb4_bin 二进制排序规则，与上游 BINARY 比较语义一致
+func (r *Report) genMySQLWhereRange(whereRange string) string {
+	if r.Normalizer == nil {
+		return whereRange
+	}
+	stringColumns := make(map[string]bool)
+	for column, dataType := range r.Normalizer.DataTypes {
+		if isStringDataType(dataType) {
+			stringColumns[column] = true
+		}
 	}
+	return compare.GenBinaryCollateRange(whereRange, stringColumns)
 }
 
 func (r *Report) GenDBQuery() (oracleQuery string, mysqlQuery string) {
 	if r.DataCompareMeta.WhereColumn == "" {
 		oracleQuery = common.StringsBuilder(
-			"SELECT ", r.DataCompareMeta.ColumnDetailS, " FROM ", r.DataCompareMeta.SchemaNameS, ".", r.DataCompareMeta.TableNameS, " WHERE ", r.DataCompareMeta.WhereRange)
+			"SELECT ", r.DataCompareMeta.ColumnDetailS, " FROM ", r.genOracleTable(), " WHERE ", r.DataCompareMeta.WhereRange)
 
 		mysqlQuery = common.StringsBuilder(
-			"SELECT ", r.DataCompareMeta.ColumnDetailT, " FROM ", r.DataCompareMeta.SchemaNameT, ".", r.DataCompareMeta.TableNameT, " WHERE ", r.DataCompareMeta.WhereRange)
+			"SELECT ", r.DataCompareMeta.ColumnDetailT, " FROM ", r.DataCompareMeta.SchemaNameT, ".", r.DataCompareMeta.TableNameT, " WHERE ", r.genMySQLWhereRange(r.DataCompareMeta.WhereRange))
 	} else {
 		oracleQuery = common.StringsBuilder(
-			"SELECT ", r.DataCompareMeta.ColumnDetailS, " FROM ", r.DataCompareMeta.SchemaNameS, ".", r.DataCompareMeta.TableNameS, " WHERE ", r.DataCompareMeta.WhereRange,
+			"SELECT ", r.DataCompareMeta.ColumnDetailS, " FROM ", r.genOracleTable(), " WHERE ", r.DataCompareMeta.WhereRange,
 			" ORDER BY ", r.DataCompareMeta.WhereColumn, " DESC")
 
 		mysqlQuery = common.StringsBuilder(
-			"SELECT ", r.DataCompareMeta.ColumnDetailT, " FROM ", r.DataCompareMeta.SchemaNameT, ".", r.DataCompareMeta.TableNameT, " WHERE ", r.DataCompareMeta.WhereRange, " ORDER BY ", r.DataCompareMeta.WhereColumn, " DESC")
+			"SELECT ", r.DataCompareMeta.ColumnDetailT, " FROM ", r.DataCompareMeta.SchemaNameT, ".", r.DataCompareMeta.TableNameT, " WHERE ", r.genMySQLWhereRange(r.DataCompareMeta.WhereRange), " ORDER BY ", r.DataCompareMeta.WhereColumn, " DESC")
 	}
 	return
 }
 
+// GenDBChecksumQuery 数据块服务端聚合校验语句，算法见 compare.GenChecksumQuery
+func (r *Report) GenDBChecksumQuery() (oracleQuery string, mysqlQuery string, err error) {
+	oraColumns := compare.GenSelectColumnNames(r.DataCompareMeta.ColumnDetailS)
+	var dataTypes []string
+	for _, c := range oraColumns {
+		var dataType string
+		if r.Normalizer != nil {
+			dataType = r.Normalizer.DataTypes[common.StringUPPER(c)]
+		}
+		dataTypes = append(dataTypes, dataType)
+	}
+	oracleQuery, mysqlQuery, err = compare.GenChecksumQuery(oraColumns, compare.GenSelectColumnNames(r.DataCompareMeta.ColumnDetailT), dataTypes,
+		common.StringsBuilder("SELECT ", r.DataCompareMeta.ColumnDetailS, " FROM ", r.genOracleTable(), " WHERE ", r.DataCompareMeta.WhereRange),
+		common.StringsBuilder("SELECT ", r.DataCompareMeta.ColumnDetailT, " FROM ", r.DataCompareMeta.SchemaNameT, ".", r.DataCompareMeta.TableNameT, " WHERE ", r.genMySQLWhereRange(r.DataCompareMeta.WhereRange)))
+	if err != nil {
+		return "", "", fmt.Errorf("oracle table [%s] mysql table [%s] %v", r.DataCompareMeta.TableNameS, r.DataCompareMeta.TableNameT, err)
+	}
+	return oracleQuery, mysqlQuery, nil
+}
+
+// isChecksumPushdown 表字段数据类型均支持服务端聚合校验
+// 浮点数字段上下游输出格式不同且按容差对比，无法服务端聚合校验
+func (r *Report) isChecksumPushdown() bool {
+	if !r.ChecksumPushdown {
+		return false
+	}
+	if r.Normalizer == nil {
+		return true
+	}
+	if r.Normalizer.HasFloatColumn() {
+		return false
+	}
+	for _, dataType := range r.Normalizer.DataTypes {
+		if dataType == common.BuildInOracleDatatypeLong || dataType == common.BuildInOracleDatatypeLongRAW {
+			return false
+		}
+	}
+	return true
+}
+
 func (r *Report) CheckOracleRows(oracleQuery string) (int64, error) {
 	rows, err := r.Oracle.GetOracleTableActualRows(oracleQuery)
 	if err != nil {
@@ -165,11 +232,72 @@ func (r *Report) ReportCheckRows() (string, error) {
 	return fixSQLStr, nil
 }
 
+// ReportCheckChecksum 数据块服务端聚合校验，返回校验值是否一致
+func (r *Report) ReportCheckChecksum() (bool, error) {
+	oraChecksum, mysqlChecksum, err := r.genChecksum()
+	if err != nil {
+		return false, err
+	}
+	return strings.EqualFold(oraChecksum, mysqlChecksum), nil
+}
+
+// genChecksum 数据块服务端聚合校验值，格式 ROWS_COUNT:CHECKSUM1:CHECKSUM2
+func (r *Report) genChecksum() (string, string, error) {
+	errORA := &errgroup.Group{}
+	errMySQL := &errgroup.Group{}
+	oraChan := make(chan string, 1)
+	mysqlChan := make(chan string, 1)
+
+	oracleQuery, mysqlQuery, err := r.GenDBChecksumQuery()
+	if err != nil {
+		return "", "", err
+	}
+
+	errORA.Go(func() error {
+		checksum, err := r.Oracle.GetOracleTableChunkChecksum(oracleQuery)
+		if err != nil {
+			return fmt.Errorf("get oracle table chunk checksum failed: %v", err)
+		}
+		oraChan <- checksum
+		return nil
+	})
+
+	errMySQL.Go(func() error {
+		checksum, err := r.Mysql.GetMySQLTableChunkChecksum(mysqlQuery)
+		if err != nil {
+			return fmt.Errorf("get mysql table chunk checksum failed: %v", err)
+		}
+		mysqlChan <- checksum
+		return nil
+	})
+
+	if err := errORA.Wait(); err != nil {
+		return "", "", err
+	}
+	if err := errMySQL.Wait(); err != nil {
+		return "", "", err
+	}
+
+	oraChecksum := <-oraChan
+	mysqlChecksum := <-mysqlChan
+
+	zap.L().Info("oracle table chunk checksum",
+		zap.String("oracle schema", r.DataCompareMeta.SchemaNameS),
+		zap.String("mysql schema", r.DataCompareMeta.SchemaNameT),
+		zap.String("oracle table", r.DataCompareMeta.TableNameS),
+		zap.String("mysql table", r.DataCompareMeta.TableNameT),
+		zap.String("range", r.DataCompareMeta.WhereRange),
+		zap.String("oracle checksum", oraChecksum),
+		zap.String("mysql checksum", mysqlChecksum))
+
+	return oraChecksum, mysqlChecksum, nil
+}
+
 func (r *Report) ReportCheckCRC32() (string, error) {
 	errORA := &errgroup.Group{}
 	errMySQL := &errgroup.Group{}
-	oraChan := make(chan DBSummary, 1)
-	mysqlChan := make(chan DBSummary, 1)
+	oraChan := make(chan compare.DBSummary, 1)
+	mysqlChan := make(chan compare.DBSummary, 1)
 
 	oracleQuery, mysqlQuery := r.GenDBQuery()
 
@@ -178,7 +306,7 @@ func (r *Report) ReportCheckCRC32() (string, error) {
 		if err != nil {
 			return fmt.Errorf("get oracle data row strings failed: %v", err)
 		}
-		oraChan <- DBSummary{
+		oraChan <- compare.DBSummary{
 			Columns:   oraColumns,
 			StringSet: oraStringSet,
 			Crc32Val:  oraCrc32Val,
@@ -191,7 +319,7 @@ func (r *Report) ReportCheckCRC32() (string, error) {
 		if err != nil {
 			return fmt.Errorf("get mysql data row strings failed: %v", err)
 		}
-		mysqlChan <- DBSummary{
+		mysqlChan <- compare.DBSummary{
 			Columns:   mysqlColumns,
 			StringSet: mysqlStringSet,
 			Crc32Val:  mysqlCrc32Val,
@@ -209,6 +337,12 @@ func (r *Report) ReportCheckCRC32() (string, error) {
 	oraReport := <-oraChan
 	mysqlReport := <-mysqlChan
 
+	// 按字段类型规范化上下游字段值，避免格式差异导致误报
+	if r.Normalizer != nil {
+		oraReport = r.Normalizer.NormalizeSummary(oraReport)
+		mysqlReport = r.Normalizer.NormalizeSummary(mysqlReport)
+	}
+
 	// 数据相同
 	if oraReport.Crc32Val == mysqlReport.Crc32Val {
 		zap.L().Info("oracle table chunk diff equal",
@@ -223,6 +357,25 @@ func (r *Report) ReportCheckCRC32() (string, error) {
 		return "", nil
 	}
 
+	sourceMore := strset.Difference(oraReport.StringSet, mysqlReport.StringSet).List()
+	targetMore := strset.Difference(mysqlReport.StringSet, oraReport.StringSet).List()
+
+	// 浮点数字段按容差绝对差值对比
+	if r.Normalizer != nil {
+		sourceMore, targetMore = r.Normalizer.MatchFloatTolerance(oraReport.Columns, sourceMore, targetMore)
+		if len(sourceMore) == 0 && len(targetMore) == 0 {
+			zap.L().Info("oracle table chunk diff equal with float tolerance",
+				zap.String("oracle schema", r.DataCompareMeta.SchemaNameS),
+				zap.String("mysql schema", r.DataCompareMeta.SchemaNameT),
+				zap.String("oracle table", r.DataCompareMeta.TableNameS),
+				zap.String("mysql table", r.DataCompareMeta.TableNameT),
+				zap.Float64("float tolerance", r.Normalizer.FloatTolerance),
+				zap.String("oracle sql", oracleQuery),
+				zap.String("mysql sql", mysqlQuery))
+			return "", nil
+		}
+	}
+
 	zap.L().Info("oracle table chunk diff isn't equal",
 		zap.String("oracle schema", r.DataCompareMeta.SchemaNameS),
 		zap.String("mysql schema", r.DataCompareMeta.SchemaNameT),
@@ -233,6 +386,21 @@ func (r *Report) ReportCheckCRC32() (string, error) {
 		zap.String("oracle sql", oracleQuery),
 		zap.String("mysql sql", mysqlQuery))
 
+	// 存在主键或者唯一键按键值对比，否则按整行数据对比
+	keyIdx, err := r.genTableKeyColumns(oraReport.Columns)
+	if err != nil {
+		return "", err
+	}
+	if len(keyIdx) > 0 {
+		fixSQL, ok, err := r.genRowDiffFixSQL(oraReport, mysqlReport, sourceMore, targetMore, keyIdx)
+		if err != nil {
+			return "", err
+		}
+		if ok {
+			return fixSQL, nil
+		}
+	}
+
 	//上游存在，下游存在 Skip
 	//上游不存在，下游不存在 Skip
 	//上游存在，下游不存在 INSERT 下游
@@ -240,8 +408,12 @@ func (r *Report) ReportCheckCRC32() (string, error) {
 
 	var fixSQL strings.Builder
 
+	sourceFix, targetFix, err := r.genFixValues(sourceMore, targetMore)
+	if err != nil {
+		return "", err
+	}
+
 	// 判断下游数据是否多
-	targetMore := strset.Difference(mysqlReport.StringSet, oraReport.StringSet).List()
 	if len(targetMore) > 0 {
 		fixSQL.WriteString("/*\n")
 		fixSQL.WriteString(fmt.Sprintf(" mysql table [%s.%s] chunk [%s] data rows are more \n", r.DataCompareMeta.SchemaNameT, r.DataCompareMeta.TableNameT, r.DataCompareMeta.WhereRange))
@@ -254,33 +426,31 @@ func (r *Report) ReportCheckCRC32() (string, error) {
 				common.StringsBuilder("SELECT COUNT(1)", " FROM ", r.DataCompareMeta.SchemaNameS, ".", r.DataCompareMeta.TableNameS, " WHERE ", r.DataCompareMeta.WhereRange),
 				oraReport.Crc32Val},
 			{"MySQL", common.StringsBuilder(
-				"SELECT COUNT(1)", " FROM ", r.DataCompareMeta.SchemaNameT, ".", r.DataCompareMeta.TableNameS, " WHERE ", r.DataCompareMeta.WhereRange),
+				"SELECT COUNT(1)", " FROM ", r.DataCompareMeta.SchemaNameT, ".", r.DataCompareMeta.TableNameT, " WHERE ", r.DataCompareMeta.WhereRange),
 				mysqlReport.Crc32Val},
 		})
 		fixSQL.WriteString(fmt.Sprintf("%v\n", sw.Render()))
 		fixSQL.WriteString("*/\n")
-		deletePrefix := common.StringsBuilder("DELETE FROM ", r.DataCompareMeta.SchemaNameT, ".", r.DataCompareMeta.TableNameS, " WHERE ")
+		deletePrefix := common.StringsBuilder("DELETE FROM ", r.DataCompareMeta.SchemaNameT, ".", r.DataCompareMeta.TableNameT, " WHERE ")
 		for _, t := range targetMore {
-			var whereCond []string
-
 			// 计算字段列个数
-			colValues := strings.Split(t, ",")
+			colValues := compare.SplitRowValues(t)
 			if len(mysqlReport.Columns) != len(colValues) {
-				return "", fmt.Errorf("mysql schema [%s] table [%s] column counts [%d] isn't match values counts [%d]", r.DataCompareMeta.SchemaNameT, r.DataCompareMeta.TableNameS, len(mysqlReport.Columns), len(colValues))
+				return "", fmt.Errorf("mysql schema [%s] table [%s] column counts [%d] isn't match values counts [%d]", r.DataCompareMeta.SchemaNameT, r.DataCompareMeta.TableNameT, len(mysqlReport.Columns), len(colValues))
 			}
-			for i := 0; i < len(mysqlReport.Columns); i++ {
-				whereCond = append(whereCond, common.StringsBuilder(mysqlReport.Columns[i], "=", colValues[i]))
+			if whereCond, ok := r.genFixWhereCondition(mysqlReport.Columns, nil, colValues, targetFix); ok {
+				r.appendFixSQL(&fixSQL, common.StringsBuilder(deletePrefix, whereCond))
+			} else {
+				r.appendFixSkip(&fixSQL, mysqlReport.Columns, colValues)
 			}
-
-			fixSQL.WriteString(fmt.Sprintf("%v;\n", common.StringsBuilder(deletePrefix, exstrings.Join(whereCond, " AND "))))
+			r.appendRowDiff(common.CompareDiffTypeExtra, mysqlReport.Columns, nil, nil, colValues)
 		}
 	}
 
 	// 判断上游数据是否多
-	sourceMore := strset.Difference(oraReport.StringSet, mysqlReport.StringSet).List()
 	if len(sourceMore) > 0 {
 		fixSQL.WriteString("/*\n")
-		fixSQL.WriteString(fmt.Sprintf(" mysql table [%s.%s] chunk [%s] data rows are less \n", r.DataCompareMeta.SchemaNameT, r.DataCompareMeta.TableNameS, r.DataCompareMeta.WhereRange))
+		fixSQL.WriteString(fmt.Sprintf(" mysql table [%s.%s] chunk [%s] data rows are less \n", r.DataCompareMeta.SchemaNameT, r.DataCompareMeta.TableNameT, r.DataCompareMeta.WhereRange))
 
 		sw := table.NewWriter()
 		sw.SetStyle(table.StyleLight)
@@ -290,14 +460,23 @@ func (r *Report) ReportCheckCRC32() (string, error) {
 				common.StringsBuilder("SELECT COUNT(1)", " FROM ", r.DataCompareMeta.SchemaNameS, ".", r.DataCompareMeta.TableNameS, " WHERE ", r.DataCompareMeta.WhereRange),
 				oraReport.Crc32Val},
 			{"MySQL", common.StringsBuilder(
-				"SELECT COUNT(1)", " FROM ", r.DataCompareMeta.SchemaNameT, ".", r.DataCompareMeta.TableNameS, " WHERE ", r.DataCompareMeta.WhereRange),
+				"SELECT COUNT(1)", " FROM ", r.DataCompareMeta.SchemaNameT, ".", r.DataCompareMeta.TableNameT, " WHERE ", r.DataCompareMeta.WhereRange),
 				mysqlReport.Crc32Val},
 		})
 		fixSQL.WriteString(fmt.Sprintf("%v\n", sw.Render()))
 		fixSQL.WriteString("*/\n")
-		insertPrefix := common.StringsBuilder("INSERT INTO ", r.DataCompareMeta.SchemaNameT, ".", r.DataCompareMeta.TableNameS, " (", strings.Join(oraReport.Columns, ","), ") VALUES (")
+		insertPrefix := common.StringsBuilder("INSERT INTO ", r.DataCompareMeta.SchemaNameT, ".", r.DataCompareMeta.TableNameT, " (")
 		for _, s := range sourceMore {
-			fixSQL.WriteString(fmt.Sprintf("%v;\n", common.StringsBuilder(insertPrefix, s, ")")))
+			colValues := compare.SplitRowValues(s)
+			if len(colValues) != len(mysqlReport.Columns) {
+				return "", fmt.Errorf("oracle schema [%s] table [%s] column counts [%d] isn't match values counts [%d]", r.DataCompareMeta.SchemaNameS, r.DataCompareMeta.TableNameS, len(mysqlReport.Columns), len(colValues))
+			}
+			if fixColumns, fixValues, ok := r.genFixColumnValues(mysqlReport.Columns, colValues, sourceFix); ok {
+				r.appendFixSQL(&fixSQL, common.StringsBuilder(insertPrefix, strings.Join(fixColumns, ","), ") VALUES (", strings.Join(fixValues, ","), ")"))
+			} else {
+				r.appendFixSkip(&fixSQL, mysqlReport.Columns, colValues)
+			}
+			r.appendRowDiff(common.CompareDiffTypeMissing, mysqlReport.Columns, nil, colValues, nil)
 		}
 	}
 	return fixSQL.String(), nil
@@ -307,9 +486,46 @@ func (r *Report) Report() (string, error) {
 	if r.OnlyCheckRows {
 		return r.ReportCheckRows()
 	}
+	// 服务端聚合校验一致直接跳过，不一致二分定位差异范围或者拉取数据行对比，校验失败回退数据行对比
+	if r.isChecksumPushdown() {
+		if r.DataCompareMeta.WhereColumn != "" && r.BisectMinRows > 0 {
+			fixSQL, err := r.ReportCheckBisect()
+			if err == nil {
+				return fixSQL, nil
+			}
+			zap.L().Warn("oracle table chunk bisect failed, fallback row data compare",
+				zap.String("oracle schema", r.DataCompareMeta.SchemaNameS),
+				zap.String("oracle table", r.DataCompareMeta.TableNameS),
+				zap.String("range", r.DataCompareMeta.WhereRange),
+				zap.Error(err))
+		} else {
+			isEqual, err := r.ReportCheckChecksum()
+			if err != nil {
+				zap.L().Warn("oracle table chunk checksum pushdown failed, fallback row data compare",
+					zap.String("oracle schema", r.DataCompareMeta.SchemaNameS),
+					zap.String("oracle table", r.DataCompareMeta.TableNameS),
+					zap.String("range", r.DataCompareMeta.WhereRange),
+					zap.Error(err))
+			} else if isEqual {
+				return "", nil
+			}
+		}
+	}
 	return r.ReportCheckCRC32()
 }
 
+// isStringDataType 上游字符数据类型
+func isStringDataType(dataType string) bool {
+	switch dataType {
+	case common.BuildInOracleDatatypeChar, common.BuildInOracleDatatypeNchar, common.BuildInOracleDatatypeCharacter,
+		common.BuildInOracleDatatypeVarchar2, common.BuildInOracleDatatypeNvarchar2, common.BuildInOracleDatatypeVarchar,
+		common.BuildInOracleDatatypeNcharVarying:
+		return true
+	default:
+		return false
+	}
+}
+
 func (r *Report) String() string {
 	jsonStr, _ := json.Marshal(r)
 	return string(jsonStr)
@@ -0,0 +1,113 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package o2m
+
+import (
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/database/meta"
+	"github.com/wentaojin/transferdb/module/compare"
+	"go.uber.org/zap"
+	"math"
+	"math/rand"
+	"time"
+)
+
+func (r *O2M) isSampleCompare() bool {
+	return r.cfg.DiffConfig.SamplePercent > 0 && r.cfg.DiffConfig.SamplePercent < 100
+}
+
+// sampleCompareMetas 随机选取百分比数据块，最少 1 个，返回抽样以及未抽样数据块
+// 抽样粒度为数据块，未使用 Oracle SAMPLE BLOCK，上下游无法按相同数据块抽样对比
+func sampleCompareMetas(compareMetas []meta.DataCompareMeta, samplePercent int) ([]meta.DataCompareMeta, []meta.DataCompareMeta) {
+	if len(compareMetas) == 0 {
+		return compareMetas, nil
+	}
+	sampleNums := int(math.Ceil(float64(len(compareMetas)*samplePercent) / 100))
+
+	rd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	var sampleMetas, skipMetas []meta.DataCompareMeta
+	for i, idx := range rd.Perm(len(compareMetas)) {
+		if i < sampleNums {
+			sampleMetas = append(sampleMetas, compareMetas[idx])
+		} else {
+			skipMetas = append(skipMetas, compareMetas[idx])
+		}
+	}
+	return sampleMetas, skipMetas
+}
+
+// escalateSampleCompare 抽样数据块存在不一致，升级校验剩余数据块，并记录抽样置信度
+func (r *O2M) escalateSampleCompare(f, df *compare.File, tableName string, totalNums, sampleNums int, skipMetas []meta.DataCompareMeta, normalizer *compare.ColumnNormalizer) error {
+	if !r.isSampleCompare() || totalNums == 0 {
+		return nil
+	}
+	failedNums, err := meta.NewDataCompareMetaModel(r.metaDB).CountsErrorDataCompareMeta(r.ctx, &meta.DataCompareMeta{
+		DBTypeS:     r.cfg.DBTypeS,
+		DBTypeT:     r.cfg.DBTypeT,
+		SchemaNameS: r.cfg.OracleConfig.SchemaName,
+		TableNameS:  tableName,
+		TaskMode:    r.cfg.TaskMode,
+		TaskStatus:  common.TaskStatusFailed,
+	})
+	if err != nil {
+		return err
+	}
+
+	isEscalate := failedNums > 0 && len(skipMetas) > 0
+	sampleDetail := fmt.Sprintf("sample chunks [%d/%d] percent [%d], mismatched chunks [%d], detect probability: 1 differ chunk [%.2f%%] 1%% differ chunks [%.2f%%] 5%% differ chunks [%.2f%%], escalate full compare [%v]",
+		sampleNums, totalNums, r.cfg.DiffConfig.SamplePercent, failedNums,
+		sampleDetectProbability(totalNums, sampleNums, 1)*100,
+		sampleDetectProbability(totalNums, sampleNums, int(math.Ceil(float64(totalNums)*0.01)))*100,
+		sampleDetectProbability(totalNums, sampleNums, int(math.Ceil(float64(totalNums)*0.05)))*100,
+		isEscalate)
+
+	zap.L().Info("compare table sample",
+		zap.String("schema", r.cfg.OracleConfig.SchemaName),
+		zap.String("table", tableName),
+		zap.String("sample detail", sampleDetail))
+
+	if isEscalate {
+		if err = r.compareChunks(f, df, skipMetas, normalizer); err != nil {
+			return err
+		}
+	}
+
+	return meta.NewWaitSyncMetaModel(r.metaDB).UpdateWaitSyncMeta(r.ctx, &meta.WaitSyncMeta{
+		DBTypeS:     r.cfg.DBTypeS,
+		DBTypeT:     r.cfg.DBTypeT,
+		SchemaNameS: r.cfg.OracleConfig.SchemaName,
+		TableNameS:  tableName,
+		TaskMode:    r.cfg.TaskMode,
+	}, map[string]interface{}{
+		"SampleDetail": sampleDetail,
+	})
+}
+
+// sampleDetectProbability 超几何分布，总数 total 数据块存在 differ 个不一致数据块，随机抽样 sample 个至少命中 1 个的概率
+func sampleDetectProbability(total, sample, differ int) float64 {
+	if differ <= 0 || total <= 0 {
+		return 0
+	}
+	if sample+differ > total {
+		return 1
+	}
+	missProb := 1.0
+	for i := 0; i < sample; i++ {
+		missProb *= float64(total-differ-i) / float64(total-i)
+	}
+	return 1 - missProb
+}
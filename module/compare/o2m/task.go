@@ -26,6 +26,7 @@ import (
 	"github.com/wentaojin/transferdb/module/check"
 	"github.com/wentaojin/transferdb/module/check/o2m"
 	"go.uber.org/zap"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -139,20 +140,30 @@ func (t *Task) AdjustDBSelectColumn() (sourceColumnInfo string, targetColumnInfo
 		return sourceColumnInfo, targetColumnInfo, err
 	}
 
+	emptyStringAs := t.cfg.AppConfig.GetEmptyStringAs(t.sourceTableName)
 	for _, colsInfo := range columnInfo {
 		colName := colsInfo["COLUMN_NAME"]
 		switch strings.ToUpper(colsInfo["DATA_TYPE"]) {
 		// 数字
-		case "NUMBER":
-			sourceColumnInfos = append(sourceColumnInfos, common.StringsBuilder("DECODE(SUBSTR(", colName, ",1,1),'.','0' || ", colName, ",", colName, ") AS ", colName))
-			targetColumnInfos = append(targetColumnInfos, common.StringsBuilder("CAST(0 + CAST(", colName, " AS CHAR) AS CHAR) AS ", colName))
-		case "DECIMAL", "DEC", "DOUBLE PRECISION", "FLOAT", "INTEGER", "INT", "REAL", "NUMERIC", "BINARY_FLOAT", "BINARY_DOUBLE", "SMALLINT":
-			sourceColumnInfos = append(sourceColumnInfos, common.StringsBuilder("DECODE(SUBSTR(", colName, ",1,1),'.','0' || ", colName, ",", colName, ") AS ", colName))
-			targetColumnInfos = append(targetColumnInfos, common.StringsBuilder("CAST(0 + CAST(", colName, " AS CHAR) AS CHAR) AS ", colName))
+		// 上游小数补齐前导 0（包括负数），下游去除小数末尾 0，避免 0 + 转换 DOUBLE 丢失精度
+		case "NUMBER", "DECIMAL", "DEC", "DOUBLE PRECISION", "FLOAT", "INTEGER", "INT", "REAL", "NUMERIC", "BINARY_FLOAT", "BINARY_DOUBLE", "SMALLINT":
+			sourceColumnInfos = append(sourceColumnInfos, common.StringsBuilder("REGEXP_REPLACE(TO_CHAR(", colName, "),'^(-?)\\.','\\10.') AS ", colName))
+			targetColumnInfos = append(targetColumnInfos, common.StringsBuilder("IF(INSTR(CAST(", colName, " AS CHAR),'.') > 0,TRIM(TRAILING '.' FROM TRIM(TRAILING '0' FROM CAST(", colName, " AS CHAR))),CAST(", colName, " AS CHAR)) AS ", colName))
 		// 字符
 		case "BFILE", "CHARACTER", "LONG", "NCHAR VARYING", "ROWID", "UROWID", "VARCHAR", "CHAR", "NCHAR", "NVARCHAR2", "NCLOB", "CLOB":
-			sourceColumnInfos = append(sourceColumnInfos, common.StringsBuilder("NVL(", colName, ",'') AS ", colName))
-			targetColumnInfos = append(targetColumnInfos, common.StringsBuilder("IFNULL(", colName, ",'') AS ", colName))
+			// 定长字符去除末尾填充空格，下游 CHAR 查询不保留末尾空格
+			switch strings.ToUpper(colsInfo["DATA_TYPE"]) {
+			case "CHARACTER", "CHAR", "NCHAR":
+				sourceColumnInfos = append(sourceColumnInfos, common.StringsBuilder("NVL(RTRIM(", colName, "),'') AS ", colName))
+			default:
+				sourceColumnInfos = append(sourceColumnInfos, common.StringsBuilder("NVL(", colName, ",'') AS ", colName))
+			}
+			// 上游 NULL 与空字符串不区分，下游按 empty-string-as 规则预期 NULL 或者空字符串，不符合规则的值转换成差异标识
+			if emptyStringAs == common.EmptyStringAsEmpty {
+				targetColumnInfos = append(targetColumnInfos, common.StringsBuilder("IF(", colName, " IS NULL,'", common.CompareEmptyStringMismatch, "',IF(CHAR_LENGTH(", colName, ") = 0,NULL,", colName, ")) AS ", colName))
+			} else {
+				targetColumnInfos = append(targetColumnInfos, common.StringsBuilder("IF(CHAR_LENGTH(", colName, ") = 0,'", common.CompareEmptyStringMismatch, "',", colName, ") AS ", colName))
+			}
 		case "XMLTYPE":
 			sourceColumnInfos = append(sourceColumnInfos, common.StringsBuilder("NVL(XMLSERIALIZE(CONTENT ", colName, " AS CLOB),'') AS ", colName))
 			targetColumnInfos = append(targetColumnInfos, common.StringsBuilder("IFNULL(", colName, ",'') AS ", colName))
@@ -170,8 +181,16 @@ func (t *Task) AdjustDBSelectColumn() (sourceColumnInfo string, targetColumnInfo
 				sourceColumnInfos = append(sourceColumnInfos, common.StringsBuilder("TO_CHAR(", colName, ") AS ", colName))
 				targetColumnInfos = append(targetColumnInfos, colName)
 			} else if strings.Contains(colsInfo["DATA_TYPE"], "TIMESTAMP") {
-				sourceColumnInfos = append(sourceColumnInfos, common.StringsBuilder("TO_CHAR(", colName, ",'yyyy-MM-dd HH24:mi:ss') AS ", colName))
-				targetColumnInfos = append(targetColumnInfos, common.StringsBuilder("FROM_UNIXTIME(UNIX_TIMESTAMP(", colName, "),'%Y-%m-%d %H:%i:%s') AS ", colName))
+				// 时间精度大于 0 上下游按精度舍入小数秒后输出，WITH TIME ZONE 去除时区按本地时间对比，与数据迁移一致
+				switch {
+				case t.cfg.DiffConfig.TimePrecision > 0:
+					precision := strconv.Itoa(t.cfg.DiffConfig.TimePrecision)
+					sourceColumnInfos = append(sourceColumnInfos, common.StringsBuilder("TO_CHAR(CAST(", colName, " AS TIMESTAMP(", precision, ")),'yyyy-MM-dd HH24:mi:ss.FF", precision, "') AS ", colName))
+					targetColumnInfos = append(targetColumnInfos, common.StringsBuilder("SUBSTRING(DATE_FORMAT(CAST(", colName, " AS DATETIME(", precision, ")),'%Y-%m-%d %H:%i:%s.%f'),1,", strconv.Itoa(20+t.cfg.DiffConfig.TimePrecision), ") AS ", colName))
+				default:
+					sourceColumnInfos = append(sourceColumnInfos, common.StringsBuilder("TO_CHAR(", colName, ",'yyyy-MM-dd HH24:mi:ss') AS ", colName))
+					targetColumnInfos = append(targetColumnInfos, common.StringsBuilder("FROM_UNIXTIME(UNIX_TIMESTAMP(", colName, "),'%Y-%m-%d %H:%i:%s') AS ", colName))
+				}
 			} else {
 				sourceColumnInfos = append(sourceColumnInfos, colName)
 				targetColumnInfos = append(targetColumnInfos, colName)
@@ -189,7 +208,9 @@ func (t *Task) AdjustDBSelectColumn() (sourceColumnInfo string, targetColumnInfo
 // 第一优先级配置文件指定字段【忽略是否存在索引】
 // 第二优先级任意取某个主键/唯一索引 NUMBER 字段
 // 第三优先级取某个唯一性 DISTINCT 高的索引 NUMBER 字段
-// 如果表没有索引 NUMBER 字段或者没有 NUMBER 字段则报错
+// 第四优先级取主键/唯一键/索引字符、日期或者联合字段，逗号分隔
+// 第五优先级表无可用索引，取 DISTINCT 高的数字、字符或者日期字段（切分以及校验全表扫描）
+// 下游不存在 ROWID，无法按上游 ROWID 范围映射下游范围，无可用字段整表单个 chunk 校验
 func (t *Task) FilterDBWhereColumn() (string, error) {
 	// 以参数配置文件 indexFiledName 忽略是否存在索引，需要人工确认
 	// 字段筛选优先级：配置文件优先级 > PK > UK > Index > Distinct Value
@@ -202,15 +223,13 @@ func (t *Task) FilterDBWhereColumn() (string, error) {
 
 	// number 数据类型字段
 	var integerColumns []string
+	columnTypes := make(map[string]string)
 	for _, colsInfo := range columnInfo {
 		// 数字
 		if strings.EqualFold(strings.ToUpper(colsInfo["DATA_TYPE"]), "NUMBER") {
 			integerColumns = append(integerColumns, colsInfo["COLUMN_NAME"])
 		}
-	}
-
-	if len(integerColumns) == 0 {
-		return "", fmt.Errorf("oracle schema [%s] table [%s] number column isn't exist, not support, pelase exclude skip or add number column index", t.cfg.OracleConfig.SchemaName, t.sourceTableName)
+		columnTypes[strings.ToUpper(colsInfo["COLUMN_NAME"])] = strings.ToUpper(colsInfo["DATA_TYPE"])
 	}
 
 	// PK、UK
@@ -316,20 +335,14 @@ func (t *Task) FilterDBWhereColumn() (string, error) {
 		}
 	}
 
-	// 如果表不存在主键/唯一键/唯一索引，直接返回报错中断，因为可能导致数据校验不准
-	if len(puConstraints) == 0 && len(ukIndex) == 0 {
-		return "", fmt.Errorf("oracle schema [%s] table [%s] pk/uk/unique index isn't exist, it's not support, please skip", t.cfg.OracleConfig.SchemaName, t.sourceTableName)
-	}
-
 	// 普通索引、联合主键/联合唯一键/联合唯一索引，选择 number distinct 高的字段
 	indexArr = append(indexArr, nonUkIndex...)
 
-	orderCols, err := t.oracle.GetOracleTableColumnDistinctValue(t.cfg.OracleConfig.SchemaName, t.sourceTableName, integerColumns)
-	if err != nil {
-		return "", fmt.Errorf("get oracle schema [%s] table [%s] column distinct values failed: %v", t.cfg.OracleConfig.SchemaName, t.sourceTableName, err)
-	}
-
-	if len(indexArr) > 0 {
+	if len(indexArr) > 0 && len(integerColumns) > 0 {
+		orderCols, err := t.oracle.GetOracleTableColumnDistinctValue(t.cfg.OracleConfig.SchemaName, t.sourceTableName, integerColumns)
+		if err != nil {
+			return "", fmt.Errorf("get oracle schema [%s] table [%s] column distinct values failed: %v", t.cfg.OracleConfig.SchemaName, t.sourceTableName, err)
+		}
 		for _, column := range orderCols {
 			for _, index := range indexArr {
 				if strings.EqualFold(column, strings.Split(index, ",")[0]) {
@@ -338,7 +351,52 @@ func (t *Task) FilterDBWhereColumn() (string, error) {
 			}
 		}
 	}
-	return "", fmt.Errorf("oracle schema [%s] table [%s] pk/uk/index number datatype column isn't exist, please skip or fixed", t.cfg.OracleConfig.SchemaName, t.sourceTableName)
+
+	// 不存在 NUMBER 索引字段，按 PK > UK > 唯一索引 > 普通索引顺序选择字符、日期或者联合索引字段，索引顺序采样切分 chunk
+	var keyArr []string
+	for _, pu := range puConstraints {
+		keyArr = append(keyArr, pu.ConstraintColumn)
+	}
+	keyArr = append(keyArr, ukIndex...)
+	keyArr = append(keyArr, nonUkIndex...)
+	for _, key := range keyArr {
+		columns := strings.Split(key, ",")
+		isSupport := true
+		for _, col := range columns {
+			if !isIndexSampleDataType(columnTypes[strings.ToUpper(col)]) {
+				isSupport = false
+				break
+			}
+		}
+		if isSupport {
+			return strings.ToUpper(key), nil
+		}
+	}
+
+	// 不存在可用索引字段，选择 DISTINCT 高的非索引字段切分
+	var sampleColumns []string
+	for _, colsInfo := range columnInfo {
+		if isIndexSampleDataType(colsInfo["DATA_TYPE"]) {
+			sampleColumns = append(sampleColumns, colsInfo["COLUMN_NAME"])
+		}
+	}
+	if len(sampleColumns) > 0 {
+		orderCols, err := t.oracle.GetOracleTableColumnDistinctValue(t.cfg.OracleConfig.SchemaName, t.sourceTableName, sampleColumns)
+		if err != nil {
+			return "", fmt.Errorf("get oracle schema [%s] table [%s] column distinct values failed: %v", t.cfg.OracleConfig.SchemaName, t.sourceTableName, err)
+		}
+		if len(orderCols) > 0 {
+			zap.L().Warn("oracle table usable index column isn't exist, chunk split by non-index column with full table scan",
+				zap.String("schema", t.cfg.OracleConfig.SchemaName),
+				zap.String("table", t.sourceTableName),
+				zap.String("column", orderCols[0]))
+			return common.StringUPPER(orderCols[0]), nil
+		}
+	}
+	zap.L().Warn("oracle table usable column isn't exist, compare whole table as single chunk",
+		zap.String("schema", t.cfg.OracleConfig.SchemaName),
+		zap.String("table", t.sourceTableName))
+	return "", nil
 }
 
 func (t *Task) IsPartitionTable() (string, error) {
@@ -0,0 +1,230 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package compare
+
+import (
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"strings"
+)
+
+// GenBinaryCollateRange 查询条件字符字段比较运算转换 CONVERT(col USING utf8mb4) COLLATE utf8mb4_bin，忽略引号内字符
+func GenBinaryCollateRange(whereRange string, stringColumns map[string]bool) string {
+	if len(stringColumns) == 0 {
+		return whereRange
+	}
+	isIdentChar := func(c byte) bool {
+		return c == '_' || c == '$' || c == '#' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+	}
+
+	var (
+		b       strings.Builder
+		inQuote bool
+	)
+	for i := 0; i < len(whereRange); {
+		c := whereRange[i]
+		if c == '\'' {
+			inQuote = !inQuote
+		}
+		if inQuote || c == '\'' || !isIdentChar(c) {
+			b.WriteByte(c)
+			i++
+			continue
+		}
+		j := i
+		for j < len(whereRange) && isIdentChar(whereRange[j]) {
+			j++
+		}
+		ident := whereRange[i:j]
+		k := j
+		for k < len(whereRange) && whereRange[k] == ' ' {
+			k++
+		}
+		if stringColumns[common.StringUPPER(ident)] && k < len(whereRange) && strings.IndexByte("<>=!", whereRange[k]) >= 0 {
+			b.WriteString(common.StringsBuilder("CONVERT(", ident, " USING utf8mb4) COLLATE utf8mb4_bin"))
+		} else {
+			b.WriteString(ident)
+		}
+		i = j
+	}
+	return b.String()
+}
+
+// GenSelectColumnNames 查询字段别名列表，忽略括号以及引号内逗号
+func GenSelectColumnNames(columnDetail string) []string {
+	var (
+		columns  []string
+		items    []string
+		depth    int
+		inQuote  bool
+		startIdx int
+	)
+	for i, c := range columnDetail {
+		switch {
+		case c == '\'':
+			inQuote = !inQuote
+		case inQuote:
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == ',' && depth == 0:
+			items = append(items, columnDetail[startIdx:i])
+			startIdx = i + 1
+		}
+	}
+	items = append(items, columnDetail[startIdx:])
+
+	for _, item := range items {
+		// 字段 colName 或者表达式 expr AS colName，均以最后一个词为字段名
+		fields := strings.Fields(item)
+		if len(fields) == 0 {
+			continue
+		}
+		columns = append(columns, fields[len(fields)-1])
+	}
+	return columns
+}
+
+// GenIndexSampleRanges 按采样边界值生成 chunk 范围条件，无边界值全表单个 chunk
+// 首个 chunk <= 首个边界，末尾 chunk > 末尾边界，字段存在 NULL 数据单独 chunk
+func GenIndexSampleRanges(columns []string, boundaries [][]string) []string {
+	if len(boundaries) == 0 {
+		return []string{"1 = 1"}
+	}
+	var notNulls, isNulls, whereRanges []string
+	for _, col := range columns {
+		notNulls = append(notNulls, common.StringsBuilder(col, " IS NOT NULL"))
+		isNulls = append(isNulls, common.StringsBuilder(col, " IS NULL"))
+	}
+	guard := ""
+	if len(columns) > 1 {
+		guard = common.StringsBuilder(strings.Join(notNulls, " AND "), " AND ")
+	}
+
+	whereRanges = append(whereRanges, common.StringsBuilder(guard, genIndexSampleLessEqual(columns, boundaries[0])))
+	for i := 1; i < len(boundaries); i++ {
+		whereRanges = append(whereRanges, common.StringsBuilder(guard,
+			"(", genIndexSampleGreater(columns, boundaries[i-1]), ") AND ", genIndexSampleLessEqual(columns, boundaries[i])))
+	}
+	whereRanges = append(whereRanges, common.StringsBuilder(guard, "(", genIndexSampleGreater(columns, boundaries[len(boundaries)-1]), ")"))
+	whereRanges = append(whereRanges, strings.Join(isNulls, " OR "))
+	return whereRanges
+}
+
+// genIndexSampleGreater 字段组合大于边界值 (c1,c2) > (v1,v2)
+func genIndexSampleGreater(columns, values []string) string {
+	var ors []string
+	for i := range columns {
+		var ands []string
+		for j := 0; j < i; j++ {
+			ands = append(ands, common.StringsBuilder(columns[j], " = ", values[j]))
+		}
+		ands = append(ands, common.StringsBuilder(columns[i], " > ", values[i]))
+		ors = append(ors, strings.Join(ands, " AND "))
+	}
+	if len(ors) == 1 {
+		return ors[0]
+	}
+	return common.StringsBuilder("(", strings.Join(ors, ") OR ("), ")")
+}
+
+// genIndexSampleLessEqual 字段组合小于等于边界值 (c1,c2) <= (v1,v2)，联合字段依赖非 NULL 条件
+func genIndexSampleLessEqual(columns, values []string) string {
+	if len(columns) == 1 {
+		return common.StringsBuilder(columns[0], " <= ", values[0])
+	}
+	return common.StringsBuilder("NOT (", genIndexSampleGreater(columns, values), ")")
+}
+
+// GenChecksumQuery 上下游数据块服务端聚合校验语句，dataTypes 为 Oracle 字段数据类型，与 oraColumns 一一对应
+// 字段逐个计算 MD5 后拼接计算行 MD5，取前 16 位拆分两段 32 位整数求和，NULL 与空值统一 '0'
+// 1、字符数据统一转换 AL32UTF8/utf8mb4 计算，避免上下游字符集不同导致误判
+// 2、RAW/BLOB 按字节计算，CLOB/NCLOB/XMLTYPE 使用 DBMS_CRYPTO.HASH 计算（需 DBMS_CRYPTO 执行权限）
+// 3、LONG/LONG RAW 无法参与表达式运算，不支持服务端聚合校验
+func GenChecksumQuery(oraColumns, mysqlColumns, dataTypes []string, oraSubQuery, mysqlSubQuery string) (string, string, error) {
+	if len(oraColumns) != len(mysqlColumns) {
+		return "", "", fmt.Errorf("oracle column counts [%d] isn't equal mysql column counts [%d]", len(oraColumns), len(mysqlColumns))
+	}
+
+	var oraHashes, mysqlHashes []string
+	for i, c := range oraColumns {
+		oraHash, mysqlHash, err := GenColumnChecksumExpr(c, mysqlColumns[i], dataTypes[i])
+		if err != nil {
+			return "", "", err
+		}
+		oraHashes = append(oraHashes, oraHash)
+		mysqlHashes = append(mysqlHashes, mysqlHash)
+	}
+
+	// 字段 MD5 拼接超过 VARCHAR2 4000 长度限制，按分组计算 MD5 后再拼接
+	for len(oraHashes) > checksumColumnGroupSize {
+		var oraGroups, mysqlGroups []string
+		for i := 0; i < len(oraHashes); i += checksumColumnGroupSize {
+			end := i + checksumColumnGroupSize
+			if end > len(oraHashes) {
+				end = len(oraHashes)
+			}
+			oraGroups = append(oraGroups, common.StringsBuilder("RAWTOHEX(STANDARD_HASH(", strings.Join(oraHashes[i:end], " || "), ",'MD5'))"))
+			mysqlGroups = append(mysqlGroups, common.StringsBuilder("UPPER(MD5(CONCAT(", strings.Join(mysqlHashes[i:end], ","), ")))"))
+		}
+		oraHashes = oraGroups
+		mysqlHashes = mysqlGroups
+	}
+
+	oracleQuery := common.StringsBuilder(
+		"SELECT COUNT(1) AS ROWS_COUNT,",
+		" NVL(SUM(TO_NUMBER(SUBSTR(H,1,8),'XXXXXXXX')),0) AS CHECKSUM1,",
+		" NVL(SUM(TO_NUMBER(SUBSTR(H,9,8),'XXXXXXXX')),0) AS CHECKSUM2",
+		" FROM (SELECT RAWTOHEX(STANDARD_HASH(", strings.Join(oraHashes, " || "), ",'MD5')) AS H",
+		" FROM (", oraSubQuery, "))")
+
+	mysqlQuery := common.StringsBuilder(
+		"SELECT COUNT(1) AS ROWS_COUNT,",
+		" IFNULL(SUM(CAST(CONV(SUBSTRING(H,1,8),16,10) AS UNSIGNED)),0) AS CHECKSUM1,",
+		" IFNULL(SUM(CAST(CONV(SUBSTRING(H,9,8),16,10) AS UNSIGNED)),0) AS CHECKSUM2",
+		" FROM (SELECT MD5(CONCAT(", strings.Join(mysqlHashes, ","), ")) AS H",
+		" FROM (", mysqlSubQuery, ") t1) t2")
+	return oracleQuery, mysqlQuery, nil
+}
+
+// checksumColumnGroupSize 字段 MD5 拼接分组大小，32 * 100 不超过 VARCHAR2 4000 长度
+const checksumColumnGroupSize = 100
+
+// GenColumnChecksumExpr 按 Oracle 字段数据类型生成上下游字段 MD5 表达式，统一大写十六进制
+func GenColumnChecksumExpr(oraColumn, mysqlColumn, dataType string) (string, string, error) {
+	switch dataType {
+	case common.BuildInOracleDatatypeLong, common.BuildInOracleDatatypeLongRAW:
+		return "", "", fmt.Errorf("column [%s] datatype [%s] not support checksum pushdown", oraColumn, dataType)
+	case common.BuildInOracleDatatypeRaw:
+		return common.StringsBuilder("CASE WHEN ", oraColumn, " IS NULL THEN '0' ELSE RAWTOHEX(STANDARD_HASH(", oraColumn, ",'MD5')) END"),
+			common.StringsBuilder("IF(", mysqlColumn, " IS NULL OR LENGTH(", mysqlColumn, ") = 0,'0',UPPER(MD5(", mysqlColumn, ")))"), nil
+	case common.BuildInOracleDatatypeBlob:
+		return common.StringsBuilder("CASE WHEN ", oraColumn, " IS NULL OR DBMS_LOB.GETLENGTH(", oraColumn, ") = 0 THEN '0' ELSE RAWTOHEX(DBMS_CRYPTO.HASH(", oraColumn, ",2)) END"),
+			common.StringsBuilder("IF(", mysqlColumn, " IS NULL OR LENGTH(", mysqlColumn, ") = 0,'0',UPPER(MD5(", mysqlColumn, ")))"), nil
+	case common.BuildInOracleDatatypeClob, common.BuildInOracleDatatypeNclob, common.BuildInOracleDatatypeXmltype:
+		// DBMS_CRYPTO.HASH 计算 CLOB 前统一转换 AL32UTF8
+		return common.StringsBuilder("CASE WHEN ", oraColumn, " IS NULL OR DBMS_LOB.GETLENGTH(", oraColumn, ") = 0 THEN '0' ELSE RAWTOHEX(DBMS_CRYPTO.HASH(", oraColumn, ",2)) END"),
+			common.StringsBuilder("IF(", mysqlColumn, " IS NULL OR LENGTH(", mysqlColumn, ") = 0,'0',UPPER(MD5(CONVERT(", mysqlColumn, " USING utf8mb4))))"), nil
+	case common.BuildInOracleDatatypeNchar, common.BuildInOracleDatatypeNvarchar2, common.BuildInOracleDatatypeNcharVarying:
+		// 国家字符集先转换数据库字符集
+		return common.StringsBuilder("CASE WHEN ", oraColumn, " IS NULL THEN '0' ELSE RAWTOHEX(STANDARD_HASH(CONVERT(TO_CHAR(", oraColumn, "),'AL32UTF8'),'MD5')) END"),
+			common.StringsBuilder("IF(", mysqlColumn, " IS NULL OR LENGTH(", mysqlColumn, ") = 0,'0',UPPER(MD5(CONVERT(", mysqlColumn, " USING utf8mb4))))"), nil
+	default:
+		return common.StringsBuilder("CASE WHEN ", oraColumn, " IS NULL THEN '0' ELSE RAWTOHEX(STANDARD_HASH(CONVERT(", oraColumn, ",'AL32UTF8'),'MD5')) END"),
+			common.StringsBuilder("IF(", mysqlColumn, " IS NULL OR LENGTH(", mysqlColumn, ") = 0,'0',UPPER(MD5(CONVERT(", mysqlColumn, " USING utf8mb4))))"), nil
+	}
+}
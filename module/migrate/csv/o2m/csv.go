@@ -23,6 +23,7 @@ import (
 	"github.com/wentaojin/transferdb/database/meta"
 	"github.com/wentaojin/transferdb/database/mysql"
 	"github.com/wentaojin/transferdb/database/oracle"
+	"github.com/wentaojin/transferdb/module/migrate/csv/storage"
 	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
 	"path/filepath"
@@ -32,11 +33,12 @@ import (
 )
 
 type O2M struct {
-	Ctx    context.Context
-	Cfg    *config.Config
-	Oracle *oracle.Oracle
-	Mysql  *mysql.MySQL
-	MetaDB *meta.Meta
+	Ctx     context.Context
+	Cfg     *config.Config
+	Oracle  *oracle.Oracle
+	Mysql   *mysql.MySQL
+	MetaDB  *meta.Meta
+	Storage storage.Storage
 }
 
 func NewCSVer(ctx context.Context, cfg *config.Config) (*O2M, error) {
@@ -44,6 +46,10 @@ func NewCSVer(ctx context.Context, cfg *config.Config) (*O2M, error) {
 	if err != nil {
 		return nil, err
 	}
+	oracleDB.Throttle, err = oracle.NewThrottle(ctx, oracleDB, cfg.ThrottleConfig)
+	if err != nil {
+		return nil, err
+	}
 	mysqlDB, err := mysql.NewMySQLDBEngine(ctx, cfg.MySQLConfig)
 	if err != nil {
 		return nil, err
@@ -52,12 +58,17 @@ func NewCSVer(ctx context.Context, cfg *config.Config) (*O2M, error) {
 	if err != nil {
 		return nil, err
 	}
+	store, err := storage.NewStorage(ctx, cfg.CSVConfig)
+	if err != nil {
+		return nil, err
+	}
 	return &O2M{
-		Ctx:    ctx,
-		Cfg:    cfg,
-		Oracle: oracleDB,
-		Mysql:  mysqlDB,
-		MetaDB: metaDB,
+		Ctx:     ctx,
+		Cfg:     cfg,
+		Oracle:  oracleDB,
+		Mysql:   mysqlDB,
+		MetaDB:  metaDB,
+		Storage: store,
 	}, nil
 }
 
@@ -299,6 +310,13 @@ func (r *O2M) CSV() error {
 		return fmt.Errorf("checkpoint isn't consistent, can't be resume, please reruning [enable-checkpoint = fase]")
 	}
 
+	// lightning 布局，数据导出前生成 schema 文件
+	if strings.EqualFold(r.Cfg.CSVConfig.Layout, common.CSVLayoutLightning) {
+		if err = r.genLightningSchemaFile(exporters); err != nil {
+			return err
+		}
+	}
+
 	// 数据 CSV
 	// 优先存在断点的表
 	// partTableTask -> waitTableTasks
@@ -354,6 +372,19 @@ func (r *O2M) CSV() error {
 		return err
 	}
 
+	// lightning 布局，全部表导出成功后生成 metadata 文件
+	if strings.EqualFold(r.Cfg.CSVConfig.Layout, common.CSVLayoutLightning) {
+		if len(failedTotals) == 0 {
+			if err = r.genLightningMetadataFile(startTime, succTotals); err != nil {
+				return err
+			}
+		} else {
+			zap.L().Warn("source schema table data csv exist failed table, skip lightning metadata file",
+				zap.String("schema", r.Cfg.OracleConfig.SchemaName),
+				zap.Int("table failed", len(failedTotals)))
+		}
+	}
+
 	zap.L().Info("source schema table data csv finished",
 		zap.String("schema", r.Cfg.OracleConfig.SchemaName),
 		zap.Int("table totals", len(exporters)),
@@ -417,12 +448,47 @@ func (r *O2M) csvPartSyncTable(csvPartTables []string) error {
 
 			waitFullMetas = append(waitFullMetas, failedFullMetas...)
 
+			// 断点续传，已成功上传的数据文件跳过
+			successFullMetas, err := meta.NewFullSyncMetaModel(r.MetaDB).DetailFullSyncMeta(r.Ctx, &meta.FullSyncMeta{
+				DBTypeS:     r.Cfg.DBTypeS,
+				DBTypeT:     r.Cfg.DBTypeT,
+				SchemaNameS: common.StringUPPER(r.Cfg.OracleConfig.SchemaName),
+				TableNameS:  common.StringUPPER(t),
+				TaskMode:    r.Cfg.TaskMode,
+				TaskStatus:  common.TaskStatusSuccess,
+			})
+			if err != nil {
+				return err
+			}
+			if len(successFullMetas) > 0 {
+				zap.L().Warn("csv table chunk resume, skip uploaded data file",
+					zap.String("schema", r.Cfg.OracleConfig.SchemaName),
+					zap.String("table", common.StringUPPER(t)),
+					zap.Int("skip chunks", len(successFullMetas)),
+					zap.Int("wait chunks", len(waitFullMetas)))
+			}
+
 			columnNameS, err := r.Oracle.GetOracleTableRowsColumnCSV(
 				common.StringsBuilder(`SELECT *`, ` FROM `,
 					common.StringUPPER(r.Cfg.OracleConfig.SchemaName), `.`, common.StringUPPER(t), ` WHERE ROWNUM = 1`))
 			if err != nil {
 				return nil
 			}
+			hasLOB, err := r.Oracle.IsOracleTableRowsLOB(
+				common.StringsBuilder(`SELECT *`, ` FROM `,
+					common.StringUPPER(r.Cfg.OracleConfig.SchemaName), `.`, common.StringUPPER(t), ` WHERE ROWNUM = 1`))
+			if err != nil {
+				return err
+			}
+
+			// parquet 格式按字段类型写入
+			var parquetColumns []ParquetColumn
+			if strings.EqualFold(r.Cfg.CSVConfig.FileFormat, common.CSVFileFormatParquet) {
+				parquetColumns, err = r.GenParquetColumns(t)
+				if err != nil {
+					return err
+				}
+			}
 
 			g1 := &errgroup.Group{}
 			g1.SetLimit(r.Cfg.CSVConfig.SQLThreads)
@@ -430,7 +496,22 @@ func (r *O2M) csvPartSyncTable(csvPartTables []string) error {
 			for _, fullSyncMeta := range waitFullMetas {
 				m := fullSyncMeta
 				g1.Go(func() error {
-					err = IMigrate(NewRows(r.Ctx, m, r.Oracle, r.MetaDB, r.Cfg, oracleDBCharacterSet, columnNameS))
+					var (
+						err       error
+						files     []string
+						checksums []string
+					)
+					if strings.EqualFold(r.Cfg.CSVConfig.FileFormat, common.CSVFileFormatParquet) {
+						rows := NewParquetRows(r.Ctx, m, r.Oracle, r.MetaDB, r.Storage, r.Cfg, parquetColumns, hasLOB)
+						err = IMigrate(rows)
+						files = rows.Files
+						checksums = rows.Checksums
+					} else {
+						rows := NewRows(r.Ctx, m, r.Oracle, r.MetaDB, r.Storage, r.Cfg, oracleDBCharacterSet, columnNameS, hasLOB)
+						err = IMigrate(rows)
+						files = rows.Files
+						checksums = rows.Checksums
+					}
 					if err != nil {
 						// record error, skip error
 						errf := meta.NewCommonModel(r.MetaDB).UpdateFullSyncMetaChunkAndCreateChunkErrorDetail(r.Ctx, &meta.FullSyncMeta{
@@ -458,7 +539,7 @@ func (r *O2M) csvPartSyncTable(csvPartTables []string) error {
 							return fmt.Errorf("get oracle schema table [%v] IMigrate failed: %v", m.String(), errf)
 						}
 
-						return nil
+						return meta.NewFullSyncMetaModel(r.MetaDB).LogFullSyncMetaPartitionProgress(r.Ctx, &m)
 					}
 
 					if errf := meta.NewFullSyncMetaModel(r.MetaDB).UpdateFullSyncMetaChunk(r.Ctx, &meta.FullSyncMeta{
@@ -469,12 +550,14 @@ func (r *O2M) csvPartSyncTable(csvPartTables []string) error {
 						TaskMode:     m.TaskMode,
 						ChunkDetailS: m.ChunkDetailS,
 					}, map[string]interface{}{
-						"TaskStatus": common.TaskStatusSuccess,
+						"TaskStatus":  common.TaskStatusSuccess,
+						"CSVFiles":    strings.Join(files, ","),
+						"CSVChecksum": strings.Join(checksums, ","),
 					}); errf != nil {
 						return errf
 					}
 
-					return nil
+					return meta.NewFullSyncMetaModel(r.MetaDB).LogFullSyncMetaPartitionProgress(r.Ctx, &m)
 				})
 			}
 
@@ -508,25 +591,20 @@ func (r *O2M) csvPartSyncTable(csvPartTables []string) error {
 				return err
 			}
 
-			// 不存在错误，清理 full_sync_meta 记录, 更新 wait_sync_meta 记录
+			// 不存在错误，更新 wait_sync_meta 记录
+			// full_sync_meta 记录保留，csv_file 用于 import 模式导入
 			if failedChunkTotalErrs == 0 {
-				err = meta.NewCommonModel(r.MetaDB).DeleteTableFullSyncMetaAndUpdateWaitSyncMeta(r.Ctx,
-					&meta.FullSyncMeta{
-						DBTypeS:     r.Cfg.DBTypeS,
-						DBTypeT:     r.Cfg.DBTypeT,
-						SchemaNameS: common.StringUPPER(r.Cfg.OracleConfig.SchemaName),
-						TableNameS:  common.StringUPPER(t),
-						TaskMode:    r.Cfg.TaskMode,
-					}, &meta.WaitSyncMeta{
-						DBTypeS:          r.Cfg.DBTypeS,
-						DBTypeT:          r.Cfg.DBTypeT,
-						SchemaNameS:      common.StringUPPER(r.Cfg.OracleConfig.SchemaName),
-						TableNameS:       common.StringUPPER(t),
-						TaskMode:         r.Cfg.TaskMode,
-						TaskStatus:       common.TaskStatusSuccess,
-						ChunkSuccessNums: int64(len(successChunkFullMeta)),
-						ChunkFailedNums:  0,
-					})
+				err = meta.NewWaitSyncMetaModel(r.MetaDB).UpdateWaitSyncMeta(r.Ctx, &meta.WaitSyncMeta{
+					DBTypeS:     r.Cfg.DBTypeS,
+					DBTypeT:     r.Cfg.DBTypeT,
+					SchemaNameS: common.StringUPPER(r.Cfg.OracleConfig.SchemaName),
+					TableNameS:  common.StringUPPER(t),
+					TaskMode:    r.Cfg.TaskMode,
+				}, map[string]interface{}{
+					"TaskStatus":       common.TaskStatusSuccess,
+					"ChunkSuccessNums": int64(len(successChunkFullMeta)),
+					"ChunkFailedNums":  0,
+				})
 				if err != nil {
 					return err
 				}
@@ -619,6 +697,18 @@ func (r *O2M) initWaitSyncTableChunk(csvWaitTables []string, tableNameRule map[s
 				return fmt.Errorf("csv config paramter output-dir can't be null, please configure")
 			}
 
+			// 清理历史遗留 full_sync_meta 记录（成功表记录保留用于 import）
+			err = meta.NewFullSyncMetaModel(r.MetaDB).DeleteFullSyncMetaBySchemaTable(r.Ctx, &meta.FullSyncMeta{
+				DBTypeS:     r.Cfg.DBTypeS,
+				DBTypeT:     r.Cfg.DBTypeT,
+				SchemaNameS: common.StringUPPER(r.Cfg.OracleConfig.SchemaName),
+				TableNameS:  common.StringUPPER(t),
+				TaskMode:    r.Cfg.TaskMode,
+			})
+			if err != nil {
+				return err
+			}
+
 			sourceColumnInfo, err := r.adjustTableSelectColumn(t, oracleCollation)
 			if err != nil {
 				return err
@@ -633,12 +723,15 @@ func (r *O2M) initWaitSyncTableChunk(csvWaitTables []string, tableNameRule map[s
 				isPartition = "NO"
 			}
 
+			// 分区表按分区/子分区数据段切分，不走全表扫
+			isPartitionWise := isPartition == "YES" && r.Cfg.AppConfig.IsPartitionWise(t)
+
 			tableRowsByStatistics, err := r.Oracle.GetOracleTableRowsByStatistics(r.Cfg.OracleConfig.SchemaName, t)
 			if err != nil {
 				return err
 			}
 			// 统计信息数据行数 0，直接全表扫
-			if tableRowsByStatistics == 0 {
+			if tableRowsByStatistics == 0 && !isPartitionWise {
 
 				err = meta.NewCommonModel(r.MetaDB).CreateFullSyncMetaAndUpdateWaitSyncMeta(r.Ctx, &meta.FullSyncMeta{
 					DBTypeS:       r.Cfg.DBTypeS,
@@ -652,10 +745,7 @@ func (r *O2M) initWaitSyncTableChunk(csvWaitTables []string, tableNameRule map[s
 					ChunkDetailS:  "1 = 1",
 					TaskMode:      r.Cfg.TaskMode,
 					TaskStatus:    common.TaskStatusWaiting,
-					CSVFile: filepath.Join(r.Cfg.CSVConfig.OutputDir,
-						common.StringUPPER(r.Cfg.OracleConfig.SchemaName), common.StringUPPER(t),
-						common.StringsBuilder(common.StringUPPER(r.Cfg.MySQLConfig.SchemaName),
-							`.`, common.StringUPPER(targetTableName), `.0.csv`)),
+					CSVFile:       r.genCSVFileName(t, targetTableName, 0),
 				}, &meta.WaitSyncMeta{
 					DBTypeS:          r.Cfg.DBTypeS,
 					DBTypeT:          r.Cfg.DBTypeT,
@@ -681,13 +771,29 @@ func (r *O2M) initWaitSyncTableChunk(csvWaitTables []string, tableNameRule map[s
 				return err
 			}
 
-			if err = r.Oracle.StartOracleCreateChunkByRowID(taskName, common.StringUPPER(r.Cfg.OracleConfig.SchemaName), common.StringUPPER(t), strconv.Itoa(r.Cfg.CSVConfig.Rows)); err != nil {
-				return err
-			}
-
-			chunkRes, err := r.Oracle.GetOracleTableChunksByRowID(taskName)
-			if err != nil {
-				return err
+			var chunkRes []map[string]string
+			if isPartitionWise {
+				// 仅按指定分区数据段切分 chunk
+				partitionCfg, _ := r.Cfg.AppConfig.GetPartitionTableConfig(t)
+				segments, err := r.Oracle.GetOracleTableSelectPartitionSegments(r.Cfg.OracleConfig.SchemaName, t, partitionCfg.Partitions, partitionCfg.RecentPartitions)
+				if err != nil {
+					return err
+				}
+				if err = r.Oracle.StartOracleCreateChunkBySegments(taskName, common.StringUPPER(r.Cfg.OracleConfig.SchemaName), common.StringUPPER(t), segments, strconv.Itoa(r.Cfg.CSVConfig.Rows)); err != nil {
+					return err
+				}
+				chunkRes, err = r.Oracle.GetOracleTablePartitionChunksByRowID(taskName, r.Cfg.OracleConfig.SchemaName, t, segments)
+				if err != nil {
+					return err
+				}
+			} else {
+				if err = r.Oracle.StartOracleCreateChunkByRowID(taskName, common.StringUPPER(r.Cfg.OracleConfig.SchemaName), common.StringUPPER(t), strconv.Itoa(r.Cfg.CSVConfig.Rows)); err != nil {
+					return err
+				}
+				chunkRes, err = r.Oracle.GetOracleTableChunksByRowID(taskName)
+				if err != nil {
+					return err
+				}
 			}
 
 			// 判断数据是否存在
@@ -704,10 +810,7 @@ func (r *O2M) initWaitSyncTableChunk(csvWaitTables []string, tableNameRule map[s
 					ChunkDetailS:  "1 = 1",
 					TaskMode:      r.Cfg.TaskMode,
 					TaskStatus:    common.TaskStatusWaiting,
-					CSVFile: filepath.Join(r.Cfg.CSVConfig.OutputDir,
-						common.StringUPPER(r.Cfg.OracleConfig.SchemaName), common.StringUPPER(t),
-						common.StringsBuilder(common.StringUPPER(r.Cfg.MySQLConfig.SchemaName),
-							`.`, common.StringUPPER(targetTableName), `.0.csv`)),
+					CSVFile:       r.genCSVFileName(t, targetTableName, 0),
 				}, &meta.WaitSyncMeta{
 					DBTypeS:          r.Cfg.DBTypeS,
 					DBTypeT:          r.Cfg.DBTypeT,
@@ -730,25 +833,23 @@ func (r *O2M) initWaitSyncTableChunk(csvWaitTables []string, tableNameRule map[s
 
 			var fullMetas []meta.FullSyncMeta
 			for i, res := range chunkRes {
-				var csvFile string
-				csvFile = filepath.Join(r.Cfg.CSVConfig.OutputDir,
-					common.StringUPPER(r.Cfg.OracleConfig.SchemaName), common.StringUPPER(t),
-					common.StringsBuilder(common.StringUPPER(r.Cfg.MySQLConfig.SchemaName), `.`,
-						common.StringUPPER(targetTableName), `.`, strconv.Itoa(i), `.csv`))
+				csvFile := r.genCSVFileName(t, targetTableName, i)
 
 				fullMetas = append(fullMetas, meta.FullSyncMeta{
-					DBTypeS:       r.Cfg.DBTypeS,
-					DBTypeT:       r.Cfg.DBTypeT,
-					SchemaNameS:   common.StringUPPER(r.Cfg.OracleConfig.SchemaName),
-					TableNameS:    common.StringUPPER(t),
-					SchemaNameT:   common.StringUPPER(r.Cfg.MySQLConfig.SchemaName),
-					TableNameT:    common.StringUPPER(targetTableName),
-					GlobalScnS:    globalSCN,
-					ColumnDetailS: sourceColumnInfo,
-					ChunkDetailS:  res["CMD"],
-					TaskMode:      r.Cfg.TaskMode,
-					TaskStatus:    common.TaskStatusWaiting,
-					CSVFile:       csvFile,
+					DBTypeS:           r.Cfg.DBTypeS,
+					DBTypeT:           r.Cfg.DBTypeT,
+					SchemaNameS:       common.StringUPPER(r.Cfg.OracleConfig.SchemaName),
+					TableNameS:        common.StringUPPER(t),
+					SchemaNameT:       common.StringUPPER(r.Cfg.MySQLConfig.SchemaName),
+					TableNameT:        common.StringUPPER(targetTableName),
+					GlobalScnS:        globalSCN,
+					ColumnDetailS:     sourceColumnInfo,
+					ChunkDetailS:      res["CMD"],
+					PartitionNameS:    res["PARTITION_NAME"],
+					SubPartitionNameS: res["SUBPARTITION_NAME"],
+					TaskMode:          r.Cfg.TaskMode,
+					TaskStatus:        common.TaskStatusWaiting,
+					CSVFile:           csvFile,
 				})
 			}
 
@@ -801,10 +902,18 @@ func (r *O2M) adjustTableSelectColumn(sourceTable string, oracleCollation bool)
 	if err != nil {
 		return "", err
 	}
+	// 虚拟列下游为生成列，不允许写入，排除
+	virtualColumns, err := r.Oracle.GetOracleSchemaTableVirtualColumn(r.Cfg.OracleConfig.SchemaName, sourceTable)
+	if err != nil {
+		return "", err
+	}
 
 	var columnNames []string
 
 	for _, rowCol := range columnsINFO {
+		if common.IsContainString(virtualColumns, rowCol["COLUMN_NAME"]) {
+			continue
+		}
 		switch strings.ToUpper(rowCol["DATA_TYPE"]) {
 		// 数字
 		case "NUMBER":
@@ -850,3 +959,15 @@ func (r *O2M) adjustTableSelectColumn(sourceTable string, oracleCollation bool)
 
 	return strings.Join(columnNames, ","), nil
 }
+
+// genCSVFileName 数据文件名 ${target_schema}.${table}.${n}.csv
+// default 布局按源端库表分目录，lightning 布局平铺于 output-dir
+func (r *O2M) genCSVFileName(sourceTable, targetTable string, chunkID int) string {
+	fileName := common.StringsBuilder(common.StringUPPER(r.Cfg.MySQLConfig.SchemaName), `.`,
+		common.StringUPPER(targetTable), `.`, strconv.Itoa(chunkID), GenDataFileSuffix(r.Cfg.CSVConfig))
+	if strings.EqualFold(r.Cfg.CSVConfig.Layout, common.CSVLayoutLightning) {
+		return filepath.Join(r.Cfg.CSVConfig.OutputDir, fileName)
+	}
+	return filepath.Join(r.Cfg.CSVConfig.OutputDir,
+		common.StringUPPER(r.Cfg.OracleConfig.SchemaName), common.StringUPPER(sourceTable), fileName)
+}
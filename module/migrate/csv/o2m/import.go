@@ -0,0 +1,558 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package o2m
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
+	"github.com/wentaojin/transferdb/database/meta"
+	"github.com/wentaojin/transferdb/database/mysql"
+	"github.com/wentaojin/transferdb/module/compare"
+	"github.com/wentaojin/transferdb/module/migrate/csv/storage"
+	sqlO2M "github.com/wentaojin/transferdb/module/migrate/sql/o2m"
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+	"io"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+type Import struct {
+	Ctx     context.Context
+	Cfg     *config.Config
+	Mysql   *mysql.MySQL
+	MetaDB  *meta.Meta
+	Storage storage.Storage
+	// 下游禁用 LOAD DATA，后续文件统一使用 batch 写入
+	loadDisabled int32
+}
+
+func NewImporter(ctx context.Context, cfg *config.Config) (*Import, error) {
+	mysqlDB, err := mysql.NewMySQLDBEngine(ctx, cfg.MySQLConfig)
+	if err != nil {
+		return nil, err
+	}
+	metaDB, err := meta.NewMetaDBEngine(ctx, cfg.MetaConfig, cfg.AppConfig.SlowlogThreshold)
+	if err != nil {
+		return nil, err
+	}
+	store, err := storage.NewStorage(ctx, cfg.CSVConfig)
+	if err != nil {
+		return nil, err
+	}
+	return &Import{
+		Ctx:     ctx,
+		Cfg:     cfg,
+		Mysql:   mysqlDB,
+		MetaDB:  metaDB,
+		Storage: store,
+	}, nil
+}
+
+// Import 按 csv 模式 full_sync_meta 记录的数据文件导入下游
+func (r *Import) Import() error {
+	startTime := time.Now()
+	zap.L().Info("source schema csv data file import start",
+		zap.String("schema", r.Cfg.OracleConfig.SchemaName))
+
+	if strings.EqualFold(r.Cfg.CSVConfig.FileFormat, common.CSVFileFormatParquet) {
+		return fmt.Errorf("csv config file-format [%s] isn't support import mode, only support [csv]", r.Cfg.CSVConfig.FileFormat)
+	}
+
+	// 关于断点恢复
+	//  - 若想断点恢复，设置 enable-checkpoint true，已导入成功的文件跳过
+	//  - 若重新导出或者重新导入，设置 enable-checkpoint false，清理元数据表 [csv_import_meta]
+	if !r.Cfg.CSVConfig.EnableCheckpoint {
+		err := meta.NewCSVImportMetaModel(r.MetaDB).DeleteCSVImportMetaBySchema(r.Ctx, &meta.CSVImportMeta{
+			DBTypeS:     r.Cfg.DBTypeS,
+			DBTypeT:     r.Cfg.DBTypeT,
+			SchemaNameS: common.StringUPPER(r.Cfg.OracleConfig.SchemaName),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	fullMetas, err := meta.NewFullSyncMetaModel(r.MetaDB).DetailFullSyncMeta(r.Ctx, &meta.FullSyncMeta{
+		DBTypeS:     r.Cfg.DBTypeS,
+		DBTypeT:     r.Cfg.DBTypeT,
+		SchemaNameS: common.StringUPPER(r.Cfg.OracleConfig.SchemaName),
+		TaskMode:    common.TaskModeCSV,
+		TaskStatus:  common.TaskStatusSuccess,
+	})
+	if err != nil {
+		return err
+	}
+	if len(fullMetas) == 0 {
+		zap.L().Warn("there are no csv data file in the meta table [full_sync_meta], please run csv mode firstly",
+			zap.String("schema", r.Cfg.OracleConfig.SchemaName))
+		return nil
+	}
+
+	// 记录待导入文件，包含 file-size 滚动生成的文件
+	for _, fm := range fullMetas {
+		files, checksums, err := r.genDataFiles(fm)
+		if err != nil {
+			return err
+		}
+		for i, fileName := range files {
+			err = meta.NewCSVImportMetaModel(r.MetaDB).CreateCSVImportMeta(r.Ctx, &meta.CSVImportMeta{
+				DBTypeS:      r.Cfg.DBTypeS,
+				DBTypeT:      r.Cfg.DBTypeT,
+				SchemaNameS:  common.StringUPPER(fm.SchemaNameS),
+				TableNameS:   common.StringUPPER(fm.TableNameS),
+				SchemaNameT:  common.StringUPPER(fm.SchemaNameT),
+				TableNameT:   common.StringUPPER(fm.TableNameT),
+				CSVFile:      fileName,
+				CSVChecksum:  checksums[i],
+				ColumnDetail: fm.ColumnDetailS,
+				TaskStatus:   common.TaskStatusWaiting,
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	importMetas, err := meta.NewCSVImportMetaModel(r.MetaDB).DetailCSVImportMeta(r.Ctx, &meta.CSVImportMeta{
+		DBTypeS:     r.Cfg.DBTypeS,
+		DBTypeT:     r.Cfg.DBTypeT,
+		SchemaNameS: common.StringUPPER(r.Cfg.OracleConfig.SchemaName),
+	})
+	if err != nil {
+		return err
+	}
+
+	var (
+		skipFiles    int
+		successFiles int64
+		failedFiles  int64
+	)
+	g := &errgroup.Group{}
+	g.SetLimit(r.Cfg.CSVConfig.ImportThreads)
+
+	for _, im := range importMetas {
+		m := im
+		if strings.EqualFold(m.TaskStatus, common.TaskStatusSuccess) {
+			skipFiles++
+			continue
+		}
+		g.Go(func() error {
+			ok, err := r.importFile(m)
+			if err != nil {
+				return err
+			}
+			if ok {
+				atomic.AddInt64(&successFiles, 1)
+			} else {
+				atomic.AddInt64(&failedFiles, 1)
+			}
+			return nil
+		})
+	}
+	if err = g.Wait(); err != nil {
+		return err
+	}
+
+	zap.L().Info("source schema csv data file import finished",
+		zap.String("schema", r.Cfg.OracleConfig.SchemaName),
+		zap.Int("file totals", len(importMetas)),
+		zap.Int("file skip", skipFiles),
+		zap.Int64("file success", successFiles),
+		zap.Int64("file failed", failedFiles),
+		zap.String("cost", time.Now().Sub(startTime).String()))
+
+	if failedFiles > 0 {
+		return fmt.Errorf("csv schema [%s] data file import failed [%d], please see meta table [csv_import_meta] error_detail and rerunning", r.Cfg.OracleConfig.SchemaName, failedFiles)
+	}
+	return nil
+}
+
+// genDataFiles chunk 导出的全部数据文件以及 sha256 校验和，按 full_sync_meta csv_files 记录顺序
+// 历史元数据未记录 csv_files 时按滚动序号探测文件是否存在
+func (r *Import) genDataFiles(fm meta.FullSyncMeta) ([]string, []string, error) {
+	var (
+		files     []string
+		checksums []string
+	)
+	if fm.CSVChecksum != "" {
+		checksums = strings.Split(fm.CSVChecksum, ",")
+	}
+	if fm.CSVFiles != "" {
+		files = strings.Split(fm.CSVFiles, ",")
+	} else {
+		suffix := GenDataFileSuffix(r.Cfg.CSVConfig)
+		for seq := 0; ; seq++ {
+			fileName := GenRollingFileName(fm.CSVFile, suffix, seq)
+			if seq > 0 {
+				exist, err := r.Storage.Exist(fileName)
+				if err != nil {
+					return nil, nil, err
+				}
+				if !exist {
+					break
+				}
+			}
+			files = append(files, fileName)
+		}
+	}
+	if len(checksums) != len(files) {
+		return nil, nil, fmt.Errorf("csv schema [%s] table [%s] chunk [%s] data file counts [%d] and checksum counts [%d] isn't match, please rerunning csv mode",
+			fm.SchemaNameS, fm.TableNameS, fm.ChunkDetailS, len(files), len(checksums))
+	}
+	return files, checksums, nil
+}
+
+// verifyDataFile 导入前校验数据文件 sha256 与导出记录是否一致，避免导入被截断或者篡改的文件
+func (r *Import) verifyDataFile(m meta.CSVImportMeta) error {
+	if m.CSVChecksum == "" {
+		return fmt.Errorf("data file [%s] checksum isn't exist, please rerunning csv mode", m.CSVFile)
+	}
+	fileR, err := r.Storage.Open(m.CSVFile)
+	if err != nil {
+		return err
+	}
+	defer fileR.Close()
+
+	h := sha256.New()
+	if _, err = io.Copy(h, fileR); err != nil {
+		return fmt.Errorf("data file [%s] read failed: %v", m.CSVFile, err)
+	}
+	if checksum := hex.EncodeToString(h.Sum(nil)); checksum != m.CSVChecksum {
+		return fmt.Errorf("data file [%s] sha256 [%s] and export checksum [%s] isn't match", m.CSVFile, checksum, m.CSVChecksum)
+	}
+	return nil
+}
+
+// importFile 单文件导入，导入失败记录元数据表并返回 false
+func (r *Import) importFile(m meta.CSVImportMeta) (bool, error) {
+	startTime := time.Now()
+	err := meta.NewCSVImportMetaModel(r.MetaDB).UpdateCSVImportMeta(r.Ctx, &m, map[string]interface{}{
+		"TaskStatus": common.TaskStatusRunning,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	method := common.CSVImportMethodLoad
+	errImport := r.verifyDataFile(m)
+	var rows int64
+	if errImport == nil {
+		rows, method, errImport = r.loadFile(m)
+	}
+	if errImport != nil {
+		zap.L().Error("csv data file import failed",
+			zap.String("schema", m.SchemaNameT),
+			zap.String("table", m.TableNameT),
+			zap.String("file", m.CSVFile),
+			zap.String("method", method),
+			zap.Error(errImport))
+		err = meta.NewCSVImportMetaModel(r.MetaDB).UpdateCSVImportMeta(r.Ctx, &m, map[string]interface{}{
+			"ImportMethod": method,
+			"TaskStatus":   common.TaskStatusFailed,
+			"ErrorDetail":  errImport.Error(),
+		})
+		if err != nil {
+			return false, err
+		}
+		return false, nil
+	}
+
+	err = meta.NewCSVImportMetaModel(r.MetaDB).UpdateCSVImportMeta(r.Ctx, &m, map[string]interface{}{
+		"ImportMethod": method,
+		"ImportRows":   rows,
+		"TaskStatus":   common.TaskStatusSuccess,
+		"ErrorDetail":  "",
+	})
+	if err != nil {
+		return false, err
+	}
+	zap.L().Info("csv data file import finished",
+		zap.String("schema", m.SchemaNameT),
+		zap.String("table", m.TableNameT),
+		zap.String("file", m.CSVFile),
+		zap.String("method", method),
+		zap.Int64("rows", rows),
+		zap.String("cost", time.Now().Sub(startTime).String()))
+	return true, nil
+}
+
+// loadFile 优先 LOAD DATA LOCAL INFILE，下游禁用、delimiter 多字符或者 null-value 无法识别时降级 batch REPLACE INTO
+// 文件字段顺序以导出查询字段为准，下游生成列不允许写入，对应文件字段丢弃
+func (r *Import) loadFile(m meta.CSVImportMeta) (int64, string, error) {
+	columnINFO, err := r.Mysql.GetMySQLTableColumn(m.SchemaNameT, m.TableNameT)
+	if err != nil {
+		return 0, common.CSVImportMethodLoad, err
+	}
+	if len(columnINFO) == 0 {
+		return 0, common.CSVImportMethodLoad, fmt.Errorf("target table [%s.%s] isn't exist or column is null", m.SchemaNameT, m.TableNameT)
+	}
+	columnMap := make(map[string]map[string]string)
+	for _, c := range columnINFO {
+		columnMap[common.StringUPPER(c["COLUMN_NAME"])] = c
+	}
+
+	var (
+		columns       []string
+		binaryColumns []bool
+		skipColumns   []bool
+	)
+	for _, col := range compare.GenSelectColumnNames(m.ColumnDetail) {
+		c, ok := columnMap[common.StringUPPER(col)]
+		if !ok {
+			return 0, common.CSVImportMethodLoad, fmt.Errorf("data file [%s] column [%s] isn't exist in the target table [%s.%s]", m.CSVFile, col, m.SchemaNameT, m.TableNameT)
+		}
+		columns = append(columns, c["COLUMN_NAME"])
+		// 二进制字段导出按 binary-encoding 编码，导入需解码
+		binaryColumns = append(binaryColumns, isMySQLBinaryType(c["DATA_TYPE"]))
+		skipColumns = append(skipColumns, strings.Contains(common.StringUPPER(c["EXTRA"]), "GENERATED"))
+	}
+	if len(columns) == 0 {
+		return 0, common.CSVImportMethodLoad, fmt.Errorf("data file [%s] column detail is null, please rerunning csv mode", m.CSVFile)
+	}
+
+	if !r.Cfg.CSVConfig.DisableLoadData && atomic.LoadInt32(&r.loadDisabled) == 0 && len(r.Cfg.CSVConfig.Delimiter) <= 1 &&
+		IsLoadDataNullSupport(r.Cfg.CSVConfig) {
+		rows, err := r.loadData(m, columns, binaryColumns, skipColumns)
+		if err == nil {
+			return rows, common.CSVImportMethodLoad, nil
+		}
+		if !mysql.IsMySQLLoadDataDisabled(err) {
+			return 0, common.CSVImportMethodLoad, err
+		}
+		if atomic.CompareAndSwapInt32(&r.loadDisabled, 0, 1) {
+			zap.L().Warn("target db load data local infile is disabled, fallback batch insert",
+				zap.String("schema", m.SchemaNameT),
+				zap.Error(err))
+		}
+	}
+
+	rows, err := r.insertData(m, columns, binaryColumns, skipColumns)
+	return rows, common.CSVImportMethodInsert, err
+}
+
+func (r *Import) loadData(m meta.CSVImportMeta, columns []string, binaryColumns, skipColumns []bool) (int64, error) {
+	reader, err := r.openDataFile(m.CSVFile)
+	if err != nil {
+		return 0, err
+	}
+	defer reader.Close()
+
+	return r.Mysql.LoadMySQLTableData(GenLoadDataSQL(r.Cfg.CSVConfig, m.CSVFile, m.SchemaNameT, m.TableNameT, columns, binaryColumns, skipColumns), m.CSVFile, reader)
+}
+
+func (r *Import) insertData(m meta.CSVImportMeta, columns []string, binaryColumns, skipColumns []bool) (int64, error) {
+	reader, err := r.openDataFile(m.CSVFile)
+	if err != nil {
+		return 0, err
+	}
+	defer reader.Close()
+
+	parser := NewDataFileParser(reader, r.Cfg.CSVConfig)
+	if r.Cfg.CSVConfig.Header {
+		if _, err = parser.ReadRow(); err != nil && err != io.EOF {
+			return 0, err
+		}
+	}
+
+	var insertColumns []string
+	for i, c := range columns {
+		if !skipColumns[i] {
+			insertColumns = append(insertColumns, c)
+		}
+	}
+	if len(insertColumns) == 0 {
+		return 0, fmt.Errorf("target table [%s.%s] writable column is null", m.SchemaNameT, m.TableNameT)
+	}
+
+	// 绑定变量上限 65535
+	batchSize := r.Cfg.AppConfig.InsertBatchSize
+	if batchSize*len(insertColumns) > common.MySQLMaxPlaceholders {
+		batchSize = common.MySQLMaxPlaceholders / len(insertColumns)
+	}
+	if batchSize <= 0 {
+		batchSize = 1
+	}
+
+	var (
+		rows      int64
+		batchRows int
+		args      []interface{}
+	)
+	targetColumns := common.StringsBuilder("`", strings.Join(insertColumns, "`,`"), "`")
+	writeBatch := func() error {
+		prepareSQL := common.StringsBuilder(
+			sqlO2M.GenMySQLInsertSQLStmtPrefix(common.StringsBuilder("`", m.SchemaNameT, "`"), common.StringsBuilder("`", m.TableNameT, "`"), []string{targetColumns}, true),
+			sqlO2M.GenMySQLPrepareBindVarStmt(len(insertColumns), batchRows))
+		if err := r.Mysql.WriteMySQLTableRows(prepareSQL, args); err != nil {
+			return err
+		}
+		rows += int64(batchRows)
+		batchRows = 0
+		args = args[:0]
+		return nil
+	}
+
+	for {
+		row, err := parser.ReadRow()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return rows, fmt.Errorf("data file [%s] parse failed after rows [%d]: %v", m.CSVFile, rows+int64(batchRows), err)
+		}
+		if len(row) != len(columns) {
+			return rows, fmt.Errorf("data file [%s] row [%d] column counts [%d] and target table column counts [%d] isn't match", m.CSVFile, rows+int64(batchRows)+1, len(row), len(columns))
+		}
+		for i, v := range row {
+			if skipColumns[i] {
+				continue
+			}
+			if s, ok := v.(string); ok && binaryColumns[i] {
+				bs, err := common.BinaryDecode(s, r.Cfg.CSVConfig.BinaryEncoding)
+				if err != nil {
+					return rows, fmt.Errorf("data file [%s] row [%d] column [%s] binary decode failed: %v", m.CSVFile, rows+int64(batchRows)+1, columns[i], err)
+				}
+				v = bs
+			}
+			args = append(args, v)
+		}
+		batchRows++
+		if batchRows == batchSize {
+			if err = writeBatch(); err != nil {
+				return rows, err
+			}
+		}
+	}
+	if batchRows > 0 {
+		if err = writeBatch(); err != nil {
+			return rows, err
+		}
+	}
+	return rows, nil
+}
+
+func (r *Import) openDataFile(fileName string) (*DataFileReader, error) {
+	fileR, err := r.Storage.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	reader, err := NewDataFileReader(fileR, r.Cfg.CSVConfig.Compress)
+	if err != nil {
+		_ = fileR.Close()
+		return nil, err
+	}
+	return reader, nil
+}
+
+// GenLoadDataSQL 按 csv 配置生成 LOAD DATA LOCAL INFILE 语句，REPLACE 保证重复导入幂等
+// LOAD DATA 无法识别的 null-value 以及二进制字段 hex/base64 解码，均借助用户变量转换，生成列读入用户变量后丢弃
+func GenLoadDataSQL(csvCfg config.CSVConfig, readerName, schemaName, tableName string, columns []string, binaryColumns, skipColumns []bool) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("LOAD DATA LOCAL INFILE 'Reader::%s' REPLACE INTO TABLE `%s`.`%s`", escapeLoadDataString(readerName), schemaName, tableName))
+
+	if strings.EqualFold(csvCfg.Charset, common.GBKCharacterSetCSV) {
+		sb.WriteString(" CHARACTER SET gbk")
+	} else {
+		sb.WriteString(" CHARACTER SET utf8mb4")
+	}
+
+	sb.WriteString(fmt.Sprintf(" FIELDS TERMINATED BY '%s'", escapeLoadDataString(csvCfg.Separator)))
+	if csvCfg.Delimiter != "" {
+		sb.WriteString(fmt.Sprintf(" ENCLOSED BY '%s'", escapeLoadDataString(csvCfg.Delimiter)))
+	}
+	if csvCfg.EscapeBackslash {
+		sb.WriteString(` ESCAPED BY '\\'`)
+	} else {
+		sb.WriteString(" ESCAPED BY ''")
+	}
+	sb.WriteString(fmt.Sprintf(" LINES TERMINATED BY '%s'", escapeLoadDataString(csvCfg.Terminator)))
+	if csvCfg.Header {
+		sb.WriteString(" IGNORE 1 LINES")
+	}
+
+	hasBinary, hasSkip := false, false
+	for _, b := range binaryColumns {
+		hasBinary = hasBinary || b
+	}
+	for _, b := range skipColumns {
+		hasSkip = hasSkip || b
+	}
+	nullNative := isLoadDataNullNative(csvCfg)
+	if nullNative && !hasBinary && !hasSkip {
+		sb.WriteString(common.StringsBuilder(" (`", strings.Join(columns, "`,`"), "`)"))
+		return sb.String()
+	}
+
+	var (
+		vars []string
+		sets []string
+	)
+	for i, c := range columns {
+		v := fmt.Sprintf("@c%d", i)
+		vars = append(vars, v)
+		if i < len(skipColumns) && skipColumns[i] {
+			continue
+		}
+		expr := v
+		if !nullNative {
+			expr = fmt.Sprintf("NULLIF(%s, '%s')", expr, escapeLoadDataString(csvCfg.NullValue))
+		}
+		if i < len(binaryColumns) && binaryColumns[i] {
+			if strings.EqualFold(csvCfg.BinaryEncoding, common.CSVBinaryEncodingBase64) {
+				expr = fmt.Sprintf("FROM_BASE64(%s)", expr)
+			} else {
+				expr = fmt.Sprintf("UNHEX(%s)", expr)
+			}
+		}
+		sets = append(sets, fmt.Sprintf("`%s` = %s", c, expr))
+	}
+	sb.WriteString(common.StringsBuilder(" (", strings.Join(vars, ","), ") SET ", strings.Join(sets, ",")))
+	return sb.String()
+}
+
+// isLoadDataNullNative LOAD DATA 原生识别的 NULL 表示
+// 未被 ENCLOSED BY 包裹的 NULL 以及 ESCAPED BY '\' 时的 \N
+func isLoadDataNullNative(csvCfg config.CSVConfig) bool {
+	return (csvCfg.Delimiter != "" && csvCfg.NullValue == common.CSVNullValueDefault) ||
+		(csvCfg.EscapeBackslash && csvCfg.NullValue == `\N`)
+}
+
+// IsLoadDataNullSupport LOAD DATA 是否可区分 NULL 与字符串
+// 非原生 null-value 借助 NULLIF 转换，存在 delimiter 时无法区分是否被包裹，需降级 batch 写入
+func IsLoadDataNullSupport(csvCfg config.CSVConfig) bool {
+	return isLoadDataNullNative(csvCfg) || csvCfg.Delimiter == ""
+}
+
+func isMySQLBinaryType(dataType string) bool {
+	switch strings.ToUpper(dataType) {
+	case common.BuildInMySQLDatatypeBinary, common.BuildInMySQLDatatypeVarbinary,
+		common.BuildInMySQLDatatypeTinyBlob, common.BuildInMySQLDatatypeBlob,
+		common.BuildInMySQLDatatypeMediumBlob, common.BuildInMySQLDatatypeLongBlob:
+		return true
+	default:
+		return false
+	}
+}
+
+func escapeLoadDataString(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `'`, `\'`, "\r", `\r`, "\n", `\n`, "\t", `\t`).Replace(s)
+}
@@ -0,0 +1,117 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package o2m
+
+import (
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/database/meta"
+	"github.com/wentaojin/transferdb/module/migrate/csv/storage"
+	reverseO2M "github.com/wentaojin/transferdb/module/reverse/o2m"
+	"go.uber.org/zap"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// genLightningSchemaFile 生成 TiDB Lightning/Dumpling 格式 schema 文件
+// ${db}-schema-create.sql 以及 ${db}.${table}-schema.sql，表结构来源于 reverse 转换
+func (r *O2M) genLightningSchemaFile(exporters []string) error {
+	startTime := time.Now()
+
+	// reverse 并发未配置时沿用 csv task-threads
+	cfg := *r.Cfg
+	if cfg.ReverseConfig.ReverseThreads <= 0 {
+		cfg.ReverseConfig.ReverseThreads = r.Cfg.CSVConfig.TaskThreads
+	}
+	rev := &reverseO2M.Reverse{
+		Ctx:    r.Ctx,
+		Cfg:    &cfg,
+		Mysql:  r.Mysql,
+		Oracle: r.Oracle,
+		MetaDB: r.MetaDB,
+	}
+	createSchema, ddls, err := rev.GenSchemaTableDDL(exporters)
+	if err != nil {
+		return fmt.Errorf("gen lightning schema file failed: %v", err)
+	}
+
+	schemaNameT := common.StringUPPER(r.Cfg.MySQLConfig.SchemaName)
+	if err = storage.WriteFile(r.Storage, filepath.Join(r.Cfg.CSVConfig.OutputDir,
+		common.StringsBuilder(schemaNameT, `-schema-create.sql`)), []byte(createSchema+"\n")); err != nil {
+		return err
+	}
+
+	for _, ddl := range ddls {
+		reverseDDLS, compDDLS := ddl.GenDDLStructure()
+		if len(compDDLS) > 0 {
+			zap.L().Warn("lightning schema file exist compatibility ddl, please manual process",
+				zap.String("schema", ddl.TargetSchemaName),
+				zap.String("table", ddl.TargetTableName),
+				zap.Strings("sql", compDDLS))
+		}
+		if len(reverseDDLS) == 0 {
+			continue
+		}
+		if err = storage.WriteFile(r.Storage, filepath.Join(r.Cfg.CSVConfig.OutputDir,
+			common.StringsBuilder(schemaNameT, `.`, common.StringUPPER(ddl.TargetTableName), `-schema.sql`)),
+			[]byte(strings.Join(reverseDDLS, "\n"))); err != nil {
+			return err
+		}
+	}
+
+	zap.L().Info("gen lightning schema file finished",
+		zap.String("schema", r.Cfg.OracleConfig.SchemaName),
+		zap.Int("table totals", len(exporters)),
+		zap.Int("table schema files", len(ddls)),
+		zap.String("output", r.Cfg.CSVConfig.OutputDir),
+		zap.String("cost", time.Now().Sub(startTime).String()))
+	return nil
+}
+
+// genLightningMetadataFile 生成 Dumpling 格式 metadata 文件，Pos 记录导出所用 Oracle SCN
+// 断点续传等场景下各表 SCN 可能不一致，取最小值
+func (r *O2M) genLightningMetadataFile(startTime time.Time, succTables []meta.WaitSyncMeta) error {
+	var (
+		globalSCN uint64
+		tableSCNs []string
+	)
+	for _, t := range succTables {
+		if globalSCN == 0 || t.GlobalScnS < globalSCN {
+			globalSCN = t.GlobalScnS
+		}
+		tableSCNs = append(tableSCNs, fmt.Sprintf("%s:%d", t.TableNameS, t.GlobalScnS))
+	}
+	for _, t := range succTables {
+		if t.GlobalScnS != globalSCN {
+			zap.L().Warn("lightning metadata table scn isn't consistent, record min scn",
+				zap.String("schema", r.Cfg.OracleConfig.SchemaName),
+				zap.Uint64("min scn", globalSCN),
+				zap.Strings("table scn", tableSCNs))
+			break
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Started dump at: %s\n", startTime.Format("2006-01-02 15:04:05")))
+	sb.WriteString("SHOW MASTER STATUS:\n")
+	sb.WriteString(fmt.Sprintf("\tLog: %s\n", common.StringUPPER(r.Cfg.OracleConfig.SchemaName)))
+	sb.WriteString(fmt.Sprintf("\tPos: %d\n", globalSCN))
+	sb.WriteString("\tGTID:\n\n")
+	sb.WriteString(fmt.Sprintf("Finished dump at: %s\n", time.Now().Format("2006-01-02 15:04:05")))
+
+	return storage.WriteFile(r.Storage, filepath.Join(r.Cfg.CSVConfig.OutputDir, common.CSVLightningMetadataFile), []byte(sb.String()))
+}
@@ -0,0 +1,342 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package o2m
+
+import (
+	"context"
+	"fmt"
+	"github.com/shopspring/decimal"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
+	"github.com/wentaojin/transferdb/database/meta"
+	"github.com/wentaojin/transferdb/database/oracle"
+	"github.com/wentaojin/transferdb/module/migrate/csv/storage"
+	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/types"
+	"github.com/xitongsys/parquet-go/writer"
+	"go.uber.org/zap"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// parquet 字段写入类型
+const (
+	parquetKindString  = "STRING"
+	parquetKindBinary  = "BINARY"
+	parquetKindInt64   = "INT64"
+	parquetKindDecimal = "DECIMAL"
+	parquetKindFloat   = "FLOAT"
+	parquetKindDouble  = "DOUBLE"
+	parquetKindDate    = "DATE"
+	parquetKindTime    = "TIMESTAMP"
+)
+
+// ParquetColumn Oracle 字段映射 parquet 字段
+type ParquetColumn struct {
+	ColumnName string
+	Kind       string
+	Precision  int
+	Scale      int
+}
+
+// Metadata parquet-go 字段定义
+func (c ParquetColumn) Metadata() string {
+	var tag string
+	switch c.Kind {
+	case parquetKindInt64:
+		tag = "type=INT64"
+	case parquetKindDecimal:
+		tag = fmt.Sprintf("type=BYTE_ARRAY, convertedtype=DECIMAL, precision=%d, scale=%d", c.Precision, c.Scale)
+	case parquetKindFloat:
+		tag = "type=FLOAT"
+	case parquetKindDouble:
+		tag = "type=DOUBLE"
+	case parquetKindDate:
+		tag = "type=INT64, convertedtype=TIMESTAMP_MILLIS"
+	case parquetKindTime:
+		tag = "type=INT64, convertedtype=TIMESTAMP_MICROS"
+	case parquetKindBinary:
+		tag = "type=BYTE_ARRAY"
+	default:
+		tag = "type=BYTE_ARRAY, convertedtype=UTF8"
+	}
+	return common.StringsBuilder("name=", c.ColumnName, ", ", tag, ", repetitiontype=OPTIONAL")
+}
+
+// Value 字段值按 parquet 物理类型转换
+func (c ParquetColumn) Value(val *string) (interface{}, error) {
+	if val == nil {
+		return nil, nil
+	}
+	switch c.Kind {
+	case parquetKindInt64:
+		return strconv.ParseInt(*val, 10, 64)
+	case parquetKindDecimal:
+		d, err := decimal.NewFromString(*val)
+		if err != nil {
+			return nil, err
+		}
+		// 按字段 scale 放大为整数，大端补码字节存储
+		return types.StrIntToBinary(d.Shift(int32(c.Scale)).Round(0).String(), "BigEndian", 0, true), nil
+	case parquetKindFloat:
+		f, err := strconv.ParseFloat(*val, 32)
+		if err != nil {
+			return nil, err
+		}
+		return float32(f), nil
+	case parquetKindDouble:
+		return strconv.ParseFloat(*val, 64)
+	case parquetKindDate:
+		t, err := time.ParseInLocation("2006-01-02 15:04:05", *val, time.UTC)
+		if err != nil {
+			return nil, err
+		}
+		return types.TimeToTIMESTAMP_MILLIS(t, true), nil
+	case parquetKindTime:
+		t, err := time.ParseInLocation("2006-01-02 15:04:05.999999999", *val, time.UTC)
+		if err != nil {
+			return nil, err
+		}
+		return types.TimeToTIMESTAMP_MICROS(t, true), nil
+	default:
+		return *val, nil
+	}
+}
+
+// GenParquetColumns Oracle 字段类型映射 parquet 逻辑类型，字段顺序以及虚拟列排除与 adjustTableSelectColumn 保持一致
+func (r *O2M) GenParquetColumns(sourceTable string) ([]ParquetColumn, error) {
+	columnsINFO, err := r.Oracle.GetOracleSchemaTableColumn(r.Cfg.OracleConfig.SchemaName, sourceTable, false)
+	if err != nil {
+		return nil, err
+	}
+	virtualColumns, err := r.Oracle.GetOracleSchemaTableVirtualColumn(r.Cfg.OracleConfig.SchemaName, sourceTable)
+	if err != nil {
+		return nil, err
+	}
+
+	var columns []ParquetColumn
+	for _, rowCol := range columnsINFO {
+		if common.IsContainString(virtualColumns, rowCol["COLUMN_NAME"]) {
+			continue
+		}
+		dataPrecision, err := strconv.Atoi(rowCol["DATA_PRECISION"])
+		if err != nil {
+			return nil, fmt.Errorf("oracle table [%s] column [%s] precision [%s] strconv.Atoi failed: %v", sourceTable, rowCol["COLUMN_NAME"], rowCol["DATA_PRECISION"], err)
+		}
+		dataScale, err := strconv.Atoi(rowCol["DATA_SCALE"])
+		if err != nil {
+			return nil, fmt.Errorf("oracle table [%s] column [%s] scale [%s] strconv.Atoi failed: %v", sourceTable, rowCol["COLUMN_NAME"], rowCol["DATA_SCALE"], err)
+		}
+
+		column := ParquetColumn{
+			ColumnName: rowCol["COLUMN_NAME"],
+			Kind:       parquetKindString,
+		}
+		dataType := strings.ToUpper(rowCol["DATA_TYPE"])
+		switch {
+		case dataType == "NUMBER":
+			// number / number(*) -> number(38,127) 精度未知，字符串保存
+			switch {
+			case dataScale == 0 && dataPrecision <= 18:
+				column.Kind = parquetKindInt64
+			case dataScale >= 0 && dataScale <= dataPrecision && dataPrecision <= 38:
+				column.Kind = parquetKindDecimal
+				column.Precision = dataPrecision
+				column.Scale = dataScale
+			}
+		case dataType == "BINARY_FLOAT":
+			column.Kind = parquetKindFloat
+		case dataType == "FLOAT", dataType == "BINARY_DOUBLE":
+			column.Kind = parquetKindDouble
+		case dataType == "DATE":
+			column.Kind = parquetKindDate
+		case strings.HasPrefix(dataType, "TIMESTAMP") && !strings.Contains(dataType, "TIME ZONE"):
+			column.Kind = parquetKindTime
+		case dataType == "RAW", dataType == "LONG RAW", dataType == "BLOB":
+			column.Kind = parquetKindBinary
+		}
+		columns = append(columns, column)
+	}
+	return columns, nil
+}
+
+type ParquetRows struct {
+	Ctx          context.Context
+	SyncMeta     meta.FullSyncMeta
+	Oracle       *oracle.Oracle
+	Cfg          *config.Config
+	Meta         *meta.Meta
+	Storage      storage.Storage
+	Columns      []ParquetColumn
+	ReadChannel  chan [][]*string
+	WriteChannel chan []interface{}
+	// 数据文件名以及 sha256 校验和，按滚动文件顺序
+	Files     []string
+	Checksums []string
+}
+
+func NewParquetRows(ctx context.Context, syncMeta meta.FullSyncMeta,
+	oracle *oracle.Oracle, meta *meta.Meta, storage storage.Storage, cfg *config.Config, columns []ParquetColumn, hasLOB bool) *ParquetRows {
+	// LOB 表缩小通道缓冲，避免缓冲 batch 物化 LOB 占用内存过大
+	channelSize := common.ChannelBufferSize
+	if hasLOB {
+		channelSize = common.LOBChannelBufferSize
+	}
+	return &ParquetRows{
+		Ctx:          ctx,
+		SyncMeta:     syncMeta,
+		Oracle:       oracle,
+		Meta:         meta,
+		Storage:      storage,
+		Cfg:          cfg,
+		Columns:      columns,
+		ReadChannel:  make(chan [][]*string, channelSize),
+		WriteChannel: make(chan []interface{}, channelSize),
+	}
+}
+
+func (t *ParquetRows) ReadData() error {
+	startTime := time.Now()
+	// 通道关闭
+	defer close(t.ReadChannel)
+
+	querySQL := common.StringsBuilder(`SELECT `, t.SyncMeta.ColumnDetailS, ` FROM `, oracle.GenOraclePartitionTable(t.SyncMeta.SchemaNameS, t.SyncMeta.TableNameS, t.SyncMeta.PartitionNameS, t.SyncMeta.SubPartitionNameS), ` WHERE `, t.SyncMeta.ChunkDetailS)
+
+	if err := t.Oracle.GetOracleTableRowsDataParquet(querySQL, t.Cfg.AppConfig.InsertBatchSize, t.Cfg.AppConfig.LOBSizeLimit, t.Cfg.AppConfig.GetEmptyStringAs(t.SyncMeta.TableNameS), t.ReadChannel); err != nil {
+		return fmt.Errorf("source schema table chunk rows extractor failed: %v, sql: %v", err, querySQL)
+	}
+
+	zap.L().Info("source schema table chunk rows extractor finished",
+		zap.String("schema", t.SyncMeta.SchemaNameS),
+		zap.String("table", t.SyncMeta.TableNameS),
+		zap.String("chunk", t.SyncMeta.ChunkDetailS),
+		zap.String("sql", querySQL),
+		zap.String("cost", time.Now().Sub(startTime).String()))
+	return nil
+}
+
+func (t *ParquetRows) ProcessData() error {
+	// 通道关闭
+	defer close(t.WriteChannel)
+
+	for dataC := range t.ReadChannel {
+		for _, rowValues := range dataC {
+			if len(rowValues) != len(t.Columns) {
+				return fmt.Errorf("source schema table column counts vs data counts isn't match")
+			}
+			rec := make([]interface{}, len(rowValues))
+			for i, val := range rowValues {
+				v, err := t.Columns[i].Value(val)
+				if err != nil {
+					return fmt.Errorf("source schema table column [%s] value [%v] convert parquet failed: %v", t.Columns[i].ColumnName, *val, err)
+				}
+				rec[i] = v
+			}
+			t.WriteChannel <- rec
+		}
+	}
+	return nil
+}
+
+func (t *ParquetRows) ApplyData() error {
+	startTime := time.Now()
+	var metadata []string
+	for _, c := range t.Columns {
+		metadata = append(metadata, c.Metadata())
+	}
+
+	seq := 0
+	suffix := GenDataFileSuffix(t.Cfg.CSVConfig)
+	fileName := GenRollingFileName(t.SyncMeta.CSVFile, suffix, seq)
+	fileW, pw, err := t.openParquetFile(fileName, metadata)
+	if err != nil {
+		return err
+	}
+
+	for rec := range t.WriteChannel {
+		// 超过 file-size 滚动生成新文件
+		if isRollingFile(t.Cfg.CSVConfig, fileW.Size()) {
+			if err = t.closeParquetFile(fileName, fileW, pw); err != nil {
+				return err
+			}
+			seq++
+			fileName = GenRollingFileName(t.SyncMeta.CSVFile, suffix, seq)
+			fileW, pw, err = t.openParquetFile(fileName, metadata)
+			if err != nil {
+				return err
+			}
+		}
+		if err = pw.Write(rec); err != nil {
+			fileW.Abort(err)
+			return fmt.Errorf("failed to write data row to parquet: %v", err)
+		}
+	}
+
+	if err = t.closeParquetFile(fileName, fileW, pw); err != nil {
+		return err
+	}
+
+	zap.L().Info("target schema table chunk data applier finished",
+		zap.String("schema", t.SyncMeta.SchemaNameT),
+		zap.String("table", t.SyncMeta.TableNameT),
+		zap.String("chunk", t.SyncMeta.ChunkDetailS),
+		zap.String("cost", time.Now().Sub(startTime).String()))
+	return nil
+}
+
+func (t *ParquetRows) openParquetFile(fileName string, metadata []string) (*DataFile, *writer.CSVWriter, error) {
+	w, err := t.Storage.Create(fileName)
+	if err != nil {
+		return nil, nil, err
+	}
+	// parquet 数据页自带压缩，文件不再整体压缩
+	fileW, err := NewDataFile(w, "")
+	if err != nil {
+		return nil, nil, err
+	}
+	pw, err := writer.NewCSVWriterFromWriter(metadata, fileW, 1)
+	if err != nil {
+		fileW.Abort(err)
+		return nil, nil, fmt.Errorf("parquet new writer failed: %v", err)
+	}
+	switch t.Cfg.CSVConfig.Compress {
+	case common.CSVCompressGzip:
+		pw.CompressionType = parquet.CompressionCodec_GZIP
+	case common.CSVCompressZstd:
+		pw.CompressionType = parquet.CompressionCodec_ZSTD
+	default:
+		pw.CompressionType = parquet.CompressionCodec_SNAPPY
+	}
+	// 文件滚动场景按 file-size 刷新 row group，避免单个 row group 超过文件大小上限
+	if t.Cfg.CSVConfig.FileSize > 0 && int64(t.Cfg.CSVConfig.FileSize)*1024*1024 < pw.RowGroupSize {
+		pw.RowGroupSize = int64(t.Cfg.CSVConfig.FileSize) * 1024 * 1024
+	}
+	return fileW, pw, nil
+}
+
+func (t *ParquetRows) closeParquetFile(fileName string, fileW *DataFile, pw *writer.CSVWriter) error {
+	if err := pw.WriteStop(); err != nil {
+		fileW.Abort(err)
+		return fmt.Errorf("parquet writer stop failed: %v", err)
+	}
+	if err := fileW.Close(); err != nil {
+		return err
+	}
+	t.Files = append(t.Files, fileName)
+	t.Checksums = append(t.Checksums, fileW.Checksum())
+	return nil
+}
@@ -0,0 +1,236 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package o2m
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
+	"io"
+	"strings"
+)
+
+// DataFileReader 数据文件读取，按 gzip/zstd/snappy 解压
+type DataFileReader struct {
+	file         io.ReadCloser
+	decompressor io.Reader
+	closer       func()
+}
+
+func NewDataFileReader(fileR io.ReadCloser, compress string) (*DataFileReader, error) {
+	d := &DataFileReader{file: fileR}
+	buf := bufio.NewReaderSize(fileR, 4096)
+
+	switch compress {
+	case common.CSVCompressGzip:
+		gr, err := gzip.NewReader(buf)
+		if err != nil {
+			_ = fileR.Close()
+			return nil, fmt.Errorf("gzip new reader failed: %v", err)
+		}
+		d.decompressor = gr
+	case common.CSVCompressZstd:
+		zr, err := zstd.NewReader(buf)
+		if err != nil {
+			_ = fileR.Close()
+			return nil, fmt.Errorf("zstd new reader failed: %v", err)
+		}
+		d.decompressor = zr
+		d.closer = zr.Close
+	case common.CSVCompressSnappy:
+		d.decompressor = snappy.NewReader(buf)
+	default:
+		d.decompressor = buf
+	}
+	return d, nil
+}
+
+func (d *DataFileReader) Read(p []byte) (int, error) {
+	return d.decompressor.Read(p)
+}
+
+func (d *DataFileReader) Close() error {
+	if d.closer != nil {
+		d.closer()
+	}
+	return d.file.Close()
+}
+
+// DataFileParser 按 csv 配置 separator/terminator/delimiter/escape-backslash/charset 解析数据文件
+// 未被 delimiter 包裹且与 null-value 一致的字段视为 NULL 值
+type DataFileParser struct {
+	reader     *bufio.Reader
+	separator  []byte
+	terminator []byte
+	delimiter  []byte
+	nullValue  string
+	escape     bool
+	gbk        bool
+}
+
+func NewDataFileParser(r io.Reader, csvCfg config.CSVConfig) *DataFileParser {
+	return &DataFileParser{
+		reader:     bufio.NewReaderSize(r, 65536),
+		separator:  []byte(csvCfg.Separator),
+		terminator: []byte(csvCfg.Terminator),
+		delimiter:  []byte(csvCfg.Delimiter),
+		nullValue:  csvCfg.NullValue,
+		escape:     csvCfg.EscapeBackslash,
+		gbk:        strings.EqualFold(csvCfg.Charset, common.GBKCharacterSetCSV),
+	}
+}
+
+// ReadRow 读取一行数据，文件结束返回 io.EOF
+func (p *DataFileParser) ReadRow() ([]interface{}, error) {
+	var row []interface{}
+	for {
+		if len(row) == 0 {
+			if _, err := p.reader.Peek(1); err == io.EOF {
+				return nil, io.EOF
+			}
+		}
+		val, rowEnd, err := p.readField()
+		if err != nil {
+			return nil, err
+		}
+		row = append(row, val)
+		if rowEnd {
+			return row, nil
+		}
+	}
+}
+
+// readField 读取单个字段，返回字段值以及是否行结束
+func (p *DataFileParser) readField() (interface{}, bool, error) {
+	var (
+		buf    []byte
+		raw    []byte
+		quoted bool
+	)
+	if len(p.delimiter) > 0 && p.hasPrefix(p.delimiter) {
+		p.discard(len(p.delimiter))
+		quoted = true
+	}
+
+	for {
+		// delimiter 后紧跟 separator/terminator/文件结束，字段结束
+		if quoted && p.hasPrefix(p.delimiter) {
+			next := p.peekAfter(len(p.delimiter))
+			if next == nil || bytes.HasPrefix(next, p.separator) || bytes.HasPrefix(next, p.terminator) {
+				p.discard(len(p.delimiter))
+				quoted = false
+				val, err := p.fieldValue(buf, raw, true)
+				if err != nil {
+					return nil, false, err
+				}
+				rowEnd, err := p.fieldEnd()
+				return val, rowEnd, err
+			}
+		}
+		if !quoted {
+			if p.hasPrefix(p.separator) {
+				p.discard(len(p.separator))
+				val, err := p.fieldValue(buf, raw, false)
+				return val, false, err
+			}
+			if p.hasPrefix(p.terminator) {
+				p.discard(len(p.terminator))
+				val, err := p.fieldValue(buf, raw, false)
+				return val, true, err
+			}
+		}
+
+		b, err := p.reader.ReadByte()
+		if err == io.EOF {
+			if quoted {
+				return nil, false, fmt.Errorf("data file field delimiter [%s] isn't closed", p.delimiter)
+			}
+			val, err := p.fieldValue(buf, raw, false)
+			return val, true, err
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		raw = append(raw, b)
+		if p.escape && b == '\\' {
+			nb, err := p.reader.ReadByte()
+			if err != nil {
+				return nil, false, fmt.Errorf("data file escape character read failed: %v", err)
+			}
+			raw = append(raw, nb)
+			b = nb
+		}
+		buf = append(buf, b)
+	}
+}
+
+// fieldEnd 字段 delimiter 结束后，消费 separator/terminator
+func (p *DataFileParser) fieldEnd() (bool, error) {
+	if p.hasPrefix(p.separator) {
+		p.discard(len(p.separator))
+		return false, nil
+	}
+	if p.hasPrefix(p.terminator) {
+		p.discard(len(p.terminator))
+		return true, nil
+	}
+	if _, err := p.reader.Peek(1); err == io.EOF {
+		return true, nil
+	}
+	return false, fmt.Errorf("data file field delimiter [%s] isn't followed by separator or terminator", p.delimiter)
+}
+
+// fieldValue raw 为未转义原始字段值，用于匹配 null-value（例如 \N）
+func (p *DataFileParser) fieldValue(buf, raw []byte, quoted bool) (interface{}, error) {
+	if !quoted && string(raw) == p.nullValue {
+		return nil, nil
+	}
+	if p.gbk {
+		utf8Bytes, err := common.GbkToUtf8(buf)
+		if err != nil {
+			return nil, err
+		}
+		return string(utf8Bytes), nil
+	}
+	return string(buf), nil
+}
+
+func (p *DataFileParser) hasPrefix(prefix []byte) bool {
+	bs, _ := p.reader.Peek(len(prefix))
+	return len(prefix) > 0 && bytes.Equal(bs, prefix)
+}
+
+// peekAfter 预读 offset 之后的数据，offset 之后无数据返回 nil
+func (p *DataFileParser) peekAfter(offset int) []byte {
+	size := len(p.separator)
+	if len(p.terminator) > size {
+		size = len(p.terminator)
+	}
+	bs, _ := p.reader.Peek(offset + size)
+	if len(bs) <= offset {
+		return nil
+	}
+	return bs[offset:]
+}
+
+func (p *DataFileParser) discard(n int) {
+	_, _ = p.reader.Discard(n)
+}
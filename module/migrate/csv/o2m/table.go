@@ -16,7 +16,6 @@ limitations under the License.
 package o2m
 
 import (
-	"bufio"
 	"context"
 	"fmt"
 	"github.com/thinkeridea/go-extend/exstrings"
@@ -24,9 +23,8 @@ import (
 	"github.com/wentaojin/transferdb/config"
 	"github.com/wentaojin/transferdb/database/meta"
 	"github.com/wentaojin/transferdb/database/oracle"
+	"github.com/wentaojin/transferdb/module/migrate/csv/storage"
 	"go.uber.org/zap"
-	"os"
-	"path/filepath"
 	"strings"
 	"time"
 )
@@ -37,23 +35,33 @@ type Rows struct {
 	Oracle        *oracle.Oracle
 	Cfg           *config.Config
 	Meta          *meta.Meta
+	Storage       storage.Storage
 	SourceCharset string
 	ColumnNameS   []string
 	ReadChannel   chan []map[string]string
 	WriteChannel  chan string
+	// 数据文件名以及 sha256 校验和，按滚动文件顺序
+	Files     []string
+	Checksums []string
 }
 
 func NewRows(ctx context.Context, syncMeta meta.FullSyncMeta,
-	oracle *oracle.Oracle, meta *meta.Meta, cfg *config.Config, sourceCharset string, columnNameS []string) *Rows {
+	oracle *oracle.Oracle, meta *meta.Meta, storage storage.Storage, cfg *config.Config, sourceCharset string, columnNameS []string, hasLOB bool) *Rows {
 
-	writeChannel := make(chan string, common.ChannelBufferSize)
-	readChannel := make(chan []map[string]string, common.ChannelBufferSize)
+	// LOB 表缩小通道缓冲，避免缓冲 batch 物化 LOB 占用内存过大
+	channelSize := common.ChannelBufferSize
+	if hasLOB {
+		channelSize = common.LOBChannelBufferSize
+	}
+	writeChannel := make(chan string, channelSize)
+	readChannel := make(chan []map[string]string, channelSize)
 
 	return &Rows{
 		Ctx:           ctx,
 		SyncMeta:      syncMeta,
 		Oracle:        oracle,
 		Meta:          meta,
+		Storage:       storage,
 		Cfg:           cfg,
 		SourceCharset: sourceCharset,
 		ColumnNameS:   columnNameS,
@@ -70,9 +78,9 @@ func (t *Rows) ReadData() error {
 		return err
 	}
 
-	querySQL := common.StringsBuilder(`SELECT `, t.SyncMeta.ColumnDetailS, ` FROM `, t.SyncMeta.SchemaNameS, `.`, t.SyncMeta.TableNameS, ` WHERE `, t.SyncMeta.ChunkDetailS)
+	querySQL := common.StringsBuilder(`SELECT `, t.SyncMeta.ColumnDetailS, ` FROM `, oracle.GenOraclePartitionTable(t.SyncMeta.SchemaNameS, t.SyncMeta.TableNameS, t.SyncMeta.PartitionNameS, t.SyncMeta.SubPartitionNameS), ` WHERE `, t.SyncMeta.ChunkDetailS)
 
-	err := t.Oracle.GetOracleTableRowsDataCSV(querySQL, t.Cfg.AppConfig.InsertBatchSize, t.Cfg.CSVConfig, t.ReadChannel)
+	err := t.Oracle.GetOracleTableRowsDataCSV(querySQL, t.Cfg.AppConfig.InsertBatchSize, t.Cfg.AppConfig.LOBSizeLimit, t.Cfg.AppConfig.GetEmptyStringAs(t.SyncMeta.TableNameS), t.Cfg.CSVConfig, t.ReadChannel)
 	if err != nil {
 		// 错误 SQL 记录
 		errf := meta.NewChunkErrorDetailModel(t.Meta).CreateChunkErrorDetail(t.Ctx, &meta.ChunkErrorDetail{
@@ -138,37 +146,48 @@ func (t *Rows) ProcessData() error {
 
 func (t *Rows) ApplyData() error {
 	startTime := time.Now()
-	// 文件目录判断
-	if err := common.PathExist(
-		filepath.Join(
-			t.Cfg.CSVConfig.OutputDir,
-			strings.ToUpper(t.SyncMeta.SchemaNameS),
-			strings.ToUpper(t.SyncMeta.TableNameS))); err != nil {
-		return err
+	var (
+		seq    int
+		header string
+	)
+	suffix := GenDataFileSuffix(t.Cfg.CSVConfig)
+	if t.Cfg.CSVConfig.Header {
+		header = common.StringsBuilder(exstrings.Join(t.ColumnNameS, t.Cfg.CSVConfig.Separator), t.Cfg.CSVConfig.Terminator)
 	}
 
-	fileW, err := os.OpenFile(t.SyncMeta.CSVFile, os.O_WRONLY|os.O_CREATE|os.O_APPEND|os.O_TRUNC, 0666)
+	fileName := GenRollingFileName(t.SyncMeta.CSVFile, suffix, seq)
+	fileW, err := t.openDataFile(fileName, header)
 	if err != nil {
 		return err
 	}
-	defer fileW.Close()
-
-	// 使用 bufio 来缓存写入文件，以提高效率
-	writer := bufio.NewWriterSize(fileW, 4096)
-	defer writer.Flush()
-
-	if t.Cfg.CSVConfig.Header {
-		if _, err = writer.WriteString(common.StringsBuilder(exstrings.Join(t.ColumnNameS, t.Cfg.CSVConfig.Separator), t.Cfg.CSVConfig.Terminator)); err != nil {
-			return fmt.Errorf("failed to write headers: %v", err)
-		}
-	}
 
 	for dataC := range t.WriteChannel {
-		if _, err = writer.WriteString(dataC); err != nil {
+		// 超过 file-size 滚动生成新文件
+		if isRollingFile(t.Cfg.CSVConfig, fileW.Size()) {
+			if err = fileW.Close(); err != nil {
+				return err
+			}
+			t.Files = append(t.Files, fileName)
+			t.Checksums = append(t.Checksums, fileW.Checksum())
+			seq++
+			fileName = GenRollingFileName(t.SyncMeta.CSVFile, suffix, seq)
+			fileW, err = t.openDataFile(fileName, header)
+			if err != nil {
+				return err
+			}
+		}
+		if _, err = fileW.WriteString(dataC); err != nil {
+			fileW.Abort(err)
 			return fmt.Errorf("failed to write data row to csv %w", err)
 		}
 	}
 
+	if err = fileW.Close(); err != nil {
+		return err
+	}
+	t.Files = append(t.Files, fileName)
+	t.Checksums = append(t.Checksums, fileW.Checksum())
+
 	endTime := time.Now()
 	zap.L().Info("target schema table chunk data applier finished",
 		zap.String("schema", t.SyncMeta.SchemaNameT),
@@ -178,6 +197,24 @@ func (t *Rows) ApplyData() error {
 	return nil
 }
 
+func (t *Rows) openDataFile(fileName, header string) (*DataFile, error) {
+	w, err := t.Storage.Create(fileName)
+	if err != nil {
+		return nil, err
+	}
+	fileW, err := NewDataFile(w, t.Cfg.CSVConfig.Compress)
+	if err != nil {
+		return nil, err
+	}
+	if header != "" {
+		if _, err = fileW.WriteString(header); err != nil {
+			fileW.Abort(err)
+			return nil, fmt.Errorf("failed to write headers: %v", err)
+		}
+	}
+	return fileW, nil
+}
+
 func (t *Rows) AdjustDBCharsetConfig() error {
 	if t.Cfg.CSVConfig.Charset == "" {
 		if val, ok := common.OracleDBCSVCharacterSetMap[strings.ToUpper(t.SourceCharset)]; ok {
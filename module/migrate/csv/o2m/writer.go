@@ -0,0 +1,153 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package o2m
+
+import (
+	"bufio"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
+	"github.com/wentaojin/transferdb/module/migrate/csv/storage"
+	"hash"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// countWriter 统计实际写入文件字节数，用于文件滚动判断
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// DataFile 数据文件写入，支持 gzip/zstd/snappy 压缩，同时计算落盘内容 sha256 校验和
+type DataFile struct {
+	file       io.WriteCloser
+	counter    *countWriter
+	hash       hash.Hash
+	buf        *bufio.Writer
+	compressor io.WriteCloser
+	writer     io.Writer
+}
+
+func NewDataFile(fileW io.WriteCloser, compress string) (*DataFile, error) {
+	d := &DataFile{
+		file: fileW,
+		hash: sha256.New(),
+	}
+	d.counter = &countWriter{w: io.MultiWriter(fileW, d.hash)}
+	// 使用 bufio 来缓存写入文件，以提高效率
+	d.buf = bufio.NewWriterSize(d.counter, 4096)
+
+	switch compress {
+	case common.CSVCompressGzip:
+		d.compressor = gzip.NewWriter(d.buf)
+	case common.CSVCompressZstd:
+		zw, err := zstd.NewWriter(d.buf)
+		if err != nil {
+			storage.AbortFile(fileW, err)
+			return nil, fmt.Errorf("zstd new writer failed: %v", err)
+		}
+		d.compressor = zw
+	case common.CSVCompressSnappy:
+		d.compressor = snappy.NewBufferedWriter(d.buf)
+	}
+
+	if d.compressor != nil {
+		d.writer = d.compressor
+	} else {
+		d.writer = d.buf
+	}
+	return d, nil
+}
+
+func (d *DataFile) Write(p []byte) (int, error) {
+	return d.writer.Write(p)
+}
+
+func (d *DataFile) WriteString(s string) (int, error) {
+	return io.WriteString(d.writer, s)
+}
+
+// Checksum 落盘文件 sha256 校验和，Close 之后调用
+func (d *DataFile) Checksum() string {
+	return hex.EncodeToString(d.hash.Sum(nil))
+}
+
+// Size 已落盘文件大小，压缩场景为压缩后大小
+func (d *DataFile) Size() int64 {
+	return d.counter.n
+}
+
+func (d *DataFile) Close() error {
+	if d.compressor != nil {
+		if err := d.compressor.Close(); err != nil {
+			storage.AbortFile(d.file, err)
+			return err
+		}
+	}
+	if err := d.buf.Flush(); err != nil {
+		storage.AbortFile(d.file, err)
+		return err
+	}
+	return d.file.Close()
+}
+
+// Abort 写入失败放弃文件，S3 终止 multipart 上传，本地删除残缺文件
+func (d *DataFile) Abort(err error) {
+	storage.AbortFile(d.file, err)
+}
+
+// GenDataFileSuffix 数据文件后缀，csv 格式按压缩方式追加压缩后缀
+func GenDataFileSuffix(csvCfg config.CSVConfig) string {
+	if strings.EqualFold(csvCfg.FileFormat, common.CSVFileFormatParquet) {
+		return ".parquet"
+	}
+	switch csvCfg.Compress {
+	case common.CSVCompressGzip:
+		return ".csv.gz"
+	case common.CSVCompressZstd:
+		return ".csv.zst"
+	case common.CSVCompressSnappy:
+		return ".csv.snappy"
+	default:
+		return ".csv"
+	}
+}
+
+// GenRollingFileName 文件滚动命名，seq 0 为原始文件名，其余在后缀前追加序号
+func GenRollingFileName(fileName, suffix string, seq int) string {
+	if seq == 0 {
+		return fileName
+	}
+	return common.StringsBuilder(strings.TrimSuffix(fileName, suffix), ".", strconv.Itoa(seq), suffix)
+}
+
+// isRollingFile 文件大小是否超过 file-size 上限
+func isRollingFile(csvCfg config.CSVConfig, size int64) bool {
+	return csvCfg.FileSize > 0 && size >= int64(csvCfg.FileSize)*1024*1024
+}
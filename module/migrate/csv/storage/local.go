@@ -0,0 +1,67 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package storage
+
+import (
+	"github.com/wentaojin/transferdb/common"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Local 本地磁盘
+type Local struct{}
+
+func NewLocalStorage() *Local {
+	return &Local{}
+}
+
+func (l *Local) Create(fileName string) (io.WriteCloser, error) {
+	// 文件目录判断
+	if err := common.PathExist(filepath.Dir(fileName)); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(fileName, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
+	if err != nil {
+		return nil, err
+	}
+	return &localWriter{File: f}, nil
+}
+
+func (l *Local) Open(fileName string) (io.ReadCloser, error) {
+	return os.Open(fileName)
+}
+
+func (l *Local) Exist(fileName string) (bool, error) {
+	_, err := os.Stat(fileName)
+	if err == nil {
+		return true, nil
+	}
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	return false, err
+}
+
+type localWriter struct {
+	*os.File
+}
+
+// Abort 关闭并删除残缺文件
+func (w *localWriter) Abort(err error) {
+	_ = w.File.Close()
+	_ = os.Remove(w.File.Name())
+}
@@ -0,0 +1,150 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package storage
+
+import (
+	"context"
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"github.com/wentaojin/transferdb/config"
+	"io"
+	"net/http"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// S3 兼容对象存储，文件名作为对象 key
+type S3 struct {
+	ctx      context.Context
+	bucket   string
+	client   *s3.S3
+	uploader *s3manager.Uploader
+}
+
+func NewS3Storage(ctx context.Context, s3Cfg config.S3Config) (*S3, error) {
+	awsCfg := &aws.Config{
+		Region:           aws.String(s3Cfg.Region),
+		S3ForcePathStyle: aws.Bool(s3Cfg.ForcePathStyle),
+	}
+	if s3Cfg.Endpoint != "" {
+		awsCfg.Endpoint = aws.String(s3Cfg.Endpoint)
+	}
+	if s3Cfg.AccessKey != "" {
+		awsCfg.Credentials = credentials.NewStaticCredentials(s3Cfg.AccessKey, s3Cfg.SecretKey, "")
+	}
+	sess, err := session.NewSession(awsCfg)
+	if err != nil {
+		return nil, fmt.Errorf("error on new s3 session: %v", err)
+	}
+	client := s3.New(sess)
+
+	if _, err = client.HeadBucketWithContext(ctx, &s3.HeadBucketInput{Bucket: aws.String(s3Cfg.Bucket)}); err != nil {
+		return nil, fmt.Errorf("error on head s3 bucket [%s]: %v", s3Cfg.Bucket, err)
+	}
+
+	// multipart 流式上传，超过 part-size 自动分片
+	uploader := s3manager.NewUploaderWithClient(client, func(u *s3manager.Uploader) {
+		u.PartSize = int64(s3Cfg.PartSize) * 1024 * 1024
+		u.Concurrency = s3Cfg.Concurrency
+	})
+	return &S3{
+		ctx:      ctx,
+		bucket:   s3Cfg.Bucket,
+		client:   client,
+		uploader: uploader,
+	}, nil
+}
+
+func (s *S3) Create(fileName string) (io.WriteCloser, error) {
+	pr, pw := io.Pipe()
+	w := &s3Writer{
+		pw:   pw,
+		done: make(chan error, 1),
+	}
+	go func() {
+		_, err := s.uploader.UploadWithContext(s.ctx, &s3manager.UploadInput{
+			Bucket: aws.String(s.bucket),
+			Key:    aws.String(objectKey(fileName)),
+			Body:   pr,
+		})
+		if err != nil {
+			err = fmt.Errorf("s3 upload object [%s] failed: %v", objectKey(fileName), err)
+		}
+		// 上传失败，写入端立即返回错误
+		_ = pr.CloseWithError(err)
+		w.done <- err
+	}()
+	return w, nil
+}
+
+func (s *S3) Open(fileName string) (io.ReadCloser, error) {
+	out, err := s.client.GetObjectWithContext(s.ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(objectKey(fileName)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("s3 get object [%s] failed: %v", objectKey(fileName), err)
+	}
+	return out.Body, nil
+}
+
+func (s *S3) Exist(fileName string) (bool, error) {
+	_, err := s.client.HeadObjectWithContext(s.ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(objectKey(fileName)),
+	})
+	if err == nil {
+		return true, nil
+	}
+	if reqErr, ok := err.(awserr.RequestFailure); ok && reqErr.StatusCode() == http.StatusNotFound {
+		return false, nil
+	}
+	return false, fmt.Errorf("s3 head object [%s] failed: %v", objectKey(fileName), err)
+}
+
+type s3Writer struct {
+	pw   *io.PipeWriter
+	done chan error
+}
+
+func (w *s3Writer) Write(p []byte) (int, error) {
+	return w.pw.Write(p)
+}
+
+// Abort 写入端携带错误关闭，上传读取失败终止 multipart 上传，不生成对象
+func (w *s3Writer) Abort(err error) {
+	_ = w.pw.CloseWithError(fmt.Errorf("s3 writer abort: %v", err))
+	<-w.done
+}
+
+// Close 等待上传完成
+func (w *s3Writer) Close() error {
+	if err := w.pw.Close(); err != nil {
+		return err
+	}
+	return <-w.done
+}
+
+// objectKey output-dir 作为 key 前缀，去除开头 / 以及 ./
+func objectKey(fileName string) string {
+	return strings.TrimPrefix(path.Clean(filepath.ToSlash(fileName)), "/")
+}
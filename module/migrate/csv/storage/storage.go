@@ -0,0 +1,71 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package storage
+
+import (
+	"context"
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
+	"io"
+	"strings"
+)
+
+// Storage csv 数据文件输出后端
+type Storage interface {
+	// Create 创建文件写入，Close 完成后文件可见
+	Create(fileName string) (io.WriteCloser, error)
+	Open(fileName string) (io.ReadCloser, error)
+	Exist(fileName string) (bool, error)
+}
+
+func NewStorage(ctx context.Context, csvCfg config.CSVConfig) (Storage, error) {
+	switch {
+	case strings.EqualFold(csvCfg.Storage, common.CSVStorageS3):
+		return NewS3Storage(ctx, csvCfg.S3Config)
+	case strings.EqualFold(csvCfg.Storage, common.CSVStorageLocal) || csvCfg.Storage == "":
+		return NewLocalStorage(), nil
+	default:
+		return nil, fmt.Errorf("csv storage [%s] isn't support", csvCfg.Storage)
+	}
+}
+
+// Aborter 写入失败放弃文件，S3 终止 multipart 上传，本地删除残缺文件，避免残缺文件可见
+type Aborter interface {
+	Abort(err error)
+}
+
+// AbortFile 放弃写入文件，不支持放弃的写入直接关闭
+func AbortFile(w io.WriteCloser, err error) {
+	if a, ok := w.(Aborter); ok {
+		a.Abort(err)
+		return
+	}
+	_ = w.Close()
+}
+
+// WriteFile 整体写入小文件，例如 schema 文件以及 metadata 文件
+func WriteFile(s Storage, fileName string, data []byte) error {
+	w, err := s.Create(fileName)
+	if err != nil {
+		return err
+	}
+	if _, err = w.Write(data); err != nil {
+		AbortFile(w, err)
+		return err
+	}
+	return w.Close()
+}
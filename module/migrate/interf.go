@@ -32,3 +32,7 @@ type Increr interface {
 type CSVer interface {
 	CSV() error
 }
+
+type Importer interface {
+	Import() error
+}
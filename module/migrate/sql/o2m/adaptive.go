@@ -0,0 +1,323 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package o2m
+
+import (
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/database/meta"
+	"github.com/wentaojin/transferdb/database/mysql"
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 表段大小以及统计信息，Bytes 包含 LOB 段大小
+type tableSizeStatistics struct {
+	NumRows   uint64
+	AvgRowLen uint64
+	Bytes     uint64
+	LOBBytes  uint64
+}
+
+// 自适应调度表任务，Prepared 之前 chunk 未知
+type scheduleTable struct {
+	TableName   string
+	StartTime   time.Time
+	Prepared    bool
+	Chunks      []meta.FullSyncMeta
+	Next        int
+	Running     int
+	Remain      int64
+	ColumnNameS []string
+	HasLOB      bool
+	StmtCache   *mysql.MySQLStmtCache
+}
+
+type scheduleChunk struct {
+	Table    *scheduleTable
+	SyncMeta meta.FullSyncMeta
+}
+
+// 表 chunk 已全部分配
+func (t *scheduleTable) isExhausted() bool {
+	return t.Prepared && t.Next >= len(t.Chunks)
+}
+
+// chunkScheduler 表 chunk 动态调度，同时处理排序靠前未分配完成的 activeTables 张表
+// 空闲并发优先分配至运行 chunk 最少的表，表 chunk 分配完成后下一张表补入，剩余表不足时空闲并发集中至未完成的表
+type chunkScheduler struct {
+	mu           sync.Mutex
+	cond         *sync.Cond
+	tables       []*scheduleTable
+	activeTables int
+	err          error
+}
+
+func newChunkScheduler(tables []*scheduleTable, activeTables int) *chunkScheduler {
+	if activeTables <= 0 {
+		activeTables = 1
+	}
+	s := &chunkScheduler{tables: tables, activeTables: activeTables}
+	s.cond = sync.NewCond(&s.mu)
+	return s
+}
+
+// next 获取下一个待同步 chunk，表未准备完成则等待，全部分配完成或者调度失败返回 false
+func (s *chunkScheduler) next() (scheduleChunk, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for {
+		if s.err != nil {
+			return scheduleChunk{}, false
+		}
+		var (
+			selected *scheduleTable
+			actives  int
+		)
+		for _, t := range s.tables {
+			if t.isExhausted() {
+				continue
+			}
+			if actives >= s.activeTables {
+				break
+			}
+			actives++
+			if t.Prepared && (selected == nil || t.Running < selected.Running) {
+				selected = t
+			}
+		}
+		if actives == 0 {
+			return scheduleChunk{}, false
+		}
+		if selected != nil {
+			c := scheduleChunk{Table: selected, SyncMeta: selected.Chunks[selected.Next]}
+			selected.Next++
+			selected.Running++
+			return c, true
+		}
+		s.cond.Wait()
+	}
+}
+
+// done chunk 同步完成，释放表运行并发
+func (s *chunkScheduler) done(t *scheduleTable) {
+	s.mu.Lock()
+	t.Running--
+	s.mu.Unlock()
+	s.cond.Broadcast()
+}
+
+// prepared 表 chunk 准备完成，加入调度
+func (s *chunkScheduler) prepared(t *scheduleTable, chunks []meta.FullSyncMeta, columnNameS []string, hasLOB bool, stmtCache *mysql.MySQLStmtCache) {
+	s.mu.Lock()
+	t.Chunks = chunks
+	t.Remain = int64(len(chunks))
+	t.ColumnNameS = columnNameS
+	t.HasLOB = hasLOB
+	t.StmtCache = stmtCache
+	t.Prepared = true
+	s.mu.Unlock()
+	s.cond.Broadcast()
+}
+
+// abort 调度失败，等待中的并发退出
+func (s *chunkScheduler) abort(err error) {
+	s.mu.Lock()
+	if s.err == nil {
+		s.err = err
+	}
+	s.mu.Unlock()
+	s.cond.Broadcast()
+}
+
+func (r *Migrate) getTableSizeStatistics() (map[string]tableSizeStatistics, error) {
+	res, err := r.Oracle.GetOracleSchemaTableSizeStatistics(r.Cfg.OracleConfig.SchemaName)
+	if err != nil {
+		return nil, err
+	}
+	stats := make(map[string]tableSizeStatistics, len(res))
+	for _, t := range res {
+		numRows, err := common.StrconvUintBitSize(t["NUM_ROWS"], 64)
+		if err != nil {
+			return nil, fmt.Errorf("get oracle schema table [%s] num_rows [%s] strconv failed: %v", t["TABLE_NAME"], t["NUM_ROWS"], err)
+		}
+		avgRowLen, err := common.StrconvUintBitSize(t["AVG_ROW_LEN"], 64)
+		if err != nil {
+			return nil, fmt.Errorf("get oracle schema table [%s] avg_row_len [%s] strconv failed: %v", t["TABLE_NAME"], t["AVG_ROW_LEN"], err)
+		}
+		bytes, err := common.StrconvUintBitSize(t["BYTES"], 64)
+		if err != nil {
+			return nil, fmt.Errorf("get oracle schema table [%s] segment bytes [%s] strconv failed: %v", t["TABLE_NAME"], t["BYTES"], err)
+		}
+		lobBytes, err := common.StrconvUintBitSize(t["LOB_BYTES"], 64)
+		if err != nil {
+			return nil, fmt.Errorf("get oracle schema table [%s] lob segment bytes [%s] strconv failed: %v", t["TABLE_NAME"], t["LOB_BYTES"], err)
+		}
+		stats[common.StringUPPER(t["TABLE_NAME"])] = tableSizeStatistics{
+			NumRows:   numRows,
+			AvgRowLen: avgRowLen,
+			Bytes:     bytes + lobBytes,
+			LOBBytes:  lobBytes,
+		}
+	}
+	return stats, nil
+}
+
+// genTableChunkSize 表 chunk 行数，返回 false 代表小表不切分，单 chunk 全表同步
+// 未开启 adaptive-chunk 使用 chunk-size，开启则按 chunk-target-size / 平均行长计算，向上取整为 insert-batch-size 整数倍
+func (r *Migrate) genTableChunkSize(stats map[string]tableSizeStatistics, tableName string) (string, bool) {
+	chunkSize := r.Cfg.FullConfig.ChunkSize
+	if !r.Cfg.FullConfig.AdaptiveChunk {
+		return strconv.Itoa(chunkSize), true
+	}
+	stat, ok := stats[common.StringUPPER(tableName)]
+	if !ok {
+		return strconv.Itoa(chunkSize), true
+	}
+	if stat.Bytes < uint64(r.Cfg.FullConfig.SmallTableSize)<<20 {
+		return "", false
+	}
+
+	// avg_row_len 不包含行外存储 LOB，按 LOB 段平均每行大小叠加
+	avgRowLen := stat.AvgRowLen
+	if avgRowLen == 0 && stat.NumRows > 0 {
+		avgRowLen = (stat.Bytes - stat.LOBBytes) / stat.NumRows
+	}
+	if stat.NumRows > 0 {
+		avgRowLen += stat.LOBBytes / stat.NumRows
+	}
+	if avgRowLen > 0 {
+		chunkSize = int((uint64(r.Cfg.FullConfig.ChunkTargetSize) << 20) / avgRowLen)
+	}
+
+	batchSize := r.Cfg.AppConfig.InsertBatchSize
+	if batchSize > 0 {
+		chunkSize = (chunkSize + batchSize - 1) / batchSize * batchSize
+	}
+	if chunkSize <= 0 {
+		chunkSize = r.Cfg.FullConfig.ChunkSize
+	}
+	return strconv.Itoa(chunkSize), true
+}
+
+// FullScheduleSyncTable 自适应表调度
+// 1、表按段大小（含 LOB 段）从大到小排序，按 table-threads 并发准备表 chunk，准备完成即加入调度，小表单 chunk 同样进入调度
+// 2、全局 table-threads * sql-threads 并发按 chunkScheduler 消费 chunk，表完成后空闲并发自动分配至未完成的表
+// 3、表最后一个 chunk 完成后更新 wait_sync_meta，chunk 状态记录以及断点续传同 FullPartSyncTable
+func (r *Migrate) FullScheduleSyncTable(syncTables []string) error {
+	taskTime := time.Now()
+
+	stats, err := r.getTableSizeStatistics()
+	if err != nil {
+		return err
+	}
+
+	tables := make([]string, len(syncTables))
+	copy(tables, syncTables)
+	sort.SliceStable(tables, func(i, j int) bool {
+		return stats[common.StringUPPER(tables[i])].Bytes > stats[common.StringUPPER(tables[j])].Bytes
+	})
+
+	var scheduleTables []*scheduleTable
+	for _, t := range tables {
+		scheduleTables = append(scheduleTables, &scheduleTable{TableName: t})
+	}
+	defer func() {
+		for _, st := range scheduleTables {
+			if st.StmtCache != nil {
+				r.closeStmtCache(st.TableName, st.StmtCache)
+			}
+		}
+	}()
+
+	scheduler := newChunkScheduler(scheduleTables, r.Cfg.FullConfig.TableThreads)
+
+	zap.L().Info("source schema table chunk schedule start",
+		zap.String("schema", r.Cfg.OracleConfig.SchemaName),
+		zap.Strings("table order", tables),
+		zap.Int("active tables", r.Cfg.FullConfig.TableThreads),
+		zap.Int("threads", r.Cfg.FullConfig.TableThreads*r.Cfg.FullConfig.SQLThreads))
+
+	// 同步并发先于表准备启动，避免准备阶段阻塞 chunk 同步
+	syncGroup := &errgroup.Group{}
+	for i := 0; i < r.Cfg.FullConfig.TableThreads*r.Cfg.FullConfig.SQLThreads; i++ {
+		syncGroup.Go(func() error {
+			for {
+				c, ok := scheduler.next()
+				if !ok {
+					return nil
+				}
+				if err := r.syncTableChunk(c.SyncMeta, c.Table.StmtCache, c.Table.ColumnNameS, c.Table.HasLOB); err != nil {
+					scheduler.abort(err)
+					return err
+				}
+				scheduler.done(c.Table)
+				if atomic.AddInt64(&c.Table.Remain, -1) == 0 {
+					r.closeStmtCache(c.Table.TableName, c.Table.StmtCache)
+					if err := r.finishSyncTable(c.Table.TableName, c.Table.StartTime); err != nil {
+						scheduler.abort(err)
+						return err
+					}
+				}
+			}
+		})
+	}
+
+	// 表 chunk 准备并行，准备完成的表即可被同步并发获取
+	prepareGroup := &errgroup.Group{}
+	prepareGroup.SetLimit(r.Cfg.FullConfig.TableThreads)
+	for _, table := range scheduleTables {
+		st := table
+		prepareGroup.Go(func() error {
+			st.StartTime = time.Now()
+			waitFullMetas, columnNameS, hasLOB, err := r.prepareSyncTable(st.TableName)
+			if err != nil {
+				scheduler.abort(err)
+				return err
+			}
+			if len(waitFullMetas) == 0 {
+				scheduler.prepared(st, nil, columnNameS, hasLOB, nil)
+				if err = r.finishSyncTable(st.TableName, st.StartTime); err != nil {
+					scheduler.abort(err)
+					return err
+				}
+				return nil
+			}
+			scheduler.prepared(st, waitFullMetas, columnNameS, hasLOB, r.Mysql.NewMySQLStmtCache())
+			return nil
+		})
+	}
+
+	// 准备失败同样等待同步并发退出，避免关闭使用中的 prepared statement
+	errPrepare := prepareGroup.Wait()
+	if err = syncGroup.Wait(); err != nil {
+		return err
+	}
+	if errPrepare != nil {
+		return errPrepare
+	}
+
+	zap.L().Info("source schema all table data loader finished",
+		zap.String("schema", r.Cfg.OracleConfig.SchemaName),
+		zap.Int("table totals", len(syncTables)),
+		zap.String("cost", time.Now().Sub(taskTime).String()))
+	return nil
+}
@@ -44,6 +44,10 @@ func NewFuller(ctx context.Context, cfg *config.Config) (*Migrate, error) {
 	if err != nil {
 		return nil, err
 	}
+	oracleDB.Throttle, err = oracle.NewThrottle(ctx, oracleDB, cfg.ThrottleConfig)
+	if err != nil {
+		return nil, err
+	}
 	mysqlDB, err := mysql.NewMySQLDBEngine(ctx, cfg.MySQLConfig)
 	if err != nil {
 		return nil, err
@@ -122,14 +126,20 @@ func (r *Migrate) Full() error {
 			if err != nil {
 				return err
 			}
-			// 清理已有表数据
-			if err := r.Mysql.TruncateMySQLTable(r.Cfg.MySQLConfig.SchemaName, tableName); err != nil {
-				return err
+			// 清理已有表数据，指定分区表下游保留未选择分区数据，不清理，选择分区数据按 REPLACE 重新写入
+			if _, ok := r.Cfg.AppConfig.GetPartitionTableConfig(tableName); ok {
+				zap.L().Warn("skip truncate partition selected table",
+					zap.String("schema", r.Cfg.MySQLConfig.SchemaName),
+					zap.String("table", tableName))
+			} else {
+				if err := r.Mysql.TruncateMySQLTable(r.Cfg.MySQLConfig.SchemaName, tableName); err != nil {
+					return err
+				}
+				zap.L().Info("truncate table",
+					zap.String("schema", r.Cfg.MySQLConfig.SchemaName),
+					zap.String("table", tableName),
+					zap.String("status", "success"))
 			}
-			zap.L().Info("truncate table",
-				zap.String("schema", r.Cfg.MySQLConfig.SchemaName),
-				zap.String("table", tableName),
-				zap.String("status", "success"))
 
 			// 判断并记录待同步表列表
 			waitSyncMetas, err := meta.NewWaitSyncMetaModel(r.MetaDB).DetailWaitSyncMeta(r.Ctx, &meta.WaitSyncMeta{
@@ -311,16 +321,33 @@ func (r *Migrate) Full() error {
 	// 数据迁移
 	// 优先存在断点的表
 	// partSyncTables -> waitSyncTables
-	if len(partSyncTables) > 0 {
-		err = r.FullPartSyncTable(partSyncTables)
-		if err != nil {
-			return err
+	if r.Cfg.FullConfig.AdaptiveSchedule {
+		// 自适应调度，断点表以及待同步表 chunk 统一队列调度
+		if len(waitSyncTables) > 0 {
+			err = r.InitWaitSyncTableChunk(waitSyncTables, oracleCollation)
+			if err != nil {
+				return err
+			}
 		}
-	}
-	if len(waitSyncTables) > 0 {
-		err = r.FullWaitSyncTable(waitSyncTables, oracleCollation)
-		if err != nil {
-			return err
+		syncTables := append(partSyncTables, waitSyncTables...)
+		if len(syncTables) > 0 {
+			err = r.FullScheduleSyncTable(syncTables)
+			if err != nil {
+				return err
+			}
+		}
+	} else {
+		if len(partSyncTables) > 0 {
+			err = r.FullPartSyncTable(partSyncTables)
+			if err != nil {
+				return err
+			}
+		}
+		if len(waitSyncTables) > 0 {
+			err = r.FullWaitSyncTable(waitSyncTables, oracleCollation)
+			if err != nil {
+				return err
+			}
 		}
 	}
 
@@ -366,101 +393,21 @@ func (r *Migrate) FullPartSyncTable(fullPartTables []string) error {
 		t := table
 		g.Go(func() error {
 			startTime := time.Now()
-			err := meta.NewWaitSyncMetaModel(r.MetaDB).UpdateWaitSyncMeta(r.Ctx, &meta.WaitSyncMeta{
-				DBTypeS:     r.Cfg.DBTypeS,
-				DBTypeT:     r.Cfg.DBTypeT,
-				SchemaNameS: common.StringUPPER(r.Cfg.OracleConfig.SchemaName),
-				TableNameS:  common.StringUPPER(t),
-				TaskMode:    r.Cfg.TaskMode,
-			}, map[string]interface{}{
-				"TaskStatus": common.TaskStatusRunning,
-			})
-			if err != nil {
-				return err
-			}
-
-			waitFullMetas, err := meta.NewFullSyncMetaModel(r.MetaDB).DetailFullSyncMeta(r.Ctx, &meta.FullSyncMeta{
-				DBTypeS:     r.Cfg.DBTypeS,
-				DBTypeT:     r.Cfg.DBTypeT,
-				SchemaNameS: common.StringUPPER(r.Cfg.OracleConfig.SchemaName),
-				TableNameS:  common.StringUPPER(t),
-				TaskMode:    r.Cfg.TaskMode,
-				TaskStatus:  common.TaskStatusWaiting,
-			})
-			if err != nil {
-				return err
-			}
-			failedFullMetas, err := meta.NewFullSyncMetaModel(r.MetaDB).DetailFullSyncMeta(r.Ctx, &meta.FullSyncMeta{
-				DBTypeS:     r.Cfg.DBTypeS,
-				DBTypeT:     r.Cfg.DBTypeT,
-				SchemaNameS: common.StringUPPER(r.Cfg.OracleConfig.SchemaName),
-				TableNameS:  common.StringUPPER(t),
-				TaskMode:    r.Cfg.TaskMode,
-				TaskStatus:  common.TaskStatusFailed,
-			})
+			waitFullMetas, columnNameS, hasLOB, err := r.prepareSyncTable(t)
 			if err != nil {
 				return err
 			}
 
-			waitFullMetas = append(waitFullMetas, failedFullMetas...)
-
-			columnNameS, err := r.Oracle.GetOracleTableRowsColumn(
-				common.StringsBuilder(`SELECT *`, ` FROM `,
-					common.StringUPPER(r.Cfg.OracleConfig.SchemaName), `.`, common.StringUPPER(t), ` WHERE ROWNUM = 1`))
-			if err != nil {
-				return nil
-			}
+			// 表级 prepared statement 缓存，表内 chunk 共享
+			stmtCache := r.Mysql.NewMySQLStmtCache()
+			defer r.closeStmtCache(t, stmtCache)
 
 			g1 := &errgroup.Group{}
 			g1.SetLimit(r.Cfg.FullConfig.SQLThreads)
 			for _, fullMeta := range waitFullMetas {
 				m := fullMeta
 				g1.Go(func() error {
-					// 数据写入
-					err := IMigrate(NewRows(r.Ctx, m, r.Oracle, r.Mysql, r.MetaDB, r.Cfg.FullConfig.ApplyThreads, r.Cfg.AppConfig.InsertBatchSize, true, columnNameS))
-
-					if err != nil {
-						// record error, skip error
-						errf := meta.NewCommonModel(r.MetaDB).UpdateFullSyncMetaChunkAndCreateChunkErrorDetail(r.Ctx, &meta.FullSyncMeta{
-							DBTypeS:      m.DBTypeS,
-							DBTypeT:      m.DBTypeT,
-							SchemaNameS:  m.SchemaNameS,
-							TableNameS:   m.TableNameS,
-							TaskMode:     m.TaskMode,
-							ChunkDetailS: m.ChunkDetailS,
-						}, map[string]interface{}{
-							"TaskStatus": common.TaskStatusFailed,
-						}, &meta.ChunkErrorDetail{
-							DBTypeS:      m.DBTypeS,
-							DBTypeT:      m.DBTypeT,
-							SchemaNameS:  m.SchemaNameS,
-							TableNameS:   m.TableNameS,
-							SchemaNameT:  m.SchemaNameT,
-							TableNameT:   m.TableNameT,
-							TaskMode:     m.TaskMode,
-							ChunkDetailS: m.ChunkDetailS,
-							InfoDetail:   m.String(),
-							ErrorDetail:  err.Error(),
-						})
-						if errf != nil {
-							return fmt.Errorf("get oracle schema table [%v] IMigrate failed: %v", m.String(), errf)
-						}
-						return nil
-					}
-
-					if errf := meta.NewFullSyncMetaModel(r.MetaDB).UpdateFullSyncMetaChunk(r.Ctx, &meta.FullSyncMeta{
-						DBTypeS:      m.DBTypeS,
-						DBTypeT:      m.DBTypeT,
-						SchemaNameS:  m.SchemaNameS,
-						TableNameS:   m.TableNameS,
-						TaskMode:     m.TaskMode,
-						ChunkDetailS: m.ChunkDetailS,
-					}, map[string]interface{}{
-						"TaskStatus": common.TaskStatusSuccess,
-					}); errf != nil {
-						return fmt.Errorf("get oracle schema table [%v] Success failed: %v", m.String(), errf)
-					}
-					return nil
+					return r.syncTableChunk(m, stmtCache, columnNameS, hasLOB)
 				})
 			}
 
@@ -468,81 +415,7 @@ func (r *Migrate) FullPartSyncTable(fullPartTables []string) error {
 				return err
 			}
 
-			// 清理元数据记录
-			// 更新 wait_sync_meta 记录
-			failedChunkTotalErrs, err := meta.NewFullSyncMetaModel(r.MetaDB).CountsErrorFullSyncMeta(r.Ctx, &meta.FullSyncMeta{
-				DBTypeS:     r.Cfg.DBTypeS,
-				DBTypeT:     r.Cfg.DBTypeT,
-				SchemaNameS: common.StringUPPER(r.Cfg.OracleConfig.SchemaName),
-				TableNameS:  common.StringUPPER(t),
-				TaskMode:    r.Cfg.TaskMode,
-				TaskStatus:  common.TaskStatusFailed,
-			})
-			if err != nil {
-				return fmt.Errorf("get meta table [full_sync_meta] counts failed, error: %v", err)
-			}
-			successChunkFullMeta, err := meta.NewFullSyncMetaModel(r.MetaDB).DetailFullSyncMeta(r.Ctx, &meta.FullSyncMeta{
-				DBTypeS:     r.Cfg.DBTypeS,
-				DBTypeT:     r.Cfg.DBTypeT,
-				SchemaNameS: common.StringUPPER(r.Cfg.OracleConfig.SchemaName),
-				TableNameS:  common.StringUPPER(t),
-				TaskMode:    r.Cfg.TaskMode,
-				TaskStatus:  common.TaskStatusSuccess,
-			})
-			if err != nil {
-				return err
-			}
-
-			// 不存在错误，清理 full_sync_meta 记录, 更新 wait_sync_meta 记录
-			if failedChunkTotalErrs == 0 {
-				err = meta.NewCommonModel(r.MetaDB).DeleteTableFullSyncMetaAndUpdateWaitSyncMeta(r.Ctx,
-					&meta.FullSyncMeta{
-						DBTypeS:     r.Cfg.DBTypeS,
-						DBTypeT:     r.Cfg.DBTypeT,
-						SchemaNameS: common.StringUPPER(r.Cfg.OracleConfig.SchemaName),
-						TableNameS:  common.StringUPPER(t),
-						TaskMode:    r.Cfg.TaskMode,
-					}, &meta.WaitSyncMeta{
-						DBTypeS:          r.Cfg.DBTypeS,
-						DBTypeT:          r.Cfg.DBTypeT,
-						SchemaNameS:      common.StringUPPER(r.Cfg.OracleConfig.SchemaName),
-						TableNameS:       common.StringUPPER(t),
-						TaskMode:         r.Cfg.TaskMode,
-						TaskStatus:       common.TaskStatusSuccess,
-						ChunkSuccessNums: int64(len(successChunkFullMeta)),
-						ChunkFailedNums:  0,
-					})
-				if err != nil {
-					return err
-				}
-				zap.L().Info("full single table oracle to mysql finished",
-					zap.String("schema", r.Cfg.OracleConfig.SchemaName),
-					zap.String("table", common.StringUPPER(t)),
-					zap.String("cost", time.Now().Sub(startTime).String()))
-			} else {
-				// 若存在错误，修改表状态，skip 清理，统一忽略，最后显示
-				err = meta.NewWaitSyncMetaModel(r.MetaDB).UpdateWaitSyncMeta(r.Ctx, &meta.WaitSyncMeta{
-					DBTypeS:     r.Cfg.DBTypeS,
-					DBTypeT:     r.Cfg.DBTypeT,
-					SchemaNameS: common.StringUPPER(r.Cfg.OracleConfig.SchemaName),
-					TableNameS:  common.StringUPPER(t),
-					TaskMode:    r.Cfg.TaskMode,
-				}, map[string]interface{}{
-					"TaskStatus":       common.TaskStatusFailed,
-					"ChunkSuccessNums": int64(len(successChunkFullMeta)),
-					"ChunkFailedNums":  failedChunkTotalErrs,
-				})
-				if err != nil {
-					return err
-				}
-				zap.L().Warn("update mysql [wait_sync_meta] meta",
-					zap.String("schema", r.Cfg.OracleConfig.SchemaName),
-					zap.String("table", common.StringUPPER(t)),
-					zap.String("mode", r.Cfg.TaskMode),
-					zap.String("updated", "table exist error, skip"),
-					zap.String("cost", time.Now().Sub(startTime).String()))
-			}
-			return nil
+			return r.finishSyncTable(t, startTime)
 		})
 	}
 
@@ -557,6 +430,199 @@ func (r *Migrate) FullPartSyncTable(fullPartTables []string) error {
 	return nil
 }
 
+// prepareSyncTable 更新表状态 RUNNING，返回待同步以及失败的 chunk 以及表字段
+func (r *Migrate) prepareSyncTable(t string) ([]meta.FullSyncMeta, []string, bool, error) {
+	err := meta.NewWaitSyncMetaModel(r.MetaDB).UpdateWaitSyncMeta(r.Ctx, &meta.WaitSyncMeta{
+		DBTypeS:     r.Cfg.DBTypeS,
+		DBTypeT:     r.Cfg.DBTypeT,
+		SchemaNameS: common.StringUPPER(r.Cfg.OracleConfig.SchemaName),
+		TableNameS:  common.StringUPPER(t),
+		TaskMode:    r.Cfg.TaskMode,
+	}, map[string]interface{}{
+		"TaskStatus": common.TaskStatusRunning,
+	})
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	waitFullMetas, err := meta.NewFullSyncMetaModel(r.MetaDB).DetailFullSyncMeta(r.Ctx, &meta.FullSyncMeta{
+		DBTypeS:     r.Cfg.DBTypeS,
+		DBTypeT:     r.Cfg.DBTypeT,
+		SchemaNameS: common.StringUPPER(r.Cfg.OracleConfig.SchemaName),
+		TableNameS:  common.StringUPPER(t),
+		TaskMode:    r.Cfg.TaskMode,
+		TaskStatus:  common.TaskStatusWaiting,
+	})
+	if err != nil {
+		return nil, nil, false, err
+	}
+	failedFullMetas, err := meta.NewFullSyncMetaModel(r.MetaDB).DetailFullSyncMeta(r.Ctx, &meta.FullSyncMeta{
+		DBTypeS:     r.Cfg.DBTypeS,
+		DBTypeT:     r.Cfg.DBTypeT,
+		SchemaNameS: common.StringUPPER(r.Cfg.OracleConfig.SchemaName),
+		TableNameS:  common.StringUPPER(t),
+		TaskMode:    r.Cfg.TaskMode,
+		TaskStatus:  common.TaskStatusFailed,
+	})
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	waitFullMetas = append(waitFullMetas, failedFullMetas...)
+
+	columnNameS, err := r.Oracle.GetOracleTableRowsColumn(
+		common.StringsBuilder(`SELECT *`, ` FROM `,
+			common.StringUPPER(r.Cfg.OracleConfig.SchemaName), `.`, common.StringUPPER(t), ` WHERE ROWNUM = 1`))
+	if err != nil {
+		return nil, nil, false, err
+	}
+	hasLOB, err := r.Oracle.IsOracleTableRowsLOB(
+		common.StringsBuilder(`SELECT *`, ` FROM `,
+			common.StringUPPER(r.Cfg.OracleConfig.SchemaName), `.`, common.StringUPPER(t), ` WHERE ROWNUM = 1`))
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	return waitFullMetas, columnNameS, hasLOB, nil
+}
+
+// syncTableChunk 单 chunk 数据同步，错误记录 chunk_error_detail 并跳过
+func (r *Migrate) syncTableChunk(m meta.FullSyncMeta, stmtCache *mysql.MySQLStmtCache, columnNameS []string, hasLOB bool) error {
+	// 数据写入
+	err := IMigrate(NewRows(r.Ctx, m, r.Oracle, r.Mysql, r.MetaDB, stmtCache, r.Cfg.FullConfig.ApplyThreads, r.Cfg.AppConfig.InsertBatchSize, r.Cfg.AppConfig.LOBSizeLimit, r.Cfg.AppConfig.GetEmptyStringAs(m.TableNameS), true, columnNameS, hasLOB))
+
+	if err != nil {
+		// record error, skip error
+		errf := meta.NewCommonModel(r.MetaDB).UpdateFullSyncMetaChunkAndCreateChunkErrorDetail(r.Ctx, &meta.FullSyncMeta{
+			DBTypeS:      m.DBTypeS,
+			DBTypeT:      m.DBTypeT,
+			SchemaNameS:  m.SchemaNameS,
+			TableNameS:   m.TableNameS,
+			TaskMode:     m.TaskMode,
+			ChunkDetailS: m.ChunkDetailS,
+		}, map[string]interface{}{
+			"TaskStatus": common.TaskStatusFailed,
+		}, &meta.ChunkErrorDetail{
+			DBTypeS:      m.DBTypeS,
+			DBTypeT:      m.DBTypeT,
+			SchemaNameS:  m.SchemaNameS,
+			TableNameS:   m.TableNameS,
+			SchemaNameT:  m.SchemaNameT,
+			TableNameT:   m.TableNameT,
+			TaskMode:     m.TaskMode,
+			ChunkDetailS: m.ChunkDetailS,
+			InfoDetail:   m.String(),
+			ErrorDetail:  err.Error(),
+		})
+		if errf != nil {
+			return fmt.Errorf("get oracle schema table [%v] IMigrate failed: %v", m.String(), errf)
+		}
+		return meta.NewFullSyncMetaModel(r.MetaDB).LogFullSyncMetaPartitionProgress(r.Ctx, &m)
+	}
+
+	if errf := meta.NewFullSyncMetaModel(r.MetaDB).UpdateFullSyncMetaChunk(r.Ctx, &meta.FullSyncMeta{
+		DBTypeS:      m.DBTypeS,
+		DBTypeT:      m.DBTypeT,
+		SchemaNameS:  m.SchemaNameS,
+		TableNameS:   m.TableNameS,
+		TaskMode:     m.TaskMode,
+		ChunkDetailS: m.ChunkDetailS,
+	}, map[string]interface{}{
+		"TaskStatus": common.TaskStatusSuccess,
+	}); errf != nil {
+		return fmt.Errorf("get oracle schema table [%v] Success failed: %v", m.String(), errf)
+	}
+	return meta.NewFullSyncMetaModel(r.MetaDB).LogFullSyncMetaPartitionProgress(r.Ctx, &m)
+}
+
+// finishSyncTable 表全部 chunk 完成后按 chunk 状态更新 wait_sync_meta
+func (r *Migrate) finishSyncTable(t string, startTime time.Time) error {
+	// 清理元数据记录
+	// 更新 wait_sync_meta 记录
+	failedChunkTotalErrs, err := meta.NewFullSyncMetaModel(r.MetaDB).CountsErrorFullSyncMeta(r.Ctx, &meta.FullSyncMeta{
+		DBTypeS:     r.Cfg.DBTypeS,
+		DBTypeT:     r.Cfg.DBTypeT,
+		SchemaNameS: common.StringUPPER(r.Cfg.OracleConfig.SchemaName),
+		TableNameS:  common.StringUPPER(t),
+		TaskMode:    r.Cfg.TaskMode,
+		TaskStatus:  common.TaskStatusFailed,
+	})
+	if err != nil {
+		return fmt.Errorf("get meta table [full_sync_meta] counts failed, error: %v", err)
+	}
+	successChunkFullMeta, err := meta.NewFullSyncMetaModel(r.MetaDB).DetailFullSyncMeta(r.Ctx, &meta.FullSyncMeta{
+		DBTypeS:     r.Cfg.DBTypeS,
+		DBTypeT:     r.Cfg.DBTypeT,
+		SchemaNameS: common.StringUPPER(r.Cfg.OracleConfig.SchemaName),
+		TableNameS:  common.StringUPPER(t),
+		TaskMode:    r.Cfg.TaskMode,
+		TaskStatus:  common.TaskStatusSuccess,
+	})
+	if err != nil {
+		return err
+	}
+
+	// 不存在错误，清理 full_sync_meta 记录, 更新 wait_sync_meta 记录
+	if failedChunkTotalErrs == 0 {
+		err = meta.NewCommonModel(r.MetaDB).DeleteTableFullSyncMetaAndUpdateWaitSyncMeta(r.Ctx,
+			&meta.FullSyncMeta{
+				DBTypeS:     r.Cfg.DBTypeS,
+				DBTypeT:     r.Cfg.DBTypeT,
+				SchemaNameS: common.StringUPPER(r.Cfg.OracleConfig.SchemaName),
+				TableNameS:  common.StringUPPER(t),
+				TaskMode:    r.Cfg.TaskMode,
+			}, &meta.WaitSyncMeta{
+				DBTypeS:          r.Cfg.DBTypeS,
+				DBTypeT:          r.Cfg.DBTypeT,
+				SchemaNameS:      common.StringUPPER(r.Cfg.OracleConfig.SchemaName),
+				TableNameS:       common.StringUPPER(t),
+				TaskMode:         r.Cfg.TaskMode,
+				TaskStatus:       common.TaskStatusSuccess,
+				ChunkSuccessNums: int64(len(successChunkFullMeta)),
+				ChunkFailedNums:  0,
+			})
+		if err != nil {
+			return err
+		}
+		zap.L().Info("full single table oracle to mysql finished",
+			zap.String("schema", r.Cfg.OracleConfig.SchemaName),
+			zap.String("table", common.StringUPPER(t)),
+			zap.String("cost", time.Now().Sub(startTime).String()))
+	} else {
+		// 若存在错误，修改表状态，skip 清理，统一忽略，最后显示
+		err = meta.NewWaitSyncMetaModel(r.MetaDB).UpdateWaitSyncMeta(r.Ctx, &meta.WaitSyncMeta{
+			DBTypeS:     r.Cfg.DBTypeS,
+			DBTypeT:     r.Cfg.DBTypeT,
+			SchemaNameS: common.StringUPPER(r.Cfg.OracleConfig.SchemaName),
+			TableNameS:  common.StringUPPER(t),
+			TaskMode:    r.Cfg.TaskMode,
+		}, map[string]interface{}{
+			"TaskStatus":       common.TaskStatusFailed,
+			"ChunkSuccessNums": int64(len(successChunkFullMeta)),
+			"ChunkFailedNums":  failedChunkTotalErrs,
+		})
+		if err != nil {
+			return err
+		}
+		zap.L().Warn("update mysql [wait_sync_meta] meta",
+			zap.String("schema", r.Cfg.OracleConfig.SchemaName),
+			zap.String("table", common.StringUPPER(t)),
+			zap.String("mode", r.Cfg.TaskMode),
+			zap.String("updated", "table exist error, skip"),
+			zap.String("cost", time.Now().Sub(startTime).String()))
+	}
+	return nil
+}
+
+func (r *Migrate) closeStmtCache(t string, stmtCache *mysql.MySQLStmtCache) {
+	if err := stmtCache.Close(); err != nil {
+		zap.L().Warn("target schema table prepared statement close failed",
+			zap.String("schema", common.StringUPPER(r.Cfg.OracleConfig.SchemaName)),
+			zap.String("table", common.StringUPPER(t)),
+			zap.Error(err))
+	}
+}
+
 func (r *Migrate) FullWaitSyncTable(fullWaitTables []string, oracleCollation bool) error {
 	err := r.InitWaitSyncTableChunk(fullWaitTables, oracleCollation)
 	if err != nil {
@@ -588,6 +654,15 @@ func (r *Migrate) InitWaitSyncTableChunk(csvWaitTables []string, oracleCollation
 		return err
 	}
 
+	// 表段大小以及统计信息，用于 chunk 自适应切分
+	var tableStats map[string]tableSizeStatistics
+	if r.Cfg.FullConfig.AdaptiveChunk {
+		tableStats, err = r.getTableSizeStatistics()
+		if err != nil {
+			return err
+		}
+	}
+
 	g := &errgroup.Group{}
 	g.SetLimit(r.Cfg.FullConfig.TaskThreads)
 
@@ -622,8 +697,15 @@ func (r *Migrate) InitWaitSyncTableChunk(csvWaitTables []string, oracleCollation
 			if err != nil {
 				return err
 			}
-			// 统计信息数据行数 0，直接全表扫
-			if tableRowsByStatistics == 0 {
+			// 分区表按分区/子分区数据段切分，不走全表扫
+			isPartitionWise := isPartition == "YES" && r.Cfg.AppConfig.IsPartitionWise(t)
+
+			chunkSize, isSplit := r.genTableChunkSize(tableStats, t)
+			if !isSplit && isPartitionWise {
+				chunkSize = strconv.Itoa(r.Cfg.FullConfig.ChunkSize)
+			}
+			// 统计信息数据行数 0 或者小表，直接全表扫
+			if !isPartitionWise && (tableRowsByStatistics == 0 || !isSplit) {
 				err = meta.NewCommonModel(r.MetaDB).CreateFullSyncMetaAndUpdateWaitSyncMeta(r.Ctx, &meta.FullSyncMeta{
 					DBTypeS:       r.Cfg.DBTypeS,
 					DBTypeT:       r.Cfg.DBTypeT,
@@ -661,13 +743,29 @@ func (r *Migrate) InitWaitSyncTableChunk(csvWaitTables []string, oracleCollation
 				return err
 			}
 
-			if err = r.Oracle.StartOracleCreateChunkByRowID(taskName, common.StringUPPER(r.Cfg.OracleConfig.SchemaName), common.StringUPPER(t), strconv.Itoa(r.Cfg.CSVConfig.Rows)); err != nil {
-				return err
-			}
-
-			chunkRes, err := r.Oracle.GetOracleTableChunksByRowID(taskName)
-			if err != nil {
-				return err
+			var chunkRes []map[string]string
+			if isPartitionWise {
+				// 仅按指定分区数据段切分 chunk
+				partitionCfg, _ := r.Cfg.AppConfig.GetPartitionTableConfig(t)
+				segments, err := r.Oracle.GetOracleTableSelectPartitionSegments(r.Cfg.OracleConfig.SchemaName, t, partitionCfg.Partitions, partitionCfg.RecentPartitions)
+				if err != nil {
+					return err
+				}
+				if err = r.Oracle.StartOracleCreateChunkBySegments(taskName, common.StringUPPER(r.Cfg.OracleConfig.SchemaName), common.StringUPPER(t), segments, chunkSize); err != nil {
+					return err
+				}
+				chunkRes, err = r.Oracle.GetOracleTablePartitionChunksByRowID(taskName, r.Cfg.OracleConfig.SchemaName, t, segments)
+				if err != nil {
+					return err
+				}
+			} else {
+				if err = r.Oracle.StartOracleCreateChunkByRowID(taskName, common.StringUPPER(r.Cfg.OracleConfig.SchemaName), common.StringUPPER(t), chunkSize); err != nil {
+					return err
+				}
+				chunkRes, err = r.Oracle.GetOracleTableChunksByRowID(taskName)
+				if err != nil {
+					return err
+				}
 			}
 
 			// 判断数据是否存在
@@ -707,17 +805,19 @@ func (r *Migrate) InitWaitSyncTableChunk(csvWaitTables []string, oracleCollation
 			var fullMetas []meta.FullSyncMeta
 			for _, res := range chunkRes {
 				fullMetas = append(fullMetas, meta.FullSyncMeta{
-					DBTypeS:       r.Cfg.DBTypeS,
-					DBTypeT:       r.Cfg.DBTypeT,
-					SchemaNameS:   common.StringUPPER(r.Cfg.OracleConfig.SchemaName),
-					TableNameS:    common.StringUPPER(t),
-					SchemaNameT:   common.StringUPPER(r.Cfg.MySQLConfig.SchemaName),
-					TableNameT:    common.StringUPPER(targetTableName),
-					GlobalScnS:    globalSCN,
-					ColumnDetailS: sourceColumnInfo,
-					ChunkDetailS:  res["CMD"],
-					TaskMode:      r.Cfg.TaskMode,
-					TaskStatus:    common.TaskStatusWaiting,
+					DBTypeS:           r.Cfg.DBTypeS,
+					DBTypeT:           r.Cfg.DBTypeT,
+					SchemaNameS:       common.StringUPPER(r.Cfg.OracleConfig.SchemaName),
+					TableNameS:        common.StringUPPER(t),
+					SchemaNameT:       common.StringUPPER(r.Cfg.MySQLConfig.SchemaName),
+					TableNameT:        common.StringUPPER(targetTableName),
+					GlobalScnS:        globalSCN,
+					ColumnDetailS:     sourceColumnInfo,
+					ChunkDetailS:      res["CMD"],
+					PartitionNameS:    res["PARTITION_NAME"],
+					SubPartitionNameS: res["SUBPARTITION_NAME"],
+					TaskMode:          r.Cfg.TaskMode,
+					TaskStatus:        common.TaskStatusWaiting,
 				})
 			}
 
@@ -797,10 +897,18 @@ func (r *Migrate) AdjustTableSelectColumn(sourceTable string, oracleCollation bo
 	if err != nil {
 		return "", err
 	}
+	// 虚拟列下游为生成列，不允许写入，排除
+	virtualColumns, err := r.Oracle.GetOracleSchemaTableVirtualColumn(r.Cfg.OracleConfig.SchemaName, sourceTable)
+	if err != nil {
+		return "", err
+	}
 
 	var columnNames []string
 
 	for _, rowCol := range columnsINFO {
+		if common.IsContainString(virtualColumns, rowCol["COLUMN_NAME"]) {
+			continue
+		}
 		switch strings.ToUpper(rowCol["DATA_TYPE"]) {
 		// 数字
 		case "NUMBER":
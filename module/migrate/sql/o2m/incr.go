@@ -34,6 +34,10 @@ func NewIncr(ctx context.Context, cfg *config.Config) (*Migrate, error) {
 	if err != nil {
 		return nil, err
 	}
+	oracleDB.Throttle, err = oracle.NewThrottle(ctx, oracleDB, cfg.ThrottleConfig)
+	if err != nil {
+		return nil, err
+	}
 	oracleMiner, err := oracle.NewOracleLogminerEngine(ctx, cfg.OracleConfig)
 	if err != nil {
 		return nil, err
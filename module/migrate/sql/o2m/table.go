@@ -18,13 +18,13 @@ package o2m
 import (
 	"context"
 	"fmt"
-	"github.com/thinkeridea/go-extend/exstrings"
 	"github.com/wentaojin/transferdb/common"
 	"github.com/wentaojin/transferdb/database/meta"
 	"github.com/wentaojin/transferdb/database/mysql"
 	"github.com/wentaojin/transferdb/database/oracle"
 	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
+	"strings"
 	"time"
 )
 
@@ -34,41 +34,67 @@ type Rows struct {
 	Oracle       *oracle.Oracle
 	MySQL        *mysql.MySQL
 	Meta         *meta.Meta
+	StmtCache    *mysql.MySQLStmtCache
 	ApplyThreads int
 	BatchSize    int
-	SafeMode     bool
-	ColumnNameS  []string
-	ReadChannel  chan []map[string]string
-	WriteChannel chan string
+	LOBSizeLimit int
+	// 字符类型字段 NULL 写入下游方式 null/empty
+	EmptyStringAs string
+	SafeMode      bool
+	ColumnNameS   []string
+	ReadChannel   chan []map[string]interface{}
+	WriteChannel  chan BindRows
+}
+
+// BindRows 绑定变量 batch 行数据，Args 按 ColumnNameS 顺序平铺
+type BindRows struct {
+	Rows int
+	Args []interface{}
 }
 
 func NewRows(ctx context.Context, syncMeta meta.FullSyncMeta,
-	oracle *oracle.Oracle, mysql *mysql.MySQL, meta *meta.Meta, applyThreads, batchSize int, safeMode bool,
-	columnNameS []string) *Rows {
+	oracle *oracle.Oracle, mysql *mysql.MySQL, meta *meta.Meta, stmtCache *mysql.MySQLStmtCache, applyThreads, batchSize, lobSizeLimit int, emptyStringAs string, safeMode bool,
+	columnNameS []string, hasLOB bool) *Rows {
+
+	// LOB 表缩小通道缓冲，避免缓冲 batch 物化 LOB 占用内存过大
+	channelSize := common.ChannelBufferSize
+	if hasLOB {
+		channelSize = common.LOBChannelBufferSize
+	}
+	readChannel := make(chan []map[string]interface{}, channelSize)
+	writeChannel := make(chan BindRows, channelSize)
 
-	readChannel := make(chan []map[string]string, common.ChannelBufferSize)
-	writeChannel := make(chan string, common.ChannelBufferSize)
+	// 绑定变量上限 65535
+	if batchSize*len(columnNameS) > common.MySQLMaxPlaceholders {
+		batchSize = common.MySQLMaxPlaceholders / len(columnNameS)
+	}
+	if batchSize <= 0 {
+		batchSize = 1
+	}
 
 	return &Rows{
-		Ctx:          ctx,
-		SyncMeta:     syncMeta,
-		Oracle:       oracle,
-		MySQL:        mysql,
-		Meta:         meta,
-		ApplyThreads: applyThreads,
-		SafeMode:     safeMode,
-		BatchSize:    batchSize,
-		ColumnNameS:  columnNameS,
-		ReadChannel:  readChannel,
-		WriteChannel: writeChannel,
+		Ctx:           ctx,
+		SyncMeta:      syncMeta,
+		Oracle:        oracle,
+		MySQL:         mysql,
+		Meta:          meta,
+		StmtCache:     stmtCache,
+		ApplyThreads:  applyThreads,
+		SafeMode:      safeMode,
+		BatchSize:     batchSize,
+		LOBSizeLimit:  lobSizeLimit,
+		EmptyStringAs: emptyStringAs,
+		ColumnNameS:   columnNameS,
+		ReadChannel:   readChannel,
+		WriteChannel:  writeChannel,
 	}
 }
 
 func (t *Rows) ReadData() error {
 	startTime := time.Now()
-	querySQL := common.StringsBuilder(`SELECT `, t.SyncMeta.ColumnDetailS, ` FROM `, t.SyncMeta.SchemaNameS, `.`, t.SyncMeta.TableNameS, ` WHERE `, t.SyncMeta.ChunkDetailS)
+	querySQL := common.StringsBuilder(`SELECT `, t.SyncMeta.ColumnDetailS, ` FROM `, oracle.GenOraclePartitionTable(t.SyncMeta.SchemaNameS, t.SyncMeta.TableNameS, t.SyncMeta.PartitionNameS, t.SyncMeta.SubPartitionNameS), ` WHERE `, t.SyncMeta.ChunkDetailS)
 
-	err := t.Oracle.GetOracleTableRowsData(querySQL, t.BatchSize, t.ReadChannel)
+	err := t.Oracle.GetOracleTableRowsData(querySQL, t.BatchSize, t.LOBSizeLimit, t.EmptyStringAs, t.ReadChannel)
 	if err != nil {
 		// 错误 SQL 记录
 		errf := meta.NewChunkErrorDetailModel(t.Meta).CreateChunkErrorDetail(t.Ctx, &meta.ChunkErrorDetail{
@@ -105,14 +131,13 @@ func (t *Rows) ReadData() error {
 }
 
 func (t *Rows) ProcessData() error {
+	var bindRows BindRows
 
 	for dataC := range t.ReadChannel {
-		var batchRows []string
-
 		for _, dMap := range dataC {
 			// 按字段名顺序遍历获取对应值
 			var (
-				rowsTMP []string
+				rowsTMP []interface{}
 			)
 			for _, column := range t.ColumnNameS {
 				if val, ok := dMap[column]; ok {
@@ -122,17 +147,21 @@ func (t *Rows) ProcessData() error {
 
 			if len(rowsTMP) != len(t.ColumnNameS) {
 				return fmt.Errorf("source schema table column counts vs data counts isn't match")
-			} else {
-				batchRows = append(batchRows, common.StringsBuilder("(", exstrings.Join(rowsTMP, ","), ")"))
+			}
+			bindRows.Args = append(bindRows.Args, rowsTMP...)
+			bindRows.Rows++
+
+			// 数据输入
+			if bindRows.Rows == t.BatchSize {
+				t.WriteChannel <- bindRows
+				bindRows = BindRows{}
 			}
 		}
+	}
 
-		// 数据输入
-		t.WriteChannel <- common.StringsBuilder(GenMySQLInsertSQLStmtPrefix(
-			t.SyncMeta.SchemaNameT,
-			t.SyncMeta.TableNameT,
-			t.ColumnNameS,
-			t.SafeMode), exstrings.Join(batchRows, ","))
+	// 非 batch 批次
+	if bindRows.Rows > 0 {
+		t.WriteChannel <- bindRows
 	}
 
 	// 通道关闭
@@ -148,9 +177,16 @@ func (t *Rows) ApplyData() error {
 	g.SetLimit(t.ApplyThreads)
 
 	for dataC := range t.WriteChannel {
-		querySql := dataC
+		bindRows := dataC
 		g.Go(func() error {
-			err := t.MySQL.WriteMySQLTable(querySql)
+			var err error
+			prepareSQL := GenMySQLTablePrepareStmt(t.SyncMeta.SchemaNameT, t.SyncMeta.TableNameT, t.ColumnNameS, bindRows.Rows, t.SafeMode)
+			// 满 batch 复用表级缓存 prepared statement，末尾非满 batch 直接绑定变量执行，不缓存
+			if bindRows.Rows == t.BatchSize && t.StmtCache != nil {
+				err = t.StmtCache.WriteMySQLTableRows(prepareSQL, bindRows.Args)
+			} else {
+				err = t.MySQL.WriteMySQLTableRows(prepareSQL, bindRows.Args)
+			}
 			if err != nil {
 				// 错误 SQL 记录
 				errf := meta.NewChunkErrorDetailModel(t.Meta).CreateChunkErrorDetail(t.Ctx, &meta.ChunkErrorDetail{
@@ -163,7 +199,7 @@ func (t *Rows) ApplyData() error {
 					TaskMode:     t.SyncMeta.TaskMode,
 					ChunkDetailS: t.SyncMeta.ChunkDetailS,
 					InfoDetail:   t.SyncMeta.String(),
-					ErrorSQL:     querySql,
+					ErrorSQL:     genErrorSQL(prepareSQL, bindRows),
 					ErrorDetail:  err.Error(),
 				})
 				if errf != nil {
@@ -188,3 +224,38 @@ func (t *Rows) ApplyData() error {
 
 	return nil
 }
+
+// genErrorSQL 错误 SQL 记录，绑定变量可能包含大字段以及 LOB，单个值以及整体均截断，避免元数据表记录过大
+func genErrorSQL(prepareSQL string, bindRows BindRows) string {
+	var sb strings.Builder
+	if len(prepareSQL) > common.ChunkErrorValueMaxLength {
+		sb.WriteString(prepareSQL[:common.ChunkErrorValueMaxLength])
+		sb.WriteString("...")
+	} else {
+		sb.WriteString(prepareSQL)
+	}
+	sb.WriteString(fmt.Sprintf(" ROWS: %d ARGS: [", bindRows.Rows))
+	for i, arg := range bindRows.Args {
+		if sb.Len() >= common.ChunkErrorSQLMaxLength {
+			sb.WriteString(fmt.Sprintf("... %d args truncated", len(bindRows.Args)-i))
+			break
+		}
+		if i > 0 {
+			sb.WriteString(" ")
+		}
+		var v string
+		switch val := arg.(type) {
+		case []byte:
+			v = fmt.Sprintf("binary(%d)", len(val))
+		default:
+			v = fmt.Sprintf("%v", val)
+		}
+		if len(v) > common.ChunkErrorValueMaxLength {
+			v = common.StringsBuilder(v[:common.ChunkErrorValueMaxLength], fmt.Sprintf("...(%d bytes)", len(v)))
+		}
+		sb.WriteString(v)
+	}
+	sb.WriteString("]")
+	// 截断可能破坏多字节字符
+	return strings.ToValidUTF8(sb.String(), "")
+}
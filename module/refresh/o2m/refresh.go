@@ -0,0 +1,202 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package o2m
+
+import (
+	"context"
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
+	"github.com/wentaojin/transferdb/database/meta"
+	"github.com/wentaojin/transferdb/database/mysql"
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+type Refresh struct {
+	Ctx    context.Context
+	Cfg    *config.Config
+	Mysql  *mysql.MySQL
+	MetaDB *meta.Meta
+}
+
+func NewRefresh(ctx context.Context, cfg *config.Config) (*Refresh, error) {
+	mysqlDB, err := mysql.NewMySQLDBEngine(ctx, cfg.MySQLConfig)
+	if err != nil {
+		return nil, err
+	}
+	metaDB, err := meta.NewMetaDBEngine(ctx, cfg.MetaConfig, cfg.AppConfig.SlowlogThreshold)
+	if err != nil {
+		return nil, err
+	}
+	return &Refresh{
+		Ctx:    ctx,
+		Cfg:    cfg,
+		Mysql:  mysqlDB,
+		MetaDB: metaDB,
+	}, nil
+}
+
+// Refresh 下游物化视图表刷新，refresh-interval > 0 周期性刷新，否则只刷新一次
+func (r *Refresh) Refresh() error {
+	for {
+		err := r.refreshMViews()
+		if err != nil {
+			return err
+		}
+		if r.Cfg.RefreshConfig.RefreshInterval <= 0 {
+			return nil
+		}
+		select {
+		case <-r.Ctx.Done():
+			return nil
+		case <-time.After(time.Duration(r.Cfg.RefreshConfig.RefreshInterval) * time.Second):
+		}
+	}
+}
+
+func (r *Refresh) refreshMViews() error {
+	startTime := time.Now()
+	zap.L().Info("refresh materialized view oracle to mysql start",
+		zap.String("schema", r.Cfg.OracleConfig.SchemaName))
+
+	mvMetas, err := meta.NewMviewRefreshMetaModel(r.MetaDB).DetailMviewRefreshMeta(r.Ctx, &meta.MviewRefreshMeta{
+		DBTypeS:     r.Cfg.DBTypeS,
+		DBTypeT:     r.Cfg.DBTypeT,
+		SchemaNameS: common.StringUPPER(r.Cfg.OracleConfig.SchemaName),
+	})
+	if err != nil {
+		return err
+	}
+	if len(mvMetas) == 0 {
+		zap.L().Warn("there are no materialized view in the meta table [mview_refresh_meta], please run [reverse] mode first",
+			zap.String("schema", r.Cfg.OracleConfig.SchemaName))
+		return nil
+	}
+
+	var failedNums int64
+
+	g := &errgroup.Group{}
+	g.SetLimit(r.Cfg.RefreshConfig.RefreshThreads)
+
+	for _, mv := range mvMetas {
+		m := mv
+		g.Go(func() error {
+			refreshMethod := m.RefreshMethodT
+			if r.Cfg.RefreshConfig.RefreshMethod != "" {
+				refreshMethod = r.Cfg.RefreshConfig.RefreshMethod
+			}
+			if strings.EqualFold(refreshMethod, common.MViewRefreshMethodNever) {
+				zap.L().Warn("skip refresh materialized view",
+					zap.String("schema", m.SchemaNameS),
+					zap.String("mview", m.MviewNameS),
+					zap.String("refresh method", refreshMethod))
+				return nil
+			}
+
+			if err := meta.NewMviewRefreshMetaModel(r.MetaDB).UpdateMviewRefreshMeta(r.Ctx, &m, map[string]interface{}{
+				"TaskStatus": common.TaskStatusRunning,
+			}); err != nil {
+				return err
+			}
+
+			refreshTime := time.Now()
+			if errR := r.refreshMView(m, refreshMethod); errR != nil {
+				atomic.AddInt64(&failedNums, 1)
+				zap.L().Error("refresh materialized view failed",
+					zap.String("schema", m.SchemaNameS),
+					zap.String("mview", m.MviewNameS),
+					zap.String("refresh method", refreshMethod),
+					zap.Error(errR))
+				return meta.NewMviewRefreshMetaModel(r.MetaDB).UpdateMviewRefreshMeta(r.Ctx, &m, map[string]interface{}{
+					"TaskStatus":  common.TaskStatusFailed,
+					"ErrorDetail": errR.Error(),
+				})
+			}
+
+			zap.L().Info("refresh materialized view finished",
+				zap.String("schema", m.SchemaNameS),
+				zap.String("mview", m.MviewNameS),
+				zap.String("target table", fmt.Sprintf("%s.%s", m.SchemaNameT, m.TableNameT)),
+				zap.String("refresh method", refreshMethod),
+				zap.String("cost", time.Now().Sub(refreshTime).String()))
+			return meta.NewMviewRefreshMetaModel(r.MetaDB).UpdateMviewRefreshMeta(r.Ctx, &m, map[string]interface{}{
+				"TaskStatus":    common.TaskStatusSuccess,
+				"LastRefreshAt": time.Now().Format("2006-01-02 15:04:05"),
+				"ErrorDetail":   "",
+			})
+		})
+	}
+	if err = g.Wait(); err != nil {
+		return err
+	}
+
+	endTime := time.Now()
+	if failedNums == 0 {
+		zap.L().Info("refresh materialized view oracle to mysql finished",
+			zap.Int("mview totals", len(mvMetas)),
+			zap.Int64("refresh failed", failedNums),
+			zap.String("cost", endTime.Sub(startTime).String()))
+	} else {
+		zap.L().Warn("refresh materialized view oracle to mysql finished",
+			zap.Int("mview totals", len(mvMetas)),
+			zap.Int64("refresh failed", failedNums),
+			zap.String("failed tips", "failed detail, please see table [mview_refresh_meta]"),
+			zap.String("cost", endTime.Sub(startTime).String()))
+	}
+	return nil
+}
+
+func (r *Refresh) refreshMView(m meta.MviewRefreshMeta, refreshMethod string) error {
+	columnINFO, err := r.Mysql.GetMySQLTableColumn(m.SchemaNameT, m.TableNameT)
+	if err != nil {
+		return err
+	}
+	if len(columnINFO) == 0 {
+		return fmt.Errorf("target table [%s.%s] isn't exist, please run [reverse] mode first", m.SchemaNameT, m.TableNameT)
+	}
+	var columns []string
+	for _, c := range columnINFO {
+		// 生成列不允许写入
+		if mysql.IsMySQLGeneratedColumn(c["EXTRA"]) {
+			continue
+		}
+		columns = append(columns, c["COLUMN_NAME"])
+	}
+
+	switch common.StringUPPER(refreshMethod) {
+	case common.MViewRefreshMethodFast:
+		pkINFO, err := r.Mysql.GetMySQLTablePrimaryKey(m.SchemaNameT, m.TableNameT)
+		if err != nil {
+			return err
+		}
+		// 无主键无法判断数据行差异，退化全量重建
+		if len(pkINFO) > 0 {
+			return r.Mysql.RefreshMySQLTableFast(m.SchemaNameT, m.TableNameT, columns, strings.Split(pkINFO[0]["COLUMN_LIST"], ","), m.QueryT)
+		}
+		zap.L().Warn("target table primary key isn't exist, fast refresh fallback complete refresh",
+			zap.String("schema", m.SchemaNameT),
+			zap.String("table", m.TableNameT))
+		return r.Mysql.RefreshMySQLTableComplete(m.SchemaNameT, m.TableNameT, columns, m.QueryT)
+	case common.MViewRefreshMethodComplete:
+		return r.Mysql.RefreshMySQLTableComplete(m.SchemaNameT, m.TableNameT, columns, m.QueryT)
+	default:
+		return fmt.Errorf("refresh method [%s] isn't support, only support [complete/fast]", refreshMethod)
+	}
+}
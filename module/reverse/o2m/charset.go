@@ -0,0 +1,230 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package o2m
+
+import (
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"go.uber.org/zap"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var columnTypeRegex = regexp.MustCompile(`^\s*([A-Za-z ]+?)\s*(?:\(\s*(\d+)\s*(?:,\s*(\d+)\s*)?\))?(?:\s|$)`)
+
+// 索引字段字节长度
+type indexColumnLength struct {
+	Column   string
+	Bytes    int
+	CharLen  int
+	MaxBytes int
+	IsString bool
+}
+
+// genTargetCharset 目标端表字符集，默认 utf8mb4
+func (r *Rule) genTargetCharset() string {
+	if r.TargetCharset == "" {
+		return common.MySQLCharacterSet
+	}
+	return common.StringUPPER(r.TargetCharset)
+}
+
+// genTargetCollation utf8mb4 排序规则转换目标端字符集排序规则
+func (r *Rule) genTargetCollation(collation string) string {
+	charset := r.genTargetCharset()
+	switch charset {
+	case common.MySQLCharacterSet:
+		return collation
+	case common.MySQLCharacterSetBinary:
+		return "binary"
+	default:
+		if strings.HasSuffix(strings.ToLower(collation), "_bin") {
+			return fmt.Sprintf("%s_bin", strings.ToLower(charset))
+		}
+		return common.MySQLCharacterSetCICollationMap[charset]
+	}
+}
+
+// GenTableIndexColumns 按目标端字段类型以及字符集计算索引字节长度
+// 超过 3072 bytes 普通索引自动转换字符字段前缀索引，主键、唯一索引前缀改变唯一性语义，返回 false 输出不兼容性文件
+func (r *Rule) GenTableIndexColumns(indexName string, columns []string, isUnique bool) ([]string, bool) {
+	var (
+		keyParts    []string
+		totalBytes  int
+		columnsLens []*indexColumnLength
+	)
+	for _, col := range columns {
+		l := r.genIndexColumnLength(col)
+		totalBytes += l.Bytes
+		columnsLens = append(columnsLens, l)
+	}
+
+	if totalBytes <= common.MySQLIndexMaxLength {
+		for _, col := range columns {
+			keyParts = append(keyParts, fmt.Sprintf("`%s`", col))
+		}
+		return keyParts, true
+	}
+
+	if isUnique {
+		zap.L().Warn("reverse index length over limit",
+			zap.String("schema", r.SourceSchemaName),
+			zap.String("table", r.SourceTableName),
+			zap.String("index name", indexName),
+			zap.Strings("index columns", columns),
+			zap.Int("index bytes", totalBytes),
+			zap.Int("index limit bytes", common.MySQLIndexMaxLength),
+			zap.String("suggest", "unique key can't use prefix index, please manual check"))
+		for _, col := range columns {
+			keyParts = append(keyParts, fmt.Sprintf("`%s`", col))
+		}
+		return keyParts, false
+	}
+
+	// 最长字符字段优先缩减前缀长度
+	sortLens := make([]*indexColumnLength, len(columnsLens))
+	copy(sortLens, columnsLens)
+	sort.SliceStable(sortLens, func(i, j int) bool {
+		return sortLens[i].Bytes > sortLens[j].Bytes
+	})
+	prefixLens := make(map[string]int)
+	for _, l := range sortLens {
+		if totalBytes <= common.MySQLIndexMaxLength {
+			break
+		}
+		if !l.IsString {
+			continue
+		}
+		overBytes := totalBytes - common.MySQLIndexMaxLength
+		// 前缀长度按字符计算，向上取整确保满足限制
+		reduceChars := (overBytes + l.MaxBytes - 1) / l.MaxBytes
+		prefixChars := l.CharLen - reduceChars
+		if prefixChars < 1 {
+			prefixChars = 1
+		}
+		totalBytes -= (l.CharLen - prefixChars) * l.MaxBytes
+		prefixLens[l.Column] = prefixChars
+	}
+
+	for _, col := range columns {
+		if val, ok := prefixLens[col]; ok {
+			keyParts = append(keyParts, fmt.Sprintf("`%s`(%d)", col, val))
+		} else {
+			keyParts = append(keyParts, fmt.Sprintf("`%s`", col))
+		}
+	}
+	if totalBytes > common.MySQLIndexMaxLength {
+		zap.L().Warn("reverse index length over limit",
+			zap.String("schema", r.SourceSchemaName),
+			zap.String("table", r.SourceTableName),
+			zap.String("index name", indexName),
+			zap.Strings("index columns", columns),
+			zap.Int("index bytes", totalBytes),
+			zap.String("suggest", "prefix index can't satisfy limit, please manual check"))
+		return keyParts, false
+	}
+
+	zap.L().Warn("reverse index length over limit, use prefix index",
+		zap.String("schema", r.SourceSchemaName),
+		zap.String("table", r.SourceTableName),
+		zap.String("index name", indexName),
+		zap.Strings("index key parts", keyParts))
+	return keyParts, true
+}
+
+// 目标端字段类型字节长度，字符类型按字符集单字符最大字节数计算
+func (r *Rule) genIndexColumnLength(columnName string) *indexColumnLength {
+	l := &indexColumnLength{Column: columnName, MaxBytes: 1}
+
+	columnType, ok := r.TableColumnDatatypeRule[columnName]
+	if !ok {
+		return l
+	}
+	match := columnTypeRegex.FindStringSubmatch(columnType)
+	if len(match) == 0 {
+		return l
+	}
+	dataType := common.StringUPPER(strings.TrimSpace(match[1]))
+	length, _ := strconv.Atoi(match[2])
+	scale, _ := strconv.Atoi(match[3])
+
+	switch dataType {
+	case "CHAR", "VARCHAR", "NCHAR", "NVARCHAR":
+		l.IsString = true
+		l.CharLen = length
+		l.MaxBytes = common.MySQLCharacterSetMaxBytes[r.genTargetCharset()]
+		if l.MaxBytes == 0 {
+			l.MaxBytes = 4
+		}
+		l.Bytes = length * l.MaxBytes
+	case "BINARY", "VARBINARY":
+		l.IsString = true
+		l.CharLen = length
+		l.Bytes = length
+	case "TINYINT", "YEAR", "BIT":
+		l.Bytes = 1
+	case "SMALLINT":
+		l.Bytes = 2
+	case "MEDIUMINT", "DATE":
+		l.Bytes = 3
+	case "INT", "INTEGER", "FLOAT":
+		l.Bytes = 4
+	case "BIGINT", "DOUBLE", "REAL":
+		l.Bytes = 8
+	case "DECIMAL", "NUMERIC":
+		// 每 9 位数字 4 bytes
+		l.Bytes = (length-scale+8)/9*4 + (scale+8)/9*4
+	case "DATETIME", "TIME":
+		l.Bytes = 5 + (length+1)/2
+	case "TIMESTAMP":
+		l.Bytes = 4 + (length+1)/2
+	default:
+		l.Bytes = 8
+	}
+	return l
+}
+
+// GenTableCharsetCompDDL 非 utf8mb4 目标端字符集抽样校验源端字符数据能否转换，无法转换字段输出不兼容性文件
+// charset-check-rows 为 0 不校验，避免每张表全表扫描
+func (r *Rule) GenTableCharsetCompDDL() (compatibleDDL []string, err error) {
+	oraCharset, ok := common.MySQLCharacterSetOracleMap[r.genTargetCharset()]
+	if !ok || r.CharsetCheckRows <= 0 {
+		return compatibleDDL, nil
+	}
+	var columns []string
+	for _, rowCol := range r.TableColumnINFO {
+		switch common.StringUPPER(rowCol["DATA_TYPE"]) {
+		case "CHAR", "VARCHAR2", "VARCHAR":
+			columns = append(columns, rowCol["COLUMN_NAME"])
+		}
+	}
+	incompColumns, err := r.Oracle.GetOracleSchemaTableCharsetIncompatibleColumn(r.SourceSchemaName, r.SourceTableName, columns, oraCharset, r.CharsetCheckRows)
+	if err != nil {
+		return compatibleDDL, err
+	}
+	for _, col := range incompColumns {
+		compatibleDDL = append(compatibleDDL, fmt.Sprintf("-- oracle table [%s.%s] column [%s] data can't convert to target charset [%s] (sample %d rows), please manual check",
+			r.SourceSchemaName, r.SourceTableName, col, strings.ToLower(r.genTargetCharset()), r.CharsetCheckRows))
+		zap.L().Warn("reverse column data charset incompatible",
+			zap.String("schema", r.SourceSchemaName),
+			zap.String("table", r.SourceTableName),
+			zap.String("column", col),
+			zap.String("target charset", r.genTargetCharset()))
+	}
+	return compatibleDDL, nil
+}
@@ -30,6 +30,7 @@ type DDL struct {
 	SourceTableName    string   `json:"source_table_name"`
 	SourceTableType    string   `json:"source_table_type"`
 	SourceTableDDL     string   `json:"-"` // 忽略
+	TableTypeDecision  string   `json:"table_type_decision"`
 	TargetSchemaName   string   `json:"target_schema"`
 	TargetTableName    string   `json:"target_table_name"`
 	TargetDBType       string   `json:"target_db_type"`
@@ -79,7 +80,7 @@ func (d *DDL) WriteFile(w *reverse.Write) (string, error) {
 	sw.SetStyle(table.StyleLight)
 	sw.AppendHeader(table.Row{"#", "ORACLE TABLE TYPE", "ORACLE", "MYSQL", "SUGGEST"})
 	sw.AppendRows([]table.Row{
-		{"TABLE", d.SourceTableType, fmt.Sprintf("%s.%s", d.SourceSchemaName, d.SourceTableName), fmt.Sprintf("%s.%s", d.TargetSchemaName, d.TargetTableName), "Create Table"},
+		{"TABLE", d.SourceTableType, fmt.Sprintf("%s.%s", d.SourceSchemaName, d.SourceTableName), fmt.Sprintf("%s.%s", d.TargetSchemaName, d.TargetTableName), d.TableTypeDecision},
 	})
 	sqlRev.WriteString(fmt.Sprintf("%v\n", sw.Render()))
 	sqlRev.WriteString(fmt.Sprintf("ORIGIN DDL:%v\n", d.SourceTableDDL))
@@ -213,6 +214,10 @@ func (d *DDL) GenDDLStructure() ([]string, []string) {
         }
 	}
 
+	// TiDB 全局临时表 ON COMMIT 子句位于建表语句末尾
+	if strings.EqualFold(d.TableTypeDecision, common.ReverseTableDecisionGlobalTemporary) {
+		tableDDL = fmt.Sprintf("%s ON COMMIT DELETE ROWS;", strings.TrimSuffix(tableDDL, ";"))
+	}
 
 	zap.L().Info("reverse oracle table structure",
 		zap.String("schema", d.TargetSchemaName),
@@ -249,6 +254,16 @@ func (d *DDL) GenDDLStructure() ([]string, []string) {
 		}
 	}
 
+	// 会话级临时表由应用会话内创建，建表模板以及约束统一输出兼容性文件
+	if strings.EqualFold(d.TableTypeDecision, common.ReverseTableDecisionTemporaryTemplate) {
+		compDDLS = append(compDDLS, fmt.Sprintf("-- oracle %s table, please create it in the application session", strings.ToLower(d.SourceTableType)))
+		compDDLS = append(compDDLS, reverseDDLS...)
+		compDDLS = append(compDDLS, foreignKeyDDL...)
+		compDDLS = append(compDDLS, checkKeyDDL...)
+		compDDLS = append(compDDLS, d.TableCompatibleDDL...)
+		return nil, compDDLS
+	}
+
 	// 外键约束、检查约束
 	if d.TargetDBType != common.DatabaseTypeTiDB {
 		if len(foreignKeyDDL) > 0 {
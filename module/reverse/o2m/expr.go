@@ -0,0 +1,93 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package o2m
+
+import (
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
+	"regexp"
+	"strings"
+)
+
+// Oracle 表达式函数转换规则，按顺序匹配替换
+// 适用于物化视图查询语句、虚拟列表达式
+var oracleExpressionRules = []struct {
+	pattern *regexp.Regexp
+	replace string
+}{
+	{regexp.MustCompile(`(?i)\bNVL\s*\(`), "IFNULL("},
+	{regexp.MustCompile(`(?i)\bSYSTIMESTAMP\b`), "NOW(6)"},
+	{regexp.MustCompile(`(?i)\bSYSDATE\b`), "NOW()"},
+}
+
+// Oracle 函数索引表达式转换规则，于 TranslateOracleExpression 之后按顺序匹配替换
+// dataTypes 非空代表仅对应源端字段数据类型生效，字段为表达式第一个分组
+// columnType 非空代表表达式结果数据类型，虚拟生成列使用，为空则沿用引用字段数据类型
+type indexExpressionRule struct {
+	pattern    *regexp.Regexp
+	replace    string
+	dataTypes  []string
+	columnType string
+}
+
+// 内置函数索引表达式转换规则
+var oracleIndexExpressionRules = []indexExpressionRule{
+	// 日期截断
+	{regexp.MustCompile("(?i)\\bTRUNC\\s*\\(\\s*`([^`]+)`\\s*\\)"), "DATE(`$1`)", []string{"DATE", "TIMESTAMP"}, "DATE"},
+	{regexp.MustCompile(`(?i)\bSUBSTR\s*\(`), "SUBSTRING(", nil, ""},
+}
+
+// GenIndexExpressionRules 配置文件自定义规则优先，内置规则在后
+func GenIndexExpressionRules(customRules []config.IndexExprRule) ([]indexExpressionRule, error) {
+	var rules []indexExpressionRule
+	for _, c := range customRules {
+		pattern, err := regexp.Compile(c.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("index expression rule pattern [%s] compile failed: %v", c.Pattern, err)
+		}
+		rules = append(rules, indexExpressionRule{
+			pattern:    pattern,
+			replace:    c.Replace,
+			dataTypes:  c.DataTypes,
+			columnType: c.ColumnType,
+		})
+	}
+	return append(rules, oracleIndexExpressionRules...), nil
+}
+
+// TranslateOracleExpression 转换 Oracle 表达式，返回转换后表达式以及是否兼容 Y/N
+func TranslateOracleExpression(expr string) (string, string) {
+	// 双引号标识符
+	exprT := regexp.MustCompile(`"([^"]+)"`).ReplaceAllString(strings.TrimSpace(expr), "`$1`")
+
+	for _, rule := range oracleExpressionRules {
+		exprT = rule.pattern.ReplaceAllString(exprT, rule.replace)
+	}
+
+	return exprT, genOracleExpressionCompatible(exprT)
+}
+
+// 转换后表达式仍包含不兼容关键字，返回 N
+func genOracleExpressionCompatible(exprT string) string {
+	upperExpr := common.StringUPPER(exprT)
+	for _, k := range common.OracleMViewQueryIncompatibleKeywords {
+		if strings.Contains(upperExpr, k) {
+			return "N"
+		}
+	}
+	return "Y"
+}
@@ -139,19 +139,14 @@ func FilterOracleCompatibleTable(cfg *config.Config, oracle *oracle.Oracle, expo
 			zap.String("suggest", "if necessary, please manually process the tables in the above list"))
 	}
 
-	var exporterTables []string
+	// 物化视图以普通表形式转换，查询语句记录 mview_refresh_meta 用于 refresh 模式刷新
 	if len(materializedView) != 0 {
 		zap.L().Warn("materialized views",
 			zap.String("schema", cfg.OracleConfig.SchemaName),
 			zap.String("materialized view list", fmt.Sprintf("%v", materializedView)),
-			zap.String("suggest", "if necessary, please manually process the tables in the above list"))
-
-		// 排除物化视图
-		exporterTables = common.FilterDifferenceStringItems(exporters, materializedView)
-	} else {
-		exporterTables = exporters
+			zap.String("suggest", "materialized view will convert to normal table, please run [refresh] mode to refresh the tables in the above list"))
 	}
-	return partitionTables, temporaryTables, clusteredTables, materializedView, exporterTables, nil
+	return partitionTables, temporaryTables, clusteredTables, materializedView, exporters, nil
 }
 
 func filterOraclePartitionTable(cfg *config.Config, oracle *oracle.Oracle, exporters []string) ([]string, error) {
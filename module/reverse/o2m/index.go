@@ -0,0 +1,243 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package o2m
+
+import (
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"go.uber.org/zap"
+	"regexp"
+	"strings"
+)
+
+var (
+	indexColumnRegex   = regexp.MustCompile("^`([^`]+)`$")
+	indexFunctionRegex = regexp.MustCompile(`([A-Za-z_][A-Za-z0-9_]*)\s*\(`)
+	indexRefColumn     = regexp.MustCompile("`([^`]+)`")
+)
+
+// 函数索引字段
+type indexKeyPart struct {
+	Expr       string
+	ColumnType string
+	IsFunction bool
+	IsDescend  bool
+}
+
+// GenTableFunctionIndex 函数索引、降序索引转换
+// 1、表达式仅为字段（降序索引）按普通索引输出，保留 DESC
+// 2、MySQL >= 8.0.13、TiDB >= 5.2.0（函数白名单）输出函数索引 ((expr))
+// 3、下游不支持函数索引且开启 function-index-gen-column，输出虚拟生成列 + 生成列索引，生成列定义随索引输出
+// 4、其他输出不兼容性文件
+func (r *Rule) GenTableFunctionIndex(idxMeta map[string]string, isUnique bool) (tableKeys []string, compatibilityIndexSQL []string) {
+	keyPrefix := "KEY"
+	createPrefix := "CREATE INDEX"
+	if isUnique {
+		keyPrefix = "UNIQUE INDEX"
+		createPrefix = "CREATE UNIQUE INDEX"
+	}
+	indexName := strings.ToUpper(idxMeta["INDEX_NAME"])
+
+	keyParts, isCompatible := r.genIndexKeyParts(idxMeta)
+
+	var (
+		keyExprs    []string
+		hasFunction bool
+	)
+	for _, k := range keyParts {
+		if k.IsFunction {
+			hasFunction = true
+			keyExprs = append(keyExprs, genIndexKeyPartDesc(fmt.Sprintf("(%s)", k.Expr), k.IsDescend))
+		} else {
+			keyExprs = append(keyExprs, genIndexKeyPartDesc(k.Expr, k.IsDescend))
+		}
+	}
+
+	switch {
+	case !isCompatible:
+		sql := fmt.Sprintf("%s `%s` ON `%s`.`%s` (%s);", createPrefix, indexName, r.TargetSchemaName, r.TargetTableName, strings.Join(keyExprs, ","))
+		compatibilityIndexSQL = append(compatibilityIndexSQL, sql)
+		zap.L().Warn("reverse function index expression maybe incompatible",
+			zap.String("schema", r.SourceSchemaName),
+			zap.String("table", idxMeta["TABLE_NAME"]),
+			zap.String("index name", idxMeta["INDEX_NAME"]),
+			zap.String("index type", idxMeta["INDEX_TYPE"]),
+			zap.String("index column list", idxMeta["COLUMN_LIST"]),
+			zap.String("create index sql", sql),
+			zap.String("suggest", "please manual check and create index"))
+		return tableKeys, compatibilityIndexSQL
+
+	case !hasFunction || r.isSupportFunctionalKeyPart(keyParts):
+		keyIndex := fmt.Sprintf("%s `%s` (%s)", keyPrefix, indexName, strings.Join(keyExprs, ","))
+		tableKeys = append(tableKeys, keyIndex)
+		zap.L().Info("reverse function index",
+			zap.String("schema", r.SourceSchemaName),
+			zap.String("table", idxMeta["TABLE_NAME"]),
+			zap.String("index name", idxMeta["INDEX_NAME"]),
+			zap.String("index type", idxMeta["INDEX_TYPE"]),
+			zap.String("index column list", idxMeta["COLUMN_LIST"]),
+			zap.String("key index info", keyIndex))
+		return tableKeys, compatibilityIndexSQL
+
+	case r.FunctionIndexGenCol:
+		var (
+			genColumns []string
+			genKeys    []string
+		)
+		for i, k := range keyParts {
+			if !k.IsFunction {
+				genKeys = append(genKeys, genIndexKeyPartDesc(k.Expr, k.IsDescend))
+				continue
+			}
+			if k.ColumnType == "" {
+				break
+			}
+			genColumn := fmt.Sprintf("%s%s%d", indexName, common.ReverseIndexGenColumnSuffix, i+1)
+			genColumns = append(genColumns, fmt.Sprintf("`%s` %s GENERATED ALWAYS AS (%s) VIRTUAL", genColumn, k.ColumnType, k.Expr))
+			genKeys = append(genKeys, genIndexKeyPartDesc(fmt.Sprintf("`%s`", genColumn), k.IsDescend))
+		}
+		// 表达式结果数据类型无法确定，输出不兼容性文件
+		if len(genKeys) == len(keyParts) {
+			keyIndex := fmt.Sprintf("%s `%s` (%s)", keyPrefix, indexName, strings.Join(genKeys, ","))
+			tableKeys = append(tableKeys, genColumns...)
+			tableKeys = append(tableKeys, keyIndex)
+			zap.L().Warn("reverse function index by generated column",
+				zap.String("schema", r.SourceSchemaName),
+				zap.String("table", idxMeta["TABLE_NAME"]),
+				zap.String("index name", idxMeta["INDEX_NAME"]),
+				zap.String("index type", idxMeta["INDEX_TYPE"]),
+				zap.String("index column list", idxMeta["COLUMN_LIST"]),
+				zap.Strings("generated columns", genColumns),
+				zap.String("key index info", keyIndex))
+			return tableKeys, compatibilityIndexSQL
+		}
+	}
+
+	sql := fmt.Sprintf("%s `%s` ON `%s`.`%s` (%s);", createPrefix, indexName, r.TargetSchemaName, r.TargetTableName, strings.Join(keyExprs, ","))
+	compatibilityIndexSQL = append(compatibilityIndexSQL, sql)
+	zap.L().Warn("reverse function index",
+		zap.String("schema", r.SourceSchemaName),
+		zap.String("table", idxMeta["TABLE_NAME"]),
+		zap.String("index name", idxMeta["INDEX_NAME"]),
+		zap.String("index type", idxMeta["INDEX_TYPE"]),
+		zap.String("index column list", idxMeta["COLUMN_LIST"]),
+		zap.String("target db type", r.TargetDBType),
+		zap.String("target db version", r.TargetDBVersion),
+		zap.String("create index sql", sql),
+		zap.String("warn", "target db not support function index"))
+	return tableKeys, compatibilityIndexSQL
+}
+
+// 索引字段表达式转换，返回字段列表以及是否兼容
+func (r *Rule) genIndexKeyParts(idxMeta map[string]string) ([]indexKeyPart, bool) {
+	exprs := strings.Split(idxMeta["COLUMN_EXPR_LIST"], common.OracleIndexColumnExprDelimiter)
+	descends := strings.Split(idxMeta["DESCEND_LIST"], ",")
+
+	isCompatible := true
+	var keyParts []indexKeyPart
+	for i, expr := range exprs {
+		keyPart := indexKeyPart{}
+		if i < len(descends) && strings.EqualFold(descends[i], "DESC") {
+			keyPart.IsDescend = true
+		}
+
+		// 普通字段非双引号形式
+		if _, ok := r.TableColumnDatatypeRule[expr]; ok {
+			keyPart.Expr = fmt.Sprintf("`%s`", expr)
+			keyParts = append(keyParts, keyPart)
+			continue
+		}
+
+		exprT, _ := TranslateOracleExpression(expr)
+		if indexColumnRegex.MatchString(exprT) {
+			keyPart.Expr = exprT
+			keyParts = append(keyParts, keyPart)
+			continue
+		}
+
+		for _, rule := range r.IndexExprRules {
+			if len(rule.dataTypes) > 0 {
+				match := rule.pattern.FindStringSubmatch(exprT)
+				if len(match) < 2 || !r.isColumnDataTypeIn(match[1], rule.dataTypes) {
+					continue
+				}
+			} else if !rule.pattern.MatchString(exprT) {
+				continue
+			}
+			exprT = rule.pattern.ReplaceAllString(exprT, rule.replace)
+			if rule.columnType != "" {
+				keyPart.ColumnType = rule.columnType
+			}
+		}
+		// 规则转换之后判断兼容性，自定义规则可转换不兼容函数
+		if strings.EqualFold(genOracleExpressionCompatible(exprT), "N") {
+			isCompatible = false
+		}
+		// 表达式结果数据类型沿用引用字段数据类型
+		if keyPart.ColumnType == "" {
+			if match := indexRefColumn.FindStringSubmatch(exprT); len(match) == 2 {
+				keyPart.ColumnType = r.TableColumnDatatypeRule[match[1]]
+			}
+		}
+
+		keyPart.Expr = exprT
+		keyPart.IsFunction = true
+		keyParts = append(keyParts, keyPart)
+	}
+	return keyParts, isCompatible
+}
+
+// 下游是否支持函数索引
+func (r *Rule) isSupportFunctionalKeyPart(keyParts []indexKeyPart) bool {
+	if !strings.EqualFold(r.TargetDBType, common.DatabaseTypeTiDB) {
+		return common.VersionOrdinal(r.TargetDBVersion) >= common.VersionOrdinal(common.MySQLFunctionalKeyPartVersion)
+	}
+	if common.VersionOrdinal(common.TiDBVersion(r.TargetDBVersion)) < common.VersionOrdinal(common.TiDBExpressionIndexVersion) {
+		return false
+	}
+	for _, k := range keyParts {
+		if !k.IsFunction {
+			continue
+		}
+		for _, fn := range indexFunctionRegex.FindAllStringSubmatch(k.Expr, -1) {
+			if !common.IsContainString(common.TiDBExpressionIndexAllowFunctions, common.StringUPPER(fn[1])) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (r *Rule) isColumnDataTypeIn(columnName string, dataTypes []string) bool {
+	for _, rowCol := range r.TableColumnINFO {
+		if !strings.EqualFold(rowCol["COLUMN_NAME"], columnName) {
+			continue
+		}
+		for _, t := range dataTypes {
+			if strings.HasPrefix(common.StringUPPER(rowCol["DATA_TYPE"]), t) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func genIndexKeyPartDesc(keyPart string, isDescend bool) string {
+	if isDescend {
+		return fmt.Sprintf("%s DESC", keyPart)
+	}
+	return keyPart
+}
@@ -0,0 +1,116 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package o2m
+
+import (
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/database/meta"
+	"go.uber.org/zap"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// GenMaterializedViewRefresh 物化视图以普通表 reverse，查询语句转换后写入 mview_refresh_meta 用于 refresh 模式
+func GenMaterializedViewRefresh(r *Reverse, tableNameRule map[string]string, materializedViews []string) error {
+	if len(materializedViews) == 0 {
+		return nil
+	}
+	startTime := time.Now()
+
+	sourceSchema := common.StringUPPER(r.Cfg.OracleConfig.SchemaName)
+	targetSchema := common.StringUPPER(r.Cfg.MySQLConfig.SchemaName)
+
+	mviews, err := r.Oracle.GetOracleSchemaMaterializedViewDetail(sourceSchema)
+	if err != nil {
+		return err
+	}
+
+	for _, mv := range mviews {
+		mviewName := common.StringUPPER(mv["MVIEW_NAME"])
+		if !common.IsContainString(materializedViews, mviewName) {
+			continue
+		}
+
+		targetTableName := mviewName
+		if val, ok := tableNameRule[mviewName]; ok {
+			targetTableName = val
+		}
+
+		queryT, isCompatible := TranslateOracleMViewQuery(mv["QUERY"], sourceSchema, targetSchema, tableNameRule)
+		if strings.EqualFold(isCompatible, "N") {
+			zap.L().Warn("materialized view query maybe incompatible",
+				zap.String("schema", sourceSchema),
+				zap.String("mview", mviewName),
+				zap.String("query", queryT),
+				zap.String("suggest", "please manual check and update meta table [mview_refresh_meta] column [query_t]"))
+		}
+
+		if err = meta.NewMviewRefreshMetaModel(r.MetaDB).CreateMviewRefreshMeta(r.Ctx, &meta.MviewRefreshMeta{
+			DBTypeS:        r.Cfg.DBTypeS,
+			DBTypeT:        r.Cfg.DBTypeT,
+			SchemaNameS:    sourceSchema,
+			MviewNameS:     mviewName,
+			SchemaNameT:    targetSchema,
+			TableNameT:     targetTableName,
+			RefreshMethodS: common.StringUPPER(mv["REFRESH_METHOD"]),
+			RefreshMethodT: genMViewTargetRefreshMethod(mv["REFRESH_METHOD"]),
+			QueryS:         mv["QUERY"],
+			QueryT:         queryT,
+			IsCompatible:   isCompatible,
+			TaskStatus:     common.TaskStatusWaiting,
+			ErrorDetail:    "",
+		}); err != nil {
+			return err
+		}
+	}
+
+	zap.L().Info("gen oracle materialized view refresh task finished",
+		zap.String("schema", sourceSchema),
+		zap.Int("mview totals", len(materializedViews)),
+		zap.String("cost", time.Now().Sub(startTime).String()))
+	return nil
+}
+
+// TranslateOracleMViewQuery 转换物化视图查询语句，返回转换后语句以及是否兼容 Y/N
+func TranslateOracleMViewQuery(query, sourceSchema, targetSchema string, tableNameRule map[string]string) (string, string) {
+	queryT := strings.TrimRight(strings.TrimSpace(query), ";")
+
+	// 源端 schema.table 替换为目标端 schema.table
+	schemaRegex := regexp.MustCompile(`(?i)"?\b` + regexp.QuoteMeta(sourceSchema) + `\b"?\s*\.\s*"?([A-Za-z0-9_$#]+)"?`)
+	queryT = schemaRegex.ReplaceAllStringFunc(queryT, func(s string) string {
+		tableName := common.StringUPPER(schemaRegex.FindStringSubmatch(s)[1])
+		if val, ok := tableNameRule[tableName]; ok {
+			tableName = val
+		}
+		return fmt.Sprintf("`%s`.`%s`", targetSchema, tableName)
+	})
+
+	return TranslateOracleExpression(queryT)
+}
+
+// 下游无物化视图日志，FAST/FORCE 基于主键增量刷新，其他全量重建
+func genMViewTargetRefreshMethod(refreshMethod string) string {
+	switch common.StringUPPER(refreshMethod) {
+	case common.MViewRefreshMethodFast, common.MViewRefreshMethodForce:
+		return common.MViewRefreshMethodFast
+	case common.MViewRefreshMethodNever:
+		return common.MViewRefreshMethodNever
+	default:
+		return common.MViewRefreshMethodComplete
+	}
+}
@@ -28,6 +28,7 @@ import (
 	"golang.org/x/sync/errgroup"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -175,7 +176,13 @@ func (r *Reverse) Reverse() error {
 	}
 
 	// 表类型不兼容项输出
-	err = GenCompatibilityTable(f, common.StringUPPER(r.Cfg.OracleConfig.SchemaName), partitionTables, temporaryTables, clusteredTables, materializedView)
+	err = GenCompatibilityTable(f, common.StringUPPER(r.Cfg.OracleConfig.SchemaName), tables, partitionTables, temporaryTables, clusteredTables, materializedView)
+	if err != nil {
+		return err
+	}
+
+	// 物化视图刷新任务
+	err = GenMaterializedViewRefresh(r, tableNameRuleMap, materializedView)
 	if err != nil {
 		return err
 	}
@@ -311,3 +318,82 @@ func (r *Reverse) Reverse() error {
 	}
 	return nil
 }
+
+// GenSchemaTableDDL 生成 schema 创建语句以及表结构 DDL，不输出 reverse 文件也不写入下游，用于 csv lightning 布局 schema 文件
+func (r *Reverse) GenSchemaTableDDL(exporters []string) (string, []*DDL, error) {
+	nlsComp, err := r.Oracle.GetOracleDBCharacterNLSCompCollation()
+	if err != nil {
+		return "", nil, err
+	}
+	nlsSort, err := r.Oracle.GetOracleDBCharacterNLSSortCollation()
+	if err != nil {
+		return "", nil, err
+	}
+	if !strings.EqualFold(nlsSort, nlsComp) {
+		return "", nil, fmt.Errorf("oracle db nls_sort [%s] and nls_comp [%s] isn't different, need be equal; because mysql db isn't support", nlsSort, nlsComp)
+	}
+
+	oracleDBVersion, err := r.Oracle.GetOracleDBVersion()
+	if err != nil {
+		return "", nil, err
+	}
+	oracleCollation := false
+	if common.VersionOrdinal(oracleDBVersion) >= common.VersionOrdinal(common.OracleTableColumnCollationDBVersion) {
+		oracleCollation = true
+	}
+
+	createSchema, err := GenCreateSchemaSQL(r.Oracle,
+		common.StringUPPER(r.Cfg.OracleConfig.SchemaName), common.StringUPPER(r.Cfg.MySQLConfig.SchemaName), nlsComp)
+	if err != nil {
+		return "", nil, err
+	}
+
+	tableNameRuleMap, tableColumnRuleMap, tableDefaultRuleMap, err := IChanger(&Change{
+		Ctx:              r.Ctx,
+		DBTypeS:          r.Cfg.DBTypeS,
+		DBTypeT:          r.Cfg.DBTypeT,
+		SourceSchemaName: common.StringUPPER(r.Cfg.OracleConfig.SchemaName),
+		TargetSchemaName: common.StringUPPER(r.Cfg.MySQLConfig.SchemaName),
+		SourceTables:     exporters,
+		OracleCollation:  oracleCollation,
+		Threads:          r.Cfg.ReverseConfig.ReverseThreads,
+		Oracle:           r.Oracle,
+		MetaDB:           r.MetaDB,
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	tables, err := GenReverseTableTask(r, tableNameRuleMap, tableColumnRuleMap, tableDefaultRuleMap, oracleDBVersion, oracleCollation, exporters, nlsSort, nlsComp)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var (
+		mu   sync.Mutex
+		ddls []*DDL
+	)
+	g := &errgroup.Group{}
+	g.SetLimit(r.Cfg.ReverseConfig.ReverseThreads)
+	for _, table := range tables {
+		t := table
+		g.Go(func() error {
+			rule, err := IReader(t)
+			if err != nil {
+				return fmt.Errorf("reader table [%s.%s] failed: %v", t.SourceSchemaName, t.SourceTableName, err)
+			}
+			ddl, err := IReverse(rule)
+			if err != nil {
+				return fmt.Errorf("reverse table [%s.%s] failed: %v", t.SourceSchemaName, t.SourceTableName, err)
+			}
+			mu.Lock()
+			ddls = append(ddls, ddl)
+			mu.Unlock()
+			return nil
+		})
+	}
+	if err = g.Wait(); err != nil {
+		return "", nil, err
+	}
+	return createSchema, ddls, nil
+}
@@ -41,6 +41,7 @@ type Info struct {
 	NormalIndexINFO       []map[string]string `json:"normal_index_info"`
 	TableCommentINFO      []map[string]string `json:"table_comment_info"`
 	TableColumnINFO       []map[string]string `json:"table_column_info"`
+	TableColumnAttrINFO   []map[string]string `json:"table_column_attr_info"`
 	ColumnCommentINFO     []map[string]string `json:"column_comment_info"`
     TablePartitionsInfo   []map[string]string `json:"table_partition_info"`
 }
@@ -67,7 +68,33 @@ func (r *Rule) GenCreateTableDDL() (interface{}, error) {
 		return nil, err
 	}
 
-	tablePrefix = fmt.Sprintf("CREATE TABLE `%s`.`%s`", targetSchema, targetTable)
+	virtualColumnDDL, err := r.GenTableVirtualColumnCompDDL()
+	if err != nil {
+		return nil, err
+	}
+	compatibleDDL = append(compatibleDDL, virtualColumnDDL...)
+
+	charsetDDL, err := r.GenTableCharsetCompDDL()
+	if err != nil {
+		return nil, err
+	}
+	compatibleDDL = append(compatibleDDL, charsetDDL...)
+
+	// 表类型转换决策
+	tableDecision := r.ReverseTableDecision()
+	switch tableDecision {
+	case common.ReverseTableDecisionGlobalTemporary:
+		tablePrefix = fmt.Sprintf("CREATE GLOBAL TEMPORARY TABLE `%s`.`%s`", targetSchema, targetTable)
+	case common.ReverseTableDecisionTemporaryTemplate:
+		tablePrefix = fmt.Sprintf("CREATE TEMPORARY TABLE `%s`.`%s`", targetSchema, targetTable)
+	default:
+		tablePrefix = fmt.Sprintf("CREATE TABLE `%s`.`%s`", targetSchema, targetTable)
+	}
+	zap.L().Info("reverse oracle table type",
+		zap.String("schema", r.SourceSchemaName),
+		zap.String("table", r.SourceTableName),
+		zap.String("table type", r.SourceTableType),
+		zap.String("decision", tableDecision))
 
 	checkKeys, err = r.GenTableCheckKey()
 	if err != nil {
@@ -89,6 +116,7 @@ func (r *Rule) GenCreateTableDDL() (interface{}, error) {
 		SourceTableName:    r.SourceTableName,
 		SourceTableType:    r.SourceTableType,
 		SourceTableDDL:     r.SourceTableDDL,
+		TableTypeDecision:  tableDecision,
 		TargetSchemaName:   r.GenSchemaName(), // change schema name
 		TargetTableName:    r.GenTableName(),  // change table name
 		TargetDBType:       r.TargetDBType,
@@ -137,6 +165,24 @@ func (r *Rule) GenTableKeys() (tableKeys []string, compatibilityIndexSQL []strin
 		return tableKeys, compatibilityIndexSQL, err
 	}
 
+	// 主键、唯一约束字节长度超过限制，前缀索引改变唯一性语义，输出不兼容性文件
+	targetSchema, targetTable := r.GenTablePrefix()
+	if len(primaryKeys) > 0 {
+		if _, ok := r.GenTableIndexColumns("PRIMARY", strings.Split(r.PrimaryKeyINFO[0]["COLUMN_LIST"], ","), true); !ok {
+			compatibilityIndexSQL = append(compatibilityIndexSQL, fmt.Sprintf("ALTER TABLE `%s`.`%s` ADD %s;", targetSchema, targetTable, primaryKeys[0]))
+			primaryKeys = []string{}
+		}
+	}
+	var uniqueKeys []string
+	for i, uk := range uniqueKeyMetas {
+		if _, ok := r.GenTableIndexColumns(r.UniqueKeyINFO[i]["CONSTRAINT_NAME"], strings.Split(r.UniqueKeyINFO[i]["COLUMN_LIST"], ","), true); !ok {
+			compatibilityIndexSQL = append(compatibilityIndexSQL, fmt.Sprintf("ALTER TABLE `%s`.`%s` ADD %s;", targetSchema, targetTable, uk))
+			continue
+		}
+		uniqueKeys = append(uniqueKeys, uk)
+	}
+	uniqueKeyMetas = uniqueKeys
+
 	if len(primaryKeys) > 0 {
 		tableKeys = append(tableKeys, primaryKeys...)
 	}
@@ -232,14 +278,18 @@ func (r *Rule) GenTableSuffix() (string, error) {
 			return tableSuffix, fmt.Errorf("oracle db nls_comp [%v] nls_sort [%v] isn't support", r.SourceDBNLSComp, r.SourceDBNLSSort)
 		}
 	}
+	// 目标端字符集排序规则
+	tableCollation = r.genTargetCollation(tableCollation)
+
 	// table-option 表后缀可选项
-	if strings.EqualFold(r.TargetDBType, common.DatabaseTypeMySQL) || r.TargetTableOption == "" {
+	// 临时表以及聚簇主键表不支持 SHARD_ROW_ID_BITS 等表选项
+	if strings.EqualFold(r.TargetDBType, common.DatabaseTypeMySQL) || r.TargetTableOption == "" || !strings.EqualFold(r.ReverseTableDecision(), common.ReverseTableDecisionNormal) {
 		zap.L().Warn("reverse oracle table suffix",
 			zap.String("table", r.String()),
 			zap.String("table-option", "table-option is null, would be disabled"))
 		// table suffix
 		tableSuffix = fmt.Sprintf("ENGINE=InnoDB DEFAULT CHARSET=%s COLLATE=%s",
-			strings.ToLower(common.MySQLCharacterSet), tableCollation)
+			strings.ToLower(r.genTargetCharset()), tableCollation)
 
 	} else {
 		// TiDB
@@ -256,10 +306,10 @@ func (r *Rule) GenTableSuffix() (string, error) {
 
 			if r.TargetTableOption != "" {
 				tableSuffix = fmt.Sprintf("ENGINE=InnoDB DEFAULT CHARSET=%s COLLATE=%s %s",
-					strings.ToLower(common.MySQLCharacterSet), tableCollation, common.StringUPPER(r.TargetTableOption))
+					strings.ToLower(r.genTargetCharset()), tableCollation, common.StringUPPER(r.TargetTableOption))
 			} else {
 				tableSuffix = fmt.Sprintf("ENGINE=InnoDB DEFAULT CHARSET=%s COLLATE=%s",
-					strings.ToLower(common.MySQLCharacterSet), tableCollation)
+					strings.ToLower(r.genTargetCharset()), tableCollation)
 			}
 		case common.TiDBClusteredIndexONValue:
 			zap.L().Warn("reverse oracle table suffix",
@@ -268,7 +318,7 @@ func (r *Rule) GenTableSuffix() (string, error) {
 				zap.String("table-option", "tidb_enable_clustered_index is on, would be disabled"))
 
 			tableSuffix = fmt.Sprintf("ENGINE=InnoDB DEFAULT CHARSET=%s COLLATE=%s",
-				strings.ToLower(common.MySQLCharacterSet), tableCollation)
+				strings.ToLower(r.genTargetCharset()), tableCollation)
 
 		default:
 			// tidb_enable_clustered_index = int_only / tidb_enable_clustered_index 不存在值，等于空
@@ -284,7 +334,7 @@ func (r *Rule) GenTableSuffix() (string, error) {
 					zap.String("table-option", "alter-primary-key isn't exits, would be disable"))
 
 				tableSuffix = fmt.Sprintf("ENGINE=InnoDB DEFAULT CHARSET=%s COLLATE=%s",
-					strings.ToLower(common.MySQLCharacterSet), tableCollation)
+					strings.ToLower(r.genTargetCharset()), tableCollation)
 
 			} else {
 				var p fastjson.Parser
@@ -306,7 +356,7 @@ func (r *Rule) GenTableSuffix() (string, error) {
 						zap.String("table-option", "integer primary key, would be disable"))
 
 					tableSuffix = fmt.Sprintf("ENGINE=InnoDB DEFAULT CHARSET=%s COLLATE=%s",
-						strings.ToLower(common.MySQLCharacterSet), tableCollation)
+						strings.ToLower(r.genTargetCharset()), tableCollation)
 
 				} else {
 					// table-option 生效
@@ -322,10 +372,10 @@ func (r *Rule) GenTableSuffix() (string, error) {
 
 						if r.TargetTableOption != "" {
 							tableSuffix = fmt.Sprintf("ENGINE=InnoDB DEFAULT CHARSET=%s COLLATE=%s %s",
-								strings.ToLower(common.MySQLCharacterSet), tableCollation, common.StringUPPER(r.TargetTableOption))
+								strings.ToLower(r.genTargetCharset()), tableCollation, common.StringUPPER(r.TargetTableOption))
 						} else {
 							tableSuffix = fmt.Sprintf("ENGINE=InnoDB DEFAULT CHARSET=%s COLLATE=%s",
-								strings.ToLower(common.MySQLCharacterSet), tableCollation)
+								strings.ToLower(r.genTargetCharset()), tableCollation)
 						}
 					} else {
 						zap.L().Error("reverse oracle table suffix",
@@ -357,6 +407,10 @@ func (r *Rule) GenTablePrimaryKey() (primaryKeys []string, err error) {
 			primaryColumns = append(primaryColumns, fmt.Sprintf("`%s`", col))
 		}
 		pk := fmt.Sprintf("PRIMARY KEY (%s)", strings.ToUpper(strings.Join(primaryColumns, ",")))
+		// 索引组织表 TiDB 显式聚簇主键，MySQL 忽略该注释
+		if strings.EqualFold(r.ReverseTableDecision(), common.ReverseTableDecisionClusteredPK) && strings.EqualFold(r.TargetDBType, common.DatabaseTypeTiDB) {
+			pk = fmt.Sprintf("%s /*T![clustered_index] CLUSTERED */", pk)
+		}
 		primaryKeys = append(primaryKeys, pk)
 	}
 
@@ -502,9 +556,11 @@ func (r *Rule) GenTableUniqueIndex() (uniqueIndexes []string, compatibilityIndex
 			if idxMeta["TABLE_NAME"] != "" && strings.ToUpper(idxMeta["UNIQUENESS"]) == "UNIQUE" {
 				switch idxMeta["INDEX_TYPE"] {
 				case "NORMAL":
-					var uniqueIndex []string
-					for _, col := range strings.Split(idxMeta["COLUMN_LIST"], ",") {
-						uniqueIndex = append(uniqueIndex, fmt.Sprintf("`%s`", col))
+					uniqueIndex, ok := r.GenTableIndexColumns(idxMeta["INDEX_NAME"], strings.Split(idxMeta["COLUMN_LIST"], ","), true)
+					if !ok {
+						compatibilityIndexSQL = append(compatibilityIndexSQL, fmt.Sprintf("CREATE UNIQUE INDEX `%s` ON `%s`.`%s` (%s);",
+							strings.ToUpper(idxMeta["INDEX_NAME"]), r.TargetSchemaName, r.TargetTableName, strings.Join(uniqueIndex, ",")))
+						continue
 					}
 
 					uniqueIDX := fmt.Sprintf("UNIQUE INDEX `%s` (%s)", strings.ToUpper(idxMeta["INDEX_NAME"]), strings.Join(uniqueIndex, ","))
@@ -522,21 +578,9 @@ func (r *Rule) GenTableUniqueIndex() (uniqueIndexes []string, compatibilityIndex
 					continue
 
 				case "FUNCTION-BASED NORMAL":
-					sql := fmt.Sprintf("CREATE UNIQUE INDEX `%s` ON `%s`.`%s` (%s);",
-						strings.ToUpper(idxMeta["INDEX_NAME"]), r.TargetSchemaName, r.TargetTableName,
-						idxMeta["COLUMN_LIST"])
-
-					compatibilityIndexSQL = append(compatibilityIndexSQL, sql)
-
-					zap.L().Warn("reverse unique key",
-						zap.String("schema", r.SourceSchemaName),
-						zap.String("table", idxMeta["TABLE_NAME"]),
-						zap.String("index name", idxMeta["INDEX_NAME"]),
-						zap.String("index type", idxMeta["INDEX_TYPE"]),
-						zap.String("index column list", idxMeta["COLUMN_LIST"]),
-						zap.String("create unique index sql", sql),
-						zap.String("warn", "mysql not support"))
-
+					keyIndexes, compSQL := r.GenTableFunctionIndex(idxMeta, true)
+					uniqueIndexes = append(uniqueIndexes, keyIndexes...)
+					compatibilityIndexSQL = append(compatibilityIndexSQL, compSQL...)
 					continue
 
 				default:
@@ -572,9 +616,11 @@ func (r *Rule) GenTableNormalIndex() (normalIndexes []string, compatibilityIndex
 			if idxMeta["TABLE_NAME"] != "" && strings.ToUpper(idxMeta["UNIQUENESS"]) == "NONUNIQUE" {
 				switch idxMeta["INDEX_TYPE"] {
 				case "NORMAL":
-					var normalIndex []string
-					for _, col := range strings.Split(idxMeta["COLUMN_LIST"], ",") {
-						normalIndex = append(normalIndex, fmt.Sprintf("`%s`", col))
+					normalIndex, ok := r.GenTableIndexColumns(idxMeta["INDEX_NAME"], strings.Split(idxMeta["COLUMN_LIST"], ","), false)
+					if !ok {
+						compatibilityIndexSQL = append(compatibilityIndexSQL, fmt.Sprintf("CREATE INDEX `%s` ON `%s`.`%s` (%s);",
+							strings.ToUpper(idxMeta["INDEX_NAME"]), r.TargetSchemaName, r.TargetTableName, strings.Join(normalIndex, ",")))
+						continue
 					}
 
 					keyIndex := fmt.Sprintf("KEY `%s` (%s)", strings.ToUpper(idxMeta["INDEX_NAME"]), strings.Join(normalIndex, ","))
@@ -591,46 +637,29 @@ func (r *Rule) GenTableNormalIndex() (normalIndexes []string, compatibilityIndex
 
 					continue
 
-				case "FUNCTION-BASED NORMAL":
-					sql := fmt.Sprintf("CREATE INDEX %s ON %s.%s (%s);",
-						strings.ToUpper(idxMeta["INDEX_NAME"]), r.TargetSchemaName, r.TargetTableName,
-						idxMeta["COLUMN_LIST"])
-
-					compatibilityIndexSQL = append(compatibilityIndexSQL, sql)
-
-					zap.L().Warn("reverse normal index",
-						zap.String("schema", r.SourceSchemaName),
-						zap.String("table", idxMeta["TABLE_NAME"]),
-						zap.String("index name", idxMeta["INDEX_NAME"]),
-						zap.String("index type", idxMeta["INDEX_TYPE"]),
-						zap.String("index column list", idxMeta["COLUMN_LIST"]),
-						zap.String("create normal index sql", sql),
-						zap.String("warn", "mysql not support"))
+				case "FUNCTION-BASED NORMAL", "FUNCTION-BASED BITMAP":
+					// 位图函数索引下游不支持位图，按函数索引转换
+					keyIndexes, compSQL := r.GenTableFunctionIndex(idxMeta, false)
+					normalIndexes = append(normalIndexes, keyIndexes...)
+					if strings.EqualFold(idxMeta["INDEX_TYPE"], common.BuildInOracleIndexTypeFunctionBasedBitmap) && len(keyIndexes) > 0 {
+						compatibilityIndexSQL = append(compatibilityIndexSQL, r.genBitmapIndexNote(idxMeta["INDEX_NAME"], keyIndexes[len(keyIndexes)-1]))
+					}
+					compatibilityIndexSQL = append(compatibilityIndexSQL, compSQL...)
 					continue
 
 				case "BITMAP":
-					sql := fmt.Sprintf("CREATE BITMAP INDEX %s ON %s.%s (%s);",
-						strings.ToUpper(idxMeta["INDEX_NAME"]), r.TargetSchemaName, r.TargetTableName,
-						idxMeta["COLUMN_LIST"])
-
-					compatibilityIndexSQL = append(compatibilityIndexSQL, sql)
-
-					zap.L().Warn("reverse normal index",
-						zap.String("schema", r.SourceSchemaName),
-						zap.String("table", idxMeta["TABLE_NAME"]),
-						zap.String("index name", idxMeta["INDEX_NAME"]),
-						zap.String("index type", idxMeta["INDEX_TYPE"]),
-						zap.String("index column list", idxMeta["COLUMN_LIST"]),
-						zap.String("create normal index sql", sql),
-						zap.String("warn", "mysql not support"))
-					continue
+					// 下游不支持位图索引，转换普通索引
+					normalIndex, ok := r.GenTableIndexColumns(idxMeta["INDEX_NAME"], strings.Split(idxMeta["COLUMN_LIST"], ","), false)
+					if !ok {
+						compatibilityIndexSQL = append(compatibilityIndexSQL, fmt.Sprintf("CREATE INDEX `%s` ON `%s`.`%s` (%s);",
+							strings.ToUpper(idxMeta["INDEX_NAME"]), r.TargetSchemaName, r.TargetTableName, strings.Join(normalIndex, ",")))
+						continue
+					}
 
-				case "FUNCTION-BASED BITMAP":
-					sql := fmt.Sprintf("CREATE BITMAP INDEX %s ON %s.%s (%s);",
-						strings.ToUpper(idxMeta["INDEX_NAME"]), r.TargetSchemaName, r.TargetTableName,
-						idxMeta["COLUMN_LIST"])
+					keyIndex := fmt.Sprintf("KEY `%s` (%s)", strings.ToUpper(idxMeta["INDEX_NAME"]), strings.Join(normalIndex, ","))
 
-					compatibilityIndexSQL = append(compatibilityIndexSQL, sql)
+					normalIndexes = append(normalIndexes, keyIndex)
+					compatibilityIndexSQL = append(compatibilityIndexSQL, r.genBitmapIndexNote(idxMeta["INDEX_NAME"], keyIndex))
 
 					zap.L().Warn("reverse normal index",
 						zap.String("schema", r.SourceSchemaName),
@@ -638,8 +667,8 @@ func (r *Rule) GenTableNormalIndex() (normalIndexes []string, compatibilityIndex
 						zap.String("index name", idxMeta["INDEX_NAME"]),
 						zap.String("index type", idxMeta["INDEX_TYPE"]),
 						zap.String("index column list", idxMeta["COLUMN_LIST"]),
-						zap.String("create normal index sql", sql),
-						zap.String("warn", "mysql not support"))
+						zap.String("key index info", keyIndex),
+						zap.String("warn", "bitmap index isn't support, reverse normal index"))
 					continue
 
 				case "DOMAIN":
@@ -697,6 +726,12 @@ func (r *Rule) GenTableNormalIndex() (normalIndexes []string, compatibilityIndex
 	return normalIndexes, compatibilityIndexSQL, err
 }
 
+// 位图索引下游不支持，已转换普通索引，兼容性文件记录说明
+func (r *Rule) genBitmapIndexNote(indexName, keyIndex string) string {
+	return fmt.Sprintf("-- oracle bitmap index [%s.%s.%s] isn't support, reversed as normal index [%s] in table [%s.%s], please manual check",
+		r.SourceSchemaName, r.SourceTableName, strings.ToUpper(indexName), keyIndex, r.TargetSchemaName, r.TargetTableName)
+}
+
 func (r *Rule) GenTableComment() (tableComment string, err error) {
 	if len(r.TableColumnINFO) > 0 && r.TableCommentINFO[0]["COMMENTS"] != "" {
 		tableComment = fmt.Sprintf("COMMENT='%s'", r.TableCommentINFO[0]["COMMENTS"])
@@ -716,7 +751,7 @@ func (r *Rule) GenTableColumn() (tableColumns []string, err error) {
 		if r.OracleCollation {
 			// 字段排序规则检查
 			if collationMapVal, ok := common.OracleCollationMap[strings.ToUpper(rowCol["COLLATION"])]; ok {
-				columnCollation = collationMapVal
+				columnCollation = r.genTargetCollation(collationMapVal)
 			} else {
 				// 字段数值数据类型不存在排序规则，排除忽略
 				if !strings.EqualFold(rowCol["COLLATION"], "") {
@@ -753,6 +788,48 @@ func (r *Rule) GenTableColumn() (tableColumns []string, err error) {
 			return tableColumns, fmt.Errorf("oracle table [%s.%s] column [%s] default value isn't exist", r.SourceSchemaName, r.SourceTableName, rowCol["COLUMN_NAME"])
 		}
 
+		// 虚拟列、标识列、不可见列
+		if attr, ok := r.genTableColumnAttr(rowCol["COLUMN_NAME"]); ok {
+			if strings.EqualFold(attr["VIRTUAL_COLUMN"], "YES") {
+				generatedExpr, isCompatible := r.genTableColumnGeneratedExpr(rowCol["COLUMN_NAME"], attr["DATA_DEFAULT"])
+				// 表达式无法转换，以 ALTER TABLE 语句输出不兼容性文件
+				if strings.EqualFold(isCompatible, "N") {
+					continue
+				}
+				// 生成列排序规则需位于 GENERATED 之前
+				if columnCollation != "" {
+					columnType = fmt.Sprintf("%s COLLATE %s", columnType, columnCollation)
+					columnCollation = ""
+				}
+				columnType = fmt.Sprintf("%s %s", columnType, generatedExpr)
+				dataDefault = ""
+			}
+			if strings.EqualFold(attr["IDENTITY_COLUMN"], "YES") {
+				// 标识列默认值为序列 ISEQ$$ nextval，转换自增列
+				columnType = fmt.Sprintf("%s AUTO_INCREMENT", columnType)
+				dataDefault = ""
+				if !r.isPrimaryKeyColumn(rowCol["COLUMN_NAME"]) {
+					zap.L().Warn("reverse identity column isn't primary key, auto_increment column must be defined as a key",
+						zap.String("schema", r.SourceSchemaName),
+						zap.String("table", r.SourceTableName),
+						zap.String("column", rowCol["COLUMN_NAME"]))
+				}
+			}
+			if strings.EqualFold(attr["HIDDEN_COLUMN"], "YES") {
+				if !strings.EqualFold(r.TargetDBType, common.DatabaseTypeTiDB) &&
+					common.VersionOrdinal(r.TargetDBVersion) >= common.VersionOrdinal(common.MySQLInvisibleColumnVersion) {
+					columnType = fmt.Sprintf("%s INVISIBLE", columnType)
+				} else {
+					zap.L().Warn("reverse invisible column isn't support, column will be visible",
+						zap.String("schema", r.SourceSchemaName),
+						zap.String("table", r.SourceTableName),
+						zap.String("column", rowCol["COLUMN_NAME"]),
+						zap.String("target db type", r.TargetDBType),
+						zap.String("target db version", r.TargetDBVersion))
+				}
+			}
+		}
+
 		if nullable == "NULL" {
 			switch {
 			case columnCollation != "" && comment != "" && dataDefault != "":
@@ -802,6 +879,65 @@ func (r *Rule) GenTableColumn() (tableColumns []string, err error) {
 	return tableColumns, nil
 }
 
+// GenTableVirtualColumnCompDDL 表达式无法自动转换的虚拟列，输出 ALTER TABLE 语句人工确认
+func (r *Rule) GenTableVirtualColumnCompDDL() (compatibleDDL []string, err error) {
+	targetSchema, targetTable := r.GenTablePrefix()
+	for _, rowCol := range r.TableColumnINFO {
+		attr, ok := r.genTableColumnAttr(rowCol["COLUMN_NAME"])
+		if !ok || !strings.EqualFold(attr["VIRTUAL_COLUMN"], "YES") {
+			continue
+		}
+		generatedExpr, isCompatible := r.genTableColumnGeneratedExpr(rowCol["COLUMN_NAME"], attr["DATA_DEFAULT"])
+		if strings.EqualFold(isCompatible, "Y") {
+			continue
+		}
+		columnType, ok := r.TableColumnDatatypeRule[rowCol["COLUMN_NAME"]]
+		if !ok {
+			return compatibleDDL, fmt.Errorf("oracle table [%s.%s] column [%s] data type isn't exist", r.SourceSchemaName, r.SourceTableName, rowCol["COLUMN_NAME"])
+		}
+		compatibleDDL = append(compatibleDDL, fmt.Sprintf("ALTER TABLE `%s`.`%s` ADD COLUMN `%s` %s %s;",
+			targetSchema, targetTable, rowCol["COLUMN_NAME"], columnType, generatedExpr))
+
+		zap.L().Warn("reverse virtual column expression maybe incompatible",
+			zap.String("schema", r.SourceSchemaName),
+			zap.String("table", r.SourceTableName),
+			zap.String("column", rowCol["COLUMN_NAME"]),
+			zap.String("expression", attr["DATA_DEFAULT"]),
+			zap.String("suggest", "please manual check and create column"))
+	}
+	return compatibleDDL, nil
+}
+
+func (r *Rule) genTableColumnAttr(columnName string) (map[string]string, bool) {
+	for _, attr := range r.TableColumnAttrINFO {
+		if strings.EqualFold(attr["COLUMN_NAME"], columnName) {
+			return attr, true
+		}
+	}
+	return nil, false
+}
+
+// 虚拟列表达式转换，主键列下游不支持 VIRTUAL，使用 STORED
+func (r *Rule) genTableColumnGeneratedExpr(columnName, expr string) (string, string) {
+	exprT, isCompatible := TranslateOracleExpression(expr)
+	if r.isPrimaryKeyColumn(columnName) {
+		return fmt.Sprintf("GENERATED ALWAYS AS (%s) STORED", exprT), isCompatible
+	}
+	return fmt.Sprintf("GENERATED ALWAYS AS (%s) VIRTUAL", exprT), isCompatible
+}
+
+func (r *Rule) isPrimaryKeyColumn(columnName string) bool {
+	if len(r.PrimaryKeyINFO) == 0 {
+		return false
+	}
+	for _, col := range strings.Split(r.PrimaryKeyINFO[0]["COLUMN_LIST"], ",") {
+		if strings.EqualFold(strings.TrimSpace(col), columnName) {
+			return true
+		}
+	}
+	return false
+}
+
 func (r *Rule) GenTableColumnComment() (columnComments []string, err error) {
 	// O2M Skip
 	return
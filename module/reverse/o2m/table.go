@@ -32,20 +32,24 @@ import (
 )
 
 type Table struct {
-	Ctx                   context.Context `json:"-"`
-	SourceSchemaName      string          `json:"source_schema_name"`
-	TargetSchemaName      string          `json:"target_schema_name"`
-	SourceTableName       string          `json:"source_table_name"`
-	TargetDBType          string          `json:"target_db_type"`
-	TargetDBVersion       string          `json:"target_db_version"`
-	TargetTableName       string          `json:"target_table_name"`
-	TargetTableOption     string          `json:"target_table_option"`
-	OracleCollation       bool            `json:"oracle_collation"`
-	SourceSchemaCollation string          `json:"source_schema_collation"` // 可为空
-	SourceTableCollation  string          `json:"source_table_collation"`  // 可为空
-	SourceDBNLSSort       string          `json:"sourcedb_nlssort"`
-	SourceDBNLSComp       string          `json:"sourcedb_nlscomp"`
-	SourceTableType       string          `json:"source_table_type"`
+	Ctx                   context.Context       `json:"-"`
+	SourceSchemaName      string                `json:"source_schema_name"`
+	TargetSchemaName      string                `json:"target_schema_name"`
+	SourceTableName       string                `json:"source_table_name"`
+	TargetDBType          string                `json:"target_db_type"`
+	TargetDBVersion       string                `json:"target_db_version"`
+	TargetTableName       string                `json:"target_table_name"`
+	TargetTableOption     string                `json:"target_table_option"`
+	OracleCollation       bool                  `json:"oracle_collation"`
+	SourceSchemaCollation string                `json:"source_schema_collation"` // 可为空
+	SourceTableCollation  string                `json:"source_table_collation"`  // 可为空
+	SourceDBNLSSort       string                `json:"sourcedb_nlssort"`
+	SourceDBNLSComp       string                `json:"sourcedb_nlscomp"`
+	SourceTableType       string                `json:"source_table_type"`
+	FunctionIndexGenCol   bool                  `json:"function_index_gen_column"`
+	IndexExprRules        []indexExpressionRule `json:"-"`
+	TargetCharset         string                `json:"target_charset"`
+	CharsetCheckRows      int                   `json:"charset_check_rows"`
 
 	TableColumnDatatypeRule   map[string]string `json:"table_column_datatype_rule"`
 	TableColumnDefaultValRule map[string]string `json:"table_column_default_val_rule"`
@@ -144,6 +148,11 @@ func GenReverseTableTask(r *Reverse, tableNameRule map[string]string, tableColum
 		}
 	}
 
+	indexExprRules, err := GenIndexExpressionRules(r.Cfg.ReverseConfig.IndexExprRules)
+	if err != nil {
+		return nil, err
+	}
+
 	startTime = time.Now()
 	g1 := &errgroup.Group{}
 	tableChan := make(chan *Table, common.ChannelBufferSize)
@@ -177,6 +186,10 @@ func GenReverseTableTask(r *Reverse, tableNameRule map[string]string, tableColum
 					TableColumnDatatypeRule:   tableColumnRule[common.StringUPPER(t)],
 					TableColumnDefaultValRule: tableDefaultRule[common.StringUPPER(t)],
 					Overwrite:                 r.Cfg.MySQLConfig.Overwrite,
+					FunctionIndexGenCol:       r.Cfg.ReverseConfig.FunctionIndexGenColumn,
+					IndexExprRules:            indexExprRules,
+					TargetCharset:             common.StringUPPER(r.Cfg.MySQLConfig.Charset),
+					CharsetCheckRows:          r.Cfg.ReverseConfig.CharsetCheckRows,
 					Oracle:                    r.Oracle,
 					MySQL:                     r.Mysql,
 					MetaDB:                    r.MetaDB,
@@ -219,6 +232,32 @@ func GenReverseTableTask(r *Reverse, tableNameRule map[string]string, tableColum
 	return tables, nil
 }
 
+// ReverseTableDecision 表类型转换决策
+// 1、事务级临时表 TiDB 转换全局临时表 ON COMMIT DELETE ROWS，MySQL 输出会话临时表模板
+// 2、会话级临时表 ON COMMIT PRESERVE ROWS 下游不支持全局形式，输出会话临时表模板
+// 3、索引组织表转换聚簇主键表，MySQL InnoDB 主键默认聚簇
+func (t *Table) ReverseTableDecision() string {
+	isTiDB := strings.EqualFold(t.TargetDBType, common.DatabaseTypeTiDB)
+	tidbVersion := common.TiDBVersion(t.TargetDBVersion)
+
+	switch common.StringUPPER(t.SourceTableType) {
+	case common.OracleTableTypeTransactionTemporary:
+		if isTiDB && common.VersionOrdinal(tidbVersion) >= common.VersionOrdinal(common.TiDBGlobalTemporaryVersion) {
+			return common.ReverseTableDecisionGlobalTemporary
+		}
+		return common.ReverseTableDecisionTemporaryTemplate
+	case common.OracleTableTypeSessionTemporary:
+		return common.ReverseTableDecisionTemporaryTemplate
+	case common.OracleTableTypeIOT:
+		if !isTiDB || common.VersionOrdinal(tidbVersion) >= common.VersionOrdinal(common.TiDBClusteredIndexVersion) {
+			return common.ReverseTableDecisionClusteredPK
+		}
+		return common.ReverseTableDecisionNormal
+	default:
+		return common.ReverseTableDecisionNormal
+	}
+}
+
 func (t *Table) GetTablePrimaryKey() ([]map[string]string, error) {
 	return t.Oracle.GetOracleSchemaTablePrimaryKey(t.SourceSchemaName, t.SourceTableName)
 }
@@ -259,6 +298,11 @@ func (t *Table) GetTableColumnComment() ([]map[string]string, error) {
 	return t.Oracle.GetOracleSchemaTableColumnComment(t.SourceSchemaName, t.SourceTableName)
 }
 
+func (t *Table) GetTableColumnAttr() ([]map[string]string, error) {
+	// 获取表字段属性【虚拟列、不可见列、标识列】
+	return t.Oracle.GetOracleSchemaTableColumnAttr(t.SourceSchemaName, t.SourceTableName)
+}
+
 func (t *Table) GetTablePartitons() ([]map[string]string, error) {
 	// Get all table partitions
 	return t.Oracle.GetOracleTablePartitions(t.SourceSchemaName, t.SourceTableName)
@@ -297,6 +341,10 @@ func (t *Table) GetTableInfo() (interface{}, error) {
 	if err != nil {
 		return nil, err
 	}
+	columnAttr, err := t.GetTableColumnAttr()
+	if err != nil {
+		return nil, err
+	}
     tablePartitionsInfo, err := t.GetTablePartitons()
 	if err != nil {
 		return nil, err
@@ -324,6 +372,7 @@ func (t *Table) GetTableInfo() (interface{}, error) {
 		NormalIndexINFO:     normalIndex,
 		TableCommentINFO:    tableComment,
 		TableColumnINFO:     columnMeta,
+		TableColumnAttrINFO: columnAttr,
 		ColumnCommentINFO:   columnComment,
         TablePartitionsInfo: tablePartitionsInfo,
 	}, nil
@@ -344,27 +393,13 @@ func (t *Table) String() string {
 
 func GenCreateSchema(w *reverse.Write, sourceSchema, targetSchema, nlsComp string, directWrite bool) error {
 	startTime := time.Now()
-	var (
-		sqlRev          strings.Builder
-		schemaCollation string
-	)
+	var sqlRev strings.Builder
 
-	oraDBVersion, err := w.Oracle.GetOracleDBVersion()
+	createSchema, err := GenCreateSchemaSQL(w.Oracle, sourceSchema, targetSchema, nlsComp)
 	if err != nil {
 		return err
 	}
 
-	oraCollation := false
-	if common.VersionOrdinal(oraDBVersion) >= common.VersionOrdinal(common.OracleTableColumnCollationDBVersion) {
-		oraCollation = true
-	}
-	if oraCollation {
-		schemaCollation, err = w.Oracle.GetOracleSchemaCollation(sourceSchema)
-		if err != nil {
-			return err
-		}
-	}
-
 	sqlRev.WriteString("/*\n")
 	sqlRev.WriteString(" oracle schema reverse mysql database\n")
 	t := table.NewWriter()
@@ -375,18 +410,7 @@ func GenCreateSchema(w *reverse.Write, sourceSchema, targetSchema, nlsComp strin
 	})
 	sqlRev.WriteString(t.Render() + "\n")
 	sqlRev.WriteString("*/\n")
-
-	if oraCollation {
-		if _, ok := common.OracleCollationMap[common.StringUPPER(schemaCollation)]; !ok {
-			return fmt.Errorf("oracle schema collation [%s] isn't support", schemaCollation)
-		}
-		sqlRev.WriteString(fmt.Sprintf("CREATE DATABASE IF NOT EXISTS %s DEFAULT CHARACTER SET %s COLLATE %s;\n\n", common.StringUPPER(targetSchema), strings.ToLower(common.MySQLCharacterSet), common.OracleCollationMap[common.StringUPPER(schemaCollation)]))
-	} else {
-		if _, ok := common.OracleCollationMap[common.StringUPPER(nlsComp)]; !ok {
-			return fmt.Errorf("oracle db nls_comp collation [%s] isn't support", nlsComp)
-		}
-		sqlRev.WriteString(fmt.Sprintf("CREATE DATABASE IF NOT EXISTS %s DEFAULT CHARACTER SET %s COLLATE %s;\n\n", common.StringUPPER(targetSchema), strings.ToLower(common.MySQLCharacterSet), common.OracleCollationMap[common.StringUPPER(nlsComp)]))
-	}
+	sqlRev.WriteString(createSchema + "\n\n")
 
 	if directWrite {
 		err = w.RWriteDB(sqlRev.String())
@@ -406,14 +430,49 @@ func GenCreateSchema(w *reverse.Write, sourceSchema, targetSchema, nlsComp strin
 	return nil
 }
 
-func GenCompatibilityTable(f *reverse.Write, sourceSchema string, partitionTables, temporaryTables, clusteredTables []string, materializedViews []string) error {
+// GenCreateSchemaSQL 生成 CREATE DATABASE 语句，排序规则优先取 schema collation，低版本取 nls_comp
+func GenCreateSchemaSQL(oracle *oracle.Oracle, sourceSchema, targetSchema, nlsComp string) (string, error) {
+	oraDBVersion, err := oracle.GetOracleDBVersion()
+	if err != nil {
+		return "", err
+	}
+
+	if common.VersionOrdinal(oraDBVersion) >= common.VersionOrdinal(common.OracleTableColumnCollationDBVersion) {
+		schemaCollation, err := oracle.GetOracleSchemaCollation(sourceSchema)
+		if err != nil {
+			return "", err
+		}
+		if _, ok := common.OracleCollationMap[common.StringUPPER(schemaCollation)]; !ok {
+			return "", fmt.Errorf("oracle schema collation [%s] isn't support", schemaCollation)
+		}
+		return fmt.Sprintf("CREATE DATABASE IF NOT EXISTS %s DEFAULT CHARACTER SET %s COLLATE %s;", common.StringUPPER(targetSchema), strings.ToLower(common.MySQLCharacterSet), common.OracleCollationMap[common.StringUPPER(schemaCollation)]), nil
+	}
+
+	if _, ok := common.OracleCollationMap[common.StringUPPER(nlsComp)]; !ok {
+		return "", fmt.Errorf("oracle db nls_comp collation [%s] isn't support", nlsComp)
+	}
+	return fmt.Sprintf("CREATE DATABASE IF NOT EXISTS %s DEFAULT CHARACTER SET %s COLLATE %s;", common.StringUPPER(targetSchema), strings.ToLower(common.MySQLCharacterSet), common.OracleCollationMap[common.StringUPPER(nlsComp)]), nil
+}
+
+func GenCompatibilityTable(f *reverse.Write, sourceSchema string, tables []*Table, partitionTables, temporaryTables, clusteredTables []string, materializedViews []string) error {
 	startTime := time.Now()
+
+	// 表类型转换决策
+	var iotTables []string
+	decisions := make(map[string]string)
+	for _, t := range tables {
+		decisions[t.SourceTableName] = t.ReverseTableDecision()
+		if strings.EqualFold(t.SourceTableType, common.OracleTableTypeIOT) {
+			iotTables = append(iotTables, t.SourceTableName)
+		}
+	}
+
 	// 兼容提示
-	if len(partitionTables) > 0 || len(temporaryTables) > 0 || len(clusteredTables) > 0 || len(materializedViews) > 0 {
+	if len(partitionTables) > 0 || len(temporaryTables) > 0 || len(clusteredTables) > 0 || len(iotTables) > 0 || len(materializedViews) > 0 {
 		var sqlComp strings.Builder
 
 		sqlComp.WriteString("/*\n")
-		sqlComp.WriteString(" oracle table maybe mysql has compatibility, will convert to normal table, please manual process\n")
+		sqlComp.WriteString(" oracle table maybe mysql has compatibility, will convert by the suggest decision, otherwise please manual process\n")
 		t := table.NewWriter()
 		t.SetStyle(table.StyleLight)
 		t.AppendHeader(table.Row{"SCHEMA", "TABLE NAME", "ORACLE TABLE TYPE", "SUGGEST"})
@@ -428,7 +487,14 @@ func GenCompatibilityTable(f *reverse.Write, sourceSchema string, partitionTable
 		if len(temporaryTables) > 0 {
 			for _, temp := range temporaryTables {
 				t.AppendRows([]table.Row{
-					{sourceSchema, temp, "Temporary", "Manual Process Table"},
+					{sourceSchema, temp, "Temporary", decisions[temp]},
+				})
+			}
+		}
+		if len(iotTables) > 0 {
+			for _, iot := range iotTables {
+				t.AppendRows([]table.Row{
+					{sourceSchema, iot, "Index Organized", decisions[iot]},
 				})
 			}
 		}
@@ -450,14 +516,14 @@ func GenCompatibilityTable(f *reverse.Write, sourceSchema string, partitionTable
 			var mviewComp strings.Builder
 
 			mviewComp.WriteString("/*\n")
-			mviewComp.WriteString(" oracle materialized view maybe mysql has compatibility, will skip convert to reverse, please manual process\n")
+			mviewComp.WriteString(" oracle materialized view maybe mysql has compatibility, will convert to normal table, refresh query see meta table [mview_refresh_meta]\n")
 			t = table.NewWriter()
 			t.SetStyle(table.StyleLight)
 			t.AppendHeader(table.Row{"SCHEMA", "MVIEW NAME", "ORACLE TABLE TYPE", "SUGGEST"})
 
 			for _, cd := range materializedViews {
 				t.AppendRows([]table.Row{
-					{sourceSchema, cd, "Materialized View", "Manual Process Table"},
+					{sourceSchema, cd, "Materialized View", "Refresh By [refresh] Mode"},
 				})
 			}
 
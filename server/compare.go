@@ -17,9 +17,11 @@ package server
 
 import (
 	"context"
+	"fmt"
 	"github.com/wentaojin/transferdb/common"
 	"github.com/wentaojin/transferdb/config"
 	"github.com/wentaojin/transferdb/module/compare"
+	"github.com/wentaojin/transferdb/module/compare/m2o"
 	"github.com/wentaojin/transferdb/module/compare/o2m"
 	"strings"
 )
@@ -35,6 +37,13 @@ func ICompare(ctx context.Context, cfg *config.Config) error {
 		if err != nil {
 			return err
 		}
+	case (strings.EqualFold(cfg.DBTypeS, common.DatabaseTypeMySQL) || strings.EqualFold(cfg.DBTypeS, common.DatabaseTypeTiDB)) && strings.EqualFold(cfg.DBTypeT, common.DatabaseTypeOracle):
+		c, err = m2o.NewCompare(ctx, cfg)
+		if err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("compare db type source [%s] target [%s] isn't support", cfg.DBTypeS, cfg.DBTypeT)
 	}
 	err = c.NewCompare()
 	if err != nil {
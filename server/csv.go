@@ -17,6 +17,7 @@ package server
 
 import (
 	"context"
+	"fmt"
 	"github.com/wentaojin/transferdb/common"
 	"github.com/wentaojin/transferdb/config"
 	"github.com/wentaojin/transferdb/module/migrate"
@@ -42,3 +43,24 @@ func ICSVer(ctx context.Context, cfg *config.Config) error {
 	}
 	return err
 }
+
+func IImporter(ctx context.Context, cfg *config.Config) error {
+	var (
+		i   migrate.Importer
+		err error
+	)
+	switch {
+	case strings.EqualFold(cfg.DBTypeS, common.DatabaseTypeOracle) && (strings.EqualFold(cfg.DBTypeT, common.DatabaseTypeMySQL) || strings.EqualFold(cfg.DBTypeT, common.DatabaseTypeTiDB)):
+		i, err = o2m.NewImporter(ctx, cfg)
+		if err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("import db type source [%s] target [%s] isn't support", cfg.DBTypeS, cfg.DBTypeT)
+	}
+	err = i.Import()
+	if err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+/*
+Copyright © 2020 Marvin
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package server
+
+import (
+	"context"
+	"fmt"
+	"github.com/wentaojin/transferdb/common"
+	"github.com/wentaojin/transferdb/config"
+	"github.com/wentaojin/transferdb/module/refresh"
+	"github.com/wentaojin/transferdb/module/refresh/o2m"
+	"strings"
+)
+
+func IRefresh(ctx context.Context, cfg *config.Config) error {
+	var (
+		r   refresh.Refresher
+		err error
+	)
+	switch {
+	case strings.EqualFold(cfg.DBTypeS, common.DatabaseTypeOracle) && strings.EqualFold(cfg.DBTypeT, common.DatabaseTypeMySQL):
+		r, err = o2m.NewRefresh(ctx, cfg)
+		if err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("refresh db type source [%s] target [%s] isn't support", cfg.DBTypeS, cfg.DBTypeT)
+	}
+	err = r.Refresh()
+	if err != nil {
+		return err
+	}
+	return nil
+}
@@ -63,6 +63,12 @@ func Run(ctx context.Context, cfg *config.Config) error {
 		if err != nil {
 			return err
 		}
+	case common.TaskModeImport:
+		// csv 数据文件导入下游
+		err := IImporter(ctx, cfg)
+		if err != nil {
+			return err
+		}
 	case common.TaskModeFull:
 		// 全量数据 ETL 非一致性（基于某个时间点，而是直接基于现有 SCN）抽取，离线环境提供与原库一致性
 		err := IMigrateFull(ctx, cfg)
@@ -75,6 +81,12 @@ func Run(ctx context.Context, cfg *config.Config) error {
 		if err != nil {
 			return err
 		}
+	case common.TaskModeRefresh:
+		// 物化视图下游刷新 - 全量重建或者基于主键增量刷新
+		err := IRefresh(ctx, cfg)
+		if err != nil {
+			return err
+		}
 	default:
 		return fmt.Errorf("flag [mode] can not null or value configure error")
 	}